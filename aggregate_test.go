@@ -0,0 +1,144 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestRegexpAgg(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:", WithMaxResultSize(24))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	_, err = db.Exec(`CREATE TABLE tickets (team TEXT, subject TEXT);
+		INSERT INTO tickets VALUES
+			('infra', 'Outage INC-101 in eu'),
+			('infra', 'no ticket'),
+			('infra', 'Follow-up inc-102, INC-103'),
+			('web', 'INC-200'),
+			('web', NULL),
+			('mobile', 'nothing here')`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	tests := []struct {
+		query    string
+		expected []sql.NullString
+	}{
+		{`SELECT regexp_agg(subject, 'INC-\d+') FROM tickets GROUP BY team ORDER BY team`,
+			[]sql.NullString{{String: "INC-101,INC-103", Valid: true}, {}, {String: "INC-200", Valid: true}}},
+		{`SELECT regexp_agg(subject, 'INC-\d+', ' | ', 'i') FROM tickets GROUP BY team ORDER BY team`,
+			[]sql.NullString{{String: "INC-101 | inc-102", Valid: true}, {}, {String: "INC-200", Valid: true}}},
+		{`SELECT regexp_agg_json(subject, 'INC-\d+') FROM tickets GROUP BY team ORDER BY team`,
+			[]sql.NullString{{String: `["INC-101","INC-103"]`, Valid: true}, {String: `[]`, Valid: true}, {String: `["INC-200"]`, Valid: true}}},
+		{`SELECT regexp_agg_json(subject, 'INC-\d+') FROM tickets WHERE 0`,
+			[]sql.NullString{{String: `[]`, Valid: true}}},
+	}
+	for _, test := range tests {
+		rows, err := db.Query(test.query)
+		if err != nil {
+			t.Errorf("%s failed: %v", test.query, err)
+			continue
+		}
+		var got []sql.NullString
+		for rows.Next() {
+			var v sql.NullString
+			if err := rows.Scan(&v); err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+			got = append(got, v)
+		}
+		_ = rows.Close()
+		if len(got) != len(test.expected) {
+			t.Errorf("%s = %+v, expected %+v", test.query, got, test.expected)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.expected[i] {
+				t.Errorf("%s row %d = %+v, expected %+v", test.query, i, got[i], test.expected[i])
+			}
+		}
+	}
+
+	var result sql.NullString
+	if err := db.QueryRow(`SELECT regexp_agg_json(subject, '\w+') FROM tickets`).Scan(&result); err == nil {
+		t.Errorf("Expected error for oversized result, got %q", result.String)
+	}
+	if err := db.QueryRow(`SELECT regexp_agg(subject, '(') FROM tickets`).Scan(&result); err == nil {
+		t.Error("Expected error for invalid pattern, got nil")
+	}
+	if err := db.QueryRow(`SELECT regexp_agg(subject) FROM tickets`).Scan(&result); err == nil {
+		t.Error("Expected error for wrong number of arguments, got nil")
+	}
+}
+
+func TestCountMatching(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	_, err = db.Exec(`CREATE TABLE logs (category TEXT, msg TEXT);
+		CREATE TABLE rules (pattern TEXT);
+		INSERT INTO rules VALUES ('^(ERROR|FATAL)\b');
+		INSERT INTO logs VALUES
+			('api', 'ERROR timeout'),
+			('api', 'INFO ok'),
+			('api', 'FATAL crash'),
+			('db', 'error lowercase'),
+			('db', NULL),
+			('web', 'INFO ok')`)
+	if err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	rows, err := db.Query(`SELECT category, count_matching(msg, pattern), count_matching(msg, pattern, 'i')
+		FROM logs, rules GROUP BY category ORDER BY category`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	expected := []struct {
+		category  string
+		count     int
+		countFold int
+	}{
+		{"api", 2, 2},
+		{"db", 0, 1},
+		{"web", 0, 0},
+	}
+	i := 0
+	for rows.Next() {
+		var category string
+		var count, countFold int
+		if err := rows.Scan(&category, &count, &countFold); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if i >= len(expected) {
+			t.Fatalf("Unexpected row %s", category)
+		}
+		if e := expected[i]; category != e.category || count != e.count || countFold != e.countFold {
+			t.Errorf("Row %d = (%s, %d, %d), expected %+v", i, category, count, countFold, e)
+		}
+		i++
+	}
+	if i != len(expected) {
+		t.Errorf("Got %d rows, expected %d", i, len(expected))
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT count_matching(msg, 'x') FROM logs WHERE 0`).Scan(&count); err != nil || count != 0 {
+		t.Errorf("count_matching over no rows = %d, %v, expected 0", count, err)
+	}
+}
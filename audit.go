@@ -0,0 +1,116 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AuditResult reports how the values of a column fare against a rule.
+type AuditResult struct {
+	Rule ColumnRule
+	// Checked is the number of rows with a non-NULL value.
+	Checked int64
+	// Violations is the number of those whose value does not match.
+	Violations int64
+	// Samples holds up to the requested number of distinct offending values.
+	Samples []string
+}
+
+// Audit checks the values of the columns of rules against their patterns,
+// like a regex-driven data linter, and returns one result per rule with up
+// to samples offending values each:
+//
+//	results, err := sqlite_regexp.Audit(db, []sqlite_regexp.ColumnRule{
+//		{Table: "users", Column: "email", Pattern: `^[^@\s]+@[^@\s]+$`},
+//	}, 5)
+//	for _, r := range results {
+//		fmt.Printf("%s.%s: %d/%d invalid %q\n", r.Rule.Table, r.Rule.Column, r.Violations, r.Checked, r.Samples)
+//	}
+//
+// NULL values are not checked.
+func Audit(db *sql.DB, rules []ColumnRule, samples int) ([]AuditResult, error) {
+	return AuditContext(context.Background(), db, rules, samples)
+}
+
+// AuditContext is like Audit, but stops, interrupting the running query,
+// once ctx is done.
+func AuditContext(ctx context.Context, db *sql.DB, rules []ColumnRule, samples int) ([]AuditResult, error) {
+	for _, r := range rules {
+		if err := r.validate(); err != nil {
+			return nil, err
+		}
+		// SQLite reads an unknown double-quoted identifier as a string, so
+		// a misspelled column would be audited as a constant.
+		if err := checkColumn(ctx, db, r.Table, r.Column); err != nil {
+			return nil, fmt.Errorf("rule %s.%s: %w", r.Table, r.Column, err)
+		}
+	}
+
+	results := make([]AuditResult, 0, len(rules))
+	for _, r := range rules {
+		res, err := auditRule(ctx, db, r, samples)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s.%s: %w", r.Table, r.Column, err)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+func auditRule(ctx context.Context, db *sql.DB, r ColumnRule, samples int) (AuditResult, error) {
+	res := AuditResult{Rule: r}
+	column, table := quoteIdent(r.Column), quoteIdent(r.Table)
+
+	query := fmt.Sprintf(`SELECT count(*), coalesce(sum(NOT regexp(?1, %s, ?2)), 0) FROM %s WHERE %s IS NOT NULL`,
+		column, table, column)
+	if err := db.QueryRowContext(ctx, query, r.Pattern, r.Flags).Scan(&res.Checked, &res.Violations); err != nil {
+		return res, err
+	}
+	if res.Violations == 0 || samples <= 0 {
+		return res, nil
+	}
+
+	query = fmt.Sprintf(`SELECT DISTINCT %s FROM %s WHERE %s IS NOT NULL AND NOT regexp(?1, %s, ?2) LIMIT ?3`,
+		column, table, column, column)
+	rows, err := db.QueryContext(ctx, query, r.Pattern, r.Flags, samples)
+	if err != nil {
+		return res, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return res, err
+		}
+		res.Samples = append(res.Samples, value)
+	}
+	return res, rows.Err()
+}
+
+// checkColumn returns an error unless table has column. The columns come
+// from an empty query rather than pragma_table_info, which a connection
+// opened WithReadOnly does not authorize with an argument.
+func checkColumn(ctx context.Context, db *sql.DB, table, column string) error {
+	rows, err := db.QueryContext(ctx, "SELECT * FROM "+quoteIdent(table)+" LIMIT 0")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	for _, c := range columns {
+		if strings.EqualFold(c, column) {
+			return nil
+		}
+	}
+	return errors.New("no such column")
+}
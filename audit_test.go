@@ -0,0 +1,64 @@
+package sqlite_regexp
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	_, err = db.Exec(`CREATE TABLE users (email TEXT, zip);
+		INSERT INTO users VALUES
+			('a@example.org', 12345),
+			('not an email', '1234'),
+			('not an email', 'ABCDE'),
+			('b@', NULL),
+			(NULL, 99999)`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	results, err := Audit(db, []ColumnRule{
+		{Table: "users", Column: "email", Pattern: `^[^@\s]+@[^@\s]+$`},
+		{Table: "users", Column: "zip", Pattern: `^\d{5}$`},
+		{Table: "users", Column: "email", Pattern: `^[a-z@. ]+$`, Flags: "i"},
+	}, 1)
+	if err != nil {
+		t.Fatalf("Audit failed: %v", err)
+	}
+
+	expected := []struct {
+		checked, violations int64
+		samples             []string
+	}{
+		{4, 3, []string{"not an email"}},
+		{4, 2, []string{"1234"}},
+		{4, 0, nil},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Got %d results, expected %d", len(results), len(expected))
+	}
+	for i, e := range expected {
+		r := results[i]
+		if r.Checked != e.checked || r.Violations != e.violations || !slices.Equal(r.Samples, e.samples) {
+			t.Errorf("Result %d = %+v, expected %+v", i, r, e)
+		}
+	}
+
+	for _, bad := range []ColumnRule{
+		{Table: "users", Column: "mail", Pattern: `x`},
+		{Table: "missing", Column: "email", Pattern: `x`},
+		{Table: "users", Column: "email", Pattern: `(`},
+	} {
+		if _, err := Audit(db, []ColumnRule{bad}, 1); err == nil {
+			t.Errorf("Audit(%+v): expected an error, got nil", bad)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+)
+
+// LabelRule labels the texts matching a pattern, see Backtest.
+type LabelRule struct {
+	Label   string
+	Pattern string
+	Flags   string
+}
+
+// BacktestReport compares the labels predicted by rules with known labels.
+type BacktestReport struct {
+	// Rows is the number of rows replayed.
+	Rows int64
+	// Correct is the number of rows whose predicted label is the known one,
+	// including rows no rule matched and which have no label.
+	Correct int64
+	// Confusion counts the rows by known label, then predicted label. The
+	// empty label stands for rows with a NULL known label, or that no rule
+	// matched.
+	Confusion map[string]map[string]int64
+	// Labels holds the statistics of every label, known or predicted,
+	// sorted by label.
+	Labels []LabelStats
+}
+
+// LabelStats are the statistics of the predictions of a label.
+type LabelStats struct {
+	Label string
+	// TruePositives is the number of rows predicted and known to have the
+	// label, FalsePositives predicted but known otherwise, FalseNegatives
+	// known but predicted otherwise.
+	TruePositives  int64
+	FalsePositives int64
+	FalseNegatives int64
+	// Precision is TruePositives over the rows predicted to have the label,
+	// Recall over the rows known to have it; both are zero without such
+	// rows.
+	Precision float64
+	Recall    float64
+}
+
+// Accuracy returns the fraction of rows whose predicted label is correct.
+func (r *BacktestReport) Accuracy() float64 {
+	if r.Rows == 0 {
+		return 0
+	}
+	return float64(r.Correct) / float64(r.Rows)
+}
+
+// Backtest replays historical rows against a candidate set of rules and
+// compares their predictions with known labels, to gate rule changes on
+// precision and recall:
+//
+//	report, err := sqlite_regexp.Backtest(ctx, db, rules,
+//		`SELECT body, category FROM tickets WHERE created > date('now', '-90 days')`,
+//		"category")
+//
+// historicalQuery must return the known labels in labelsColumn and the texts
+// in its only other column. Each row is predicted the label of the first
+// rule it matches, in order, or none. Every pattern is compiled before the
+// query runs, so invalid rules fail with a pattern error.
+func Backtest(ctx context.Context, db *sql.DB, rules []LabelRule, historicalQuery, labelsColumn string) (*BacktestReport, error) {
+	compiled, err := compileLabelRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BacktestReport{Confusion: make(map[string]map[string]int64)}
+	err = replayLabelled(ctx, db, historicalQuery, labelsColumn, func(known, text sql.NullString) error {
+		predicted := ""
+		if text.Valid {
+			for _, m := range compiled {
+				if m.re.MatchString(text.String) {
+					predicted = m.label
+					break
+				}
+			}
+		}
+
+		report.Rows++
+		if predicted == known.String {
+			report.Correct++
+		}
+		byPredicted, ok := report.Confusion[known.String]
+		if !ok {
+			byPredicted = make(map[string]int64)
+			report.Confusion[known.String] = byPredicted
+		}
+		byPredicted[predicted]++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Labels = labelStats(report.Confusion)
+	return report, nil
+}
+
+// compileLabelRules compiles the patterns of rules, in order.
+func compileLabelRules(rules []LabelRule) ([]labelMatcher, error) {
+	compiled := make([]labelMatcher, len(rules))
+	for i, r := range rules {
+		if r.Label == "" {
+			return nil, fmt.Errorf("rule %d has no label", i)
+		}
+		flags, err := core.ParseFlags(r.Flags)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.Label, err)
+		}
+		re, err := core.Compile(r.Pattern, flags)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.Label, err)
+		}
+		compiled[i] = labelMatcher{label: r.Label, re: re}
+	}
+	return compiled, nil
+}
+
+// replayLabelled runs historicalQuery, which must return labelsColumn and one
+// text column, and calls fn with the known label and text of every row.
+func replayLabelled(ctx context.Context, db *sql.DB, historicalQuery, labelsColumn string, fn func(known, text sql.NullString) error) error {
+	rows, err := db.QueryContext(ctx, historicalQuery)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if len(columns) != 2 || (columns[0] != labelsColumn && columns[1] != labelsColumn) {
+		return fmt.Errorf("historical query must return %s and a text column, got %v", labelsColumn, columns)
+	}
+	labelIndex := 0
+	if columns[1] == labelsColumn {
+		labelIndex = 1
+	}
+
+	values := make([]sql.NullString, 2)
+	for rows.Next() {
+		if err := rows.Scan(&values[0], &values[1]); err != nil {
+			return err
+		}
+		if err := fn(values[labelIndex], values[1-labelIndex]); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+type labelMatcher struct {
+	label string
+	re    *regexp.Regexp
+}
+
+// labelStats computes the statistics of every non-empty label of confusion.
+func labelStats(confusion map[string]map[string]int64) []LabelStats {
+	stats := make(map[string]*LabelStats)
+	get := func(label string) *LabelStats {
+		s, ok := stats[label]
+		if !ok {
+			s = &LabelStats{Label: label}
+			stats[label] = s
+		}
+		return s
+	}
+	for known, byPredicted := range confusion {
+		for predicted, n := range byPredicted {
+			switch {
+			case known == predicted:
+				if known != "" {
+					get(known).TruePositives += n
+				}
+			default:
+				if known != "" {
+					get(known).FalseNegatives += n
+				}
+				if predicted != "" {
+					get(predicted).FalsePositives += n
+				}
+			}
+		}
+	}
+
+	labels := make([]LabelStats, 0, len(stats))
+	for _, s := range stats {
+		if predicted := s.TruePositives + s.FalsePositives; predicted > 0 {
+			s.Precision = float64(s.TruePositives) / float64(predicted)
+		}
+		if known := s.TruePositives + s.FalseNegatives; known > 0 {
+			s.Recall = float64(s.TruePositives) / float64(known)
+		}
+		labels = append(labels, *s)
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Label < labels[j].Label })
+	return labels
+}
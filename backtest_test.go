@@ -0,0 +1,88 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBacktest(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	_, err = db.Exec(`CREATE TABLE tickets (body TEXT, category TEXT);
+		INSERT INTO tickets VALUES
+			('Invoice 42 is wrong', 'billing'),
+			('Please refund my invoice', 'billing'),
+			('Refund for the crash', 'bugs'),
+			('The app crashes on start', 'bugs'),
+			('Charged twice', 'billing'),
+			('Hello there', NULL),
+			(NULL, 'bugs')`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	rules := []LabelRule{
+		{Label: "billing", Pattern: `invoice|refund`, Flags: "i"},
+		{Label: "bugs", Pattern: `crash`},
+	}
+	report, err := Backtest(context.Background(), db, rules, `SELECT body, category FROM tickets`, "category")
+	if err != nil {
+		t.Fatalf("Backtest failed: %v", err)
+	}
+
+	if report.Rows != 7 || report.Correct != 4 {
+		t.Errorf("Got %d rows and %d correct, expected 7 and 4", report.Rows, report.Correct)
+	}
+	if n := report.Confusion["bugs"]["billing"]; n != 1 {
+		t.Errorf("Expected 1 bug predicted as billing, got %d", n)
+	}
+	if n := report.Confusion["billing"][""]; n != 1 {
+		t.Errorf("Expected 1 unmatched billing ticket, got %d", n)
+	}
+
+	expected := []LabelStats{
+		{Label: "billing", TruePositives: 2, FalsePositives: 1, FalseNegatives: 1, Precision: 2.0 / 3, Recall: 2.0 / 3},
+		{Label: "bugs", TruePositives: 1, FalseNegatives: 2, Precision: 1, Recall: 1.0 / 3},
+	}
+	if len(report.Labels) != len(expected) {
+		t.Fatalf("Got %+v, expected %+v", report.Labels, expected)
+	}
+	for i, s := range report.Labels {
+		if s != expected[i] {
+			t.Errorf("Label %d: got %+v, expected %+v", i, s, expected[i])
+		}
+	}
+}
+
+func TestBacktestErrors(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	ctx := context.Background()
+
+	_, err = Backtest(ctx, db, []LabelRule{{Label: "x", Pattern: "("}}, `SELECT 'a', 'b' AS label`, "label")
+	if !IsPatternError(err) {
+		t.Errorf("Expected a pattern error, got %v", err)
+	}
+	_, err = Backtest(ctx, db, []LabelRule{{Pattern: "a"}}, `SELECT 'a', 'b' AS label`, "label")
+	if err == nil {
+		t.Error("Expected an error for a rule without label")
+	}
+	_, err = Backtest(ctx, db, nil, `SELECT 'a', 'b', 'c' AS label`, "label")
+	if err == nil {
+		t.Error("Expected an error for a query with three columns")
+	}
+	_, err = Backtest(ctx, db, nil, `SELECT 'a', 'b'`, "label")
+	if err == nil {
+		t.Error("Expected an error for a query without the labels column")
+	}
+}
@@ -0,0 +1,143 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BucketQuery describes a report of the number of rows matching each of a
+// set of patterns per time bucket, see MatchCountsByBucket.
+type BucketQuery struct {
+	// Table is the table (or view) to scan.
+	Table string
+	// TimeColumn holds the time of each row, as a number of seconds since
+	// the Unix epoch or as a timestamp in one of SQLite's date and time
+	// formats. Rows with a NULL time are ignored.
+	TimeColumn string
+	// TextColumn holds the text matched against the patterns.
+	TextColumn string
+	// Patterns maps the name reported for each pattern to the pattern.
+	Patterns map[string]string
+	// Flags are the matching flags used for every pattern.
+	Flags string
+	// Bucket is the width of the buckets, a whole number of seconds.
+	Bucket time.Duration
+}
+
+// BucketCount is the number of rows of a bucket matching a pattern.
+type BucketCount struct {
+	// Start is the start of the bucket.
+	Start time.Time
+	// Pattern is the name of the pattern.
+	Pattern string
+	// Matches is the number of matching rows.
+	Matches int64
+}
+
+// SQL returns the query computing the report, with its arguments. It
+// returns the columns bucket (the start of the bucket, in seconds since the
+// Unix epoch), pattern and matches, ordered by bucket and pattern, e.g. to
+// materialize the report:
+//
+//	query, args, err := q.SQL()
+//	_, err = db.Exec("CREATE TABLE error_rates AS "+query, args...)
+//
+// Buckets where a pattern matches no row are left out.
+func (q BucketQuery) SQL() (string, []any, error) {
+	if q.Table == "" || q.TimeColumn == "" || q.TextColumn == "" {
+		return "", nil, errors.New("bucket query needs a table, a time column and a text column")
+	}
+	if len(q.Patterns) == 0 {
+		return "", nil, errors.New("bucket query needs at least one pattern")
+	}
+	width := int64(q.Bucket / time.Second)
+	if width <= 0 || q.Bucket%time.Second != 0 {
+		return "", nil, fmt.Errorf("bucket width must be a positive whole number of seconds, got %s", q.Bucket)
+	}
+
+	names := make([]string, 0, len(q.Patterns))
+	for name := range q.Patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	args := []any{q.Flags}
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = fmt.Sprintf("(?%d, ?%d)", 2*i+2, 2*i+3)
+		args = append(args, name, q.Patterns[name])
+	}
+
+	tc := quoteIdent(q.TimeColumn)
+	seconds := fmt.Sprintf("CASE WHEN typeof(%s) IN ('integer', 'real') THEN %s ELSE unixepoch(%s) END", tc, tc, tc)
+	query := fmt.Sprintf(`WITH patterns(name, pattern) AS (VALUES %s),
+texts(seconds, text) AS (SELECT %s, %s FROM %s)
+SELECT CAST(texts.seconds / %d AS INTEGER) * %d AS bucket, patterns.name AS pattern, count(*) AS matches
+FROM texts, patterns
+WHERE texts.seconds IS NOT NULL AND regexp(patterns.pattern, texts.text, ?1)
+GROUP BY bucket, pattern
+ORDER BY bucket, pattern`,
+		strings.Join(values, ", "), seconds, quoteIdent(q.TextColumn), quoteIdent(q.Table), width, width)
+	return query, args, nil
+}
+
+// MatchCountsByBucket runs the report described by q, such as an error rate
+// by pattern over time:
+//
+//	counts, err := sqlite_regexp.MatchCountsByBucket(db, sqlite_regexp.BucketQuery{
+//		Table:      "logs",
+//		TimeColumn: "ts",
+//		TextColumn: "line",
+//		Patterns:   map[string]string{"timeout": `(?i)timed? ?out`, "oom": `OutOfMemory`},
+//		Bucket:     time.Hour,
+//	})
+//
+// Buckets where a pattern matches no row are left out.
+func MatchCountsByBucket(db *sql.DB, q BucketQuery) ([]BucketCount, error) {
+	return MatchCountsByBucketContext(context.Background(), db, q)
+}
+
+// MatchCountsByBucketContext is like MatchCountsByBucket, but stops,
+// interrupting the query, once ctx is done.
+func MatchCountsByBucketContext(ctx context.Context, db *sql.DB, q BucketQuery) ([]BucketCount, error) {
+	query, args, err := q.SQL()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var counts []BucketCount
+	for rows.Next() {
+		var (
+			bucket int64
+			c      BucketCount
+		)
+		if err := rows.Scan(&bucket, &c.Pattern, &c.Matches); err != nil {
+			return nil, err
+		}
+		c.Start = time.Unix(bucket, 0).UTC()
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,79 @@
+package sqlite_regexp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatchCountsByBucket(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	_, err = db.Exec(`CREATE TABLE "app logs" (ts, line TEXT);
+		INSERT INTO "app logs" VALUES
+			('2024-05-01 10:05:00', 'request timed out'),
+			('2024-05-01 10:59:59', 'Timeout talking to db'),
+			('2024-05-01 10:30:00', 'OutOfMemoryError'),
+			(1714561200, 'timeout again'),
+			(1714561200.5, NULL),
+			(NULL, 'timeout without time'),
+			('2024-05-01 12:00:00', 'all good')`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	q := BucketQuery{
+		Table:      "app logs",
+		TimeColumn: "ts",
+		TextColumn: "line",
+		Patterns:   map[string]string{"timeout": `time ?d? ?out`, "oom": `OutOfMemory`},
+		Flags:      "i",
+		Bucket:     time.Hour,
+	}
+	counts, err := MatchCountsByBucket(db, q)
+	if err != nil {
+		t.Fatalf("MatchCountsByBucket failed: %v", err)
+	}
+	ten := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	expected := []BucketCount{
+		{Start: ten, Pattern: "oom", Matches: 1},
+		{Start: ten, Pattern: "timeout", Matches: 2},
+		{Start: ten.Add(time.Hour), Pattern: "timeout", Matches: 1},
+	}
+	if len(counts) != len(expected) {
+		t.Fatalf("Got %+v, expected %+v", counts, expected)
+	}
+	for i := range expected {
+		if !counts[i].Start.Equal(expected[i].Start) || counts[i].Pattern != expected[i].Pattern || counts[i].Matches != expected[i].Matches {
+			t.Errorf("Count %d = %+v, expected %+v", i, counts[i], expected[i])
+		}
+	}
+
+	// The generated query can be materialized.
+	query, args, err := q.SQL()
+	if err != nil {
+		t.Fatalf("SQL failed: %v", err)
+	}
+	if _, err := db.Exec("CREATE TABLE rates AS "+query, args...); err != nil {
+		t.Fatalf("Failed to materialize report: %v", err)
+	}
+	var total int
+	if err := db.QueryRow(`SELECT sum(matches) FROM rates`).Scan(&total); err != nil || total != 4 {
+		t.Errorf("Materialized %d matches, %v, expected 4", total, err)
+	}
+
+	for _, bad := range []BucketQuery{
+		{TimeColumn: "ts", TextColumn: "line", Patterns: q.Patterns, Bucket: time.Hour},
+		{Table: "logs", TimeColumn: "ts", TextColumn: "line", Bucket: time.Hour},
+		{Table: "logs", TimeColumn: "ts", TextColumn: "line", Patterns: q.Patterns, Bucket: 1500 * time.Millisecond},
+	} {
+		if _, _, err := bad.SQL(); err == nil {
+			t.Errorf("SQL(%+v): expected an error, got nil", bad)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestHelpersCancellation checks that the helpers stop promptly when their
+// context is done, on workloads that would otherwise run for a long time.
+func TestHelpersCancellation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a large table")
+	}
+
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// An endless view for the read-only helpers, and a large table to index.
+	_, err = db.Exec(`CREATE VIEW endless AS
+			WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n)
+			SELECT i AS id, i AS ts, printf('%d lorem ipsum dolor sit amet %d', i, i * 7) AS line FROM n;
+		CREATE TABLE big AS SELECT line FROM endless LIMIT 500000;`)
+	if err != nil {
+		t.Fatalf("Failed to create workload: %v", err)
+	}
+
+	helpers := map[string]func(ctx context.Context) error{
+		"MatchWindowsContext": func(ctx context.Context) error {
+			_, err := MatchWindowsContext(ctx, db, WindowQuery{
+				Query:   `SELECT id, line FROM endless`,
+				Pattern: `never matches$`,
+				Size:    10,
+			})
+			return err
+		},
+		"MatchCountsByBucketContext": func(ctx context.Context) error {
+			_, err := MatchCountsByBucketContext(ctx, db, BucketQuery{
+				Table:      "endless",
+				TimeColumn: "ts",
+				TextColumn: "line",
+				Patterns:   map[string]string{"seven": `7\d*$`},
+				Bucket:     time.Minute,
+			})
+			return err
+		},
+		"CreateExtractIndexContext": func(ctx context.Context) error {
+			return CreateExtractIndexContext(ctx, db, ExtractIndex{
+				Name:    "big_words",
+				Table:   "big",
+				Column:  "line",
+				Pattern: `(?:\w+\s+){3}(\w+)(?:\s+\w+)*\s+\d+$`,
+				Group:   1,
+			})
+		},
+	}
+	for name, run := range helpers {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		err := run(ctx)
+		elapsed := time.Since(start)
+		cancel()
+
+		if err == nil {
+			t.Errorf("%s: expected an error, got nil after %s", name, elapsed)
+			continue
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: expected context.DeadlineExceeded, got %v", name, err)
+		}
+		if elapsed > time.Second {
+			t.Errorf("%s: took %s to stop", name, elapsed)
+		}
+	}
+
+	var indexes int
+	if err := db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE name = 'big_words'`).Scan(&indexes); err != nil || indexes != 0 {
+		t.Errorf("Interrupted index exists: %d, %v", indexes, err)
+	}
+}
+
+func TestRegistrationContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if db, err := OpenWithRegexpContext(ctx, ":memory:"); !errors.Is(err, context.Canceled) {
+		if db != nil {
+			_ = db.Close()
+		}
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	db, err := OpenWithRegexpContext(context.Background(), ":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexpContext failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// With its only connection taken, registering waits for the pool.
+	db.SetMaxOpenConns(1)
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Conn failed: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := RegisterRegexpFunctionContext(ctx, db); !IsTimeout(err) {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+}
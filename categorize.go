@@ -0,0 +1,236 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+)
+
+// CategorizeSpec describes the categorization of the items of a table by a
+// table of patterns, see Categorize.
+type CategorizeSpec struct {
+	// ItemsTable is the table (or view) of the items, ItemColumn the text
+	// matched against the patterns, and KeyColumn the unique key the
+	// results identify items by, rowid by default.
+	ItemsTable string
+	ItemColumn string
+	KeyColumn  string
+	// PatternsTable is the table of the patterns. PatternColumn and
+	// CategoryColumn are its columns holding the patterns and their
+	// categories, pattern and category by default.
+	PatternsTable  string
+	PatternColumn  string
+	CategoryColumn string
+	// Flags are the matching flags of every pattern. FlagsColumn, if set,
+	// holds flags of each pattern, applied after Flags.
+	Flags       string
+	FlagsColumn string
+	// PriorityColumn, if set, orders the patterns, lowest first; they are
+	// in rowid order otherwise, or among patterns of equal priority.
+	PriorityColumn string
+	// FirstMatch keeps only the category of the first pattern matching an
+	// item, instead of one result per matching pattern.
+	FirstMatch bool
+	// IncludeUnmatched adds the items no pattern matches, with an empty
+	// category.
+	IncludeUnmatched bool
+	// ResultTable, if set, is the table the results are written to, with
+	// the columns key, item and category, instead of being returned. It
+	// must not exist.
+	ResultTable string
+}
+
+// CategoryMatch is the category of an item.
+type CategoryMatch struct {
+	// Key is the value of the key column of the item.
+	Key      any
+	Item     string
+	Category string
+}
+
+// InvalidPattern is a pattern that does not compile, which Categorize skips.
+type InvalidPattern struct {
+	// RowID is the rowid of the pattern in the patterns table.
+	RowID    int64
+	Pattern  string
+	Category string
+	// Err is the message of the compilation error.
+	Err string
+}
+
+// CategorizeResult is the result of Categorize.
+type CategorizeResult struct {
+	// Matches are the categories of the items, ordered by key then pattern,
+	// unless they were written to the result table.
+	Matches []CategoryMatch
+	// Written is the number of rows written to the result table.
+	Written int64
+	// Invalid are the patterns skipped because they do not compile, ordered
+	// by rowid.
+	Invalid []InvalidPattern
+}
+
+// withDefaults returns s with the default column names filled in, after
+// checking it.
+func (s CategorizeSpec) withDefaults() (CategorizeSpec, error) {
+	if s.ItemsTable == "" || s.ItemColumn == "" || s.PatternsTable == "" {
+		return s, errors.New("categorization needs an items table, an item column and a patterns table")
+	}
+	if _, err := core.ParseFlags(s.Flags); err != nil {
+		return s, fmt.Errorf("categorization: %w", err)
+	}
+	if s.KeyColumn == "" {
+		s.KeyColumn = "rowid"
+	}
+	if s.PatternColumn == "" {
+		s.PatternColumn = "pattern"
+	}
+	if s.CategoryColumn == "" {
+		s.CategoryColumn = "category"
+	}
+	return s, nil
+}
+
+// flagsExpr returns the expression of the flags of a pattern, reading the
+// flags column of the patterns table.
+func (s CategorizeSpec) flagsExpr() string {
+	if s.FlagsColumn == "" {
+		return "?1"
+	}
+	return fmt.Sprintf("?1 || coalesce(%s, '')", quoteIdent(s.FlagsColumn))
+}
+
+// SQL returns the query categorizing the items, with its arguments. It
+// returns the columns key, item and category, and skips the patterns that
+// do not compile.
+func (s CategorizeSpec) SQL() (string, []any, error) {
+	s, err := s.withDefaults()
+	if err != nil {
+		return "", nil, err
+	}
+	priority := "0"
+	if s.PriorityColumn != "" {
+		priority = quoteIdent(s.PriorityColumn)
+	}
+	join := "JOIN"
+	if s.IncludeUnmatched {
+		join = "LEFT JOIN"
+	}
+
+	window := ""
+	if s.FirstMatch {
+		window = fmt.Sprintf(", row_number() OVER (PARTITION BY i.%s ORDER BY p.priority, p.id) AS n", quoteIdent(s.KeyColumn))
+	}
+	// The valid patterns are materialized, so that invalid ones are never
+	// matched, whichever order SQLite joins the tables in.
+	query := fmt.Sprintf(`WITH categorize_patterns(id, pattern, flags, category, priority) AS MATERIALIZED (
+SELECT rowid, %[1]s, %[2]s, %[3]s, %[4]s FROM %[5]s WHERE regexp_valid(%[1]s, %[2]s)
+)
+SELECT i.%[6]s AS "key", i.%[7]s AS item, p.category AS category%[8]s
+FROM %[9]s i %[10]s categorize_patterns p ON regexp(p.pattern, i.%[7]s, p.flags)`,
+		quoteIdent(s.PatternColumn), s.flagsExpr(), quoteIdent(s.CategoryColumn), priority, quoteIdent(s.PatternsTable),
+		quoteIdent(s.KeyColumn), quoteIdent(s.ItemColumn), window, quoteIdent(s.ItemsTable), join)
+	if s.FirstMatch {
+		query = `SELECT "key", item, category FROM (` + query + `) WHERE n = 1 ORDER BY "key"`
+	} else {
+		query += `
+ORDER BY "key", p.priority, p.id`
+	}
+	return query, []any{s.Flags}, nil
+}
+
+// Categorize categorizes the items of a table by the patterns of another,
+// the pattern-based join the suite is built for:
+//
+//	result, err := sqlite_regexp.Categorize(ctx, db, sqlite_regexp.CategorizeSpec{
+//		ItemsTable:    "transactions",
+//		ItemColumn:    "description",
+//		KeyColumn:     "id",
+//		PatternsTable: "rules",
+//		FirstMatch:    true,
+//	})
+//
+// Patterns that do not compile are skipped and reported in the result
+// rather than failing the query; NULL patterns are skipped silently. db
+// must have the function suite on every connection, as when opened with
+// OpenWithRegexp.
+func Categorize(ctx context.Context, db *sql.DB, spec CategorizeSpec) (*CategorizeResult, error) {
+	query, args, err := spec.SQL()
+	if err != nil {
+		return nil, err
+	}
+	spec, _ = spec.withDefaults()
+
+	result := &CategorizeResult{}
+	if result.Invalid, err = invalidPatterns(ctx, db, spec); err != nil {
+		return nil, err
+	}
+
+	if spec.ResultTable != "" {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s AS %s", quoteIdent(spec.ResultTable), query), args...); err != nil {
+			return nil, err
+		}
+		if err := db.QueryRowContext(ctx, "SELECT count(*) FROM "+quoteIdent(spec.ResultTable)).Scan(&result.Written); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	for rows.Next() {
+		var (
+			m              CategoryMatch
+			item, category sql.NullString
+		)
+		if err := rows.Scan(&m.Key, &item, &category); err != nil {
+			return nil, err
+		}
+		m.Item, m.Category = item.String, category.String
+		result.Matches = append(result.Matches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// invalidPatterns returns the patterns of spec that do not compile.
+func invalidPatterns(ctx context.Context, db *sql.DB, spec CategorizeSpec) ([]InvalidPattern, error) {
+	pattern := quoteIdent(spec.PatternColumn)
+	query := fmt.Sprintf(`SELECT rowid, %[1]s, %[2]s, regexp_error(%[1]s, %[3]s) FROM %[4]s
+WHERE NOT regexp_valid(%[1]s, %[3]s) ORDER BY rowid`,
+		pattern, quoteIdent(spec.CategoryColumn), spec.flagsExpr(), quoteIdent(spec.PatternsTable))
+	rows, err := db.QueryContext(ctx, query, spec.Flags)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var invalid []InvalidPattern
+	for rows.Next() {
+		var (
+			p        InvalidPattern
+			category sql.NullString
+		)
+		if err := rows.Scan(&p.RowID, &p.Pattern, &category, &p.Err); err != nil {
+			return nil, err
+		}
+		p.Category = category.String
+		invalid = append(invalid, p)
+	}
+	return invalid, rows.Err()
+}
@@ -0,0 +1,123 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCategorize(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(`
+		CREATE TABLE items (id TEXT PRIMARY KEY, item TEXT);
+		INSERT INTO items VALUES ('a', 'phone-case'), ('b', 'laptop-bag'), ('c', 'apple'), ('d', 'PHONE'), ('e', NULL);
+		CREATE TABLE rules (pattern TEXT, name TEXT, flags TEXT, rank INTEGER);
+		INSERT INTO rules VALUES
+			('^(phone|laptop|tablet)', 'electronics', NULL, 2),
+			('bag|case$', 'accessories', NULL, 1),
+			('(unclosed', 'broken', NULL, 0),
+			('^phone$', 'exact', 'i', 3),
+			(NULL, 'none', NULL, 0),
+			('x', 'bad flags', 'q', 0)`); err != nil {
+		t.Fatal(err)
+	}
+
+	format := func(result *CategorizeResult) string {
+		var matches []string
+		for _, m := range result.Matches {
+			matches = append(matches, fmt.Sprintf("%v:%s", m.Key, m.Category))
+		}
+		return strings.Join(matches, " ")
+	}
+	base := CategorizeSpec{
+		ItemsTable:     "items",
+		ItemColumn:     "item",
+		KeyColumn:      "id",
+		PatternsTable:  "rules",
+		CategoryColumn: "name",
+		FlagsColumn:    "flags",
+	}
+	tests := []struct {
+		name     string
+		edit     func(*CategorizeSpec)
+		expected string
+	}{
+		{"all matches", func(s *CategorizeSpec) {}, "a:electronics a:accessories b:electronics b:accessories d:exact"},
+		{"priority", func(s *CategorizeSpec) { s.PriorityColumn = "rank" }, "a:accessories a:electronics b:accessories b:electronics d:exact"},
+		{"first match", func(s *CategorizeSpec) { s.FirstMatch = true }, "a:electronics b:electronics d:exact"},
+		{"first match by priority", func(s *CategorizeSpec) { s.FirstMatch, s.PriorityColumn = true, "rank" }, "a:accessories b:accessories d:exact"},
+		{"unmatched", func(s *CategorizeSpec) { s.FirstMatch, s.IncludeUnmatched = true, true }, "a:electronics b:electronics c: d:exact e:"},
+		{"default flags", func(s *CategorizeSpec) { s.Flags, s.FirstMatch = "i", true }, "a:electronics b:electronics d:electronics"},
+		{"flags column overrides", func(s *CategorizeSpec) { s.Flags, s.FlagsColumn = "i", "" }, "a:electronics a:accessories b:electronics b:accessories d:electronics d:exact"},
+	}
+	for _, tt := range tests {
+		spec := base
+		tt.edit(&spec)
+		result, err := Categorize(ctx, db, spec)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := format(result); got != tt.expected {
+			t.Errorf("%s: categorized %q, expected %q", tt.name, got, tt.expected)
+		}
+	}
+
+	result, err := Categorize(ctx, db, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Invalid) != 2 {
+		t.Fatalf("Invalid patterns %+v, expected 2", result.Invalid)
+	}
+	if p := result.Invalid[0]; p.RowID != 3 || p.Pattern != "(unclosed" || p.Category != "broken" || !strings.Contains(p.Err, "missing closing )") {
+		t.Errorf("First invalid pattern %+v", p)
+	}
+	if p := result.Invalid[1]; p.RowID != 6 || !strings.Contains(p.Err, "invalid flag 'q'") {
+		t.Errorf("Second invalid pattern %+v", p)
+	}
+	if m := result.Matches[0]; m.Key != "a" || m.Item != "phone-case" {
+		t.Errorf("First match %+v", m)
+	}
+
+	// Items and patterns with default column names, written to a table.
+	spec := CategorizeSpec{ItemsTable: "items", ItemColumn: "item", PatternsTable: "rules", CategoryColumn: "name",
+		FirstMatch: true, ResultTable: "categories"}
+	result, err = Categorize(ctx, db, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Written != 2 || result.Matches != nil || len(result.Invalid) != 1 {
+		t.Errorf("Written %d rows, matches %v, invalid %v; expected 2 rows written and 1 invalid pattern",
+			result.Written, result.Matches, result.Invalid)
+	}
+	var keys string
+	if err := db.QueryRow(`SELECT group_concat("key" || '=' || category, ' ') FROM categories`).Scan(&keys); err != nil {
+		t.Fatal(err)
+	}
+	if keys != "1=electronics 2=electronics" {
+		t.Errorf("Result table holds %q", keys)
+	}
+	if _, err := Categorize(ctx, db, spec); err == nil {
+		t.Error("Expected an error for an existing result table")
+	}
+
+	for _, spec := range []CategorizeSpec{
+		{ItemColumn: "item", PatternsTable: "rules"},
+		{ItemsTable: "items", ItemColumn: "item", PatternsTable: "rules", Flags: "q"},
+	} {
+		if _, err := Categorize(ctx, db, spec); err == nil {
+			t.Errorf("Expected an error for %+v", spec)
+		}
+	}
+}
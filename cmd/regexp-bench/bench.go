@@ -0,0 +1,224 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+	"github.com/go-go-golems/go-sqlite-regexp/sampledata"
+)
+
+const (
+	engineRE2  = "re2"
+	enginePCRE = "pcre"
+
+	modeJoin        = "join"
+	modeSet         = "set"
+	modeSetCombined = "set-combined"
+
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+type benchOptions struct {
+	items           int
+	patterns        int
+	density         float64
+	seed            int64
+	runs            int
+	engines         []string
+	modes           []string
+	caseInsensitive bool
+	fullFolding     bool
+	memo            int
+	format          string
+}
+
+// result is the measure of one run of a mode.
+type result struct {
+	Engine         string  `json:"engine"`
+	Mode           string  `json:"mode"`
+	Run            int     `json:"run"`
+	Items          int     `json:"items"`
+	Patterns       int     `json:"patterns"`
+	Matches        int64   `json:"matches"`
+	Seconds        float64 `json:"seconds"`
+	ItemsPerSecond float64 `json:"items_per_second"`
+	CacheHits      uint64  `json:"cache_hits"`
+	CacheMisses    uint64  `json:"cache_misses"`
+	AllocsPerItem  float64 `json:"allocs_per_item"`
+	BytesPerItem   float64 `json:"bytes_per_item"`
+}
+
+// queries are the statements of the modes, returning the number of matches.
+var queries = map[string]string{
+	modeJoin:        `SELECT count(*) FROM items i JOIN patterns p ON i.item REGEXP p.pattern`,
+	modeSet:         `SELECT count(regexp_set_match(item, 'bench')) FROM items`,
+	modeSetCombined: `SELECT count(regexp_set_match(item, 'bench_combined')) FROM items`,
+}
+
+func (o benchOptions) check() error {
+	for _, e := range o.engines {
+		if e != engineRE2 && e != enginePCRE {
+			return fmt.Errorf("unknown engine %q, expected re2 or pcre", e)
+		}
+	}
+	for _, m := range o.modes {
+		if _, ok := queries[m]; !ok {
+			return fmt.Errorf("unknown mode %q, expected join, set or set-combined", m)
+		}
+	}
+	if o.runs < 1 {
+		return fmt.Errorf("invalid number of runs %d", o.runs)
+	}
+	if o.memo < 0 {
+		return fmt.Errorf("invalid memo size %d", o.memo)
+	}
+	if o.format != formatTable && o.format != formatJSON {
+		return fmt.Errorf("unknown format %q, expected table or json", o.format)
+	}
+	return nil
+}
+
+func runBench(opts benchOptions, out, errOut io.Writer) error {
+	if err := opts.check(); err != nil {
+		return err
+	}
+	var results []result
+	for _, engine := range opts.engines {
+		r, err := benchEngine(opts, engine, errOut)
+		if err != nil {
+			return fmt.Errorf("%s: %w", engine, err)
+		}
+		results = append(results, r...)
+	}
+
+	if opts.format == formatJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "engine\tmode\trun\titems\tpatterns\tmatches\tseconds\titems/s\tcache hits\tcache misses\tallocs/item\tbytes/item")
+	for _, r := range results {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%.3f\t%.0f\t%d\t%d\t%.1f\t%.0f\n",
+			r.Engine, r.Mode, r.Run, r.Items, r.Patterns, r.Matches, r.Seconds, r.ItemsPerSecond,
+			r.CacheHits, r.CacheMisses, r.AllocsPerItem, r.BytesPerItem)
+	}
+	return w.Flush()
+}
+
+// benchEngine generates the data for engine and runs every mode on it.
+func benchEngine(opts benchOptions, engine string, errOut io.Writer) ([]result, error) {
+	cache := sqlite_regexp.NewCache()
+	dbOpts := []sqlite_regexp.Option{sqlite_regexp.WithCache(cache)}
+	if opts.caseInsensitive {
+		dbOpts = append(dbOpts, sqlite_regexp.WithDefaultCaseInsensitive())
+	}
+	if opts.fullFolding {
+		dbOpts = append(dbOpts, sqlite_regexp.WithCaseFolding(sqlite_regexp.FoldFull))
+	}
+	if engine == enginePCRE {
+		dbOpts = append(dbOpts, sqlite_regexp.WithEngineTags())
+	}
+	var memo *sqlite_regexp.ResultMemo
+	if opts.memo > 0 {
+		memo = sqlite_regexp.NewResultMemo(opts.memo)
+		dbOpts = append(dbOpts, sqlite_regexp.WithResultMemo(memo))
+	}
+	sets, err := sqlite_regexp.NewPatternSets(dbOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sqlite_regexp.OpenWithRegexp(":memory:", append(dbOpts, sqlite_regexp.WithPatternSets(sets))...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	// The in-memory database is only seen by its own connection.
+	db.SetMaxOpenConns(1)
+
+	if err := sampledata.Populate(db, opts.items, opts.seed,
+		sampledata.WithPatterns(opts.patterns), sampledata.WithMatchDensity(opts.density)); err != nil {
+		return nil, err
+	}
+	if engine == enginePCRE {
+		if _, err := db.Exec(`UPDATE patterns SET pattern = 'pcre:' || pattern`); err != nil {
+			return nil, err
+		}
+	}
+
+	var results []result
+	for _, mode := range opts.modes {
+		if engine == enginePCRE && mode != modeJoin {
+			_, _ = fmt.Fprintf(errOut, "skipping mode %s, which only supports re2, for engine pcre\n", mode)
+			continue
+		}
+		cache.Clear()
+		if memo != nil {
+			memo.Clear()
+		}
+		if err := loadSets(db, sets, mode); err != nil {
+			return nil, err
+		}
+		for run := 1; run <= opts.runs; run++ {
+			r, err := measure(db, cache, queries[mode], opts.items)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", mode, err)
+			}
+			r.Engine, r.Mode, r.Run, r.Patterns = engine, mode, run, opts.patterns
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+// loadSets defines the pattern set of the set modes.
+func loadSets(db *sql.DB, sets *sqlite_regexp.PatternSets, mode string) error {
+	if !slices.Contains([]string{modeSet, modeSetCombined}, mode) {
+		return nil
+	}
+	name := "bench"
+	if mode == modeSetCombined {
+		name = "bench_combined"
+	}
+	sets.SetCombined(mode == modeSetCombined)
+	_, err := sqlite_regexp.LoadPatternSet(db, sets, name, sqlite_regexp.PatternSetTable{Table: "patterns"})
+	return err
+}
+
+// measure runs query, which counts the matches of the items, once.
+func measure(db *sql.DB, cache *sqlite_regexp.Cache, query string, items int) (result, error) {
+	before := cache.Stats()
+	var mem runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&mem)
+	mallocs, bytes := mem.Mallocs, mem.TotalAlloc
+
+	r := result{Items: items}
+	start := time.Now()
+	if err := db.QueryRow(query).Scan(&r.Matches); err != nil {
+		return r, err
+	}
+	elapsed := time.Since(start)
+
+	runtime.ReadMemStats(&mem)
+	after := cache.Stats()
+	r.Seconds = elapsed.Seconds()
+	r.CacheHits, r.CacheMisses = after.Hits-before.Hits, after.Misses-before.Misses
+	if items > 0 {
+		r.ItemsPerSecond = float64(items) / elapsed.Seconds()
+		r.AllocsPerItem = float64(mem.Mallocs-mallocs) / float64(items)
+		r.BytesPerItem = float64(mem.TotalAlloc-bytes) / float64(items)
+	}
+	return r, nil
+}
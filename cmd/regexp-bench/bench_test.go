@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func runCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	cmd := newRootCommand()
+	cmd.SetArgs(args)
+	cmd.SetOut(&out)
+	cmd.SetErr(&errOut)
+	err := cmd.Execute()
+	if errOut.Len() > 0 {
+		t.Logf("stderr: %s", errOut.String())
+	}
+	return out.String(), err
+}
+
+func TestBench(t *testing.T) {
+	out, err := runCommand(t, "--items", "200", "--patterns", "10", "--runs", "2",
+		"--engine", "re2,pcre", "--mode", "join,set,set-combined", "--format", "json")
+	if err != nil {
+		t.Fatalf("regexp-bench failed: %v", err)
+	}
+	var results []result
+	if err := json.Unmarshal([]byte(out), &results); err != nil {
+		t.Fatalf("Invalid JSON output %q: %v", out, err)
+	}
+	// The set modes are skipped for pcre.
+	if len(results) != 8 {
+		t.Fatalf("Got %d results, expected 8: %+v", len(results), results)
+	}
+	// Every mode and engine finds the same matches, each item matching at
+	// most one pattern.
+	matches := results[0].Matches
+	if matches == 0 || matches > 200 {
+		t.Errorf("Got %d matches", matches)
+	}
+	for _, r := range results {
+		if r.Items != 200 || r.Patterns != 10 || r.Matches != matches {
+			t.Errorf("Unexpected result %+v", r)
+		}
+	}
+	join := results[:2]
+	if join[0].Mode != modeJoin || join[0].CacheMisses != 10 || join[1].CacheMisses != 0 || join[1].CacheHits != 2000 {
+		t.Errorf("Unexpected cache behavior of the join %+v", join)
+	}
+	if last := results[len(results)-1]; last.Engine != enginePCRE || last.Mode != modeJoin || last.Run != 2 {
+		t.Errorf("Unexpected last result %+v", last)
+	}
+
+	out, err = runCommand(t, "-n", "50", "-p", "3", "-r", "1")
+	if err != nil {
+		t.Fatalf("regexp-bench failed: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[0], "engine") {
+		t.Errorf("Unexpected table %q", out)
+	}
+
+	for _, args := range [][]string{
+		{"--engine", "pcre2"},
+		{"--mode", "cross"},
+		{"--runs", "0"},
+		{"--format", "xml"},
+		{"--items", "-1"},
+		{"--memo", "-1"},
+	} {
+		if _, err := runCommand(t, args...); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestBenchMemo(t *testing.T) {
+	var matches []int64
+	for _, memo := range []string{"0", "1000"} {
+		out, err := runCommand(t, "-n", "300", "-p", "5", "-r", "1", "--memo", memo, "-o", "json")
+		if err != nil {
+			t.Fatalf("regexp-bench --memo %s failed: %v", memo, err)
+		}
+		var results []result
+		if err := json.Unmarshal([]byte(out), &results); err != nil {
+			t.Fatalf("Invalid JSON output %q: %v", out, err)
+		}
+		for _, r := range results {
+			matches = append(matches, r.Matches)
+		}
+	}
+	// The memo does not change the matches of any mode.
+	if len(matches) != 4 || matches[0] != matches[2] || matches[1] != matches[3] {
+		t.Errorf("Unexpected matches %v", matches)
+	}
+}
@@ -0,0 +1,65 @@
+// Command regexp-bench measures the regexp function suite on synthetic data,
+// so that tuning changes can be compared before they are rolled out:
+//
+//	regexp-bench --items 100000 --patterns 50 --engine re2,pcre --mode join,set
+//
+// It generates the items and patterns tables of the sampledata package in an
+// in-memory database for each engine, then runs every mode against them
+// --runs times, reporting for each run its duration, its throughput, the
+// hits and misses of the pattern cache and the Go allocations per item. The
+// first run starts with an empty cache; the later ones show it warm.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	var opts benchOptions
+
+	cmd := &cobra.Command{
+		Use:   "regexp-bench",
+		Short: "Benchmark REGEXP joins on synthetic item and pattern tables",
+		Long: "Generates a patterns table and an items table of configurable sizes,\n" +
+			"then measures, for each engine and mode, the throughput of matching\n" +
+			"the items against the patterns, the behavior of the pattern cache and\n" +
+			"the Go allocations per item. Modes:\n\n" +
+			"  join          SELECT ... FROM items JOIN patterns ON item REGEXP pattern\n" +
+			"  set           regexp_set_match(item, set) over a pattern set of the table\n" +
+			"  set-combined  the same, with the set compiled into one alternation\n\n" +
+			"Engines are re2 and pcre, the backtracking engine of WithEngineTags;\n" +
+			"the set modes only support re2. The cache columns count the lookups\n" +
+			"of the RE2 pattern cache, which pcre patterns and compiled pattern\n" +
+			"sets bypass.",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBench(opts, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().IntVarP(&opts.items, "items", "n", 100_000, "number of items")
+	cmd.Flags().IntVarP(&opts.patterns, "patterns", "p", 50, "number of patterns")
+	cmd.Flags().Float64Var(&opts.density, "density", 0.5, "fraction of items matching a pattern")
+	cmd.Flags().Int64Var(&opts.seed, "seed", 42, "seed of the generated data")
+	cmd.Flags().IntVarP(&opts.runs, "runs", "r", 3, "number of runs of each mode")
+	cmd.Flags().StringSliceVarP(&opts.engines, "engine", "e", []string{engineRE2}, "engines to compare: re2, pcre")
+	cmd.Flags().StringSliceVarP(&opts.modes, "mode", "m", []string{modeJoin, modeSet}, "modes to compare: join, set, set-combined")
+	cmd.Flags().BoolVar(&opts.caseInsensitive, "case-insensitive", false, "match every pattern ignoring case (WithDefaultCaseInsensitive)")
+	cmd.Flags().BoolVar(&opts.fullFolding, "full-case-folding", false, "use Unicode full case folding (WithCaseFolding(FoldFull))")
+	cmd.Flags().IntVar(&opts.memo, "memo", 0, "memoize up to this many match results (WithResultMemo), 0 to disable")
+	cmd.Flags().StringVarP(&opts.format, "format", "o", formatTable, "output format: table or json")
+
+	return cmd
+}
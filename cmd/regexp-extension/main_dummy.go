@@ -0,0 +1,11 @@
+// Command regexp-extension builds the regexp function suite as a SQLite
+// loadable extension:
+//
+//	go build -buildmode=c-shared -o regexp.so ./cmd/regexp-extension
+//
+// Loading regexp.so (or regexp.dylib / regexp.dll) from the sqlite3 CLI,
+// Python or any other SQLite host registers the same functions, with the same
+// semantics, as sqlite_regexp.RegisterRegexpFunction.
+package main
+
+func main() {}
@@ -0,0 +1,80 @@
+#include <sqlite3ext.h>
+#include <stdlib.h>
+#include "regexp_extension.h"
+SQLITE_EXTENSION_INIT1
+
+// Forward declarations for Go
+extern void go_call(sqlite3_context *ctx, int argc, sqlite3_value **argv);
+extern void go_step(sqlite3_context *ctx, int argc, sqlite3_value **argv);
+extern void go_final(sqlite3_context *ctx);
+extern int go_register_functions(sqlite3* db);
+extern void go_delete_handle(uintptr_t aux);
+
+// Helpers to read arguments
+sqlite3_value* value_at(sqlite3_value **argv, int idx) { return argv[idx]; }
+int value_type(sqlite3_value* v) { return sqlite3_value_type(v); }
+sqlite3_int64 value_int64(sqlite3_value* v) { return sqlite3_value_int64(v); }
+double value_double(sqlite3_value* v) { return sqlite3_value_double(v); }
+const unsigned char* value_text(sqlite3_value* v) { return sqlite3_value_text(v); }
+const void* value_blob(sqlite3_value* v) { return sqlite3_value_blob(v); }
+int value_bytes(sqlite3_value* v) { return sqlite3_value_bytes(v); }
+uintptr_t user_data(sqlite3_context* ctx) { return (uintptr_t)sqlite3_user_data(ctx); }
+
+// Slot holding the handle of the Go state of an aggregate group, zeroed when
+// SQLite allocates it. Without create, NULL is returned if no row was stepped.
+uintptr_t* aggregate_handle(sqlite3_context* ctx, int create) {
+    return (uintptr_t*)sqlite3_aggregate_context(ctx, create ? sizeof(uintptr_t) : 0);
+}
+
+// Statement-scoped data attached to a constant argument, which SQLite deletes
+// once the argument changes or the statement is finalized. Prepared
+// evaluations are kept as cgo handles, deleted by go_delete_handle, and
+// markers as 1, without destructor.
+uintptr_t get_auxdata(sqlite3_context* ctx, int n) { return (uintptr_t)sqlite3_get_auxdata(ctx, n); }
+static void delete_handle(void* p) { go_delete_handle((uintptr_t)p); }
+void set_auxdata_handle(sqlite3_context* ctx, int n, uintptr_t h) { sqlite3_set_auxdata(ctx, n, (void*)h, delete_handle); }
+void set_auxdata_marker(sqlite3_context* ctx, int n) { sqlite3_set_auxdata(ctx, n, (void*)1, NULL); }
+
+// Helpers to set results. Text and blobs are copied by SQLite.
+void result_null(sqlite3_context* ctx) { sqlite3_result_null(ctx); }
+void result_error(sqlite3_context* ctx, const char* msg, int n) { sqlite3_result_error(ctx, msg, n); }
+void result_error_nomem(sqlite3_context* ctx) { sqlite3_result_error_nomem(ctx); }
+void result_int64(sqlite3_context* ctx, sqlite3_int64 v) { sqlite3_result_int64(ctx, v); }
+void result_double(sqlite3_context* ctx, double v) { sqlite3_result_double(ctx, v); }
+void result_text(sqlite3_context* ctx, const char* s, int n) { sqlite3_result_text(ctx, s, n, SQLITE_TRANSIENT); }
+void result_blob(sqlite3_context* ctx, const void* p, int n) { sqlite3_result_blob(ctx, p, n, SQLITE_TRANSIENT); }
+
+// C-visible trampoline that calls into the Go implementation. The function to
+// run is identified by the user data passed to create_function.
+static void call_go(sqlite3_context *ctx, int argc, sqlite3_value **argv) {
+    go_call(ctx, argc, argv);
+}
+
+// Helper to register one function of the suite with SQLite
+int create_function(sqlite3* db, const char* name, int nargs, int deterministic, uintptr_t id) {
+    int flags = SQLITE_UTF8;
+    if (deterministic) {
+        flags |= SQLITE_DETERMINISTIC;
+    }
+    return sqlite3_create_function(db, name, nargs, flags, (void*)id, call_go, NULL, NULL);
+}
+
+// Trampolines for aggregates, identified by the user data passed to
+// create_aggregate.
+static void step_go(sqlite3_context *ctx, int argc, sqlite3_value **argv) {
+    go_step(ctx, argc, argv);
+}
+
+static void final_go(sqlite3_context *ctx) {
+    go_final(ctx);
+}
+
+// Helper to register one aggregate of the suite with SQLite
+int create_aggregate(sqlite3* db, const char* name, int nargs, uintptr_t id) {
+    return sqlite3_create_function(db, name, nargs, SQLITE_UTF8, (void*)id, NULL, step_go, final_go);
+}
+
+int sqlite3_regexp_init(sqlite3 *db, char **pzErrMsg, const sqlite3_api_routines *pApi) {
+    SQLITE_EXTENSION_INIT2(pApi);
+    return go_register_functions(db);
+}
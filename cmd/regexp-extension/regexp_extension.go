@@ -0,0 +1,267 @@
+package main
+
+// #cgo pkg-config: sqlite3
+// #include <stdlib.h>
+// #include "regexp_extension.h"
+import "C"
+
+import (
+	"runtime/cgo"
+	"sync"
+	"unsafe"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+)
+
+// functions and aggregates are the suite registered on every database that
+// loads the extension. The user data of each SQLite function is its index in
+// the slice it comes from.
+var (
+	functions     []core.Function
+	aggregates    []core.Aggregate
+	functionsOnce sync.Once
+)
+
+//export go_register_functions
+func go_register_functions(db *C.sqlite3) C.int {
+	functionsOnce.Do(func() {
+		cfg := core.DefaultConfig()
+		// The similarity functions only add names, and loading them here
+		// saves loading a second extension for them.
+		cfg.Similarity = true
+		functions = core.Functions(&cfg)
+		aggregates = core.Aggregates(&cfg)
+	})
+
+	for i, fn := range functions {
+		deterministic := C.int(0)
+		if fn.Deterministic {
+			deterministic = 1
+		}
+		arities := []int{-1}
+		if fn.MaxArgs >= 0 {
+			arities = arities[:0]
+			for n := fn.MinArgs; n <= fn.MaxArgs; n++ {
+				arities = append(arities, n)
+			}
+		}
+
+		name := C.CString(fn.Name)
+		for _, n := range arities {
+			rc := C.create_function(db, name, C.int(n), deterministic, C.uintptr_t(i))
+			if rc != C.SQLITE_OK {
+				C.free(unsafe.Pointer(name))
+				return rc
+			}
+		}
+		C.free(unsafe.Pointer(name))
+	}
+
+	for i, agg := range aggregates {
+		name := C.CString(agg.Name)
+		for n := agg.MinArgs; n <= agg.MaxArgs; n++ {
+			rc := C.create_aggregate(db, name, C.int(n), C.uintptr_t(i))
+			if rc != C.SQLITE_OK {
+				C.free(unsafe.Pointer(name))
+				return rc
+			}
+		}
+		C.free(unsafe.Pointer(name))
+	}
+	return C.SQLITE_OK
+}
+
+//export go_call
+func go_call(ctx *C.sqlite3_context, argc C.int, argv **C.sqlite3_value) {
+	fn := functions[int(C.user_data(ctx))]
+
+	args := make([]any, int(argc))
+	for i := range args {
+		v := C.value_at(argv, C.int(i))
+		if fn.BorrowsText && i == fn.TextArg {
+			args[i] = borrowedValue(v)
+			continue
+		}
+		args[i] = goValue(v)
+	}
+
+	call := fn.Call
+	if fn.Prepare != nil {
+		if eval := prepared(ctx, fn, args); eval != nil {
+			call = eval
+		}
+	}
+	result, err := call(args...)
+	if err != nil {
+		msg := err.Error()
+		C.result_error(ctx, cString(msg), C.int(len(msg)))
+		return
+	}
+	setResult(ctx, result)
+}
+
+// prepared returns the evaluation of fn prepared for the pattern and flags of
+// args, or nil to use fn.Impl, so that the common "col REGEXP ?" compiles its
+// pattern once per statement and skips the cache lookups. The evaluation is
+// kept in the auxdata of the pattern argument, as twice its handle, and
+// SQLite drops it as soon as the pattern or flags change.
+//
+// SQLite only keeps the auxdata of constant arguments, so the first call
+// only leaves odd markers on both arguments: a pattern read from a column
+// loses them on every row and is never prepared.
+func prepared(ctx *C.sqlite3_context, fn core.Function, args []any) func(args ...any) (any, error) {
+	hasFlags := fn.FlagsArg < len(args)
+	aux := C.get_auxdata(ctx, C.int(fn.PatternArg))
+	if aux != 0 && (!hasFlags || C.get_auxdata(ctx, C.int(fn.FlagsArg)) != 0) {
+		if aux%2 == 0 {
+			return cgo.Handle(aux / 2).Value().(func(args ...any) (any, error))
+		}
+		eval := fn.Prepare(args...)
+		if eval == nil {
+			return nil
+		}
+		C.set_auxdata_handle(ctx, C.int(fn.PatternArg), C.uintptr_t(cgo.NewHandle(eval))*2)
+		return eval
+	}
+
+	C.set_auxdata_marker(ctx, C.int(fn.PatternArg))
+	if hasFlags {
+		C.set_auxdata_marker(ctx, C.int(fn.FlagsArg))
+	}
+	return nil
+}
+
+//export go_delete_handle
+func go_delete_handle(aux C.uintptr_t) {
+	cgo.Handle(aux / 2).Delete()
+}
+
+// go_step feeds a row to the state of its group, which is created on the
+// first row and kept in the SQLite aggregate context as a cgo.Handle.
+//
+//export go_step
+func go_step(ctx *C.sqlite3_context, argc C.int, argv **C.sqlite3_value) {
+	slot := C.aggregate_handle(ctx, 1)
+	if slot == nil {
+		C.result_error_nomem(ctx)
+		return
+	}
+	if *slot == 0 {
+		agg := aggregates[int(C.user_data(ctx))]
+		*slot = C.uintptr_t(cgo.NewHandle(agg.New()))
+	}
+	state := cgo.Handle(*slot).Value().(core.AggregateState)
+
+	args := make([]any, int(argc))
+	for i := range args {
+		args[i] = goValue(C.value_at(argv, C.int(i)))
+	}
+	if err := state.Step(args...); err != nil {
+		msg := err.Error()
+		C.result_error(ctx, cString(msg), C.int(len(msg)))
+	}
+}
+
+// go_final returns the result of a group and releases its state. SQLite calls
+// it even if a step failed, and without any step for an empty input.
+//
+//export go_final
+func go_final(ctx *C.sqlite3_context) {
+	var state core.AggregateState
+	if slot := C.aggregate_handle(ctx, 0); slot != nil && *slot != 0 {
+		h := cgo.Handle(*slot)
+		state = h.Value().(core.AggregateState)
+		h.Delete()
+		*slot = 0
+	} else {
+		state = aggregates[int(C.user_data(ctx))].New()
+	}
+
+	result, err := state.Final()
+	if err != nil {
+		msg := err.Error()
+		C.result_error(ctx, cString(msg), C.int(len(msg)))
+		return
+	}
+	setResult(ctx, result)
+}
+
+// goValue converts a SQLite value to the Go representation expected by the
+// core functions.
+func goValue(v *C.sqlite3_value) any {
+	switch C.value_type(v) {
+	case C.SQLITE_INTEGER:
+		return int64(C.value_int64(v))
+	case C.SQLITE_FLOAT:
+		return float64(C.value_double(v))
+	case C.SQLITE_TEXT:
+		p := C.value_text(v)
+		return C.GoStringN((*C.char)(unsafe.Pointer(p)), C.value_bytes(v))
+	case C.SQLITE_BLOB:
+		p := C.value_blob(v)
+		return C.GoBytes(p, C.value_bytes(v))
+	default:
+		return nil
+	}
+}
+
+// borrowedValue is goValue for the text argument of a function with
+// BorrowsText: texts and BLOBs point into SQLite's buffer, valid until the
+// function returns, to save a copy per row.
+func borrowedValue(v *C.sqlite3_value) any {
+	switch C.value_type(v) {
+	case C.SQLITE_TEXT:
+		p := C.value_text(v)
+		return unsafe.String((*byte)(unsafe.Pointer(p)), int(C.value_bytes(v)))
+	case C.SQLITE_BLOB:
+		p := C.value_blob(v)
+		n := int(C.value_bytes(v))
+		if p == nil {
+			// An empty BLOB has no buffer, but is not NULL.
+			return []byte{}
+		}
+		return unsafe.Slice((*byte)(p), n)
+	default:
+		return goValue(v)
+	}
+}
+
+func setResult(ctx *C.sqlite3_context, result any) {
+	switch r := result.(type) {
+	case nil:
+		C.result_null(ctx)
+	case int64:
+		C.result_int64(ctx, C.sqlite3_int64(r))
+	case float64:
+		C.result_double(ctx, C.double(r))
+	case bool:
+		if r {
+			C.result_int64(ctx, 1)
+		} else {
+			C.result_int64(ctx, 0)
+		}
+	case string:
+		C.result_text(ctx, cString(r), C.int(len(r)))
+	case []byte:
+		if len(r) == 0 {
+			C.result_blob(ctx, unsafe.Pointer(&emptyCString[0]), 0)
+			return
+		}
+		C.result_blob(ctx, unsafe.Pointer(&r[0]), C.int(len(r)))
+	default:
+		msg := "unsupported result type"
+		C.result_error(ctx, cString(msg), C.int(len(msg)))
+	}
+}
+
+var emptyCString = []byte{0}
+
+// cString returns a pointer to the bytes of s for a C call that copies them
+// (SQLite does, with SQLITE_TRANSIENT). A non-nil pointer is returned even for
+// the empty string, because SQLite treats a NULL text pointer as NULL.
+func cString(s string) *C.char {
+	if len(s) == 0 {
+		return (*C.char)(unsafe.Pointer(&emptyCString[0]))
+	}
+	return (*C.char)(unsafe.Pointer(unsafe.StringData(s)))
+}
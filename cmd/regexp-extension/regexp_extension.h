@@ -0,0 +1,27 @@
+#pragma once
+#include <stdint.h>
+#include <sqlite3ext.h>
+
+// Helpers exposed to Go via cgo. Every sqlite3_* call made by an extension has
+// to go through the sqlite3_api routines table, which is only reachable from C.
+sqlite3_value* value_at(sqlite3_value **argv, int idx);
+int value_type(sqlite3_value* v);
+sqlite3_int64 value_int64(sqlite3_value* v);
+double value_double(sqlite3_value* v);
+const unsigned char* value_text(sqlite3_value* v);
+const void* value_blob(sqlite3_value* v);
+int value_bytes(sqlite3_value* v);
+uintptr_t user_data(sqlite3_context* ctx);
+uintptr_t get_auxdata(sqlite3_context* ctx, int n);
+void set_auxdata_handle(sqlite3_context* ctx, int n, uintptr_t h);
+void set_auxdata_marker(sqlite3_context* ctx, int n);
+void result_null(sqlite3_context* ctx);
+void result_error(sqlite3_context* ctx, const char* msg, int n);
+void result_error_nomem(sqlite3_context* ctx);
+void result_int64(sqlite3_context* ctx, sqlite3_int64 v);
+void result_double(sqlite3_context* ctx, double v);
+void result_text(sqlite3_context* ctx, const char* s, int n);
+void result_blob(sqlite3_context* ctx, const void* p, int n);
+int create_function(sqlite3* db, const char* name, int nargs, int deterministic, uintptr_t id);
+uintptr_t* aggregate_handle(sqlite3_context* ctx, int create);
+int create_aggregate(sqlite3* db, const char* name, int nargs, uintptr_t id);
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"database/sql"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+	"github.com/mattn/go-sqlite3"
+)
+
+// TestExtensionMatchesLibrary builds the loadable extension, loads it into a
+// plain go-sqlite3 connection and checks that it returns the same results as
+// the Go registration.
+func TestExtensionMatchesLibrary(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building the c-shared extension is slow")
+	}
+
+	lib := filepath.Join(t.TempDir(), "regexp.so")
+	out, err := exec.Command("go", "build", "-buildmode=c-shared", "-o", lib, ".").CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to build extension: %v\n%s", err, out)
+	}
+
+	sql.Register("sqlite3_regexp_extension_test", &sqlite3.SQLiteDriver{Extensions: []string{lib}})
+	extDB, err := sql.Open("sqlite3_regexp_extension_test", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database with extension: %v", err)
+	}
+	defer func() {
+		_ = extDB.Close()
+	}()
+
+	goDB, err := sqlite_regexp.OpenWithRegexp(":memory:", sqlite_regexp.WithSimilarityFunctions())
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = goDB.Close()
+	}()
+
+	queries := []string{
+		`SELECT 'hello' REGEXP '^h.llo$'`,
+		`SELECT 'hello' REGEXP '^x'`,
+		`SELECT NULL REGEXP 'a'`,
+		`SELECT 123 REGEXP '^\d+$'`,
+		`SELECT regexp_like('ABC', 'b', 'i')`,
+		`SELECT regexp_find_all('a1 b22 c333', '\d+')`,
+		`SELECT regexp_captures('k1=v1 k2=', '(\w+)=(\w+)?')`,
+		`SELECT regexp_tokenize('a, b,,c', '[,\s]+')`,
+		`SELECT regexp_find_all('', 'x')`,
+		`SELECT regexp_extract_all('k1=v1 k2=', '(\w+)=(\w+)?', 2)`,
+		`SELECT regexp_agg(column1, '\d+') FROM (VALUES ('a1'), ('b'), ('c22'))`,
+		`SELECT regexp_agg(column1, 'X', '; ', 'i') FROM (VALUES ('x'), (NULL), ('X'))`,
+		`SELECT regexp_agg(column1, '\d+') FROM (VALUES ('a'))`,
+		`SELECT regexp_agg_json(column1, '\d+') FROM (VALUES ('a1'), ('c22'))`,
+		`SELECT regexp_agg_json(column1, '\d+') FROM (VALUES ('a')) WHERE 0`,
+		`SELECT count_matching(column1, '^a', 'i') FROM (VALUES ('ab'), ('Ac'), ('b'), (NULL))`,
+		`SELECT count_matching(column1, 'a') FROM (VALUES ('a')) WHERE 0`,
+		// Constant patterns are prepared once per statement, patterns and
+		// flags read from columns are not.
+		`SELECT group_concat(column1 REGEXP '^a', ',') FROM (VALUES ('ab'), ('b'), (NULL), ('ac'))`,
+		`SELECT group_concat(regexp_like(column1, 'A', 'i'), ',') FROM (VALUES ('a'), ('b'), ('xa'))`,
+		`SELECT group_concat(regexp(column1, 'ab'), ',') FROM (VALUES ('^a'), ('b$'), ('^x'), ('^a'))`,
+		`SELECT group_concat(regexp('^a', column1, column2), ',') FROM (VALUES ('Ab', 'i'), ('Ab', ''), ('Ab', 'i'), ('Ab', 'i'))`,
+		// Texts and BLOBs are matched in place.
+		`SELECT group_concat(column1 REGEXP 'b.$', ',') FROM (VALUES ('ab'), (x'6263'), (''), (x''), ('abc'))`,
+		`SELECT regexp_like(zeroblob(0), '^$')`,
+		`SELECT group_concat(regexp_full_match(column1, 'a|ab'), ',') FROM (VALUES ('ab'), ('abc'), (NULL))`,
+		`SELECT levenshtein('kitten', 'sitting') || soundex('Robert') || metaphone('Knight')`,
+	}
+	for _, query := range queries {
+		var extResult, goResult sql.NullString
+		if err := extDB.QueryRow(query).Scan(&extResult); err != nil {
+			t.Errorf("%s failed with extension: %v", query, err)
+			continue
+		}
+		if err := goDB.QueryRow(query).Scan(&goResult); err != nil {
+			t.Errorf("%s failed with library: %v", query, err)
+			continue
+		}
+		if extResult != goResult {
+			t.Errorf("%s: extension returned %+v, library returned %+v", query, extResult, goResult)
+		}
+	}
+
+	var result int
+	if err := extDB.QueryRow(`SELECT 'a' REGEXP '['`).Scan(&result); err == nil {
+		t.Error("Expected error for invalid pattern, got nil")
+	}
+	if err := extDB.QueryRow(`SELECT regexp_agg(column1, '[') FROM (VALUES ('a'))`).Scan(&result); err == nil {
+		t.Error("Expected error for invalid aggregate pattern, got nil")
+	}
+}
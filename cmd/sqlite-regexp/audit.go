@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+	"github.com/spf13/cobra"
+)
+
+// errViolations is returned when an audit finds values breaking a rule, so
+// that the command can gate a pipeline.
+var errViolations = errors.New("audit found violations")
+
+type auditOptions struct {
+	file      string
+	rules     []string
+	rulesFile string
+	flags     string
+	samples   int
+}
+
+func newAuditCommand() *cobra.Command {
+	var opts auditOptions
+
+	cmd := &cobra.Command{
+		Use:   "audit --file DATABASE (--rule TABLE.COLUMN=REGEXP)... [--rules FILE]",
+		Short: "Report the values of a SQLite database breaking regexp rules",
+		Long: "Opens a SQLite database read-only, checks the non-NULL values of each\n" +
+			"rule's column against its pattern and prints, per rule, the number of\n" +
+			"values checked, the number of violations and sample offending values.\n" +
+			"It exits with a non-zero status if any rule is violated, e.g.\n\n" +
+			"  sqlite-regexp audit --file app.db --rule 'users.email=^[^@\\s]+@[^@\\s]+$'\n\n" +
+			"Rules can also be read from a JSON file holding an array of\n" +
+			"{\"table\", \"column\", \"pattern\", \"flags\"} objects.",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAudit(opts, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.file, "file", "f", "", "SQLite database to audit")
+	cmd.Flags().StringArrayVarP(&opts.rules, "rule", "r", nil, "rule of the form TABLE.COLUMN=REGEXP (repeatable)")
+	cmd.Flags().StringVar(&opts.rulesFile, "rules", "", "JSON file of rules")
+	cmd.Flags().StringVar(&opts.flags, "flags", "", "matching flags of the --rule rules, e.g. 'i' for case-insensitive")
+	cmd.Flags().IntVarP(&opts.samples, "samples", "n", 3, "number of offending values to show per rule")
+	_ = cmd.MarkFlagRequired("file")
+
+	return cmd
+}
+
+func runAudit(opts auditOptions, out io.Writer) error {
+	rules, err := auditRules(opts)
+	if err != nil {
+		return err
+	}
+
+	db, err := sqlite_regexp.OpenWithRegexp(opts.file, sqlite_regexp.WithReadOnly())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	db.SetMaxOpenConns(1)
+
+	results, err := sqlite_regexp.Audit(db, rules, opts.samples)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "rule\tchecked\tviolations\tsamples")
+	failed := false
+	for _, r := range results {
+		samples := make([]string, len(r.Samples))
+		for i, s := range r.Samples {
+			samples[i] = fmt.Sprintf("%q", s)
+		}
+		_, _ = fmt.Fprintf(w, "%s.%s\t%d\t%d\t%s\n",
+			r.Rule.Table, r.Rule.Column, r.Checked, r.Violations, strings.Join(samples, ", "))
+		failed = failed || r.Violations > 0
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed {
+		return errViolations
+	}
+	return nil
+}
+
+// auditRules collects the rules given with --rule and --rules.
+func auditRules(opts auditOptions) ([]sqlite_regexp.ColumnRule, error) {
+	var rules []sqlite_regexp.ColumnRule
+	if opts.rulesFile != "" {
+		data, err := os.ReadFile(opts.rulesFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &rules); err != nil {
+			return nil, fmt.Errorf("%s: %w", opts.rulesFile, err)
+		}
+	}
+	for _, rule := range opts.rules {
+		target, pattern, ok := strings.Cut(rule, "=")
+		table, column, ok2 := strings.Cut(target, ".")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid rule %q, expected TABLE.COLUMN=REGEXP", rule)
+		}
+		rules = append(rules, sqlite_regexp.ColumnRule{Table: table, Column: column, Pattern: pattern, Flags: opts.flags})
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("no rules given, use --rule or --rules")
+	}
+	return rules, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+)
+
+func TestAudit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.db")
+	db, err := sqlite_regexp.OpenWithRegexp(path)
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE users (email TEXT, zip TEXT);
+		INSERT INTO users VALUES ('a@example.org', '12345'), ('nope', '1234'), (NULL, '54321');`)
+	_ = db.Close()
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	out, err := runCommand(t, "audit", "--file", path, "--rule", `users.zip=^\d{5}$`, "--rule", `users.email=^[^@]+@`)
+	if !errors.Is(err, errViolations) {
+		t.Errorf("Expected errViolations, got %v", err)
+	}
+	expected := "rule         checked  violations  samples\n" +
+		"users.zip    3        1           \"1234\"\n" +
+		"users.email  2        1           \"nope\"\n"
+	if out != expected {
+		t.Errorf("audit = %q, expected %q", out, expected)
+	}
+
+	rules := filepath.Join(dir, "rules.json")
+	if err := os.WriteFile(rules, []byte(`[{"table": "users", "column": "email", "pattern": "^[a-z@.]+$", "flags": "i"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err = runCommand(t, "audit", "--file", path, "--rules", rules)
+	if err != nil {
+		t.Errorf("audit failed: %v", err)
+	}
+	if expected := "rule         checked  violations  samples\nusers.email  2        0           \n"; out != expected {
+		t.Errorf("audit = %q, expected %q", out, expected)
+	}
+
+	for _, args := range [][]string{
+		{"audit", "--file", path},
+		{"audit", "--file", path, "--rule", "users=x"},
+		{"audit", "--file", path, "--rule", "users.mail=x"},
+	} {
+		if _, err := runCommand(t, args...); err == nil || errors.Is(err, errViolations) {
+			t.Errorf("%v: expected an error, got %v", args, err)
+		}
+	}
+}
+
+func TestAuditSpecialPath(t *testing.T) {
+	// Characters with a meaning in URIs are part of the file name.
+	path := filepath.Join(t.TempDir(), "app #1 100%.db")
+	db, err := sqlite_regexp.OpenWithRegexp(path)
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE users (zip TEXT); INSERT INTO users VALUES ('12345')`)
+	_ = db.Close()
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	out, err := runCommand(t, "audit", "--file", path, "--rule", `users.zip=^\d{5}$`)
+	if err != nil {
+		t.Fatalf("audit failed: %v", err)
+	}
+	if expected := "rule       checked  violations  samples\nusers.zip  1        0           \n"; out != expected {
+		t.Errorf("audit = %q, expected %q", out, expected)
+	}
+}
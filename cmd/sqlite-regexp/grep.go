@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+	"github.com/spf13/cobra"
+)
+
+// sqliteMagic is the header every SQLite database file starts with.
+var sqliteMagic = []byte("SQLite format 3\x00")
+
+type grepOptions struct {
+	file      string
+	table     string
+	columns   []string
+	pattern   string
+	flags     string
+	invert    bool
+	delimiter string
+}
+
+func newGrepCommand() *cobra.Command {
+	var opts grepOptions
+
+	cmd := &cobra.Command{
+		Use:   "grep --file FILE --pattern REGEXP [--column COLUMN]...",
+		Short: "Print the rows of a CSV or SQLite file matching a regexp",
+		Long: "Loads a CSV file, or opens a SQLite database read-only, and prints the\n" +
+			"rows where one of the given columns (any column by default) matches\n" +
+			"the pattern, e.g.\n\n" +
+			"  sqlite-regexp grep --file data.csv --column msg --pattern 'timeout|refused'",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGrep(opts, cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.file, "file", "f", "", "CSV file or SQLite database to search")
+	cmd.Flags().StringVarP(&opts.table, "table", "t", "", "table to search in a SQLite database (default: its only table)")
+	cmd.Flags().StringSliceVarP(&opts.columns, "column", "c", nil, "column to match (repeatable; default: every column)")
+	cmd.Flags().StringVarP(&opts.pattern, "pattern", "e", "", "regular expression to match")
+	cmd.Flags().StringVar(&opts.flags, "flags", "", "matching flags, e.g. 'i' for case-insensitive")
+	cmd.Flags().BoolVarP(&opts.invert, "invert-match", "v", false, "print the rows that do not match")
+	cmd.Flags().StringVarP(&opts.delimiter, "delimiter", "d", ",", "CSV field delimiter")
+	_ = cmd.MarkFlagRequired("file")
+	_ = cmd.MarkFlagRequired("pattern")
+
+	return cmd
+}
+
+func runGrep(opts grepOptions, out, errOut io.Writer) error {
+	isSQLite, err := hasSQLiteHeader(opts.file)
+	if err != nil {
+		return err
+	}
+
+	var db *sql.DB
+	if isSQLite {
+		db, err = sqlite_regexp.OpenWithRegexp(opts.file, sqlite_regexp.WithReadOnly())
+	} else {
+		db, err = sqlite_regexp.OpenWithRegexp(":memory:")
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	db.SetMaxOpenConns(1)
+
+	table := opts.table
+	if isSQLite {
+		if table == "" {
+			if table, err = onlyTable(db); err != nil {
+				return err
+			}
+		}
+	} else {
+		if table == "" {
+			table = "data"
+		}
+		if err := loadCSV(db, opts.file, table, opts.delimiter); err != nil {
+			return err
+		}
+	}
+
+	columns, err := tableColumns(db, table)
+	if err != nil {
+		return err
+	}
+	if len(opts.columns) > 0 {
+		// SQLite reads an unknown double-quoted identifier as a string, so
+		// a misspelled column would silently match against its own name.
+		for _, column := range opts.columns {
+			if !slices.Contains(columns, column) {
+				return fmt.Errorf("no such column: %s", column)
+			}
+		}
+		columns = opts.columns
+	}
+
+	conditions := make([]string, len(columns))
+	for i, column := range columns {
+		conditions[i] = fmt.Sprintf("coalesce(regexp(?1, %s, ?2), 0)", quoteIdent(column))
+	}
+	where := strings.Join(conditions, " OR ")
+	if opts.invert {
+		where = "NOT (" + where + ")"
+	}
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s", quoteIdent(table), where)
+
+	shell := NewShell(db, out, errOut)
+	shell.execute(query, opts.pattern, opts.flags)
+	if shell.failed {
+		return errStatementsFailed
+	}
+	return nil
+}
+
+func hasSQLiteHeader(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	header := make([]byte, len(sqliteMagic))
+	if _, err := io.ReadFull(f, header); err != nil {
+		// Files shorter than the header are not databases.
+		return false, nil
+	}
+	return bytes.Equal(header, sqliteMagic), nil
+}
+
+// loadCSV creates table with one TEXT column per header field of the CSV file
+// at path and loads its rows.
+func loadCSV(db *sql.DB, path, table, delimiter string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	r := csv.NewReader(f)
+	if delimiter == `\t` {
+		delimiter = "\t"
+	}
+	if len([]rune(delimiter)) != 1 {
+		return fmt.Errorf("delimiter must be a single character, got %q", delimiter)
+	}
+	r.Comma = []rune(delimiter)[0]
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("reading CSV header: %w", err)
+	}
+	columns := make([]string, len(header))
+	placeholders := make([]string, len(header))
+	for i, name := range header {
+		columns[i] = quoteIdent(name) + " TEXT"
+		placeholders[i] = "?"
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.Exec(fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdent(table), strings.Join(columns, ", "))); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s VALUES (%s)", quoteIdent(table), strings.Join(placeholders, ", ")))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	values := make([]any, len(header))
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		// Short rows are padded with NULLs and long rows truncated.
+		for i := range values {
+			values[i] = nil
+			if i < len(record) {
+				values[i] = record[i]
+			}
+		}
+		if _, err := stmt.Exec(values...); err != nil {
+			line, _ := r.FieldPos(0)
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// onlyTable returns the name of the only table of db.
+func onlyTable(db *sql.DB) (string, error) {
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return "", err
+		}
+		tables = append(tables, name)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	if len(tables) != 1 {
+		return "", fmt.Errorf("database has %d tables (%s), choose one with --table", len(tables), strings.Join(tables, ", "))
+	}
+	return tables[0], nil
+}
+
+// tableColumns returns the columns of table. It reads them from an empty
+// query rather than pragma_table_info, which a read-only connection does
+// not authorize with an argument.
+func tableColumns(db *sql.DB, table string) ([]string, error) {
+	rows, err := db.Query(`SELECT * FROM ` + quoteIdent(table) + ` LIMIT 0`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	return rows.Columns()
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+)
+
+func runCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	cmd := newRootCommand()
+	cmd.SetArgs(args)
+	cmd.SetOut(&out)
+	cmd.SetErr(&errOut)
+	err := cmd.Execute()
+	if errOut.Len() > 0 {
+		t.Logf("stderr: %s", errOut.String())
+	}
+	return out.String(), err
+}
+
+func TestGrepCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	csv := "id,msg\n1,connection timeout\n2,ok\n3,\"conn refused\"\n4\n"
+	if err := os.WriteFile(path, []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--column", "msg", "--pattern", "timeout|refused"}, "id  msg\n1   connection timeout\n3   conn refused\n"},
+		{[]string{"--pattern", "^2$"}, "id  msg\n2   ok\n"},
+		{[]string{"--column", "msg", "--pattern", "OK", "--flags", "i"}, "id  msg\n2   ok\n"},
+		{[]string{"--column", "msg", "--pattern", "o", "-v"}, "id  msg\n4   NULL\n"},
+		{[]string{"--pattern", "nothing"}, ""},
+	}
+	for _, test := range tests {
+		out, err := runCommand(t, append([]string{"grep", "--file", path}, test.args...)...)
+		if err != nil {
+			t.Errorf("grep %v failed: %v", test.args, err)
+			continue
+		}
+		if out != test.expected {
+			t.Errorf("grep %v = %q, expected %q", test.args, out, test.expected)
+		}
+	}
+
+	if _, err := runCommand(t, "grep", "--file", path, "--column", "nope", "--pattern", "a"); err == nil {
+		t.Error("Expected error for unknown column, got nil")
+	}
+}
+
+func TestGrepSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.db")
+	db, err := sqlite_regexp.OpenWithRegexp(path)
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE logs (level TEXT, line TEXT);
+		INSERT INTO logs VALUES ('INFO', 'started'), ('ERROR', 'disk full');
+		CREATE TABLE other (x);`)
+	_ = db.Close()
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	if _, err := runCommand(t, "grep", "--file", path, "--pattern", "full"); err == nil {
+		t.Error("Expected error without --table for a database with two tables, got nil")
+	}
+
+	out, err := runCommand(t, "grep", "--file", path, "--table", "logs", "--pattern", "full")
+	if err != nil {
+		t.Fatalf("grep failed: %v", err)
+	}
+	if out != "level  line\nERROR  disk full\n" {
+		t.Errorf("Unexpected output %q", out)
+	}
+}
+
+func TestGrepSQLiteSpecialPath(t *testing.T) {
+	// Characters with a meaning in URIs are part of the file name.
+	path := filepath.Join(t.TempDir(), "logs #1 100%.db")
+	db, err := sqlite_regexp.OpenWithRegexp(path)
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE logs (line TEXT); INSERT INTO logs VALUES ('disk full'), ('ok')`)
+	_ = db.Close()
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	out, err := runCommand(t, "grep", "--file", path, "--pattern", "full")
+	if err != nil {
+		t.Fatalf("grep failed: %v", err)
+	}
+	if out != "line\ndisk full\n" {
+		t.Errorf("Unexpected output %q", out)
+	}
+}
@@ -0,0 +1,108 @@
+// Command sqlite-regexp is an interactive SQLite shell with the regexp
+// function suite preloaded:
+//
+//	sqlite-regexp data.db
+//	sqlite> SELECT name FROM users WHERE name REGEXP '^J';
+//
+// Statements may span several lines and run once terminated by ';'. The
+// .tables, .schema, .help and .quit commands work as in the sqlite3 shell.
+//
+// The grep subcommand prints the rows of a CSV file or SQLite database that
+// match a pattern:
+//
+//	sqlite-regexp grep --file data.csv --column msg --pattern 'timeout|refused'
+//
+// The audit subcommand reports the values of a SQLite database breaking
+// column rules, and fails if there are any:
+//
+//	sqlite-regexp audit --file app.db --rule 'users.email=^[^@\s]+@[^@\s]+$'
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		// Statement errors have already been reported by the shell.
+		if !errors.Is(err, errStatementsFailed) {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	var (
+		command        string
+		postgres       bool
+		maxResultSize  int
+		maxInputLength int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "sqlite-regexp [database]",
+		Short: "SQLite shell with REGEXP and the regexp function suite",
+		Long: "Opens a SQLite database (an in-memory one by default) with REGEXP,\n" +
+			"regexp_like, regexp_find_all and the other functions of the suite\n" +
+			"registered, and reads SQL statements interactively or from stdin.",
+		Args:          cobra.MaximumNArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dsn := ":memory:"
+			if len(args) > 0 {
+				dsn = args[0]
+			}
+
+			opts := []sqlite_regexp.Option{
+				sqlite_regexp.WithMaxResultSize(maxResultSize),
+				sqlite_regexp.WithMaxInputLength(maxInputLength),
+			}
+			if postgres {
+				opts = append(opts, sqlite_regexp.WithPostgresCompat())
+			}
+
+			db, err := sqlite_regexp.OpenWithRegexp(dsn, opts...)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = db.Close()
+			}()
+			// An in-memory database is only seen by the connection that
+			// created it, so the shell keeps to one.
+			db.SetMaxOpenConns(1)
+
+			shell := NewShell(db, cmd.OutOrStdout(), cmd.ErrOrStderr())
+			if command != "" {
+				return shell.RunScript(command)
+			}
+			return shell.Run(os.Stdin, historyFile())
+		},
+	}
+
+	cmd.AddCommand(newGrepCommand())
+	cmd.AddCommand(newAuditCommand())
+
+	cmd.Flags().StringVarP(&command, "command", "c", "", "run the given statements and exit")
+	cmd.Flags().BoolVar(&postgres, "postgres", false, "register the PostgreSQL compatibility functions")
+	cmd.Flags().IntVar(&maxResultSize, "max-result-size", sqlite_regexp.DefaultMaxResultSize, "maximum size in bytes of JSON results (0 disables the limit)")
+	cmd.Flags().IntVar(&maxInputLength, "max-input-length", 0, "maximum length in bytes of the text processed by the JSON functions (0 disables the limit)")
+
+	return cmd
+}
+
+func historyFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".sqlite_regexp_history")
+}
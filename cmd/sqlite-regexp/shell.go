@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/chzyer/readline"
+)
+
+const (
+	prompt             = "sqlite> "
+	continuationPrompt = "   ...> "
+)
+
+// errStatementsFailed is returned when a non-interactive session had errors,
+// so that scripts piped into the shell exit with a non-zero status.
+var errStatementsFailed = errors.New("one or more statements failed")
+
+// lineReader is the part of *readline.Instance used by the shell, so that
+// piped input can be read without a terminal.
+type lineReader interface {
+	Readline() (string, error)
+	SetPrompt(prompt string)
+}
+
+// Shell runs SQL statements and dot commands against a database.
+type Shell struct {
+	db     *sql.DB
+	out    io.Writer
+	errOut io.Writer
+	failed bool
+}
+
+// NewShell returns a shell that prints results to out and errors to errOut.
+func NewShell(db *sql.DB, out, errOut io.Writer) *Shell {
+	return &Shell{db: db, out: out, errOut: errOut}
+}
+
+// Run reads statements from in until EOF or .quit. When in is a terminal it
+// is read with line editing and history; otherwise it is read as a script.
+func (s *Shell) Run(in *os.File, history string) error {
+	if !readline.IsTerminal(int(in.Fd())) {
+		return s.run(&scriptReader{scanner: bufio.NewScanner(in)}, false)
+	}
+
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:          prompt,
+		HistoryFile:     history,
+		InterruptPrompt: "^C",
+		EOFPrompt:       ".quit",
+		Stdin:           in,
+		Stdout:          s.out,
+		Stderr:          s.errOut,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rl.Close()
+	}()
+	_, _ = fmt.Fprintln(s.out, `Enter ".help" for usage hints.`)
+	return s.run(rl, true)
+}
+
+// RunScript runs the statements and dot commands in script.
+func (s *Shell) RunScript(script string) error {
+	return s.run(&scriptReader{scanner: bufio.NewScanner(strings.NewReader(script))}, false)
+}
+
+func (s *Shell) run(r lineReader, interactive bool) error {
+	var buf strings.Builder
+	for {
+		if buf.Len() == 0 {
+			r.SetPrompt(prompt)
+		} else {
+			r.SetPrompt(continuationPrompt)
+		}
+
+		line, err := r.Readline()
+		if errors.Is(err, readline.ErrInterrupt) {
+			buf.Reset()
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if buf.Len() == 0 && strings.HasPrefix(strings.TrimSpace(line), ".") {
+			if quit := s.dotCommand(strings.TrimSpace(line)); quit {
+				break
+			}
+			continue
+		}
+
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+		statements, rest := splitStatements(buf.String())
+		for _, stmt := range statements {
+			s.execute(stmt)
+		}
+		buf.Reset()
+		if strings.TrimSpace(rest) != "" {
+			buf.WriteString(rest)
+		}
+	}
+
+	// Like the sqlite3 shell, run a final statement missing its semicolon.
+	if stmt := strings.TrimSpace(buf.String()); stmt != "" {
+		s.execute(stmt)
+	}
+
+	if s.failed && !interactive {
+		return errStatementsFailed
+	}
+	return nil
+}
+
+// dotCommand runs a dot command, reporting whether it quits the shell.
+func (s *Shell) dotCommand(line string) bool {
+	fields := strings.Fields(line)
+	var arg string
+	if len(fields) > 1 {
+		arg = fields[1]
+	}
+
+	switch fields[0] {
+	case ".quit", ".exit":
+		return true
+	case ".help":
+		_, _ = fmt.Fprint(s.out, helpText)
+	case ".tables":
+		s.tables(arg)
+	case ".schema":
+		s.schema(arg)
+	default:
+		s.errorf("unknown command %q, enter \".help\" for help", fields[0])
+	}
+	return false
+}
+
+const helpText = `.exit             Exit this program
+.help             Show this message
+.quit             Exit this program
+.schema [REGEXP]  Show the CREATE statements of tables matching REGEXP
+.tables [REGEXP]  List the tables and views matching REGEXP
+`
+
+// tables lists the tables and views whose name matches pattern, or all of
+// them when pattern is empty.
+func (s *Shell) tables(pattern string) {
+	rows, err := s.db.Query(`
+		SELECT name FROM sqlite_master
+		WHERE type IN ('table', 'view')
+		  AND name NOT LIKE 'sqlite_%'
+		  AND (?1 = '' OR name REGEXP ?1)
+		ORDER BY name`, pattern)
+	if err != nil {
+		s.errorf("%v", err)
+		return
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			s.errorf("%v", err)
+			return
+		}
+		_, _ = fmt.Fprintln(s.out, name)
+	}
+	if err := rows.Err(); err != nil {
+		s.errorf("%v", err)
+	}
+}
+
+// schema prints the CREATE statements of the objects whose table name
+// matches pattern, or of every object when pattern is empty.
+func (s *Shell) schema(pattern string) {
+	rows, err := s.db.Query(`
+		SELECT sql FROM sqlite_master
+		WHERE sql IS NOT NULL
+		  AND name NOT LIKE 'sqlite_%'
+		  AND (?1 = '' OR tbl_name REGEXP ?1)
+		ORDER BY tbl_name, type DESC, name`, pattern)
+	if err != nil {
+		s.errorf("%v", err)
+		return
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var stmt string
+		if err := rows.Scan(&stmt); err != nil {
+			s.errorf("%v", err)
+			return
+		}
+		_, _ = fmt.Fprintf(s.out, "%s;\n", stmt)
+	}
+	if err := rows.Err(); err != nil {
+		s.errorf("%v", err)
+	}
+}
+
+// execute runs a statement and prints the rows it returns as a table.
+func (s *Shell) execute(stmt string, args ...any) {
+	rows, err := s.db.Query(stmt, args...)
+	if err != nil {
+		s.errorf("%v", err)
+		return
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		s.errorf("%v", err)
+		return
+	}
+	if len(columns) == 0 {
+		// Statements without a result set are run by Query, but
+		// their error only surfaces from Err.
+		for rows.Next() {
+		}
+		if err := rows.Err(); err != nil {
+			s.errorf("%v", err)
+		}
+		return
+	}
+
+	// As in the sqlite3 shell, the header is only printed above the first row,
+	// so a query failing on its first step or returning nothing prints none.
+	w := tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
+	values := make([]any, len(columns))
+	ptrs := make([]any, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	cells := make([]string, len(columns))
+	for first := true; rows.Next(); first = false {
+		if first {
+			_, _ = fmt.Fprintln(w, strings.Join(columns, "\t"))
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			s.errorf("%v", err)
+			return
+		}
+		for i, v := range values {
+			cells[i] = formatValue(v)
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	_ = w.Flush()
+	if err := rows.Err(); err != nil {
+		s.errorf("%v", err)
+	}
+}
+
+func (s *Shell) errorf(format string, args ...any) {
+	s.failed = true
+	_, _ = fmt.Fprintf(s.errOut, "Error: "+format+"\n", args...)
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// scriptReader reads lines without a terminal.
+type scriptReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *scriptReader) Readline() (string, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.scanner.Text(), nil
+}
+
+func (r *scriptReader) SetPrompt(string) {}
+
+var _ lineReader = &readline.Instance{}
+var _ lineReader = &scriptReader{}
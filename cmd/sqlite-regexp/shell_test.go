@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		input      string
+		statements []string
+		rest       string
+	}{
+		{"SELECT 1;", []string{"SELECT 1;"}, ""},
+		{"SELECT 1; SELECT\n2;\nSELECT 3", []string{"SELECT 1;", "SELECT\n2;"}, "\nSELECT 3"},
+		{"SELECT ';'", nil, "SELECT ';'"},
+		{"SELECT 'a;b'; -- c;\n", []string{"SELECT 'a;b';"}, " -- c;\n"},
+		{"SELECT /* ; */ 1;", []string{"SELECT /* ; */ 1;"}, ""},
+		{`SELECT "a;" FROM [b;];`, []string{`SELECT "a;" FROM [b;];`}, ""},
+		{"CREATE TRIGGER t AFTER INSERT ON a BEGIN\n  DELETE FROM b;\n", nil, "CREATE TRIGGER t AFTER INSERT ON a BEGIN\n  DELETE FROM b;\n"},
+		{"CREATE TEMP TRIGGER t AFTER INSERT ON a BEGIN DELETE FROM b; END;",
+			[]string{"CREATE TEMP TRIGGER t AFTER INSERT ON a BEGIN DELETE FROM b; END;"}, ""},
+		{";;", nil, ""},
+	}
+	for _, test := range tests {
+		statements, rest := splitStatements(test.input)
+		if !reflect.DeepEqual(statements, test.statements) || rest != test.rest {
+			t.Errorf("splitStatements(%q) = %q, %q, expected %q, %q",
+				test.input, statements, rest, test.statements, test.rest)
+		}
+	}
+}
+
+func TestShellScript(t *testing.T) {
+	db, err := sqlite_regexp.OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	db.SetMaxOpenConns(1)
+
+	var out, errOut bytes.Buffer
+	shell := NewShell(db, &out, &errOut)
+	err = shell.RunScript(`CREATE TABLE users (name TEXT);
+CREATE TABLE orders (id INTEGER);
+INSERT INTO users VALUES ('John'), ('Jane'),
+  ('Bob');
+.tables ^u
+.schema users
+SELECT name
+  FROM users
+ WHERE name REGEXP '^J'
+ ORDER BY name;
+SELECT NULL AS n`)
+	if err != nil {
+		t.Fatalf("RunScript failed: %v\n%s", err, errOut.String())
+	}
+
+	expected := `users
+CREATE TABLE users (name TEXT);
+name
+Jane
+John
+n
+NULL
+`
+	if out.String() != expected {
+		t.Errorf("Unexpected output:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
+
+func TestShellScriptErrors(t *testing.T) {
+	db, err := sqlite_regexp.OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	db.SetMaxOpenConns(1)
+
+	var out, errOut bytes.Buffer
+	shell := NewShell(db, &out, &errOut)
+	err = shell.RunScript("SELECT 'a' REGEXP '[';\n.bogus\nSELECT 1 AS one;")
+	if err != errStatementsFailed {
+		t.Errorf("Expected errStatementsFailed, got %v", err)
+	}
+	if !strings.Contains(errOut.String(), "missing closing ]") || !strings.Contains(errOut.String(), `unknown command ".bogus"`) {
+		t.Errorf("Unexpected error output:\n%s", errOut.String())
+	}
+	// Later statements still run.
+	if out.String() != "one\n1\n" {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+}
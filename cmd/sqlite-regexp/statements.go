@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// splitStatements splits input into the complete statements it contains and
+// the incomplete text that follows them. A statement is complete once it is
+// terminated by a ';' outside of quotes and comments; inside CREATE TRIGGER,
+// only a ';' following END terminates it, as in sqlite3_complete.
+func splitStatements(input string) ([]string, string) {
+	var statements []string
+	start := 0
+	var words []string
+	var word strings.Builder
+
+	endWord := func() {
+		if word.Len() > 0 {
+			words = append(words, strings.ToUpper(word.String()))
+			word.Reset()
+		}
+	}
+
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			endWord()
+			end := strings.IndexByte(input[i+1:], c)
+			if end < 0 {
+				return statements, input[start:]
+			}
+			i += end + 1
+		case c == '[':
+			endWord()
+			end := strings.IndexByte(input[i+1:], ']')
+			if end < 0 {
+				return statements, input[start:]
+			}
+			i += end + 1
+		case c == '-' && strings.HasPrefix(input[i:], "--"):
+			endWord()
+			end := strings.IndexByte(input[i:], '\n')
+			if end < 0 {
+				return statements, input[start:]
+			}
+			i += end
+		case c == '/' && strings.HasPrefix(input[i:], "/*"):
+			endWord()
+			end := strings.Index(input[i+2:], "*/")
+			if end < 0 {
+				return statements, input[start:]
+			}
+			i += end + 3
+		case c == ';':
+			endWord()
+			if isTrigger(words) && words[len(words)-1] != "END" {
+				words = append(words, ";")
+				continue
+			}
+			if stmt := strings.TrimSpace(input[start : i+1]); stmt != ";" {
+				statements = append(statements, stmt)
+			}
+			start = i + 1
+			words = words[:0]
+		case c == '_' || c > unicode.MaxASCII || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)):
+			word.WriteByte(c)
+		default:
+			endWord()
+		}
+	}
+	return statements, input[start:]
+}
+
+// isTrigger reports whether the statement starting with words is a CREATE
+// [TEMP] TRIGGER statement.
+func isTrigger(words []string) bool {
+	if len(words) < 2 || words[0] != "CREATE" {
+		return false
+	}
+	if words[1] == "TEMP" || words[1] == "TEMPORARY" {
+		return len(words) > 2 && words[2] == "TRIGGER"
+	}
+	return words[1] == "TRIGGER"
+}
@@ -0,0 +1,128 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+	"github.com/mattn/go-sqlite3"
+)
+
+// CollationOption configures a collation registered with WithCollation or
+// RegisterRegexpCollation.
+type CollationOption func(*collationConfig)
+
+type collationConfig struct {
+	flags   string
+	numeric bool
+}
+
+// NumericKey compares sort keys as numbers, for natural sorting. Keys that
+// are not numbers sort after those that are.
+func NumericKey() CollationOption {
+	return func(c *collationConfig) {
+		c.numeric = true
+	}
+}
+
+// KeyFlags sets the matching flags used to extract sort keys.
+func KeyFlags(flags string) CollationOption {
+	return func(c *collationConfig) {
+		c.flags = flags
+	}
+}
+
+// WithCollation registers, on every connection, a collation named name
+// ordering strings by a sort key extracted with pattern, as described for
+// RegisterRegexpCollation:
+//
+//	db, err := sqlite_regexp.OpenWithRegexp("app.db",
+//		sqlite_regexp.WithCollation("natsort", `(\d+)$`, sqlite_regexp.NumericKey()))
+//	// SELECT name FROM items ORDER BY name COLLATE natsort
+//
+// An invalid pattern or flags make the registration fail.
+func WithCollation(name, pattern string, opts ...CollationOption) Option {
+	collation, err := newCollation(pattern, opts)
+	return func(c *config) {
+		if c.err != nil {
+			return
+		}
+		if err != nil {
+			c.err = fmt.Errorf("collation %s: %w", name, err)
+			return
+		}
+		c.collations = append(c.collations, namedCollation{name: name, collation: collation})
+	}
+}
+
+// namedCollation is a collation of WithCollation.
+type namedCollation struct {
+	name      string
+	collation *core.Collation
+}
+
+// RegisterRegexpCollation registers a collation ordering strings by a sort
+// key extracted with pattern: the first capturing group of its first match,
+// or the whole match if it has no group. For example, to sort "item2" before
+// "item12":
+//
+//	err := sqlite_regexp.RegisterRegexpCollation(db, "natsort", `(\d+)$`, sqlite_regexp.NumericKey())
+//	// SELECT name FROM items ORDER BY name COLLATE natsort
+//
+// Strings without a key sort first, and strings with equal keys are ordered
+// bytewise. Like RegisterRegexpFunction, it registers the collation on one
+// connection of db, so that it only suits a db limited to a single
+// connection that is never closed; WithCollation registers it on every
+// connection of a pool.
+func RegisterRegexpCollation(db *sql.DB, name, pattern string, opts ...CollationOption) error {
+	collation, err := newCollation(pattern, opts)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		sqliteConn, ok := driverConn.(*sqlite3.SQLiteConn)
+		if !ok {
+			return driver.ErrBadConn
+		}
+		return sqliteConn.RegisterCollation(name, collation.Compare)
+	})
+}
+
+// newCollation returns the collation extracting its keys with pattern.
+func newCollation(pattern string, opts []CollationOption) (*core.Collation, error) {
+	var cfg collationConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	flags, err := core.ParseFlags(cfg.flags)
+	if err != nil {
+		return nil, err
+	}
+	collation, err := core.NewCollation(pattern, flags)
+	if err != nil {
+		return nil, err
+	}
+	collation.Numeric = cfg.numeric
+	return collation, nil
+}
+
+// registerCollations registers the collations of cfg with conn.
+func registerCollations(conn *sqlite3.SQLiteConn, cfg *config) error {
+	for _, c := range cfg.collations {
+		if err := conn.RegisterCollation(c.name, c.collation.Compare); err != nil {
+			return err
+		}
+	}
+	return nil
+}
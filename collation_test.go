@@ -0,0 +1,112 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRegisterRegexpCollation(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	if err := RegisterRegexpCollation(db, "natsort", `(\d+)$`, NumericKey()); err != nil {
+		t.Fatalf("RegisterRegexpCollation failed: %v", err)
+	}
+	if err := RegisterRegexpCollation(db, "by_word", `[a-z]+`, KeyFlags("i")); err != nil {
+		t.Fatalf("RegisterRegexpCollation failed: %v", err)
+	}
+
+	_, err = db.Exec(`CREATE TABLE items (name TEXT);
+		INSERT INTO items VALUES ('item12'), ('item2'), ('item1'), ('misc'), ('ITEM3')`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{`SELECT name FROM items ORDER BY name COLLATE natsort`,
+			[]string{"misc", "item1", "item2", "ITEM3", "item12"}},
+		{`SELECT name FROM items ORDER BY name COLLATE natsort DESC`,
+			[]string{"item12", "ITEM3", "item2", "item1", "misc"}},
+		{`SELECT name FROM items WHERE name > 'x10' COLLATE natsort ORDER BY name COLLATE natsort`,
+			[]string{"item12"}},
+		{`SELECT name FROM items ORDER BY name COLLATE by_word, name`,
+			[]string{"ITEM3", "item1", "item12", "item2", "misc"}},
+	}
+	for _, test := range tests {
+		rows, err := db.Query(test.query)
+		if err != nil {
+			t.Errorf("%s failed: %v", test.query, err)
+			continue
+		}
+		var got []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+			got = append(got, name)
+		}
+		_ = rows.Close()
+		if !slices.Equal(got, test.expected) {
+			t.Errorf("%s = %q, expected %q", test.query, got, test.expected)
+		}
+	}
+
+	if err := RegisterRegexpCollation(db, "bad", `(`); err == nil {
+		t.Error("Expected error for invalid pattern, got nil")
+	}
+	if err := RegisterRegexpCollation(db, "bad", `a`, KeyFlags("q")); err == nil {
+		t.Error("Expected error for invalid flags, got nil")
+	}
+}
+
+func TestWithCollation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collation.db")
+	db, err := OpenWithRegexp(path, WithCollation("natsort", `(\d+)$`, NumericKey()))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	if _, err := db.Exec(`CREATE TABLE items (name TEXT);
+		INSERT INTO items VALUES ('item12'), ('item2'), ('item1')`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	// Every connection of the pool has the collation.
+	ctx := context.Background()
+	var conns []*sql.Conn
+	for range 3 {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("Conn failed: %v", err)
+		}
+		conns = append(conns, conn)
+	}
+	for i, conn := range conns {
+		var names string
+		err := conn.QueryRowContext(ctx, `SELECT group_concat(name) FROM (SELECT name FROM items ORDER BY name COLLATE natsort)`).Scan(&names)
+		if err != nil || names != "item1,item2,item12" {
+			t.Errorf("Connection %d: got %q, %v", i, names, err)
+		}
+		_ = conn.Close()
+	}
+
+	for _, opt := range []Option{WithCollation("bad", `(`), WithCollation("bad", `a`, KeyFlags("z"))} {
+		if _, err := OpenWithRegexp(":memory:", opt); err == nil {
+			t.Error("Expected an error for an invalid collation")
+		}
+	}
+}
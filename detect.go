@@ -0,0 +1,96 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"database/sql"
+)
+
+// RegexpSupport reports the parts of the function suite available on a
+// database, see DetectRegexpSupport.
+type RegexpSupport struct {
+	// Operator reports a REGEXP operator returning correct results.
+	Operator bool
+	// Functions holds the functions of the suite that could be called, by
+	// name.
+	Functions map[string]bool
+}
+
+// Has reports whether the function name could be called.
+func (s RegexpSupport) Has(name string) bool {
+	return s.Functions[name]
+}
+
+// supportProbes are the queries checking the functions of the suite, each
+// only needing the function to exist.
+var supportProbes = []struct {
+	name, query string
+}{
+	{"regexp_like", `SELECT regexp_like('a', 'a')`},
+	{"regexp_full_match", `SELECT regexp_full_match('a', 'a')`},
+	{"similar_to", `SELECT similar_to('a', 'a')`},
+	{"glob_to_regexp", `SELECT glob_to_regexp('a')`},
+	{"like_to_regexp", `SELECT like_to_regexp('a')`},
+	{"regexp_replace", `SELECT regexp_replace('a', 'a', 'b')`},
+	{"regexp_extract", `SELECT regexp_extract('a', 'a')`},
+	{"regexp_capture_count", `SELECT regexp_capture_count('(a)')`},
+	{"regexp_valid", `SELECT regexp_valid('a')`},
+	{"regexp_error", `SELECT regexp_error('a')`},
+	{"regexp_find_all", `SELECT regexp_find_all('a', 'a')`},
+	{"regexp_captures", `SELECT regexp_captures('a', 'a')`},
+	{"regexp_tokenize", `SELECT regexp_tokenize('a', 'a')`},
+	{"regexp_extract_all", `SELECT regexp_extract_all('a', 'a')`},
+	{"regexp_agg", `SELECT regexp_agg('a', 'a')`},
+	{"regexp_agg_json", `SELECT regexp_agg_json('a', 'a')`},
+	{"count_matching", `SELECT count_matching('a', 'a')`},
+}
+
+// DetectRegexpSupport probes db, whatever its driver, for a working REGEXP
+// operator and the functions of the suite, so that code written for several
+// databases can choose a query at runtime:
+//
+//	support, err := sqlite_regexp.DetectRegexpSupport(db)
+//	if err == nil && support.Operator {
+//		query = `SELECT * FROM users WHERE name REGEXP ?`
+//	}
+//
+// The probes run on one connection of db. Functions registered with
+// RegisterRegexpFunction are only on one connection of the pool, so use a
+// driver registered with RegisterDriver for the report to hold for all of
+// them. Failing probes only mark the feature as missing; the error returned
+// is that of acquiring the connection, or of ctx.
+func DetectRegexpSupport(db *sql.DB) (RegexpSupport, error) {
+	return DetectRegexpSupportContext(context.Background(), db)
+}
+
+// DetectRegexpSupportContext is like DetectRegexpSupport, but stops once ctx
+// is done.
+func DetectRegexpSupportContext(ctx context.Context, db *sql.DB) (RegexpSupport, error) {
+	support := RegexpSupport{Functions: make(map[string]bool)}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return support, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	// Drivers return integers or booleans.
+	probe := func(query string) (string, bool) {
+		var result sql.NullString
+		if err := conn.QueryRowContext(ctx, query).Scan(&result); err != nil {
+			return "", false
+		}
+		return result.String, true
+	}
+	isTrue := func(s string) bool { return s == "1" || s == "true" }
+
+	match, ok1 := probe(`SELECT 'abc' REGEXP 'b'`)
+	noMatch, ok2 := probe(`SELECT 'abc' REGEXP '^b'`)
+	support.Operator = ok1 && ok2 && isTrue(match) && !isTrue(noMatch)
+	for _, p := range supportProbes {
+		if _, ok := probe(p.query); ok {
+			support.Functions[p.name] = true
+		}
+	}
+	return support, ctx.Err()
+}
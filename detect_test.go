@@ -0,0 +1,60 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestDetectRegexpSupport(t *testing.T) {
+	plain, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer func() {
+		_ = plain.Close()
+	}()
+	support, err := DetectRegexpSupport(plain)
+	if err != nil {
+		t.Fatalf("DetectRegexpSupport failed: %v", err)
+	}
+	if support.Operator || len(support.Functions) != 0 {
+		t.Errorf("Expected no support on a plain database, got %+v", support)
+	}
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	db.SetMaxOpenConns(1)
+	if err := RegisterMatchFunctions(db); err != nil {
+		t.Fatalf("RegisterMatchFunctions failed: %v", err)
+	}
+	support, err = DetectRegexpSupport(db)
+	if err != nil {
+		t.Fatalf("DetectRegexpSupport failed: %v", err)
+	}
+	if !support.Operator || !support.Has("regexp_like") || support.Has("regexp_replace") {
+		t.Errorf("Expected the operator and regexp_like only, got %+v", support)
+	}
+
+	full, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = full.Close()
+	}()
+	full.SetMaxOpenConns(1)
+	support, err = DetectRegexpSupport(full)
+	if err != nil {
+		t.Fatalf("DetectRegexpSupport failed: %v", err)
+	}
+	for _, p := range supportProbes {
+		if !support.Has(p.name) {
+			t.Errorf("Expected %s to be detected", p.name)
+		}
+	}
+}
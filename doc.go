@@ -31,6 +31,28 @@
 // This allows you to categorize items based on flexible pattern matching
 // rather than exact string matches.
 //
+// # Matching Flags
+//
+// Every function accepts an optional trailing flags string: 'i'
+// (case-insensitive), 'c' (case-sensitive), 'm' (multi-line), 's' ('.' matches
+// newline), 'U' (ungreedy) and 'x' (free-spacing: whitespace and #-comments
+// in the pattern are ignored):
+//
+//	SELECT regexp_replace(name, '^mr\.? ', '', 'i') FROM users;
+//	SELECT regexp_extract(ref, 'ORD-(\d+)', 1) FROM orders;
+//
+// # JSON Array Functions
+//
+// regexp_find_all, regexp_captures, regexp_tokenize and regexp_extract_all
+// return their results as JSON arrays that can be unpacked with json_each:
+//
+//	SELECT value FROM json_each(regexp_find_all(body, '#\w+'));
+//
+// Their output is capped by WithMaxResultSize and their input by
+// WithMaxInputLength; once a cap is reached they fail with a
+// *ResultTooLargeError or *InputTooLongError, return NULL under OverflowNull,
+// or return a {"truncated":true,...} envelope under OverflowEnvelope.
+//
 // # Performance
 //
 // The package automatically caches compiled regular expressions to improve
@@ -0,0 +1,118 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// ConnectHook returns a go-sqlite3 connect hook installing the function suite
+// configured by opts on every connection, for drivers registered by the
+// application:
+//
+//	sql.Register("sqlite3_app", &sqlite3.SQLiteDriver{
+//		ConnectHook: sqlite_regexp.ConnectHook(sqlite_regexp.WithPostgresCompat()),
+//	})
+//
+// Unlike RegisterRegexpFunction, which registers the functions on one
+// connection of a *sql.DB, the functions are available on every connection
+// of the pool, however often it recreates them, like with OpenWithRegexp.
+func ConnectHook(opts ...Option) func(*sqlite3.SQLiteConn) error {
+	return connectHook(newConfig(opts...))
+}
+
+func connectHook(cfg *config) func(*sqlite3.SQLiteConn) error {
+	return func(conn *sqlite3.SQLiteConn) error {
+		return registerFunctions(conn, cfg)
+	}
+}
+
+// RegisterDriver registers with database/sql a go-sqlite3 driver named name
+// whose connections all have the function suite configured by opts, see
+// ConnectHook. Like sql.Register, it panics if name is already registered,
+// so it is meant to be called from an init function.
+func RegisterDriver(name string, opts ...Option) {
+	sql.Register(name, &sqlite3.SQLiteDriver{ConnectHook: ConnectHook(opts...)})
+}
+
+var (
+	adoptedMu sync.Mutex
+	adopted   = make(map[*sqlite3.SQLiteDriver]bool)
+)
+
+// AdoptExistingDriver adds the function suite configured by opts to the
+// go-sqlite3 driver the application registered as driverName, such as one
+// whose ConnectHook registers a minimal regexp function, so that its
+// connections get the suite without changing the driver name or DSNs used
+// across the codebase:
+//
+//	sql.Register("sqlite3_app", &sqlite3.SQLiteDriver{ConnectHook: legacyHook})
+//
+//	func main() {
+//		if err := sqlite_regexp.AdoptExistingDriver("sqlite3_app"); err != nil {
+//			log.Fatal(err)
+//		}
+//		...
+//	}
+//
+// The existing ConnectHook keeps running first on every new connection, and
+// the suite then replaces its functions of the same names. Connections
+// opened before are left as they are, and since go-sqlite3 reads the hook
+// without locking, AdoptExistingDriver must be called at startup, before
+// any database of the driver is used. A driver can only be adopted once.
+func AdoptExistingDriver(driverName string, opts ...Option) error {
+	cfg := newConfig(opts...)
+	if cfg.err != nil {
+		return cfg.err
+	}
+	// sql.Open only looks the driver up.
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		return err
+	}
+	drv := db.Driver()
+	_ = db.Close()
+	sqliteDriver, ok := drv.(*sqlite3.SQLiteDriver)
+	if !ok {
+		return fmt.Errorf("driver %q is a %T, not a go-sqlite3 driver", driverName, drv)
+	}
+
+	adoptedMu.Lock()
+	defer adoptedMu.Unlock()
+	if adopted[sqliteDriver] {
+		return fmt.Errorf("driver %q is already adopted", driverName)
+	}
+	adopted[sqliteDriver] = true
+	previous, hook := sqliteDriver.ConnectHook, connectHook(cfg)
+	sqliteDriver.ConnectHook = func(conn *sqlite3.SQLiteConn) error {
+		if previous != nil {
+			if err := previous(conn); err != nil {
+				return err
+			}
+		}
+		return hook(conn)
+	}
+	return nil
+}
+
+// connector opens connections to dsn with driver, for sql.OpenDB.
+type connector struct {
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+}
+
+var _ driver.Connector = &connector{}
+
+// Connect implements driver.Connector.
+func (c *connector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+// Driver implements driver.Connector.
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
@@ -0,0 +1,27 @@
+// Package driver registers a go-sqlite3 driver with the regexp function suite
+// on every connection, so that tools only need a blank import and a driver
+// name to use it:
+//
+//	import _ "github.com/go-go-golems/go-sqlite-regexp/driver"
+//
+//	db, err := sql.Open("sqlite3_regexp", "app.db")
+//
+// Tools whose configuration names drivers by their own conventions register
+// the suite under those names instead, from an init function:
+//
+//	func init() {
+//		sqlite_regexp.RegisterDriver("sqlite-regexp", sqlite_regexp.WithPostgresCompat())
+//	}
+package driver
+
+import (
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+)
+
+// DriverName is the name of the driver registered by the package, with the
+// default options of the suite.
+const DriverName = "sqlite3_regexp"
+
+func init() {
+	sqlite_regexp.RegisterDriver(DriverName)
+}
@@ -0,0 +1,37 @@
+package driver
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestDriver(t *testing.T) {
+	db, err := sql.Open(DriverName, ":memory:")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// Every connection of the pool has the functions, not just the first.
+	ctx := context.Background()
+	var conns []*sql.Conn
+	for range 3 {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("Conn failed: %v", err)
+		}
+		conns = append(conns, conn)
+	}
+	for i, conn := range conns {
+		var result int
+		if err := conn.QueryRowContext(ctx, `SELECT regexp_like('abc', '^a')`).Scan(&result); err != nil {
+			t.Errorf("Connection %d: %v", i, err)
+		} else if result != 1 {
+			t.Errorf("Connection %d: expected 1, got %d", i, result)
+		}
+		_ = conn.Close()
+	}
+}
@@ -0,0 +1,78 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+type notSQLiteDriver struct{}
+
+func (notSQLiteDriver) Open(string) (driver.Conn, error) {
+	return nil, driver.ErrBadConn
+}
+
+func TestAdoptExistingDriver(t *testing.T) {
+	// A hand-rolled hook with a naive regexp and a function of its own.
+	hookCalls := 0
+	sql.Register("sqlite3_adopt_test", &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			hookCalls++
+			if err := conn.RegisterFunc("regexp", func(pattern, text string) bool {
+				return regexp.MustCompile(pattern).MatchString(text)
+			}, true); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("app_version", func() string { return "1.2" }, true)
+		},
+	})
+
+	cache := NewCache()
+	if err := AdoptExistingDriver("sqlite3_adopt_test", WithCache(cache)); err != nil {
+		t.Fatalf("AdoptExistingDriver failed: %v", err)
+	}
+	if err := AdoptExistingDriver("sqlite3_adopt_test"); err == nil {
+		t.Error("Expected an error adopting a driver twice")
+	}
+
+	db, err := sql.Open("sqlite3_adopt_test", ":memory:")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var version string
+	var matched, like int
+	var invalid sql.NullInt64
+	if err := db.QueryRow(`SELECT app_version(), 'ABC' REGEXP '^a', regexp_like('ABC', '^a', 'i')`).
+		Scan(&version, &matched, &like); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if version != "1.2" || matched != 0 || like != 1 {
+		t.Errorf("Got %q, %d, %d, expected \"1.2\", 0, 1", version, matched, like)
+	}
+	// The naive regexp would panic on an invalid pattern.
+	if err := db.QueryRow(`SELECT 'a' REGEXP '('`).Scan(&invalid); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+	if hookCalls == 0 {
+		t.Error("The existing hook was not called")
+	}
+	if n := cache.Stats().Registrations; n == 0 {
+		t.Error("The suite was not registered with its cache")
+	}
+
+	for _, name := range []string{"sqlite3_adopt_unknown", "sqlite3_adopt_other"} {
+		if name == "sqlite3_adopt_other" {
+			sql.Register(name, notSQLiteDriver{})
+		}
+		if err := AdoptExistingDriver(name); err == nil {
+			t.Errorf("Expected an error adopting %s", name)
+		}
+	}
+}
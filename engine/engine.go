@@ -0,0 +1,107 @@
+// Package engine exposes the matcher of the regexp function suite without
+// database/sql or SQLite, for tools that need the same semantics as the SQL
+// functions: the same flags, pattern library expansion and compiled pattern
+// cache.
+//
+//	e := engine.New(engine.WithFlags("i"))
+//	ok, err := e.Match(`^error:`, line)
+//
+// Engines share the process-wide compiled pattern cache, like databases
+// registered without sqlite_regexp.WithCache, unless given their own.
+package engine
+
+import (
+	"regexp"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+)
+
+// Cache is a cache of compiled patterns, the same as sqlite_regexp.Cache.
+type Cache = core.Cache
+
+// NewCache returns an empty cache of compiled patterns.
+func NewCache() *Cache {
+	return core.NewCache()
+}
+
+// Library is a library of named patterns, the same as
+// sqlite_regexp.PatternLibrary.
+type Library = core.Library
+
+// NewLibrary returns an empty pattern library.
+func NewLibrary() *Library {
+	return core.NewLibrary()
+}
+
+// IsPatternError reports whether err is caused by an invalid pattern, flag or
+// library include.
+func IsPatternError(err error) bool {
+	return core.IsPatternError(err)
+}
+
+// Engine compiles and matches patterns. It is safe for concurrent use.
+type Engine struct {
+	cfg   core.Config
+	flags core.Flags
+	err   error
+}
+
+// Option configures an Engine.
+type Option func(*Engine)
+
+// WithFlags sets the flags used by Match, in the syntax of the flags argument
+// of the SQL functions ("i", "ms", ...). Invalid flags make Match fail.
+func WithFlags(flags string) Option {
+	return func(e *Engine) {
+		e.flags, e.err = core.ParseFlags(flags)
+	}
+}
+
+// WithCache makes the engine cache its compiled patterns in c instead of the
+// process-wide cache.
+func WithCache(c *Cache) Option {
+	return func(e *Engine) {
+		e.cfg.Cache = c
+	}
+}
+
+// WithLibrary expands {{name}} references in patterns from l, like
+// sqlite_regexp.WithPatternLibrary.
+func WithLibrary(l *Library) Option {
+	return func(e *Engine) {
+		e.cfg.Library = l
+	}
+}
+
+// New returns an engine configured by opts.
+func New(opts ...Option) *Engine {
+	e := &Engine{cfg: core.DefaultConfig()}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Match reports whether text contains a match of pattern, as
+// "text REGEXP pattern" does with the flags of the engine.
+func (e *Engine) Match(pattern, text string) (bool, error) {
+	if e.err != nil {
+		return false, e.err
+	}
+	re, err := e.cfg.Compile(pattern, e.flags)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(text), nil
+}
+
+// Compile returns the compiled form of pattern with flags, in the syntax of
+// the flags argument of the SQL functions, ignoring the flags of the engine.
+// The result is cached and must not be modified.
+func (e *Engine) Compile(pattern, flags string) (*regexp.Regexp, error) {
+	f, err := core.ParseFlags(flags)
+	if err != nil {
+		return nil, err
+	}
+	return e.cfg.Compile(pattern, f)
+}
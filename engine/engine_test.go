@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"testing"
+)
+
+func TestEngine(t *testing.T) {
+	lib := NewLibrary()
+	if err := lib.Define("word", `[a-z]+`); err != nil {
+		t.Fatalf("Define failed: %v", err)
+	}
+	cache := NewCache()
+	e := New(WithFlags("i"), WithLibrary(lib), WithCache(cache))
+
+	tests := []struct {
+		pattern, text string
+		expected      bool
+	}{
+		{`^hello`, "HELLO world", true},
+		{`^{{word}} \d+$`, "Order 42", true},
+		{`^\d+$`, "abc", false},
+	}
+	for _, tt := range tests {
+		ok, err := e.Match(tt.pattern, tt.text)
+		if err != nil || ok != tt.expected {
+			t.Errorf("Match(%q, %q) = %v, %v; expected %v", tt.pattern, tt.text, ok, err, tt.expected)
+		}
+	}
+	if cache.Size() != len(tests) {
+		t.Errorf("Expected %d patterns in the engine cache, got %d", len(tests), cache.Size())
+	}
+
+	re, err := e.Compile(`^a.b$`, "s")
+	if err != nil || !re.MatchString("a\nb") || re.MatchString("A\nB") {
+		t.Errorf("Compile ignored its flags: %v, %v", re, err)
+	}
+
+	if _, err := e.Match(`(`, "a"); !IsPatternError(err) {
+		t.Errorf("Expected a pattern error, got %v", err)
+	}
+	if _, err := New(WithFlags("q")).Match("a", "a"); !IsPatternError(err) {
+		t.Errorf("Expected an invalid flag error, got %v", err)
+	}
+}
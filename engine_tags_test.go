@@ -0,0 +1,60 @@
+package sqlite_regexp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEngineTags(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:", WithEngineTags(), WithFunctionAlias("rlike"))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	_, err = db.Exec(`CREATE TABLE files (name TEXT);
+		INSERT INTO files VALUES ('main.go'), ('test_main.go'), ('util.go'), ('README.md');
+		CREATE TABLE rules (name TEXT, pattern TEXT);
+		INSERT INTO rules VALUES ('go', '\.go$'), ('source', 'pcre:^(?!test_)\w+\.go$'), ('docs', 're2:(?i)readme')`)
+	if err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	rows, err := db.Query(`SELECT r.name || ':' || f.name FROM rules r JOIN files f ON f.name REGEXP r.pattern ORDER BY r.rowid, f.rowid`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	var got []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		got = append(got, s)
+	}
+	_ = rows.Close()
+	expected := "go:main.go,go:test_main.go,go:util.go,source:main.go,source:util.go,docs:README.md"
+	if strings.Join(got, ",") != expected {
+		t.Errorf("Got matches %q, expected %s", got, expected)
+	}
+
+	var count int
+	for query, want := range map[string]int{
+		`SELECT count(*) FROM files WHERE name REGEXP 'pcre:^(?!test_).*\.go$'`:                 2,
+		`SELECT count(*) FROM files WHERE regexp_like(name, 'pcre:^(?!test_)', 'i')`:            3,
+		`SELECT count(*) FROM files WHERE regexp_full_match(name, 'pcre:(?:(?!test_)\w)+\.go')`: 2,
+		`SELECT count(*) FROM files WHERE rlike('pcre:^m(?=ain)', name)`:                        1,
+	} {
+		if err := db.QueryRow(query).Scan(&count); err != nil {
+			t.Errorf("%s failed: %v", query, err)
+		} else if count != want {
+			t.Errorf("%s = %d, expected %d", query, count, want)
+		}
+	}
+
+	if _, err := db.Exec(`SELECT regexp_extract('ab', 'pcre:(?<=a)b')`); err == nil {
+		t.Error("Expected regexp_extract to reject a pcre pattern")
+	}
+}
@@ -0,0 +1,71 @@
+// Package entregexp opens ent drivers for SQLite databases with the regexp
+// function suite on every connection, and provides REGEXP predicates for
+// ent's query builders:
+//
+//	drv, err := entregexp.Open("file:app.db?_fk=1")
+//	...
+//	client := ent.NewClient(ent.Driver(drv))
+//	users, err := client.User.Query().
+//		Where(entregexp.Regexp(user.FieldEmail, `@example\.(com|org)$`)).
+//		All(ctx)
+//
+// The predicates are functions of *sql.Selector, which the predicate types
+// generated by ent, such as predicate.User, are defined as, so they can be
+// passed wherever those are expected, including to the generated And, Or
+// and Not.
+package entregexp
+
+import (
+	"context"
+
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+)
+
+// Open opens an ent driver for the SQLite database dsn, with the function
+// suite configured by opts on every connection, like
+// sqlite_regexp.OpenWithRegexp.
+func Open(dsn string, opts ...sqlite_regexp.Option) (*entsql.Driver, error) {
+	return OpenContext(context.Background(), dsn, opts...)
+}
+
+// OpenContext is like Open, but gives up once ctx is done while opening the
+// first connection.
+func OpenContext(ctx context.Context, dsn string, opts ...sqlite_regexp.Option) (*entsql.Driver, error) {
+	db, err := sqlite_regexp.OpenWithRegexpContext(ctx, dsn, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return entsql.OpenDB(dialect.SQLite, db), nil
+}
+
+// Regexp returns the predicate "field REGEXP pattern".
+func Regexp(field, pattern string) func(*entsql.Selector) {
+	return func(s *entsql.Selector) {
+		s.Where(entsql.P(func(b *entsql.Builder) {
+			b.Ident(s.C(field)).WriteString(" REGEXP ").Arg(pattern)
+		}))
+	}
+}
+
+// NotRegexp returns the predicate "field NOT REGEXP pattern", which, like
+// any comparison, is not true for a NULL field.
+func NotRegexp(field, pattern string) func(*entsql.Selector) {
+	return func(s *entsql.Selector) {
+		s.Where(entsql.P(func(b *entsql.Builder) {
+			b.Ident(s.C(field)).WriteString(" NOT REGEXP ").Arg(pattern)
+		}))
+	}
+}
+
+// RegexpFlags returns the predicate "regexp(pattern, field, flags)", the
+// REGEXP operator with matching flags such as "i".
+func RegexpFlags(field, pattern, flags string) func(*entsql.Selector) {
+	return func(s *entsql.Selector) {
+		s.Where(entsql.P(func(b *entsql.Builder) {
+			b.WriteString("regexp(").Arg(pattern).Comma().Ident(s.C(field)).Comma().Arg(flags).WriteString(")")
+		}))
+	}
+}
@@ -0,0 +1,82 @@
+package entregexp
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+)
+
+// predicate stands for the predicate types generated by ent, such as
+// predicate.User.
+type predicate func(*entsql.Selector)
+
+func TestPredicates(t *testing.T) {
+	ctx := context.Background()
+	// A file, since every connection to ":memory:" has a database of its own.
+	drv, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() {
+		_ = drv.Close()
+	}()
+	if drv.Dialect() != dialect.SQLite {
+		t.Errorf("Dialect() = %q, expected %q", drv.Dialect(), dialect.SQLite)
+	}
+
+	if err := drv.Exec(ctx, `CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT)`, []any{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, email := range []any{"ann@example.com", "bob@EXAMPLE.org", "eve@evil.test", nil} {
+		if err := drv.Exec(ctx, `INSERT INTO users (email) VALUES (?)`, []any{email}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		preds    []predicate
+		expected []string
+	}{
+		{"Regexp", []predicate{Regexp("email", `@example\.(com|org)$`)}, []string{"ann@example.com"}},
+		{"RegexpFlags", []predicate{RegexpFlags("email", `@example\.`, "i")}, []string{"ann@example.com", "bob@EXAMPLE.org"}},
+		{"NotRegexp", []predicate{NotRegexp("email", `@example`)}, []string{"bob@EXAMPLE.org", "eve@evil.test"}},
+		{"Or", []predicate{entsql.OrPredicates(Regexp("email", `^a`), Regexp("email", `^e`))}, []string{"ann@example.com", "eve@evil.test"}},
+		{"Not", []predicate{entsql.NotPredicates(RegexpFlags("email", `example`, "i"))}, []string{"eve@evil.test"}},
+		{"And", []predicate{RegexpFlags("email", `example`, "i"), NotRegexp("email", `^a`)}, []string{"bob@EXAMPLE.org"}},
+	}
+	for _, tt := range tests {
+		users := entsql.Table("users")
+		s := entsql.Select(users.C("email")).From(users).OrderBy(users.C("id"))
+		for _, p := range tt.preds {
+			p(s)
+		}
+		query, args := s.Query()
+
+		var rows entsql.Rows
+		if err := drv.Query(ctx, query, args, &rows); err != nil {
+			t.Errorf("%s: %s failed: %v", tt.name, query, err)
+			continue
+		}
+		var emails []string
+		if err := entsql.ScanSlice(rows, &emails); err != nil {
+			t.Fatal(err)
+		}
+		_ = rows.Close()
+		if strings.Join(emails, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("%s: %s found %q, expected %q", tt.name, query, emails, tt.expected)
+		}
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	if _, err := Open(":memory:", sqlite_regexp.WithFunctionAlias("not a name")); err == nil {
+		t.Error("Expected an error for an invalid option")
+	}
+}
@@ -0,0 +1,56 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+	"github.com/mattn/go-sqlite3"
+)
+
+// The predicates below classify the errors of the helpers of this package
+// and of queries calling the functions, so that applications can decide
+// whether to retry without matching error text:
+//
+//	for attempt := 0; ; attempt++ {
+//		_, err = db.ExecContext(ctx, query)
+//		if err == nil || !sqlite_regexp.IsRetryable(err) || attempt == 3 {
+//			break
+//		}
+//		time.Sleep(backoff(attempt))
+//	}
+
+// IsPatternError reports whether err is caused by an invalid pattern or
+// flags: a syntax error, an invalid flag, or an unknown or cyclic library
+// include. Retrying such errors is pointless until the pattern is fixed.
+func IsPatternError(err error) bool {
+	if core.IsPatternError(err) {
+		return true
+	}
+	// The errors of the functions reach the caller as SQLite errors holding
+	// their message.
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrError &&
+		core.IsPatternMessage(sqliteErr.Error())
+}
+
+// IsTimeout reports whether err is caused by a context deadline, including
+// queries interrupted when their context expired.
+func IsTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// IsRetryable reports whether the operation failing with err may succeed
+// if retried: the database was busy or locked by another connection, or
+// the operation timed out. Pattern errors, cancellations and other errors
+// are not retryable.
+func IsRetryable(err error) bool {
+	if IsTimeout(err) {
+		return true
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	return false
+}
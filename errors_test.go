@@ -0,0 +1,117 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestErrorClassification(t *testing.T) {
+	lib := NewPatternLibrary()
+	db, err := OpenWithRegexp(":memory:", WithPatternLibrary(lib), WithPostgresCompat())
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	patternErrors := map[string]string{
+		"syntax":           `SELECT 'abc' REGEXP '('`,
+		"flag":             `SELECT regexp_like('abc', 'a', 'z')`,
+		"postgres option":  `SELECT regexp_matches('abc', 'a', 'z')`,
+		"unknown include":  `SELECT 'abc' REGEXP '{{missing}}'`,
+		"extract argument": `SELECT regexp_extract('abc', '(a', 1)`,
+	}
+	for name, query := range patternErrors {
+		_, err := db.Exec(query)
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+			continue
+		}
+		if !IsPatternError(err) || IsRetryable(err) || IsTimeout(err) {
+			t.Errorf("%s: %v misclassified", name, err)
+		}
+	}
+
+	err = CreateValidationTriggers(db, ColumnRule{Table: "t", Column: "c", Pattern: `[`})
+	if !IsPatternError(err) || IsRetryable(err) {
+		t.Errorf("Trigger rule error %v misclassified", err)
+	}
+
+	_, err = db.Exec(`SELECT * FROM missing_table`)
+	if err == nil || IsPatternError(err) || IsRetryable(err) {
+		t.Errorf("Missing table error %v misclassified", err)
+	}
+	if IsPatternError(nil) || IsRetryable(nil) || IsTimeout(nil) {
+		t.Error("nil classified as an error")
+	}
+	if IsRetryable(context.Canceled) || !IsRetryable(context.DeadlineExceeded) {
+		t.Error("Context errors misclassified")
+	}
+}
+
+func TestErrorClassificationBusy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "busy.db")
+	db, err := OpenWithRegexp(path + "?_busy_timeout=0")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	if _, err := db.Exec(`CREATE TABLE t (x)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	other, err := OpenWithRegexp(path + "?_busy_timeout=0")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = other.Close()
+	}()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if _, err := tx.Exec(`INSERT INTO t VALUES (1)`); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	_, err = other.Exec(`INSERT INTO t VALUES (2)`)
+	if err == nil {
+		t.Fatal("Expected a busy error")
+	}
+	if !IsRetryable(err) || IsPatternError(err) || IsTimeout(err) {
+		t.Errorf("Busy error %v misclassified", err)
+	}
+}
+
+func TestErrorClassificationTimeout(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var n int64
+	err = db.QueryRowContext(ctx, `WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n)
+		SELECT count(*) FROM n WHERE printf('%d', i) REGEXP 'x'`).Scan(&n)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected a deadline error, got %v", err)
+	}
+	if !IsTimeout(err) || !IsRetryable(err) || IsPatternError(err) {
+		t.Errorf("Timeout error %v misclassified", err)
+	}
+}
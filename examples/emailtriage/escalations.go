@@ -0,0 +1,37 @@
+//go:build sqlite_vtable || vtable
+
+package emailtriage
+
+import "database/sql"
+
+// Escalation is a message followed by another one matching a later pattern,
+// such as an outage report followed by a security concern.
+type Escalation struct {
+	From, To string // Message-IDs
+	// Messages is how many messages later To came.
+	Messages int
+}
+
+// Escalations returns the messages whose subject or body matches first and
+// that are followed, within the next within messages, by one matching then.
+// It runs the regexp_sequence table-valued function over the messages in
+// the order they were ingested, so it needs the suite built with the
+// sqlite_vtable tag.
+func Escalations(db *sql.DB, first, then string, within int) ([]Escalation, error) {
+	rows, err := db.Query(`SELECT start_key, end_key, rows - 1 FROM regexp_sequence(
+		'SELECT message_id, subject || char(10) || body FROM messages ORDER BY id',
+		?, ?, ?)`, first, then, within)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var escalations []Escalation
+	for rows.Next() {
+		var e Escalation
+		if err := rows.Scan(&e.From, &e.To, &e.Messages); err != nil {
+			return nil, err
+		}
+		escalations = append(escalations, e)
+	}
+	return escalations, rows.Err()
+}
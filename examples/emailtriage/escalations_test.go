@@ -0,0 +1,29 @@
+//go:build sqlite_vtable || vtable
+
+package emailtriage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEscalations(t *testing.T) {
+	db := openInbox(t)
+	for _, tt := range []struct {
+		first, then string
+		within      int
+		want        []Escalation
+	}{
+		{`\bINC-\d+`, `(?i)\bpassword\b`, 2, []Escalation{{"<2@example.com>", "<4@example.com>", 2}}},
+		{`\bINC-\d+`, `(?i)\bpassword\b`, 1, nil},
+		{`\bINV-\d+`, `(?i)\binvoice\b`, 3, []Escalation{{"<1@example.com>", "<4@example.com>", 3}}},
+	} {
+		got, err := Escalations(db, tt.first, tt.then, tt.within)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Escalations(%q, %q, %d) = %+v, want %+v", tt.first, tt.then, tt.within, got, tt.want)
+		}
+	}
+}
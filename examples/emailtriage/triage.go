@@ -0,0 +1,284 @@
+// Package emailtriage is a worked example of the regexp function suite: it
+// ingests an mbox into SQLite, applies a rule bundle stored in a table,
+// materializes the resulting tags and exposes coverage views.
+//
+//	db, _ := sql.Open(driver.DriverName, "inbox.db")
+//	_ = emailtriage.Setup(db)
+//	_, _ = emailtriage.Ingest(db, mbox)
+//	_ = emailtriage.LoadRules(db, emailtriage.DefaultRules())
+//	_ = emailtriage.Materialize(db)
+//
+// Rules are plain rows matched with a join on regexp(), so that a bundle can
+// be changed without touching the code, and references such as ticket
+// numbers are extracted by expanding regexp_find_all with json_each. Built
+// with the sqlite_vtable tag, Escalations correlates messages with the
+// regexp_sequence table-valued function.
+package emailtriage
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+)
+
+//go:embed rules.json
+var defaultRules []byte
+
+// Rule tags the messages whose field matches pattern, with flags in the
+// syntax of the flags argument of the SQL functions.
+type Rule struct {
+	Tag     string `json:"tag"`
+	Field   string `json:"field"` // "from", "subject" or "body"
+	Pattern string `json:"pattern"`
+	Flags   string `json:"flags,omitempty"`
+}
+
+// DefaultRules returns the rule bundle shipped with the package.
+func DefaultRules() []Rule {
+	var rules []Rule
+	if err := json.Unmarshal(defaultRules, &rules); err != nil {
+		panic(fmt.Sprintf("invalid embedded rules: %v", err))
+	}
+	return rules
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS messages (
+	id         INTEGER PRIMARY KEY,
+	message_id TEXT,
+	sender     TEXT,
+	subject    TEXT,
+	date       TEXT,
+	body       TEXT
+);
+CREATE TABLE IF NOT EXISTS rules (
+	id      INTEGER PRIMARY KEY,
+	tag     TEXT NOT NULL,
+	field   TEXT NOT NULL CHECK (field IN ('from', 'subject', 'body')),
+	pattern TEXT NOT NULL,
+	flags   TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS message_tags (
+	message INTEGER NOT NULL REFERENCES messages(id),
+	rule    INTEGER NOT NULL REFERENCES rules(id),
+	tag     TEXT NOT NULL,
+	PRIMARY KEY (message, rule)
+);
+CREATE TABLE IF NOT EXISTS message_refs (
+	message INTEGER NOT NULL REFERENCES messages(id),
+	ref     TEXT NOT NULL,
+	PRIMARY KEY (message, ref)
+);
+CREATE VIEW IF NOT EXISTS tag_coverage AS
+	SELECT tag, count(DISTINCT message) AS messages
+	FROM message_tags GROUP BY tag;
+CREATE VIEW IF NOT EXISTS rule_coverage AS
+	SELECT r.id, r.tag, r.field, r.pattern, count(t.message) AS hits
+	FROM rules r LEFT JOIN message_tags t ON t.rule = r.id
+	GROUP BY r.id;
+CREATE VIEW IF NOT EXISTS untagged_messages AS
+	SELECT m.* FROM messages m
+	WHERE NOT EXISTS (SELECT 1 FROM message_tags t WHERE t.message = m.id);
+`
+
+// Setup creates the tables and views of the pipeline if they do not exist.
+func Setup(db *sql.DB) error {
+	_, err := db.Exec(schema)
+	return err
+}
+
+// Ingest reads an mbox and inserts its messages, returning how many were
+// inserted. Messages start at lines beginning with "From ", and ">From "
+// lines of the bodies are unescaped.
+func Ingest(db *sql.DB, r io.Reader) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	stmt, err := tx.Prepare(`INSERT INTO messages (message_id, sender, subject, date, body) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	n := 0
+	err = splitMbox(r, func(raw []byte) error {
+		msg, err := mail.ReadMessage(bytes.NewReader(raw))
+		if err != nil {
+			return fmt.Errorf("message %d: %w", n+1, err)
+		}
+		body, err := io.ReadAll(msg.Body)
+		if err != nil {
+			return fmt.Errorf("message %d: %w", n+1, err)
+		}
+		sender := msg.Header.Get("From")
+		if addr, err := mail.ParseAddress(sender); err == nil {
+			sender = addr.Address
+		}
+		if _, err := stmt.Exec(msg.Header.Get("Message-ID"), sender, msg.Header.Get("Subject"),
+			msg.Header.Get("Date"), strings.TrimRight(string(body), "\n")); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// splitMbox calls fn with the raw headers and body of each message of r.
+func splitMbox(r io.Reader, fn func(raw []byte) error) error {
+	var msg []byte
+	started := false
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if bytes.HasPrefix(line, []byte("From ")) {
+			if started {
+				if err := fn(msg); err != nil {
+					return err
+				}
+			}
+			msg, started = msg[:0], true
+			continue
+		}
+		if !started {
+			continue
+		}
+		if bytes.HasPrefix(line, []byte(">From ")) {
+			line = line[1:]
+		}
+		msg = append(msg, line...)
+		msg = append(msg, '\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if started {
+		return fn(msg)
+	}
+	return nil
+}
+
+// LoadRules replaces the rules of the database with rules. Invalid patterns
+// are reported here rather than when tags are materialized.
+func LoadRules(db *sql.DB, rules []Rule) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.Exec(`DELETE FROM message_tags`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM rules`); err != nil {
+		return err
+	}
+	for i, rule := range rules {
+		var n int
+		if err := tx.QueryRow(`SELECT regexp_capture_count(?)`, rule.Pattern).Scan(&n); err != nil {
+			return fmt.Errorf("rule %d (%s): %w", i+1, rule.Tag, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO rules (tag, field, pattern, flags) VALUES (?, ?, ?, ?)`,
+			rule.Tag, rule.Field, rule.Pattern, rule.Flags); err != nil {
+			return fmt.Errorf("rule %d (%s): %w", i+1, rule.Tag, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// refPattern matches the references extracted into message_refs: invoice and
+// incident numbers.
+const refPattern = `\b(?:INV|INC)-\d+\b`
+
+// Materialize recomputes message_tags and message_refs from the messages and
+// rules of the database.
+func Materialize(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.Exec(`DELETE FROM message_tags`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM message_refs`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO message_tags (message, rule, tag)
+		SELECT m.id, r.id, r.tag
+		FROM messages m JOIN rules r
+		ON regexp(r.pattern, CASE r.field
+			WHEN 'from' THEN m.sender
+			WHEN 'subject' THEN m.subject
+			ELSE m.body END, r.flags)`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO message_refs (message, ref)
+		SELECT m.id, refs.value
+		FROM messages m, json_each(regexp_find_all(m.subject || char(10) || m.body, ?)) refs`,
+		refPattern); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Coverage summarizes the tags of the database.
+type Coverage struct {
+	Messages int
+	Untagged int
+	// Tags maps each tag to the number of messages it is on.
+	Tags map[string]int
+	// UnusedRules lists the rules matching no message, by id.
+	UnusedRules []int
+}
+
+// ReadCoverage reads the coverage views.
+func ReadCoverage(db *sql.DB) (*Coverage, error) {
+	c := &Coverage{Tags: map[string]int{}}
+	if err := db.QueryRow(`SELECT (SELECT count(*) FROM messages), (SELECT count(*) FROM untagged_messages)`).
+		Scan(&c.Messages, &c.Untagged); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT tag, messages FROM tag_coverage`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tag string
+		var n int
+		if err := rows.Scan(&tag, &n); err != nil {
+			return nil, err
+		}
+		c.Tags[tag] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`SELECT id FROM rule_coverage WHERE hits = 0 ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		c.UnusedRules = append(c.UnusedRules, id)
+	}
+	return c, rows.Err()
+}
@@ -0,0 +1,159 @@
+package emailtriage
+
+import (
+	"database/sql"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/go-sqlite-regexp/driver"
+)
+
+func openInbox(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open(driver.DriverName, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection has its own in-memory database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if err := Setup(db); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open("testdata/inbox.mbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	n, err := Ingest(db, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Fatalf("ingested %d messages, want 5", n)
+	}
+	if err := LoadRules(db, DefaultRules()); err != nil {
+		t.Fatal(err)
+	}
+	if err := Materialize(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestPipeline(t *testing.T) {
+	db := openInbox(t)
+
+	rows, err := db.Query(`SELECT m.message_id, group_concat(DISTINCT t.tag)
+		FROM messages m JOIN message_tags t ON t.message = m.id
+		GROUP BY m.id ORDER BY m.id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	got := map[string][]string{}
+	for rows.Next() {
+		var id, tags string
+		if err := rows.Scan(&id, &tags); err != nil {
+			t.Fatal(err)
+		}
+		got[id] = strings.Split(tags, ",")
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"<1@example.com>":    {"billing"},
+		"<2@example.com>":    {"outage"},
+		"<3@vendor.example>": {"newsletter"},
+		"<4@example.com>":    {"billing", "security"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tags = %v, want %v", got, want)
+	}
+
+	c, err := ReadCoverage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCoverage := &Coverage{
+		Messages: 5,
+		Untagged: 1,
+		Tags:     map[string]int{"billing": 2, "outage": 1, "security": 1, "newsletter": 1},
+	}
+	if !reflect.DeepEqual(c, wantCoverage) {
+		t.Errorf("coverage = %+v, want %+v", c, wantCoverage)
+	}
+}
+
+func TestIngestUnescapesFrom(t *testing.T) {
+	db := openInbox(t)
+	var body string
+	if err := db.QueryRow(`SELECT body FROM messages WHERE message_id = '<2@example.com>'`).Scan(&body); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body, "\nFrom the logs") {
+		t.Errorf("body = %q, want an unescaped From line", body)
+	}
+}
+
+func TestRefs(t *testing.T) {
+	db := openInbox(t)
+	rows, err := db.Query(`SELECT ref FROM message_refs ORDER BY message, ref`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var refs []string
+	for rows.Next() {
+		var ref string
+		if err := rows.Scan(&ref); err != nil {
+			t.Fatal(err)
+		}
+		refs = append(refs, ref)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"INV-004211", "INC-77", "INC-78"}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("refs = %v, want %v", refs, want)
+	}
+}
+
+func TestLoadRulesInvalidPattern(t *testing.T) {
+	db := openInbox(t)
+	err := LoadRules(db, []Rule{{Tag: "broken", Field: "body", Pattern: "("}})
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("err = %v, want an error naming the rule", err)
+	}
+	// The previous bundle and its tags are kept.
+	c, err := ReadCoverage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Untagged != 1 {
+		t.Errorf("untagged = %d after a failed load, want 1", c.Untagged)
+	}
+}
+
+func TestUnusedRules(t *testing.T) {
+	db := openInbox(t)
+	rules := append(DefaultRules(), Rule{Tag: "legal", Field: "subject", Pattern: `(?i)\bsubpoena\b`})
+	if err := LoadRules(db, rules); err != nil {
+		t.Fatal(err)
+	}
+	if err := Materialize(db); err != nil {
+		t.Fatal(err)
+	}
+	c, err := ReadCoverage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{len(rules)}; !reflect.DeepEqual(c.UnusedRules, want) {
+		t.Errorf("unused rules = %v, want %v", c.UnusedRules, want)
+	}
+}
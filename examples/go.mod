@@ -1,10 +1,21 @@
 module examples
 
-go 1.23.4
+go 1.25.0
 
 replace github.com/go-go-golems/go-sqlite-regexp => ../
 
 require (
-	github.com/go-go-golems/go-sqlite-regexp v0.0.0-00010101000000-000000000000 // indirect
-	github.com/mattn/go-sqlite3 v1.14.30 // indirect
+	github.com/go-go-golems/go-sqlite-regexp v0.0.0-00010101000000-000000000000
+	github.com/mattn/go-sqlite3 v1.14.30
+)
+
+require (
+	github.com/dlclark/regexp2 v1.12.0 // indirect
+	github.com/go-go-golems/logcopter v0.1.0 // indirect
+	github.com/mattn/go-colorable v0.1.14 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/rs/zerolog v1.35.1 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.40.0 // indirect
 )
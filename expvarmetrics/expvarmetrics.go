@@ -0,0 +1,100 @@
+// Package expvarmetrics publishes the counters of the regexp function suite
+// with expvar, for the dashboards reading /debug/vars:
+//
+//	if err := expvarmetrics.Publish("sqlite_regexp"); err != nil {
+//		return err
+//	}
+//
+// The variable is a JSON object of the form:
+//
+//	{
+//		"cache": {"size": 12, "hits": 1830, "misses": 12, "compile_errors": 1,
+//			"expired": 0, "bytes": 48210, "evicted": 0, "registrations": 4},
+//		"calls": {"regexp": 1520, "regexp_replace": 323},
+//		"errors": {"regexp": 1}
+//	}
+//
+// Unlike the metrics package, it does not depend on Prometheus.
+package expvarmetrics
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+)
+
+// counters counts the evaluations of each function.
+type counters struct {
+	// functions maps a function name to its *functionCounters.
+	functions sync.Map
+}
+
+type functionCounters struct {
+	calls  atomic.Uint64
+	errors atomic.Uint64
+}
+
+var _ core.Observer = &counters{}
+
+func (c *counters) ObserveCall(e core.CallEvent) {
+	v, ok := c.functions.Load(e.Function)
+	if !ok {
+		v, _ = c.functions.LoadOrStore(e.Function, &functionCounters{})
+	}
+	fc := v.(*functionCounters)
+	fc.calls.Add(1)
+	if e.Err != nil {
+		fc.errors.Add(1)
+	}
+}
+
+func (c *counters) snapshot() map[string]any {
+	stats := core.ReadCacheStats()
+	calls := make(map[string]uint64)
+	errors := make(map[string]uint64)
+	c.functions.Range(func(k, v any) bool {
+		fc := v.(*functionCounters)
+		calls[k.(string)] = fc.calls.Load()
+		if n := fc.errors.Load(); n > 0 {
+			errors[k.(string)] = n
+		}
+		return true
+	})
+	return map[string]any{
+		"cache": map[string]any{
+			"size":           stats.Size,
+			"hits":           stats.Hits,
+			"misses":         stats.Misses,
+			"compile_errors": stats.CompileErrors,
+			"expired":        stats.Expired,
+			"bytes":          stats.Bytes,
+			"evicted":        stats.Evicted,
+			"registrations":  stats.Registrations,
+		},
+		"calls":  calls,
+		"errors": errors,
+	}
+}
+
+var publishMu sync.Mutex
+
+// Publish publishes the counters as the expvar variable name, and starts
+// counting the evaluations of the functions, for the rest of the process.
+// Counting evaluations has a small cost, so only publish the counters if
+// they are used. It fails if a variable with that name exists.
+func Publish(name string) error {
+	publishMu.Lock()
+	defer publishMu.Unlock()
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("expvar %q already published", name)
+	}
+	c := &counters{}
+	core.AddObserver(c)
+	expvar.Publish(name, expvar.Func(func() any {
+		return c.snapshot()
+	}))
+	return nil
+}
@@ -0,0 +1,51 @@
+package expvarmetrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+)
+
+func TestPublish(t *testing.T) {
+	if err := Publish("sqlite_regexp_test"); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if err := Publish("sqlite_regexp_test"); err == nil {
+		t.Error("Expected an error publishing twice")
+	}
+
+	db, err := sqlite_regexp.OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	if _, err := db.Exec(`SELECT 'abc' REGEXP 'b', 'abc' REGEXP 'c'`); err != nil {
+		t.Fatalf("REGEXP failed: %v", err)
+	}
+	if _, err := db.Exec(`SELECT regexp_like('abc', '(')`); err == nil {
+		t.Fatal("Invalid pattern accepted")
+	}
+
+	var got struct {
+		Cache struct {
+			Size          int    `json:"size"`
+			Misses        uint64 `json:"misses"`
+			CompileErrors uint64 `json:"compile_errors"`
+		} `json:"cache"`
+		Calls  map[string]uint64 `json:"calls"`
+		Errors map[string]uint64 `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(expvar.Get("sqlite_regexp_test").String()), &got); err != nil {
+		t.Fatalf("Invalid expvar JSON: %v", err)
+	}
+	if got.Calls["regexp"] != 2 || got.Calls["regexp_like"] != 1 || got.Errors["regexp_like"] != 1 {
+		t.Errorf("Unexpected counters %+v", got)
+	}
+	if got.Cache.Size < 2 || got.Cache.Misses < 3 || got.Cache.CompileErrors < 1 {
+		t.Errorf("Unexpected cache counters %+v", got.Cache)
+	}
+}
@@ -1,3 +0,0 @@
-package main
-
-func main() {}
@@ -0,0 +1,256 @@
+package sqlite_regexp
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// UntranslatableError is returned by RegexpToGlob for a pattern that GLOB
+// cannot express.
+type UntranslatableError struct {
+	Pattern string
+	Reason  string
+}
+
+func (e *UntranslatableError) Error() string {
+	return fmt.Sprintf("cannot translate %q to GLOB: %s", e.Pattern, e.Reason)
+}
+
+// RegexpToGlob translates a simple pattern to a GLOB pattern matching the
+// same texts, for databases opened without the function suite, such as stock
+// SQLite files opened by other tools:
+//
+//	glob, err := sqlite_regexp.RegexpToGlob(`^ORD-\d\d\d$`, true)
+//	// ORD-[0-9][0-9][0-9]
+//
+// Literals, character classes, '.', '.*', '^' and '$' are translated, as
+// is case folding with the i flag; alternations and other repetitions fail
+// with an *UntranslatableError. GLOB is used rather than LIKE, which is
+// case-insensitive. With strict false, '.' is translated to '?' although
+// it does not match newlines unless (?s) is set; with strict true, such
+// patterns fail too.
+func RegexpToGlob(pattern string, strict bool) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+	re = re.Simplify()
+
+	parts := []*syntax.Regexp{re}
+	if re.Op == syntax.OpConcat {
+		parts = re.Sub
+	}
+	anchoredStart := len(parts) > 0 && parts[0].Op == syntax.OpBeginText
+	if anchoredStart {
+		parts = parts[1:]
+	}
+	anchoredEnd := len(parts) > 0 && parts[len(parts)-1].Op == syntax.OpEndText
+	if anchoredEnd {
+		parts = parts[:len(parts)-1]
+	}
+
+	t := globTranslator{pattern: pattern, strict: strict}
+	if !anchoredStart {
+		t.b.WriteByte('*')
+	}
+	for _, part := range parts {
+		if err := t.translate(part); err != nil {
+			return "", err
+		}
+	}
+	if !anchoredEnd {
+		t.b.WriteByte('*')
+	}
+	return t.b.String(), nil
+}
+
+// RegexpPredicate returns a SQL predicate matching the SQL expression expr
+// against pattern, with its arguments: "expr REGEXP ?" if support reports the
+// operator, see DetectRegexpSupport, or else "expr GLOB ?" with the
+// translation of pattern by RegexpToGlob. A single code path can so serve
+// databases with and without the suite:
+//
+//	where, args, err := sqlite_regexp.RegexpPredicate(support, "sku", `^ORD-`, true)
+//	rows, err := db.Query("SELECT * FROM orders WHERE "+where, args...)
+func RegexpPredicate(support RegexpSupport, expr, pattern string, strict bool) (string, []any, error) {
+	if support.Operator {
+		return expr + " REGEXP ?", []any{pattern}, nil
+	}
+	glob, err := RegexpToGlob(pattern, strict)
+	if err != nil {
+		return "", nil, err
+	}
+	return expr + " GLOB ?", []any{glob}, nil
+}
+
+type globTranslator struct {
+	pattern string
+	strict  bool
+	b       strings.Builder
+}
+
+func (t *globTranslator) fail(format string, args ...any) error {
+	return &UntranslatableError{Pattern: t.pattern, Reason: fmt.Sprintf(format, args...)}
+}
+
+func (t *globTranslator) translate(re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpEmptyMatch:
+		return nil
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			t.literal(r, re.Flags&syntax.FoldCase != 0)
+		}
+		return nil
+	case syntax.OpAnyChar:
+		t.b.WriteByte('?')
+		return nil
+	case syntax.OpAnyCharNotNL:
+		if t.strict {
+			return t.fail("'.' does not match newlines, unlike '?'")
+		}
+		t.b.WriteByte('?')
+		return nil
+	case syntax.OpCharClass:
+		t.class(re.Rune)
+		return nil
+	case syntax.OpStar, syntax.OpPlus:
+		sub := re.Sub[0]
+		if sub.Op != syntax.OpAnyChar && sub.Op != syntax.OpAnyCharNotNL {
+			return t.fail("only '.' can be repeated")
+		}
+		if sub.Op == syntax.OpAnyCharNotNL && t.strict {
+			return t.fail("'.' does not match newlines, unlike '*'")
+		}
+		if re.Op == syntax.OpPlus {
+			t.b.WriteByte('?')
+		}
+		t.b.WriteByte('*')
+		return nil
+	case syntax.OpCapture:
+		return t.translate(re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if err := t.translate(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	case syntax.OpAlternate:
+		return t.fail("GLOB has no alternation")
+	case syntax.OpBeginText, syntax.OpEndText:
+		return t.fail("anchors must start or end the pattern")
+	default:
+		return t.fail("unsupported %s", re)
+	}
+}
+
+// literal writes r, or the class of its case variants if fold is set.
+func (t *globTranslator) literal(r rune, fold bool) {
+	if fold && unicode.SimpleFold(r) != r {
+		variants := []rune{r}
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			variants = append(variants, f)
+		}
+		slices.Sort(variants)
+		ranges := make([]rune, 0, 2*len(variants))
+		for _, v := range variants {
+			ranges = append(ranges, v, v)
+		}
+		t.class(ranges)
+		return
+	}
+	switch r {
+	case '*', '?', '[':
+		t.b.WriteString("[" + string(r) + "]")
+	default:
+		t.b.WriteRune(r)
+	}
+}
+
+// class writes the character class of the sorted rune ranges, negated if it
+// holds both ends of Unicode. ']' has to come first in a GLOB class, '-'
+// first or last, and '^' anywhere but first.
+func (t *globTranslator) class(ranges []rune) {
+	if len(ranges) == 2 && ranges[0] == ranges[1] {
+		t.literal(ranges[0], false)
+		return
+	}
+	negated := len(ranges) > 0 && ranges[0] == 0 && ranges[len(ranges)-1] == unicode.MaxRune
+	if negated {
+		ranges = complementRanges(ranges)
+	}
+
+	var rbracket, dash bool
+	var rest []rune
+	for i := 0; i < len(ranges); i += 2 {
+		// Only the ends of a range can be special.
+		lo, hi := ranges[i], ranges[i+1]
+		for _, special := range []rune{'-', ']'} {
+			if lo == special || hi == special {
+				if special == ']' {
+					rbracket = true
+				} else {
+					dash = true
+				}
+				if lo == special {
+					lo++
+				} else {
+					hi--
+				}
+			}
+		}
+		if lo <= hi {
+			rest = append(rest, lo, hi)
+		}
+	}
+	t.b.WriteByte('[')
+	if negated {
+		t.b.WriteByte('^')
+	}
+	if rbracket {
+		t.b.WriteByte(']')
+	} else if !negated && len(rest) > 0 && rest[0] == '^' {
+		// A leading '-' is literal too; otherwise move '^' to the end.
+		switch {
+		case dash:
+			t.b.WriteByte('-')
+			dash = false
+		case rest[1] > '^':
+			rest[0] = '^' + 1
+			rest = append(rest, '^', '^')
+		default:
+			rest = append(rest[2:], '^', '^')
+		}
+	}
+	for i := 0; i < len(rest); i += 2 {
+		t.b.WriteRune(rest[i])
+		if rest[i+1] != rest[i] {
+			t.b.WriteByte('-')
+			t.b.WriteRune(rest[i+1])
+		}
+	}
+	if dash {
+		t.b.WriteByte('-')
+	}
+	t.b.WriteByte(']')
+}
+
+// complementRanges returns the ranges of the runes not in the sorted ranges.
+func complementRanges(ranges []rune) []rune {
+	var out []rune
+	next := rune(0)
+	for i := 0; i < len(ranges); i += 2 {
+		if ranges[i] > next {
+			out = append(out, next, ranges[i]-1)
+		}
+		next = ranges[i+1] + 1
+	}
+	if next <= unicode.MaxRune {
+		out = append(out, next, unicode.MaxRune)
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestRegexpToGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		strict  bool
+		glob    string
+	}{
+		{`^ORD-\d\d\d$`, true, `ORD-[0-9][0-9][0-9]`},
+		{`error`, true, `*error*`},
+		{`^a.*b$`, false, `a*b`},
+		{`(?s)^a.+b`, true, `a?*b*`},
+		{`^(?i)ab$`, true, `[Aa][Bb]`},
+		{`^k$`, true, `k`},
+		{`^a*b?[*]$`, true, ""},
+		{`^[^0-9]x$`, true, `[^0-9]x`},
+		{`^[\]\-^a]$`, true, `[]^a-]`},
+		{`^[\^-a]$`, true, `[_-a^]`},
+		{`^[\-^]$`, true, `[-^]`},
+		{`^[ -~]$`, true, `[ -~]`},
+		{`^[^\-]$`, true, `[^-]`},
+		{`^x{3}\?$`, true, `xxx[?]`},
+		{`(?m)^a`, true, ""},
+		{`^a|b$`, true, ""},
+		{`^a.$`, true, ""},
+		{`^a.$`, false, `a?`},
+	}
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	texts := []string{"ORD-123", "ORD-12", "an error here", "ab", "AB", "aXb", "a\nb", "K", "k", "]", "-", "^", "a", "1x", "ax", "xxx?", "xxxx", "_", "`", "~", " ", "é"}
+
+	for _, tt := range tests {
+		glob, err := RegexpToGlob(tt.pattern, tt.strict)
+		if tt.glob == "" {
+			var ue *UntranslatableError
+			if !errors.As(err, &ue) {
+				t.Errorf("RegexpToGlob(%q) = %q, %v; expected an UntranslatableError", tt.pattern, glob, err)
+			}
+			continue
+		}
+		if err != nil || glob != tt.glob {
+			t.Errorf("RegexpToGlob(%q) = %q, %v; expected %q", tt.pattern, glob, err, tt.glob)
+			continue
+		}
+
+		// The translation of strict patterns matches exactly the same texts.
+		if !tt.strict {
+			continue
+		}
+		re := regexp.MustCompile(tt.pattern)
+		for _, text := range texts {
+			var matched bool
+			if err := db.QueryRow(`SELECT ? GLOB ?`, text, glob).Scan(&matched); err != nil {
+				t.Fatalf("GLOB failed: %v", err)
+			}
+			if matched != re.MatchString(text) {
+				t.Errorf("%q GLOB %q = %v, but REGEXP %q = %v", text, glob, matched, tt.pattern, !matched)
+			}
+		}
+	}
+}
+
+func TestRegexpPredicate(t *testing.T) {
+	where, args, err := RegexpPredicate(RegexpSupport{Operator: true}, "sku", `^ORD-\d`, true)
+	if err != nil || where != "sku REGEXP ?" || args[0] != `^ORD-\d` {
+		t.Errorf("Got %q %v, %v", where, args, err)
+	}
+	where, args, err = RegexpPredicate(RegexpSupport{}, "sku", `^ORD-\d`, true)
+	if err != nil || where != "sku GLOB ?" || args[0] != `ORD-[0-9]*` {
+		t.Errorf("Got %q %v, %v", where, args, err)
+	}
+	if _, _, err := RegexpPredicate(RegexpSupport{}, "sku", `ab|cd`, true); err == nil {
+		t.Error("Expected an error for an untranslatable pattern")
+	}
+}
@@ -0,0 +1,143 @@
+package sqlite_regexp
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// FeatureFormat is the output format of ExportFeatures.
+type FeatureFormat int
+
+const (
+	// FeaturesCSV writes a header row, then one row per replayed row.
+	FeaturesCSV FeatureFormat = iota
+	// FeaturesNDJSON writes one JSON object per replayed row.
+	FeaturesNDJSON
+)
+
+// ExportFeatures replays historical rows against rules like Backtest, but
+// writes a feature vector per row instead of aggregating them, so that models
+// complementing the rules can be trained on the matches of the rules
+// themselves rather than a reimplementation of them. It returns the number
+// of rows written.
+//
+// Every row holds the text, its known label, the label predicted by the
+// first matching rule, and one feature per rule, in order, that is 1 if the
+// rule matches the text and 0 otherwise. In CSV, the feature columns are
+// named rule<n>_<label>, numbering rules from 1:
+//
+//	text,label,predicted,rule1_billing,rule2_bugs
+//	Refund for the crash,bugs,billing,1,1
+//
+// In NDJSON, the features are an array:
+//
+//	{"text":"Refund for the crash","label":"bugs","predicted":"billing","features":[1,1]}
+//
+// NULL texts and labels are written as empty strings, and a NULL text
+// matches no rule.
+func ExportFeatures(ctx context.Context, db *sql.DB, rules []LabelRule, historicalQuery, labelsColumn string, w io.Writer, format FeatureFormat) (int64, error) {
+	compiled, err := compileLabelRules(rules)
+	if err != nil {
+		return 0, err
+	}
+	var out featureWriter
+	switch format {
+	case FeaturesCSV:
+		out = newCSVFeatureWriter(w, compiled)
+	case FeaturesNDJSON:
+		out = &ndjsonFeatureWriter{w: bufio.NewWriter(w)}
+	default:
+		return 0, fmt.Errorf("unknown feature format %d", format)
+	}
+
+	var written int64
+	features := make([]int, len(compiled))
+	err = replayLabelled(ctx, db, historicalQuery, labelsColumn, func(known, text sql.NullString) error {
+		predicted := ""
+		for i, m := range compiled {
+			features[i] = 0
+			if text.Valid && m.re.MatchString(text.String) {
+				features[i] = 1
+				if predicted == "" {
+					predicted = m.label
+				}
+			}
+		}
+		if err := out.write(text.String, known.String, predicted, features); err != nil {
+			return err
+		}
+		written++
+		return nil
+	})
+	if flushErr := out.flush(); err == nil {
+		err = flushErr
+	}
+	return written, err
+}
+
+type featureWriter interface {
+	write(text, label, predicted string, features []int) error
+	flush() error
+}
+
+type csvFeatureWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+// newCSVFeatureWriter returns a writer that has written the header, even if
+// no row follows. A failure to write it is reported by flush.
+func newCSVFeatureWriter(w io.Writer, rules []labelMatcher) *csvFeatureWriter {
+	header := []string{"text", "label", "predicted"}
+	for i, m := range rules {
+		header = append(header, fmt.Sprintf("rule%d_%s", i+1, m.label))
+	}
+	c := &csvFeatureWriter{w: csv.NewWriter(w)}
+	_ = c.w.Write(header)
+	return c
+}
+
+func (c *csvFeatureWriter) write(text, label, predicted string, features []int) error {
+	c.record = append(c.record[:0], text, label, predicted)
+	for _, f := range features {
+		c.record = append(c.record, strconv.Itoa(f))
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvFeatureWriter) flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonFeatureWriter struct {
+	w *bufio.Writer
+}
+
+type featureRow struct {
+	Text      string `json:"text"`
+	Label     string `json:"label"`
+	Predicted string `json:"predicted"`
+	Features  []int  `json:"features"`
+}
+
+func (n *ndjsonFeatureWriter) write(text, label, predicted string, features []int) error {
+	line, err := json.Marshal(featureRow{Text: text, Label: label, Predicted: predicted, Features: features})
+	if err != nil {
+		return err
+	}
+	if _, err := n.w.Write(line); err != nil {
+		return err
+	}
+	return n.w.WriteByte('\n')
+}
+
+func (n *ndjsonFeatureWriter) flush() error {
+	return n.w.Flush()
+}
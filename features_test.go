@@ -0,0 +1,67 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExportFeatures(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	_, err = db.Exec(`CREATE TABLE tickets (id INTEGER PRIMARY KEY, body TEXT, category TEXT);
+		INSERT INTO tickets (body, category) VALUES
+			('Refund for the crash', 'bugs'),
+			('Charged, twice', 'billing'),
+			(NULL, NULL)`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	rules := []LabelRule{
+		{Label: "billing", Pattern: `invoice|refund|charged`, Flags: "i"},
+		{Label: "bugs", Pattern: `crash`},
+	}
+	query := `SELECT body, category FROM tickets ORDER BY id`
+	ctx := context.Background()
+
+	var csv strings.Builder
+	n, err := ExportFeatures(ctx, db, rules, query, "category", &csv, FeaturesCSV)
+	if err != nil {
+		t.Fatalf("ExportFeatures failed: %v", err)
+	}
+	expected := "text,label,predicted,rule1_billing,rule2_bugs\n" +
+		"Refund for the crash,bugs,billing,1,1\n" +
+		"\"Charged, twice\",billing,billing,1,0\n" +
+		",,,0,0\n"
+	if n != 3 || csv.String() != expected {
+		t.Errorf("Got %d rows:\n%s\nexpected 3:\n%s", n, csv.String(), expected)
+	}
+
+	var ndjson strings.Builder
+	_, err = ExportFeatures(ctx, db, rules, query, "category", &ndjson, FeaturesNDJSON)
+	if err != nil {
+		t.Fatalf("ExportFeatures failed: %v", err)
+	}
+	expected = `{"text":"Refund for the crash","label":"bugs","predicted":"billing","features":[1,1]}` + "\n" +
+		`{"text":"Charged, twice","label":"billing","predicted":"billing","features":[1,0]}` + "\n" +
+		`{"text":"","label":"","predicted":"","features":[0,0]}` + "\n"
+	if ndjson.String() != expected {
+		t.Errorf("Got:\n%s\nexpected:\n%s", ndjson.String(), expected)
+	}
+
+	csv.Reset()
+	_, err = ExportFeatures(ctx, db, rules, `SELECT body, category FROM tickets WHERE 0`, "category", &csv, FeaturesCSV)
+	if err != nil || csv.String() != "text,label,predicted,rule1_billing,rule2_bugs\n" {
+		t.Errorf("Expected only the header, got %q, %v", csv.String(), err)
+	}
+
+	_, err = ExportFeatures(ctx, db, []LabelRule{{Label: "x", Pattern: "("}}, query, "category", &csv, FeaturesCSV)
+	if !IsPatternError(err) {
+		t.Errorf("Expected a pattern error, got %v", err)
+	}
+}
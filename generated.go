@@ -0,0 +1,125 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ExtractColumn describes a generated column holding a value extracted from
+// another column with regexp_extract, see AddExtractColumn.
+type ExtractColumn struct {
+	Table string
+	// Name is the name of the generated column, and Source that of the
+	// column it is extracted from.
+	Name   string
+	Source string
+	// Pattern, Group (or GroupName, if set) and Flags are the arguments of
+	// regexp_extract.
+	Pattern   string
+	Group     int
+	GroupName string
+	Flags     string
+	// Index, if set, is the name of an index created on the column, unique
+	// if Unique is set.
+	Index  string
+	Unique bool
+}
+
+// extract returns the extraction of c as that of an index.
+func (c ExtractColumn) extract() ExtractIndex {
+	return ExtractIndex{
+		Name:      c.Index,
+		Table:     c.Table,
+		Column:    c.Source,
+		Pattern:   c.Pattern,
+		Group:     c.Group,
+		GroupName: c.GroupName,
+		Flags:     c.Flags,
+		Unique:    c.Unique,
+	}
+}
+
+// SQL validates c and returns the statements adding the column, then
+// creating its index if it has one. SQLite only adds virtual generated
+// columns to existing tables, computed when read, and only of
+// deterministic expressions: patterns including library patterns are
+// rejected, as their matches change with the library.
+func (c ExtractColumn) SQL() ([]string, error) {
+	if c.Table == "" || c.Name == "" || c.Source == "" {
+		return nil, errors.New("extract column needs a table, a name and a source column")
+	}
+	if err := c.extract().check(); err != nil {
+		return nil, fmt.Errorf("extract column %s: %w", c.Name, err)
+	}
+
+	stmts := []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s TEXT GENERATED ALWAYS AS (%s) VIRTUAL",
+		quoteIdent(c.Table), quoteIdent(c.Name), c.extract().Expr())}
+	if c.Index != "" {
+		unique := ""
+		if c.Unique {
+			unique = "UNIQUE "
+		}
+		stmts = append(stmts, fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS %s ON %s(%s)",
+			unique, quoteIdent(c.Index), quoteIdent(c.Table), quoteIdent(c.Name)))
+	}
+	return stmts, nil
+}
+
+// AddExtractColumn adds a generated column holding a value extracted with
+// regexp_extract, and its index, unless the table has a column of that
+// name, so that regex-derived columns need no hand-written DDL:
+//
+//	err := sqlite_regexp.AddExtractColumn(db, sqlite_regexp.ExtractColumn{
+//		Table:     "orders",
+//		Name:      "invoice",
+//		Source:    "note",
+//		Pattern:   `INV-(?P<number>\d{6})`,
+//		GroupName: "number",
+//		Index:     "orders_invoice",
+//	})
+//	// SELECT * FROM orders WHERE invoice = '000042'
+//
+// The statements run in a transaction, after checking that its connection
+// has regexp_extract. Every connection that reads or writes the table must
+// have the function suite registered.
+func AddExtractColumn(db *sql.DB, c ExtractColumn) error {
+	return AddExtractColumnContext(context.Background(), db, c)
+}
+
+// AddExtractColumnContext is like AddExtractColumn, but stops, interrupting
+// the statements, once ctx is done. The column is then not added.
+func AddExtractColumnContext(ctx context.Context, db *sql.DB, c ExtractColumn) error {
+	stmts, err := c.SQL()
+	if err != nil {
+		return err
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var probe sql.NullString
+	if err := tx.QueryRowContext(ctx, `SELECT regexp_extract('a', 'a')`).Scan(&probe); err != nil {
+		return fmt.Errorf("extract column %s: regexp_extract is not registered: %w", c.Name, err)
+	}
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT count(*) > 0 FROM pragma_table_xinfo(?) WHERE name = ? COLLATE NOCASE`,
+		c.Table, c.Name).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		stmts = stmts[1:]
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("extract column %s: %w", c.Name, err)
+		}
+	}
+	return tx.Commit()
+}
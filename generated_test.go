@@ -0,0 +1,99 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestAddExtractColumn(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	_, err = db.Exec(`CREATE TABLE orders (id INTEGER PRIMARY KEY, note TEXT);
+		INSERT INTO orders (note) VALUES ('paid inv-000042'), ('INV-000043 pending'), ('no invoice')`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	col := ExtractColumn{
+		Table:     "orders",
+		Name:      "invoice",
+		Source:    "note",
+		Pattern:   `INV-(?P<number>\d{6})`,
+		GroupName: "number",
+		Flags:     "i",
+		Index:     "orders_invoice",
+	}
+	if err := AddExtractColumn(db, col); err != nil {
+		t.Fatalf("AddExtractColumn failed: %v", err)
+	}
+	// Adding it again does nothing.
+	if err := AddExtractColumn(db, col); err != nil {
+		t.Fatalf("AddExtractColumn failed: %v", err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO orders (note) VALUES ('INV-000044')`); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query(`SELECT id, invoice FROM orders ORDER BY id`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	var got []string
+	for rows.Next() {
+		var id int
+		var invoice sql.NullString
+		if err := rows.Scan(&id, &invoice); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		got = append(got, invoice.String)
+	}
+	_ = rows.Close()
+	if strings.Join(got, ",") != "000042,000043,,000044" {
+		t.Errorf("Got invoices %q", got)
+	}
+
+	var plan string
+	if err := db.QueryRow(`EXPLAIN QUERY PLAN SELECT id FROM orders WHERE invoice = '000042'`).Scan(new(int), new(int), new(int), &plan); err != nil {
+		t.Fatalf("EXPLAIN failed: %v", err)
+	}
+	if !strings.Contains(plan, "orders_invoice") {
+		t.Errorf("Expected the query to use the index, plan: %s", plan)
+	}
+
+	for _, bad := range []ExtractColumn{
+		{Table: "orders", Source: "note", Pattern: `x`},
+		{Table: "orders", Name: "c1", Source: "note", Pattern: `{{invoice}}`},
+		{Table: "orders", Name: "c2", Source: "note", Pattern: `(x)`, Group: 2},
+		{Table: "orders", Name: "c3", Source: "note", Pattern: `x`, Flags: "q"},
+	} {
+		if _, err := bad.SQL(); err == nil {
+			t.Errorf("SQL(%+v): expected an error, got nil", bad)
+		}
+	}
+}
+
+func TestAddExtractColumnUnregistered(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE orders (note TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+
+	err = AddExtractColumn(db, ExtractColumn{Table: "orders", Name: "n", Source: "note", Pattern: `\d+`})
+	if err == nil || !strings.Contains(err.Error(), "regexp_extract is not registered") {
+		t.Errorf("Expected an unregistered function error, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestUnicodeGlob(t *testing.T) {
+	builtin, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() {
+		_ = builtin.Close()
+	}()
+
+	db, err := OpenWithRegexp(":memory:", WithUnicodeGlob())
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// The replacement agrees with SQLite's GLOB on these.
+	cases := [][2]string{
+		{"report.txt", "*.txt"},
+		{"report.txt", "*.TXT"},
+		{"abc", "a?c"},
+		{"ac", "a?c"},
+		{"café", "caf?"},
+		{"b", "[a-c]"},
+		{"d", "[a-c]"},
+		{"d", "[^a-c]"},
+		{"]", "[]]"},
+		{"-", "[a-]"},
+		{"a\nb", "a*b"},
+		{"a[b", "a[b"},
+		{`a\b`, `a\*`},
+		{"x", "[z-a]"},
+	}
+	for _, c := range cases {
+		var expected, got int
+		if err := builtin.QueryRow(`SELECT ? GLOB ?`, c[0], c[1]).Scan(&expected); err != nil {
+			t.Fatalf("Built-in GLOB failed: %v", err)
+		}
+		if err := db.QueryRow(`SELECT ? GLOB ?`, c[0], c[1]).Scan(&got); err != nil {
+			t.Errorf("%q GLOB %q failed: %v", c[0], c[1], err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("%q GLOB %q = %d, SQLite returns %d", c[0], c[1], got, expected)
+		}
+	}
+
+	var result int
+	if err := db.QueryRow(`SELECT glob('*CAFÉ*', 'un café', 'i')`).Scan(&result); err != nil {
+		t.Fatalf("glob with flags failed: %v", err)
+	}
+	if result != 1 {
+		t.Error("Expected case-insensitive GLOB to fold non-ASCII letters")
+	}
+}
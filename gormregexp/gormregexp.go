@@ -0,0 +1,76 @@
+// Package gormregexp connects GORM to SQLite databases with the regexp
+// function suite on every connection, and provides conditions using it for
+// GORM's chain API:
+//
+//	db, err := gorm.Open(gormregexp.Open("app.db"), &gorm.Config{})
+//	...
+//	var users []User
+//	err = db.Where(gormregexp.Regexp("email", `@example\.(com|org)$`)).Find(&users).Error
+//
+// gorm.io/driver/sqlite opens its pool with the plain go-sqlite3 driver, so
+// registering the functions on the pool GORM returns only reaches one of its
+// connections; the dialector of this package opens the pool with
+// sqlite_regexp.OpenWithRegexp instead.
+package gormregexp
+
+import (
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+)
+
+// Dialector is the SQLite dialector of gorm.io/driver/sqlite, opening its
+// pool with the function suite configured by Options unless Conn is set.
+type Dialector struct {
+	sqlite.Dialector
+	Options []sqlite_regexp.Option
+}
+
+var _ gorm.Dialector = &Dialector{}
+
+// Open returns a dialector for the SQLite database dsn, whose connections
+// all have the function suite configured by opts.
+func Open(dsn string, opts ...sqlite_regexp.Option) gorm.Dialector {
+	return &Dialector{Dialector: sqlite.Dialector{DSN: dsn}, Options: opts}
+}
+
+// Initialize opens the pool, reporting invalid options, and initializes the
+// SQLite dialector with it.
+func (d Dialector) Initialize(db *gorm.DB) error {
+	if d.Conn == nil {
+		conn, err := sqlite_regexp.OpenWithRegexp(d.DSN, d.Options...)
+		if err != nil {
+			return err
+		}
+		d.Conn = conn
+	}
+	return d.Dialector.Initialize(db)
+}
+
+// Regexp returns the condition "column REGEXP pattern".
+func Regexp(column, pattern string) clause.Expression {
+	return clause.Expr{SQL: "? REGEXP ?", Vars: []any{clause.Column{Name: column}, pattern}}
+}
+
+// NotRegexp returns the condition "column NOT REGEXP pattern", which, like
+// any comparison, is not true for a NULL column.
+func NotRegexp(column, pattern string) clause.Expression {
+	return clause.Expr{SQL: "? NOT REGEXP ?", Vars: []any{clause.Column{Name: column}, pattern}}
+}
+
+// RegexpFlags returns the condition regexp(pattern, column, flags), the
+// REGEXP operator with matching flags such as "i".
+func RegexpFlags(column, pattern, flags string) clause.Expression {
+	return clause.Expr{SQL: "regexp(?, ?, ?)", Vars: []any{pattern, clause.Column{Name: column}, flags}}
+}
+
+// Extract returns the expression regexp_extract(column, pattern, group), the
+// given group of the first match of pattern in column, for Select, Order or
+// Group:
+//
+//	db.Model(&Order{}).Select("id, ? AS number", gormregexp.Extract("note", `INV-(\d+)`, 1))
+func Extract(column, pattern string, group int) clause.Expression {
+	return clause.Expr{SQL: "regexp_extract(?, ?, ?)", Vars: []any{clause.Column{Name: column}, pattern, group}}
+}
@@ -0,0 +1,123 @@
+package gormregexp
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+)
+
+type customer struct {
+	ID    uint
+	Email string
+	Note  *string
+}
+
+func openTestDB(t *testing.T, opts ...sqlite_regexp.Option) *gorm.DB {
+	t.Helper()
+	// A file, since every connection to ":memory:" has a database of its own.
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	db, err := gorm.Open(Open(dsn, opts...), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("gorm.Open failed: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+	return db
+}
+
+func TestConditions(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&customer{}); err != nil {
+		t.Fatal(err)
+	}
+	note := "invoice INV-123456 paid"
+	customers := []customer{
+		{Email: "ann@example.com", Note: &note},
+		{Email: "bob@EXAMPLE.org"},
+		{Email: "eve@evil.test"},
+	}
+	if err := db.Create(&customers).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	emails := func(tx *gorm.DB) []string {
+		t.Helper()
+		var found []customer
+		if err := tx.Order("id").Find(&found).Error; err != nil {
+			t.Fatal(err)
+		}
+		var emails []string
+		for _, c := range found {
+			emails = append(emails, c.Email)
+		}
+		return emails
+	}
+	tests := []struct {
+		name     string
+		tx       *gorm.DB
+		expected []string
+	}{
+		{"Regexp", db.Where(Regexp("email", `@example\.(com|org)$`)), []string{"ann@example.com"}},
+		{"RegexpFlags", db.Where(RegexpFlags("email", `@example\.(com|org)$`, "i")), []string{"ann@example.com", "bob@EXAMPLE.org"}},
+		{"NotRegexp", db.Where(NotRegexp("email", `@example`)), []string{"bob@EXAMPLE.org", "eve@evil.test"}},
+		{"qualified column", db.Where(Regexp("customers.email", `^e`)), []string{"eve@evil.test"}},
+		{"combined", db.Where(RegexpFlags("email", `example`, "i")).Not(Regexp("email", `^a`)), []string{"bob@EXAMPLE.org"}},
+	}
+	for _, tt := range tests {
+		got := emails(tt.tx)
+		if len(got) != len(tt.expected) {
+			t.Errorf("%s: found %q, expected %q", tt.name, got, tt.expected)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("%s: found %q, expected %q", tt.name, got, tt.expected)
+				break
+			}
+		}
+	}
+
+	var rows []struct {
+		ID     uint
+		Number string
+	}
+	if err := db.Model(&customer{}).Select("id, ? AS number", Extract("note", `INV-(\d+)`, 1)).
+		Where("note IS NOT NULL").Scan(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Number != "123456" {
+		t.Errorf("Extracted %+v, expected invoice number 123456", rows)
+	}
+}
+
+func TestPoolConnections(t *testing.T) {
+	db := openTestDB(t, sqlite_regexp.WithPostgresCompat())
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection of the pool, not only the first, has the suite.
+	sqlDB.SetMaxIdleConns(0)
+	for i := 0; i < 3; i++ {
+		var matched bool
+		if err := db.Raw(`SELECT pg_imatch('Hello', '^h')`).Scan(&matched).Error; err != nil || !matched {
+			t.Fatalf("Query %d: matched %v, %v", i, matched, err)
+		}
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	_, err := gorm.Open(Open(":memory:", sqlite_regexp.WithFunctionAlias("not a name")), &gorm.Config{Logger: logger.Discard})
+	if err == nil {
+		t.Error("Expected an error for an invalid option")
+	}
+}
@@ -0,0 +1,120 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+)
+
+// ExtractIndex describes an index on a value extracted from a column with
+// regexp_extract, see CreateExtractIndex.
+type ExtractIndex struct {
+	// Name is the name of the index.
+	Name   string
+	Table  string
+	Column string
+	// Pattern, Group (or GroupName, if set) and Flags are the arguments of
+	// regexp_extract.
+	Pattern   string
+	Group     int
+	GroupName string
+	Flags     string
+	Unique    bool
+}
+
+// Expr returns the indexed expression. Queries only use the index if they
+// use this exact expression:
+//
+//	SELECT * FROM orders WHERE <ix.Expr()> = 'INV-000042'
+func (ix ExtractIndex) Expr() string {
+	group := fmt.Sprint(ix.Group)
+	if ix.GroupName != "" {
+		group = quoteString(ix.GroupName)
+	}
+	args := []string{quoteIdent(ix.Column), quoteString(ix.Pattern), group}
+	if ix.Flags != "" {
+		args = append(args, quoteString(ix.Flags))
+	}
+	return "regexp_extract(" + strings.Join(args, ", ") + ")"
+}
+
+// SQL validates ix and returns the statement creating it. SQLite only
+// indexes deterministic expressions: patterns including library patterns
+// are rejected, as their matches change with the library.
+func (ix ExtractIndex) SQL() (string, error) {
+	if ix.Name == "" || ix.Table == "" || ix.Column == "" {
+		return "", errors.New("extract index needs a name, a table and a column")
+	}
+	if err := ix.check(); err != nil {
+		return "", fmt.Errorf("extract index %s: %w", ix.Name, err)
+	}
+
+	unique := ""
+	if ix.Unique {
+		unique = "UNIQUE "
+	}
+	return fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS %s ON %s(%s)",
+		unique, quoteIdent(ix.Name), quoteIdent(ix.Table), ix.Expr()), nil
+}
+
+// check checks the arguments of regexp_extract of ix.
+func (ix ExtractIndex) check() error {
+	if core.HasIncludes(ix.Pattern) {
+		return errors.New("pattern includes library patterns, which are not deterministic")
+	}
+	flags, err := core.ParseFlags(ix.Flags)
+	if err != nil {
+		return err
+	}
+	re, err := core.Compile(ix.Pattern, flags)
+	if err != nil {
+		return err
+	}
+	if ix.GroupName != "" {
+		if re.SubexpIndex(ix.GroupName) < 0 {
+			return fmt.Errorf("no capture group named %q", ix.GroupName)
+		}
+	} else if ix.Group < 0 || ix.Group > re.NumSubexp() {
+		return fmt.Errorf("group %d out of range, pattern has %d groups", ix.Group, re.NumSubexp())
+	}
+	return nil
+}
+
+// CreateExtractIndex creates an index on a value extracted with
+// regexp_extract, unless an index with the same name exists, so that
+// regex-derived lookups can use it:
+//
+//	ix := sqlite_regexp.ExtractIndex{
+//		Name:    "orders_invoice",
+//		Table:   "orders",
+//		Column:  "note",
+//		Pattern: `INV-\d{6}`,
+//	}
+//	err := sqlite_regexp.CreateExtractIndex(db, ix)
+//	// SELECT * FROM orders WHERE regexp_extract("note", 'INV-\d{6}', 0) = 'INV-000042'
+//
+// Every connection that writes to the table, or reads it through the index,
+// must have the function suite registered.
+func CreateExtractIndex(db *sql.DB, ix ExtractIndex) error {
+	return CreateExtractIndexContext(context.Background(), db, ix)
+}
+
+// CreateExtractIndexContext is like CreateExtractIndex, but stops,
+// interrupting the statement, once ctx is done. The index is then not
+// created.
+func CreateExtractIndexContext(ctx context.Context, db *sql.DB, ix ExtractIndex) error {
+	stmt, err := ix.SQL()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, stmt)
+	return err
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,83 @@
+package sqlite_regexp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCreateExtractIndex(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	_, err = db.Exec(`CREATE TABLE orders (id INTEGER PRIMARY KEY, note TEXT);
+		INSERT INTO orders (note) VALUES ('paid inv-000042'), ('INV-000043 pending'), ('no invoice')`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	ix := ExtractIndex{
+		Name:      "orders_invoice",
+		Table:     "orders",
+		Column:    "note",
+		Pattern:   `INV-(?P<number>\d{6})`,
+		GroupName: "number",
+		Flags:     "i",
+	}
+	if err := CreateExtractIndex(db, ix); err != nil {
+		t.Fatalf("CreateExtractIndex failed: %v", err)
+	}
+	// Creating it again does nothing.
+	if err := CreateExtractIndex(db, ix); err != nil {
+		t.Fatalf("CreateExtractIndex failed: %v", err)
+	}
+
+	query := `SELECT id FROM orders WHERE ` + ix.Expr() + ` = '000042'`
+	var id int
+	if err := db.QueryRow(query).Scan(&id); err != nil || id != 1 {
+		t.Errorf("%s = %d, %v, expected 1", query, id, err)
+	}
+
+	var plan strings.Builder
+	rows, err := db.Query(`EXPLAIN QUERY PLAN ` + query)
+	if err != nil {
+		t.Fatalf("EXPLAIN failed: %v", err)
+	}
+	for rows.Next() {
+		var id, parent, notUsed int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		plan.WriteString(detail)
+	}
+	_ = rows.Close()
+	if !strings.Contains(plan.String(), "orders_invoice") {
+		t.Errorf("Expected the query to use the index, plan: %s", plan.String())
+	}
+
+	unique := ExtractIndex{Name: "orders_unique", Table: "orders", Column: "note", Pattern: `\w+$`, Unique: true}
+	if err := CreateExtractIndex(db, unique); err != nil {
+		t.Fatalf("CreateExtractIndex failed: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO orders (note) VALUES ('also pending')`); err == nil {
+		t.Error("Expected a UNIQUE constraint violation, got nil")
+	}
+
+	for _, bad := range []ExtractIndex{
+		{Table: "orders", Column: "note", Pattern: `x`},
+		{Name: "i1", Table: "orders", Column: "note", Pattern: `{{invoice}}`},
+		{Name: "i2", Table: "orders", Column: "note", Pattern: `(`},
+		{Name: "i3", Table: "orders", Column: "note", Pattern: `(x)`, Group: 2},
+		{Name: "i4", Table: "orders", Column: "note", Pattern: `(x)`, GroupName: "y"},
+		{Name: "i5", Table: "orders", Column: "note", Pattern: `x`, Flags: "q"},
+	} {
+		if _, err := bad.SQL(); err == nil {
+			t.Errorf("SQL(%+v): expected an error, got nil", bad)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package core
+
+import "strings"
+
+// Aggregate describes an aggregate SQL function of the suite. New returns
+// the state of one group: Step is called with the arguments of each of its
+// rows, converted like those of Function.Impl, and Final returns the result.
+type Aggregate struct {
+	Name    string
+	MinArgs int
+	MaxArgs int
+	New     func() AggregateState
+}
+
+// AggregateState accumulates the rows of one group of an Aggregate.
+type AggregateState interface {
+	Step(args ...any) error
+	Final() (any, error)
+}
+
+// Aggregates returns the aggregate functions of the suite for cfg.
+func Aggregates(cfg *Config) []Aggregate {
+	return []Aggregate{
+		{Name: "regexp_agg", MinArgs: 2, MaxArgs: 4, New: func() AggregateState { return &regexpAgg{cfg: cfg} }},
+		{Name: "regexp_agg_json", MinArgs: 2, MaxArgs: 3, New: func() AggregateState { return newRegexpAggJSON(cfg) }},
+		{Name: "count_matching", MinArgs: 2, MaxArgs: 3, New: func() AggregateState { return &countMatching{cfg: cfg} }},
+	}
+}
+
+// firstMatch returns the first match of pattern in text, for aggregates
+// taking (text, pattern, ..., flags) with the flags at position flagsAt. It
+// reports false if there is no match or an argument is NULL.
+func (c *Config) firstMatch(function string, args []any, flagsAt int) (string, bool, error) {
+	text, ok := TextArg(args[0])
+	if !ok {
+		return "", false, nil
+	}
+	pattern, ok := TextArg(args[1])
+	if !ok {
+		return "", false, nil
+	}
+	flags, ok, err := flagsArg(function, args, flagsAt)
+	if !ok {
+		return "", false, err
+	}
+	re, err := c.Compile(pattern, flags)
+	if err != nil {
+		return "", false, err
+	}
+	loc := re.FindStringIndex(text)
+	if loc == nil {
+		return "", false, nil
+	}
+	return text[loc[0]:loc[1]], true, nil
+}
+
+// regexpAgg implements regexp_agg(text, pattern [, separator [, flags]]),
+// which concatenates the first match of pattern in each row, like
+// group_concat: the separator (',' by default) of each row but the first is
+// written before its match, rows without a match are skipped and the result
+// is NULL if no row matched.
+type regexpAgg struct {
+	cfg     *Config
+	b       strings.Builder
+	matched bool
+}
+
+func (a *regexpAgg) Step(args ...any) error {
+	match, ok, err := a.cfg.firstMatch("regexp_agg", args, 3)
+	if !ok {
+		return err
+	}
+
+	sep := ","
+	if len(args) > 2 {
+		sep, _ = TextArg(args[2])
+	}
+	if a.matched {
+		a.b.WriteString(sep)
+	}
+	a.b.WriteString(match)
+	a.matched = true
+	return nil
+}
+
+func (a *regexpAgg) Final() (any, error) {
+	if !a.matched {
+		return nil, nil
+	}
+	return a.b.String(), nil
+}
+
+// regexpAggJSON implements regexp_agg_json(text, pattern [, flags]), which
+// returns the first match of pattern in each row as a JSON array, subject to
+// the same caps as the other JSON functions.
+type regexpAggJSON struct {
+	cfg *Config
+	b   *jsonBuilder
+	n   int
+}
+
+func newRegexpAggJSON(cfg *Config) *regexpAggJSON {
+	b := &jsonBuilder{limit: cfg.MaxResultSize}
+	b.raw("[")
+	return &regexpAggJSON{cfg: cfg, b: b}
+}
+
+func (a *regexpAggJSON) Step(args ...any) error {
+	if a.b.overflow {
+		return nil
+	}
+	if text, ok := TextArg(args[0]); ok {
+		text, ok = a.b.limitInput(a.cfg, text)
+		if !ok {
+			return nil
+		}
+		args = append([]any{text}, args[1:]...)
+	}
+	match, ok, err := a.cfg.firstMatch("regexp_agg_json", args, 2)
+	if !ok {
+		return err
+	}
+
+	if a.n > 0 {
+		a.b.raw(",")
+	}
+	a.b.str(match)
+	a.b.commit()
+	a.n++
+	return nil
+}
+
+func (a *regexpAggJSON) Final() (any, error) {
+	a.b.raw("]")
+	return a.b.result(a.cfg, "regexp_agg_json")
+}
+
+// countMatching implements count_matching(text, pattern [, flags]), which
+// counts the rows whose text matches pattern. Like count, it returns 0 for an
+// empty group and skips rows where an argument is NULL. The pattern only
+// needs compiling once, as it goes through the shared cache.
+type countMatching struct {
+	cfg *Config
+	n   int64
+}
+
+func (a *countMatching) Step(args ...any) error {
+	text, ok := TextArg(args[0])
+	if !ok {
+		return nil
+	}
+	pattern, ok := TextArg(args[1])
+	if !ok {
+		return nil
+	}
+	flags, ok, err := flagsArg("count_matching", args, 2)
+	if !ok {
+		return err
+	}
+	re, err := a.cfg.Compile(pattern, flags)
+	if err != nil {
+		return err
+	}
+	if re.MatchString(text) {
+		a.n++
+	}
+	return nil
+}
+
+func (a *countMatching) Final() (any, error) {
+	return a.n, nil
+}
@@ -0,0 +1,52 @@
+package core
+
+import "testing"
+
+func runAggregate(t *testing.T, cfg *Config, name string, rows ...[]any) (any, error) {
+	t.Helper()
+	for _, agg := range Aggregates(cfg) {
+		if agg.Name != name {
+			continue
+		}
+		state := agg.New()
+		for _, args := range rows {
+			if err := state.Step(args...); err != nil {
+				return nil, err
+			}
+		}
+		return state.Final()
+	}
+	t.Fatalf("Unknown aggregate %q", name)
+	return nil, nil
+}
+
+func TestRegexpAggJSONMaxInputLength(t *testing.T) {
+	cfg := &Config{MaxInputLength: 3, OverflowMode: OverflowEnvelope}
+	result, err := runAggregate(t, cfg, "regexp_agg_json",
+		[]any{"ab1234", `\d+`}, []any{"123", `\d+`}, []any{nil, `\d+`})
+	if err != nil {
+		t.Fatalf("regexp_agg_json failed: %v", err)
+	}
+	// Long rows are matched on their truncated text.
+	expected := `{"truncated":true,"reason":"input_length","partial":["1","123"]}`
+	if result != expected {
+		t.Errorf("regexp_agg_json = %v, expected %q", result, expected)
+	}
+
+	cfg.OverflowMode = OverflowError
+	if _, err := runAggregate(t, cfg, "regexp_agg_json", []any{"ab1234", `\d+`}); err == nil {
+		t.Error("Expected *InputTooLongError, got nil")
+	}
+}
+
+func TestRegexpAggSeparator(t *testing.T) {
+	result, err := runAggregate(t, &Config{}, "regexp_agg",
+		[]any{"x1", `\d`, nil}, []any{"y2", `\d`, nil}, []any{"z3", `\d`, "+"})
+	if err != nil {
+		t.Fatalf("regexp_agg failed: %v", err)
+	}
+	// A NULL separator joins matches without one, like group_concat.
+	if result != "12+3" {
+		t.Errorf("regexp_agg = %v, expected %q", result, "12+3")
+	}
+}
@@ -0,0 +1,56 @@
+package core
+
+import "fmt"
+
+// ChangeRequest describes a library change submitted to an Approver.
+type ChangeRequest struct {
+	// Proposed is the version recorded if the change is approved.
+	Proposed Version
+	// Current is the latest version of the pattern, or nil if it has never
+	// been defined.
+	Current *Version
+	// RollbackTo is the version restored by RollbackPattern, or 0.
+	RollbackTo int
+}
+
+// Approver approves library changes. Define, Remove and RollbackPattern only
+// apply a change once Approve returns nil; its error is returned to the
+// caller wrapped in a *RejectedError.
+//
+// Approve is called with changes serialized. It may read the library but
+// must not change it.
+type Approver interface {
+	Approve(req ChangeRequest) error
+}
+
+// ApproverFunc adapts a function to the Approver interface.
+type ApproverFunc func(req ChangeRequest) error
+
+// Approve calls f(req).
+func (f ApproverFunc) Approve(req ChangeRequest) error {
+	return f(req)
+}
+
+var _ Approver = ApproverFunc(nil)
+
+// RejectedError is returned when an Approver rejects a library change.
+type RejectedError struct {
+	Request ChangeRequest
+	Err     error
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("change to pattern %q rejected: %v", e.Request.Proposed.Name, e.Err)
+}
+
+func (e *RejectedError) Unwrap() error {
+	return e.Err
+}
+
+// SetApprover makes every later change of the library subject to a's
+// approval. A nil Approver approves every change.
+func (l *Library) SetApprover(a Approver) {
+	l.changeMu.Lock()
+	l.approver = a
+	l.changeMu.Unlock()
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLibraryApprover(t *testing.T) {
+	lib := NewLibrary()
+	if err := lib.Define("invoice", `INV-\d+`); err != nil {
+		t.Fatal(err)
+	}
+
+	errNoReason := errors.New("a reason is required")
+	var requests []ChangeRequest
+	lib.SetApprover(ApproverFunc(func(req ChangeRequest) error {
+		requests = append(requests, req)
+		// The approver may read the library without deadlocking.
+		_ = lib.History(req.Proposed.Name)
+		if req.Proposed.Reason == "" {
+			return errNoReason
+		}
+		return nil
+	}))
+
+	err := lib.Define("invoice", `INV-\d{6}`, ByAuthor("bob"))
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) || !errors.Is(err, errNoReason) {
+		t.Fatalf("Expected *RejectedError wrapping errNoReason, got %v", err)
+	}
+	if p, _ := lib.Get("invoice"); p != `INV-\d+` {
+		t.Errorf("Rejected change was applied: %q", p)
+	}
+	if len(lib.History("invoice")) != 1 {
+		t.Error("Rejected change was recorded")
+	}
+
+	if err := lib.Define("invoice", `INV-\d{6}`, ByAuthor("bob"), Because("TICKET-42")); err != nil {
+		t.Fatalf("Approved change failed: %v", err)
+	}
+	if err := lib.Remove("invoice", Because("obsolete")); err != nil {
+		t.Fatalf("Approved removal failed: %v", err)
+	}
+	if err := lib.RollbackPattern("invoice", 1); err == nil {
+		t.Error("Expected rollback without a reason to be rejected")
+	}
+	if err := lib.RollbackPattern("invoice", 1, Because("revert")); err != nil {
+		t.Fatalf("Approved rollback failed: %v", err)
+	}
+
+	last := requests[len(requests)-1]
+	if last.RollbackTo != 1 || last.Proposed.Version != 4 || last.Proposed.Pattern != `INV-\d+` ||
+		last.Current == nil || !last.Current.Removed {
+		t.Errorf("Unexpected rollback request %+v", last)
+	}
+	history := lib.History("invoice")
+	if history[1].Reason != "TICKET-42" || history[1].Author != "bob" || history[2].Reason != "obsolete" {
+		t.Errorf("Reasons were not recorded: %+v", history)
+	}
+
+	// Invalid patterns are rejected before reaching the approver.
+	n := len(requests)
+	if err := lib.Define("broken", `(`, Because("test")); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+	if len(requests) != n {
+		t.Error("Approver was asked about an invalid pattern")
+	}
+}
@@ -0,0 +1,505 @@
+package core
+
+import (
+	"hash/maphash"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// cacheKey identifies a compiled pattern. Flags are kept apart from the
+// pattern so that equivalent flag strings ("is" and "si") share an entry.
+// form is the textForm the pattern is compiled for, see Config.formOf.
+type cacheKey struct {
+	pattern string
+	flags   Flags
+	form    textForm
+}
+
+// compile compiles the pattern of k.
+func (k cacheKey) compile() (*regexp.Regexp, error) {
+	if k.form == (textForm{}) {
+		return k.flags.compile(k.pattern)
+	}
+	return k.form.compile(k.pattern, k.flags)
+}
+
+// cacheShards is the number of shards of a Cache. Lookups only take the read
+// lock of one shard, so that many concurrent queries do not all contend for
+// the same lock.
+const cacheShards = 32
+
+// Cache caches compiled regular expressions. Functions use the cache of
+// their Config, or the process-wide cache shared by Compile if it has none.
+type Cache struct {
+	seed   maphash.Seed
+	shards [cacheShards]cacheShard
+
+	// ttl is the time-to-live of the entries in nanoseconds, zero if they
+	// do not expire. lastSweep is when expired entries were last removed.
+	ttl       atomic.Int64
+	lastSweep atomic.Int64
+	expired   atomic.Uint64
+
+	// bytes is the estimated memory of the entries, see patternCost, and
+	// maxBytes its budget, zero if unlimited. evictMu serializes evictions.
+	bytes    atomic.Int64
+	maxBytes atomic.Int64
+	evictMu  sync.Mutex
+	evicted  atomic.Uint64
+
+	// registrations counts the connections the suite was registered on with
+	// the cache, see Config.CountRegistration.
+	registrations atomic.Uint64
+
+	// backtracking holds the patterns of the backtracking engine, see
+	// Config.EngineTags.
+	backtracking backtrackCache
+}
+
+// cacheShard is exactly 64 bytes, a cache line, so that updating one does
+// not slow down its neighbours.
+type cacheShard struct {
+	mu      sync.RWMutex
+	entries map[cacheKey]*cacheEntry
+	// inflight holds the patterns being compiled, so that concurrent
+	// lookups of a new pattern wait for one compilation.
+	inflight map[cacheKey]*compileCall
+	// The statistics are counted per shard too, as a single set of
+	// counters would be as contended as a single lock.
+	hits, misses, compileErrors atomic.Uint64
+}
+
+// cacheEntry is a compiled pattern with its estimated memory cost, when and
+// how long it took to compile, and its uses. Times are in nanoseconds since
+// the Unix epoch.
+type cacheEntry struct {
+	re          *regexp.Regexp
+	cost        int64
+	compiledAt  int64
+	compileTime time.Duration
+	hits        atomic.Uint64
+	lastUsed    atomic.Int64
+}
+
+// nowNano returns the current time for the bookkeeping of entries.
+var nowNano = func() int64 { return time.Now().UnixNano() }
+
+// compileCall is a compilation in progress. done is closed once re and err
+// are set.
+type compileCall struct {
+	done chan struct{}
+	re   *regexp.Regexp
+	err  error
+}
+
+// NewCache returns an empty cache.
+func NewCache() *Cache {
+	c := &Cache{seed: maphash.MakeSeed()}
+	for i := range c.shards {
+		c.shards[i].entries = make(map[cacheKey]*cacheEntry)
+		c.shards[i].inflight = make(map[cacheKey]*compileCall)
+	}
+	return c
+}
+
+// shard returns the shard holding key.
+func (c *Cache) shard(key cacheKey) *cacheShard {
+	h := maphash.String(c.seed, key.pattern) ^ uint64(key.flags) ^
+		uint64(key.form.norm)<<8 ^ uint64(key.form.fold)<<16
+	return &c.shards[h%cacheShards]
+}
+
+// sharedCache is the cache of Compile, used by every Config without its own.
+var sharedCache = NewCache()
+
+// CacheStats are counters of a compiled pattern cache since it was created.
+type CacheStats struct {
+	// Size is the number of patterns in the cache.
+	Size int
+	// Misses counts the lookups of Compile that compiled the pattern, and
+	// Hits the others, which found it in the cache or waited for another
+	// lookup to compile it.
+	Hits   uint64
+	Misses uint64
+	// CompileErrors counts the misses for invalid patterns.
+	CompileErrors uint64
+	// Expired counts the patterns removed because they were not used for
+	// the time-to-live of the cache.
+	Expired uint64
+	// Bytes is an estimate of the memory used by the compiled patterns, and
+	// Evicted counts the patterns removed to keep it within the budget of
+	// the cache.
+	Bytes   int64
+	Evicted uint64
+	// Registrations counts the connections the function suite was
+	// registered on with the cache. In a pool it should level off at about
+	// the pool size: a count that keeps growing with the queries shows
+	// connections being recreated, each paying for a registration, such as
+	// with a short ConnMaxIdleTime or ConnMaxLifetime.
+	Registrations uint64
+}
+
+// SetTTL makes the patterns that are not used for ttl expire, or keeps them
+// until the cache is cleared if ttl is zero or less, the default. Expired
+// patterns are removed during later lookups, at most every ttl/2. The
+// patterns already in the cache count as used when SetTTL is called.
+func (c *Cache) SetTTL(ttl time.Duration) {
+	now := c.markUsed()
+	c.lastSweep.Store(now)
+	c.ttl.Store(max(int64(ttl), 0))
+}
+
+// SetMaxBytes sets the budget of the cache: once the estimated memory of the
+// compiled patterns exceeds n bytes, the least recently used ones are
+// evicted until it is back under 90% of n. Zero or less, the default, makes
+// the cache unbounded. The estimate, reported in CacheStats.Bytes, counts
+// the instructions of the compiled program, so a large alternation weighs
+// more than many short patterns.
+func (c *Cache) SetMaxBytes(n int64) {
+	c.maxBytes.Store(max(n, 0))
+	c.evict()
+}
+
+// markUsed records every entry as used now and returns now.
+func (c *Cache) markUsed() int64 {
+	now := nowNano()
+	for i := range c.shards {
+		shard := &c.shards[i]
+		shard.mu.RLock()
+		for _, e := range shard.entries {
+			e.lastUsed.Store(now)
+		}
+		shard.mu.RUnlock()
+	}
+	return now
+}
+
+// Compile returns the compiled form of pattern with flags, compiling and
+// caching it on first use. Concurrent first uses of a pattern compile it
+// once, the others waiting for the result.
+func (c *Cache) Compile(pattern string, flags Flags) (*regexp.Regexp, error) {
+	return c.compile(cacheKey{pattern: pattern, flags: flags})
+}
+
+// compile returns the compiled pattern of key, compiling it on a miss.
+func (c *Cache) compile(key cacheKey) (*regexp.Regexp, error) {
+	shard := c.shard(key)
+	now := nowNano()
+	ttl := c.ttl.Load()
+	if last := c.lastSweep.Load(); ttl > 0 && now-last >= ttl/2 && c.lastSweep.CompareAndSwap(last, now) {
+		c.sweep(now - ttl)
+	}
+
+	// Check cache first
+	shard.mu.RLock()
+	e, exists := shard.entries[key]
+	shard.mu.RUnlock()
+	if exists {
+		shard.hits.Add(1)
+		e.use(now)
+		return e.re, nil
+	}
+
+	shard.mu.Lock()
+	if e, exists := shard.entries[key]; exists {
+		shard.mu.Unlock()
+		shard.hits.Add(1)
+		e.use(now)
+		return e.re, nil
+	}
+	if call, ok := shard.inflight[key]; ok {
+		shard.mu.Unlock()
+		<-call.done
+		shard.hits.Add(1)
+		return call.re, call.err
+	}
+	call := &compileCall{done: make(chan struct{})}
+	shard.inflight[key] = call
+	shard.mu.Unlock()
+	shard.misses.Add(1)
+
+	// Compile the regex and cache it
+	call.re, call.err = key.compile()
+	compileTime := time.Duration(nowNano() - now)
+	if call.err != nil {
+		shard.compileErrors.Add(1)
+	}
+
+	var cost int64
+	if call.err == nil {
+		cost = patternCost(key.flags.apply(key.pattern))
+	}
+	shard.mu.Lock()
+	if call.err == nil {
+		e := &cacheEntry{re: call.re, cost: cost, compiledAt: now, compileTime: compileTime}
+		e.lastUsed.Store(now)
+		shard.entries[key] = e
+		c.bytes.Add(cost)
+	}
+	delete(shard.inflight, key)
+	shard.mu.Unlock()
+	close(call.done)
+
+	if limit := c.maxBytes.Load(); limit > 0 && c.bytes.Load() > limit {
+		c.evict()
+	}
+
+	return call.re, call.err
+}
+
+// Clear empties the cache.
+func (c *Cache) Clear() {
+	evicted := 0
+	for i := range c.shards {
+		shard := &c.shards[i]
+		shard.mu.Lock()
+		evicted += len(shard.entries)
+		for _, e := range shard.entries {
+			c.bytes.Add(-e.cost)
+		}
+		shard.entries = make(map[cacheKey]*cacheEntry)
+		shard.mu.Unlock()
+	}
+	c.backtracking.clear()
+
+	if l := logger.Load(); l != nil {
+		l.Info("regexp: cache cleared", "evicted", evicted)
+	}
+}
+
+// Size returns the number of compiled patterns in the cache.
+func (c *Cache) Size() int {
+	size := 0
+	for i := range c.shards {
+		shard := &c.shards[i]
+		shard.mu.RLock()
+		size += len(shard.entries)
+		shard.mu.RUnlock()
+	}
+	return size
+}
+
+// Stats returns the current statistics of the cache.
+func (c *Cache) Stats() CacheStats {
+	stats := CacheStats{Size: c.Size()}
+	for i := range c.shards {
+		shard := &c.shards[i]
+		stats.Hits += shard.hits.Load()
+		stats.Misses += shard.misses.Load()
+		stats.CompileErrors += shard.compileErrors.Load()
+	}
+	stats.Expired = c.expired.Load()
+	stats.Bytes = c.bytes.Load()
+	stats.Evicted = c.evicted.Load()
+	stats.Registrations = c.registrations.Load()
+	return stats
+}
+
+// use records a lookup of e at now. Hot patterns are used by many goroutines
+// at once, so the time is only updated once it is a millisecond old.
+func (e *cacheEntry) use(now int64) {
+	e.hits.Add(1)
+	if now-e.lastUsed.Load() > int64(time.Millisecond) {
+		e.lastUsed.Store(now)
+	}
+}
+
+// sweep removes the entries last used before deadline.
+func (c *Cache) sweep(deadline int64) {
+	expired := 0
+	for i := range c.shards {
+		shard := &c.shards[i]
+		shard.mu.Lock()
+		for key, e := range shard.entries {
+			if e.lastUsed.Load() < deadline {
+				delete(shard.entries, key)
+				c.bytes.Add(-e.cost)
+				expired++
+			}
+		}
+		shard.mu.Unlock()
+	}
+	if expired == 0 {
+		return
+	}
+	c.expired.Add(uint64(expired))
+	if l := logger.Load(); l != nil {
+		l.Info("regexp: cache entries expired", "expired", expired)
+	}
+}
+
+// evict removes the least recently used entries until the cache is under 90%
+// of its budget, if it is over it.
+func (c *Cache) evict() {
+	c.evictMu.Lock()
+	defer c.evictMu.Unlock()
+	limit := c.maxBytes.Load()
+	if limit <= 0 || c.bytes.Load() <= limit {
+		return
+	}
+
+	type candidate struct {
+		shard    *cacheShard
+		key      cacheKey
+		lastUsed int64
+	}
+	var candidates []candidate
+	for i := range c.shards {
+		shard := &c.shards[i]
+		shard.mu.RLock()
+		for key, e := range shard.entries {
+			candidates = append(candidates, candidate{shard, key, e.lastUsed.Load()})
+		}
+		shard.mu.RUnlock()
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].lastUsed < candidates[j].lastUsed })
+
+	target := limit / 10 * 9
+	evicted := 0
+	for _, cand := range candidates {
+		if c.bytes.Load() <= target {
+			break
+		}
+		cand.shard.mu.Lock()
+		// The entry may have been replaced or removed since.
+		if e, ok := cand.shard.entries[cand.key]; ok && e.lastUsed.Load() == cand.lastUsed {
+			delete(cand.shard.entries, cand.key)
+			c.bytes.Add(-e.cost)
+			evicted++
+		}
+		cand.shard.mu.Unlock()
+	}
+	if evicted == 0 {
+		return
+	}
+	c.evicted.Add(uint64(evicted))
+	if l := logger.Load(); l != nil {
+		l.Info("regexp: cache entries evicted", "evicted", evicted, "bytes", c.bytes.Load(), "max_bytes", limit)
+	}
+}
+
+// CachedPattern describes a pattern of a Cache, as listed by its Patterns
+// method.
+type CachedPattern struct {
+	// Pattern and Flags are the arguments of the functions using it, Flags
+	// being in canonical form ("is" for "si").
+	Pattern string
+	Flags   string
+	// CompiledAt is when the pattern was compiled, and CompileTime how long
+	// it took.
+	CompiledAt  time.Time
+	CompileTime time.Duration
+	// Hits counts the lookups that found the pattern in the cache, and
+	// LastUsed is the last of them, to the millisecond, or CompiledAt.
+	Hits     uint64
+	LastUsed time.Time
+	// Bytes is the estimated memory of the compiled pattern.
+	Bytes int64
+}
+
+// Patterns lists the patterns of the cache, most used first, to find out
+// which patterns of a set are actually exercised.
+func (c *Cache) Patterns() []CachedPattern {
+	var patterns []CachedPattern
+	for i := range c.shards {
+		shard := &c.shards[i]
+		shard.mu.RLock()
+		for key, e := range shard.entries {
+			patterns = append(patterns, CachedPattern{
+				Pattern:     key.pattern,
+				Flags:       key.flags.String(),
+				CompiledAt:  time.Unix(0, e.compiledAt),
+				CompileTime: e.compileTime,
+				Hits:        e.hits.Load(),
+				LastUsed:    time.Unix(0, e.lastUsed.Load()),
+				Bytes:       e.cost,
+			})
+		}
+		shard.mu.RUnlock()
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if patterns[i].Hits != patterns[j].Hits {
+			return patterns[i].Hits > patterns[j].Hits
+		}
+		if patterns[i].Pattern != patterns[j].Pattern {
+			return patterns[i].Pattern < patterns[j].Pattern
+		}
+		return patterns[i].Flags < patterns[j].Flags
+	})
+	return patterns
+}
+
+// patternCost estimates the memory used by the compiled form of expr: a
+// fixed overhead, the expression, and the instructions of its program, which
+// dominate for large patterns.
+func patternCost(expr string) int64 {
+	const (
+		overhead = 512
+		instSize = 40
+	)
+	cost := int64(overhead + len(expr))
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return cost
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return cost
+	}
+	for _, inst := range prog.Inst {
+		cost += instSize + 4*int64(len(inst.Rune))
+	}
+	return cost
+}
+
+// Compile compiles pattern with flags through the process-wide cache.
+func Compile(pattern string, flags Flags) (*regexp.Regexp, error) {
+	return sharedCache.Compile(pattern, flags)
+}
+
+// ClearCache empties the process-wide cache.
+func ClearCache() {
+	sharedCache.Clear()
+}
+
+// CacheSize returns the number of compiled patterns in the process-wide
+// cache.
+func CacheSize() int {
+	return sharedCache.Size()
+}
+
+// SetCacheTTL sets the time-to-live of the patterns of the process-wide
+// cache, see Cache.SetTTL.
+func SetCacheTTL(ttl time.Duration) {
+	sharedCache.SetTTL(ttl)
+}
+
+// SetCacheMaxBytes sets the budget of the process-wide cache, see
+// Cache.SetMaxBytes.
+func SetCacheMaxBytes(n int64) {
+	sharedCache.SetMaxBytes(n)
+}
+
+// CachedPatterns lists the patterns of the process-wide cache, see
+// Cache.Patterns.
+func CachedPatterns() []CachedPattern {
+	return sharedCache.Patterns()
+}
+
+// ReadCacheStats returns the statistics of the process-wide cache.
+func ReadCacheStats() CacheStats {
+	return sharedCache.Stats()
+}
+
+// Match reports whether text contains a match of pattern.
+func Match(pattern, text string) (bool, error) {
+	re, err := Compile(pattern, 0)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(text), nil
+}
@@ -0,0 +1,218 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+func TestCacheConcurrent(t *testing.T) {
+	c := NewCache()
+	var g errgroup.Group
+	for w := 0; w < 64; w++ {
+		g.Go(func() error {
+			for i := 0; i < 200; i++ {
+				pattern := fmt.Sprintf(`^item-%d-\d+$`, i%50)
+				re, err := c.Compile(pattern, Flags(i/50%2))
+				if err != nil {
+					return err
+				}
+				if re.String() == "" {
+					return fmt.Errorf("empty regexp for %s", pattern)
+				}
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	stats := c.Stats()
+	if stats.Size != 100 {
+		t.Errorf("Expected 100 patterns, got %d", stats.Size)
+	}
+	// Each pattern is compiled once, however many lookups race for it.
+	if stats.Hits+stats.Misses != 64*200 || stats.Misses != 100 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	c.Clear()
+	if c.Size() != 0 {
+		t.Errorf("Expected empty cache, got %d", c.Size())
+	}
+}
+
+func TestCacheCompilesOnce(t *testing.T) {
+	c := NewCache()
+	start := make(chan struct{})
+	var g errgroup.Group
+	for w := 0; w < 64; w++ {
+		g.Go(func() error {
+			<-start
+			_, err := c.Compile(`(\w+)@(\w+)\.(com|org|net)`, 0)
+			return err
+		})
+	}
+	close(start)
+	if err := g.Wait(); err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if stats := c.Stats(); stats.Misses != 1 || stats.Hits != 63 {
+		t.Errorf("Expected one compilation, got %+v", stats)
+	}
+
+	// Failed compilations are shared by concurrent lookups but not cached.
+	for i := 0; i < 2; i++ {
+		if _, err := c.Compile(`(`, 0); err == nil {
+			t.Fatal("Invalid pattern accepted")
+		}
+	}
+	if stats := c.Stats(); stats.CompileErrors != 2 || stats.Size != 1 {
+		t.Errorf("Unexpected stats after errors %+v", stats)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	now := time.Now().UnixNano()
+	nowNano = func() int64 { return now }
+	defer func() {
+		nowNano = func() int64 { return time.Now().UnixNano() }
+	}()
+	advance := func(d time.Duration) {
+		now += int64(d)
+	}
+
+	c := NewCache()
+	_, _ = c.Compile("kept-before-ttl", 0)
+	c.SetTTL(time.Minute)
+	_, _ = c.Compile("stale", 0)
+	_, _ = c.Compile("hot", 0)
+
+	advance(40 * time.Second)
+	_, _ = c.Compile("hot", 0)
+	if c.Size() != 3 {
+		t.Errorf("Expected 3 patterns before expiry, got %d", c.Size())
+	}
+
+	// The untouched patterns, including the one cached before SetTTL,
+	// expire at the first lookup after the sweep interval; the hot one was
+	// used within the TTL.
+	advance(40 * time.Second)
+	_, _ = c.Compile("hot", 0)
+	if c.Size() != 1 {
+		t.Errorf("Expected only the hot pattern, got %d", c.Size())
+	}
+	if stats := c.Stats(); stats.Expired != 2 {
+		t.Errorf("Expected 2 expired patterns, got %+v", stats)
+	}
+
+	c.SetTTL(0)
+	advance(time.Hour)
+	_, _ = c.Compile("other", 0)
+	if c.Size() != 2 {
+		t.Errorf("Patterns expired with the TTL disabled: %d left", c.Size())
+	}
+}
+
+func TestCacheMaxBytes(t *testing.T) {
+	now := time.Now().UnixNano()
+	nowNano = func() int64 {
+		now += int64(time.Second)
+		return now
+	}
+	defer func() {
+		nowNano = func() int64 { return time.Now().UnixNano() }
+	}()
+
+	small := patternCost(`^a0$`)
+	words := make([]string, 300)
+	for i := range words {
+		words[i] = fmt.Sprintf("word%d", i)
+	}
+	giant := strings.Join(words, "|")
+	if big := patternCost(giant); big < 5*small {
+		t.Fatalf("Expected a large alternation to weigh much more than a small pattern: %d vs %d", big, small)
+	}
+
+	c := NewCache()
+	c.SetMaxBytes(15 * small)
+	for i := 0; i < 10; i++ {
+		_, _ = c.Compile(fmt.Sprintf(`^a%d$`, i), 0)
+	}
+	if stats := c.Stats(); stats.Size != 10 || stats.Evicted != 0 {
+		t.Fatalf("Unexpected stats before the budget is exceeded %+v", stats)
+	}
+
+	// Use ^a0$ again, then blow the budget with the giant pattern: the
+	// least recently used patterns are evicted until the cache is under
+	// 90% of its budget.
+	_, _ = c.Compile(`^a0$`, 0)
+	_, _ = c.Compile(giant, 0)
+	stats := c.Stats()
+	if stats.Bytes > 15*small/10*9 || stats.Evicted == 0 {
+		t.Errorf("Unexpected stats after eviction %+v", stats)
+	}
+	before := c.Stats().Misses
+	_, _ = c.Compile(`^a0$`, 0)
+	_, _ = c.Compile(giant, 0)
+	if c.Stats().Misses != before {
+		t.Error("Recently used patterns were evicted")
+	}
+	_, _ = c.Compile(`^a1$`, 0)
+	if c.Stats().Misses != before+1 {
+		t.Error("Least recently used pattern was kept")
+	}
+
+	// Without a budget nothing is evicted, and Clear resets the estimate.
+	c.SetMaxBytes(0)
+	for i := 0; i < 50; i++ {
+		_, _ = c.Compile(fmt.Sprintf(`^b%d$`, i), 0)
+	}
+	if c.Size() < 50 {
+		t.Errorf("Patterns evicted without a budget: %d left", c.Size())
+	}
+	c.Clear()
+	if stats := c.Stats(); stats.Bytes != 0 {
+		t.Errorf("Expected no bytes after Clear, got %+v", stats)
+	}
+}
+
+func TestCachePatterns(t *testing.T) {
+	now := time.Now().UnixNano()
+	nowNano = func() int64 { return now }
+	defer func() {
+		nowNano = func() int64 { return time.Now().UnixNano() }
+	}()
+	compiledAt := time.Unix(0, now)
+
+	c := NewCache()
+	_, _ = c.Compile("cold", 0)
+	_, _ = c.Compile("hot", FlagCaseInsensitive|FlagDotNL)
+	now += int64(time.Minute)
+	for range 3 {
+		_, _ = c.Compile("hot", FlagCaseInsensitive|FlagDotNL)
+	}
+	_, _ = c.Compile("(", 0)
+
+	patterns := c.Patterns()
+	if len(patterns) != 2 {
+		t.Fatalf("Expected 2 patterns, got %+v", patterns)
+	}
+	hot, cold := patterns[0], patterns[1]
+	if hot.Pattern != "hot" || hot.Flags != "is" || hot.Hits != 3 {
+		t.Errorf("Unexpected hot pattern %+v", hot)
+	}
+	if !hot.CompiledAt.Equal(compiledAt) || !hot.LastUsed.Equal(compiledAt.Add(time.Minute)) {
+		t.Errorf("Unexpected times for the hot pattern %+v", hot)
+	}
+	if cold.Pattern != "cold" || cold.Hits != 0 || !cold.LastUsed.Equal(compiledAt) {
+		t.Errorf("Unexpected cold pattern %+v", cold)
+	}
+	if cold.Bytes <= 0 {
+		t.Errorf("Expected an estimated size, got %+v", cold)
+	}
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Collation orders strings by a sort key extracted with a pattern: the first
+// capturing group of its first match, or the whole match if it has no group.
+// Strings without a key sort first, and strings with equal keys are ordered
+// bytewise, so that the order is total as SQLite requires.
+type Collation struct {
+	re    *regexp.Regexp
+	group int
+	// Numeric compares keys as numbers; keys that are not numbers sort after
+	// those that are, bytewise.
+	Numeric bool
+}
+
+// NewCollation returns a collation extracting its keys with pattern.
+func NewCollation(pattern string, flags Flags) (*Collation, error) {
+	re, err := Compile(pattern, flags)
+	if err != nil {
+		return nil, err
+	}
+	c := &Collation{re: re}
+	if re.NumSubexp() > 0 {
+		c.group = 1
+	}
+	return c, nil
+}
+
+// key returns the sort key of s, and whether it has one.
+func (c *Collation) key(s string) (string, bool) {
+	m := c.re.FindStringSubmatchIndex(s)
+	if m == nil || m[2*c.group] < 0 {
+		return "", false
+	}
+	return s[m[2*c.group]:m[2*c.group+1]], true
+}
+
+// Compare returns -1, 0 or 1 depending on whether a sorts before, like or
+// after b.
+func (c *Collation) Compare(a, b string) int {
+	ka, oka := c.key(a)
+	kb, okb := c.key(b)
+	switch {
+	case !oka && !okb:
+		return strings.Compare(a, b)
+	case !oka:
+		return -1
+	case !okb:
+		return 1
+	}
+	if cmp := c.compareKeys(ka, kb); cmp != 0 {
+		return cmp
+	}
+	return strings.Compare(a, b)
+}
+
+func (c *Collation) compareKeys(a, b string) int {
+	if !c.Numeric {
+		return strings.Compare(a, b)
+	}
+	na, oka := parseNumber(a)
+	nb, okb := parseNumber(b)
+	switch {
+	case oka && okb:
+		if na < nb {
+			return -1
+		} else if na > nb {
+			return 1
+		}
+		return 0
+	case oka:
+		return -1
+	case okb:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// parseNumber parses a numeric key. Out of range numbers are still ordered,
+// as ±Inf.
+func parseNumber(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, false
+	}
+	return f, !math.IsNaN(f)
+}
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCollation(t *testing.T) {
+	c, err := NewCollation(`(\d+)$`, 0)
+	if err != nil {
+		t.Fatalf("NewCollation failed: %v", err)
+	}
+	c.Numeric = true
+
+	items := []string{"item12", "item2", "none", "box2", "item400", "item010", "abc"}
+	slices.SortFunc(items, c.Compare)
+	expected := []string{"abc", "none", "box2", "item2", "item010", "item12", "item400"}
+	if !slices.Equal(items, expected) {
+		t.Errorf("Numeric order = %q, expected %q", items, expected)
+	}
+
+	c.Numeric = false
+	slices.SortFunc(items, c.Compare)
+	expected = []string{"abc", "none", "item010", "item12", "box2", "item2", "item400"}
+	if !slices.Equal(items, expected) {
+		t.Errorf("Text order = %q, expected %q", items, expected)
+	}
+
+	// Without a group, the whole match is the key.
+	c, err = NewCollation(`[a-z]+`, FlagCaseInsensitive)
+	if err != nil {
+		t.Fatalf("NewCollation failed: %v", err)
+	}
+	if c.Compare("1 beta", "2 alpha") != 1 || c.Compare("x", "x") != 0 {
+		t.Error("Unexpected order of whole-match keys")
+	}
+}
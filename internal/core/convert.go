@@ -0,0 +1,49 @@
+package core
+
+import "unicode/utf8"
+
+// globToRegexp implements glob_to_regexp(pattern), the regular expression
+// matching the texts SQLite's GLOB matches with pattern, so that tables
+// mixing GLOB and regular expression patterns can be joined with REGEXP
+// alone. It returns NULL for NULL.
+func globToRegexp(args ...any) (any, error) {
+	pattern, ok := TextArg(args[0])
+	if !ok {
+		return nil, nil
+	}
+	return GlobToRegexp(pattern), nil
+}
+
+// likeToRegexp implements like_to_regexp(pattern [, escape]), the regular
+// expression matching the texts LIKE matches with pattern and escape. When
+// Config.UnicodeLike replaces LIKE, the escape defaults to Config.LikeEscape
+// and Config.LikeCaseSensitive applies; otherwise there is no default escape
+// and the expression ignores case with (?i), like SQLite's LIKE except that
+// it folds every Unicode letter, not only ASCII ones. It returns NULL if any
+// argument is NULL.
+func (c *Config) likeToRegexp(args ...any) (any, error) {
+	pattern, ok := TextArg(args[0])
+	if !ok {
+		return nil, nil
+	}
+	var escape rune
+	if c.UnicodeLike {
+		escape = c.LikeEscape
+	}
+	if len(args) > 1 {
+		e, ok := TextArg(args[1])
+		if !ok {
+			return nil, nil
+		}
+		if utf8.RuneCountInString(e) != 1 {
+			return nil, errLikeEscape
+		}
+		escape, _ = utf8.DecodeRuneInString(e)
+	}
+
+	expr := LikeToRegexp(pattern, escape)
+	if !c.UnicodeLike || !c.LikeCaseSensitive {
+		expr = "(?i)" + expr
+	}
+	return expr, nil
+}
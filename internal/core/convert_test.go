@@ -0,0 +1,41 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestConvertFunctions(t *testing.T) {
+	cfg := DefaultConfig()
+	unicodeLike := DefaultConfig()
+	unicodeLike.UnicodeLike = true
+	unicodeLike.LikeEscape = '\\'
+	unicodeLike.LikeCaseSensitive = true
+
+	tests := []struct {
+		cfg      *Config
+		function string
+		args     []any
+		expected any
+	}{
+		{&cfg, "glob_to_regexp", []any{"*.txt"}, `^(?s:.*\.txt)$`},
+		{&cfg, "glob_to_regexp", []any{"[a-c]?"}, `^(?s:[a-c].)$`},
+		{&cfg, "glob_to_regexp", []any{nil}, nil},
+		{&cfg, "like_to_regexp", []any{"a%_"}, `(?i)^(?s:a.*.)$`},
+		{&cfg, "like_to_regexp", []any{`100\%`}, `(?i)^(?s:100\\.*)$`},
+		{&cfg, "like_to_regexp", []any{`100#%`, "#"}, `(?i)^(?s:100%)$`},
+		{&cfg, "like_to_regexp", []any{"a", nil}, nil},
+		{&unicodeLike, "like_to_regexp", []any{`100\%`}, `^(?s:100%)$`},
+	}
+	for _, tt := range tests {
+		f := findFunction(Functions(tt.cfg), tt.function)
+		result, err := f.Call(tt.args...)
+		if err != nil || result != tt.expected {
+			t.Errorf("%s%q = %v, %v; expected %v", tt.function, tt.args, result, err, tt.expected)
+		}
+	}
+
+	f := findFunction(Functions(&cfg), "like_to_regexp")
+	if _, err := f.Call("a", "##"); err != errLikeEscape {
+		t.Errorf("Expected %v for a long escape, got %v", errLikeEscape, err)
+	}
+}
@@ -0,0 +1,371 @@
+// Package core implements the regexp function suite independently of any
+// SQLite binding. The go-sqlite3 registration in the root package and the
+// loadable extension in cmd/regexp-extension both expose the functions
+// returned by Functions, so they share identical semantics.
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultMaxResultSize is the default upper bound, in bytes, for the result
+// of functions that return JSON arrays.
+const DefaultMaxResultSize = 16 << 20
+
+// OverflowMode controls what a function returns when one of its caps is
+// reached.
+type OverflowMode int
+
+const (
+	// OverflowError makes the function fail with a *ResultTooLargeError or
+	// *InputTooLongError.
+	OverflowError OverflowMode = iota
+	// OverflowNull makes the function return NULL.
+	OverflowNull
+	// OverflowEnvelope makes the function return a JSON object of the form
+	// {"truncated":true,"reason":"result_size","partial":[...]}, where partial
+	// holds the elements computed before the cap was reached and reason is
+	// "result_size" or "input_length". Results within the caps are returned
+	// unchanged, so callers can tell "no match" apart from "gave up".
+	OverflowEnvelope
+)
+
+// BlobEncoding selects how the match functions read BLOB texts.
+type BlobEncoding int
+
+const (
+	// BlobUTF8 reads BLOBs as UTF-8, like texts; invalid bytes only match
+	// patterns that match U+FFFD, such as '.'.
+	BlobUTF8 BlobEncoding = iota
+	// BlobLatin1 reads every byte of a BLOB as the character of the same
+	// code point, so that '.' matches any byte and \xff the byte 0xff.
+	BlobLatin1
+)
+
+// Config holds the settings shared by every function of the suite.
+type Config struct {
+	MaxResultSize  int
+	MaxInputLength int
+	OverflowMode   OverflowMode
+	Postgres       bool
+	UnicodeGlob    bool
+	// UnicodeLike replaces the built-in LIKE; LikeEscape is its default
+	// escape character (none if zero) and LikeCaseSensitive disables case
+	// folding.
+	UnicodeLike       bool
+	LikeEscape        rune
+	LikeCaseSensitive bool
+	// Library, when set, expands {{name}} references in patterns.
+	Library *Library
+	// Cache, when set, caches the compiled patterns instead of the
+	// process-wide cache.
+	Cache *Cache
+	// SlowCallThreshold is the duration above which evaluations are logged
+	// as slow, see SetLogger. Zero or less disables the slow evaluation log.
+	SlowCallThreshold time.Duration
+	// Aliases are additional names of the regexp function.
+	Aliases []Alias
+	// BlobEncoding is how regexp, regexp_like and the aliases of regexp
+	// read BLOB texts.
+	BlobEncoding BlobEncoding
+	// Longest compiles every pattern with FlagLongest, and POSIXClasses
+	// with FlagPOSIX. CaseInsensitive compiles them with FlagCaseInsensitive
+	// unless their flags include 'c'.
+	Longest         bool
+	POSIXClasses    bool
+	CaseInsensitive bool
+	// Literals is how patterns written as /body/flags are read.
+	Literals LiteralDialect
+	// CaseFolding and Normalization are how regexp, regexp_like,
+	// regexp_full_match and the aliases of regexp compare texts.
+	CaseFolding   CaseFolding
+	Normalization Normalization
+	// Similarity adds the string similarity functions, levenshtein,
+	// soundex, difference and metaphone.
+	Similarity bool
+	// Replacement is how regexp_replace reads its replacements.
+	Replacement ReplacementSyntax
+	// EngineTags reads a leading re2: or pcre: tag of the patterns as the
+	// engine to compile them with, see RE2Tag and PCRETag.
+	// BacktrackTimeout is how long a match of the backtracking engine may
+	// take, zero or less for no limit.
+	EngineTags       bool
+	BacktrackTimeout time.Duration
+	// Policy, when set, is consulted before compiling every pattern given
+	// to the functions.
+	Policy PatternPolicy
+	// Sets, when set, adds regexp_set_match, matching texts against its
+	// pattern sets.
+	Sets *PatternSets
+	// Memo, when set, memoizes the results of the match functions.
+	Memo *ResultMemo
+}
+
+// Alias is an additional name of the regexp function, such as RLIKE for SQL
+// generated for MySQL.
+type Alias struct {
+	Name string
+	// TextFirst takes the text before the pattern, like regexp_like, instead
+	// of the order of regexp, that of the "text REGEXP pattern" operator.
+	TextFirst bool
+	// Flags are used by the calls without a flags argument.
+	Flags Flags
+}
+
+// DefaultConfig returns the configuration used when no option is given.
+func DefaultConfig() Config {
+	return Config{
+		MaxResultSize:     DefaultMaxResultSize,
+		OverflowMode:      OverflowError,
+		SlowCallThreshold: DefaultSlowCallThreshold,
+		BacktrackTimeout:  DefaultBacktrackTimeout,
+	}
+}
+
+// Function describes a SQL function of the suite. Impl receives the SQLite
+// values converted to Go (nil for NULL, int64, float64, string or []byte) and
+// returns one of those types, nil meaning NULL.
+//
+// Bindings register the function once for every argument count between
+// MinArgs and MaxArgs, so SQLite itself rejects calls with a wrong number of
+// arguments. A negative MaxArgs registers a single variadic function.
+// PatternArg and TextArg are the indexes of the pattern and text arguments,
+// reported in logs.
+//
+// Prepare, if set, compiles the pattern and flags of a call (argument
+// FlagsArg) once, for bindings that can keep the result while they stay the
+// same, such as in SQLite auxdata. The returned function evaluates calls
+// with the same pattern and flags, without looking them up in the cache.
+// Prepare returns nil if they are NULL or invalid, leaving Impl to handle
+// the call.
+//
+// BorrowsText reports that the function does not keep its text argument
+// after the call, so that bindings can pass it without copying, as a string
+// or []byte pointing into SQLite's own buffer.
+type Function struct {
+	Name          string
+	MinArgs       int
+	MaxArgs       int
+	Deterministic bool
+	PatternArg    int
+	TextArg       int
+	FlagsArg      int
+	Impl          func(args ...any) (any, error)
+	Prepare       func(args ...any) func(args ...any) (any, error)
+	BorrowsText   bool
+}
+
+// Call checks the number of arguments and runs the function.
+func (f Function) Call(args ...any) (any, error) {
+	if len(args) < f.MinArgs || (f.MaxArgs >= 0 && len(args) > f.MaxArgs) {
+		return nil, fmt.Errorf("wrong number of arguments to function %s()", f.Name)
+	}
+	return f.Impl(args...)
+}
+
+// Functions returns the function suite for cfg.
+func Functions(cfg *Config) []Function {
+	funcs := []Function{
+		{Name: "regexp", TextArg: 1, FlagsArg: 2, MinArgs: 2, MaxArgs: 3, Deterministic: true, Impl: cfg.regexpFunc,
+			Prepare: cfg.prepareMatch("regexp", 0, 1, cfg.compileMatch), BorrowsText: true},
+		{Name: "regexp_like", PatternArg: 1, FlagsArg: 2, MinArgs: 2, MaxArgs: 3, Deterministic: true, Impl: cfg.regexpLike,
+			Prepare: cfg.prepareMatch("regexp_like", 1, 0, cfg.compileMatch), BorrowsText: true},
+		{Name: "regexp_full_match", PatternArg: 1, FlagsArg: 2, MinArgs: 2, MaxArgs: 3, Deterministic: true, Impl: cfg.regexpFullMatch,
+			Prepare: cfg.prepareMatch("regexp_full_match", 1, 0, cfg.compileFull), BorrowsText: true},
+		{Name: "similar_to", PatternArg: 1, MinArgs: 2, MaxArgs: 3, Deterministic: true, Impl: cfg.similarTo},
+		{Name: "glob_to_regexp", MinArgs: 1, MaxArgs: 1, Deterministic: true, Impl: globToRegexp},
+		{Name: "like_to_regexp", MinArgs: 1, MaxArgs: 2, Deterministic: true, Impl: cfg.likeToRegexp},
+		{Name: "regexp_replace", PatternArg: 1, MinArgs: 3, MaxArgs: 4, Deterministic: true, Impl: cfg.regexpReplace},
+		{Name: "regexp_extract", PatternArg: 1, MinArgs: 2, MaxArgs: 4, Deterministic: true, Impl: cfg.regexpExtract},
+		{Name: "regexp_extract_all", PatternArg: 1, MinArgs: 2, MaxArgs: 4, Deterministic: true, Impl: cfg.regexpExtractAll},
+		{Name: "regexp_capture_count", MinArgs: 1, MaxArgs: 2, Deterministic: true, Impl: cfg.regexpCaptureCount},
+		{Name: "regexp_valid", MinArgs: 1, MaxArgs: 2, Deterministic: true, Impl: cfg.regexpValid},
+		{Name: "regexp_error", MinArgs: 1, MaxArgs: 2, Deterministic: true, Impl: cfg.regexpError},
+	}
+	for _, fn := range jsonFunctions {
+		funcs = append(funcs, Function{Name: fn.name, PatternArg: 1, MinArgs: 2, MaxArgs: 3, Deterministic: true, Impl: fn.sqlFunc(cfg)})
+	}
+	if cfg.UnicodeGlob {
+		funcs = append(funcs, Function{Name: "glob", TextArg: 1, MinArgs: 2, MaxArgs: 3, Deterministic: true, Impl: cfg.glob})
+	}
+	if cfg.UnicodeLike {
+		funcs = append(funcs, Function{Name: "like", TextArg: 1, MinArgs: 2, MaxArgs: 3, Deterministic: true, Impl: cfg.like})
+	}
+	if cfg.Postgres {
+		funcs = append(funcs, postgresFunctions(cfg)...)
+	}
+	if cfg.Similarity {
+		funcs = append(funcs, similarityFunctions()...)
+	}
+	if cfg.Sets != nil {
+		// Sets can be redefined, so the result is not a function of the
+		// arguments alone.
+		funcs = append(funcs, Function{Name: "regexp_set_match", PatternArg: 1, MinArgs: 2, MaxArgs: 2, Impl: cfg.regexpSetMatch})
+	}
+	for _, a := range cfg.Aliases {
+		fn := Function{Name: a.Name, TextArg: 1, FlagsArg: 2, MinArgs: 2, MaxArgs: 3, Deterministic: true, Impl: cfg.aliasFunc(a)}
+		if a.TextFirst {
+			fn.PatternArg, fn.TextArg = 1, 0
+		}
+		funcs = append(funcs, fn)
+	}
+	for i := range funcs {
+		if prepare := funcs[i].Prepare; prepare != nil {
+			f := funcs[i]
+			funcs[i].Prepare = func(args ...any) func(args ...any) (any, error) {
+				eval := prepare(args...)
+				if eval == nil {
+					return nil
+				}
+				f.Impl = eval
+				return observed(f, cfg.SlowCallThreshold)
+			}
+		}
+		funcs[i].Impl = observed(funcs[i], cfg.SlowCallThreshold)
+	}
+	return funcs
+}
+
+// regexpFunc implements regexp(pattern, text [, flags]), the function SQLite
+// calls for the "text REGEXP pattern" operator. It returns 1 on a match, 0
+// otherwise, and NULL if any argument is NULL.
+func (c *Config) regexpFunc(args ...any) (any, error) {
+	pattern, ok := TextArg(args[0])
+	if !ok || isNull(args[1]) {
+		return nil, nil
+	}
+	flags, ok, err := flagsArg("regexp", args, 2)
+	if !ok {
+		return nil, err
+	}
+	if result, ok, err := c.backtrackingMatch(pattern, flags, false, args[1]); ok {
+		return result, err
+	}
+	re, err := c.compileMatch(pattern, flags)
+	if err != nil {
+		return nil, err
+	}
+	return boolResult(c.matchArg(re, c.formOf(flags), args[1])), nil
+}
+
+// aliasFunc returns the implementation of the alias a of regexp.
+func (c *Config) aliasFunc(a Alias) func(args ...any) (any, error) {
+	flags := a.Flags.spec()
+	return func(args ...any) (any, error) {
+		pattern, text := args[0], args[1]
+		if a.TextFirst {
+			pattern, text = text, pattern
+		}
+		if len(args) > 2 {
+			return c.regexpFunc(pattern, text, args[2])
+		}
+		return c.regexpFunc(pattern, text, flags)
+	}
+}
+
+// prepareMatch returns the Prepare of a match function whose pattern and text
+// are the arguments patternArg and textArg, and which compiles its pattern
+// with compile.
+func (c *Config) prepareMatch(name string, patternArg, textArg int, compile func(string, Flags) (*regexp.Regexp, error)) func(args ...any) func(args ...any) (any, error) {
+	return func(args ...any) func(args ...any) (any, error) {
+		pattern, ok := TextArg(args[patternArg])
+		if !ok {
+			return nil
+		}
+		flags, ok, err := flagsArg(name, args, 2)
+		if !ok || err != nil {
+			return nil
+		}
+		re, err := compile(pattern, flags)
+		if err != nil {
+			return nil
+		}
+		form := c.formOf(flags)
+		return func(args ...any) (any, error) {
+			if isNull(args[textArg]) {
+				return nil, nil
+			}
+			return boolResult(c.matchArg(re, form, args[textArg])), nil
+		}
+	}
+}
+
+func boolResult(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// isNull reports whether the SQLite function argument v is NULL.
+func isNull(v any) bool {
+	// go-sqlite3 passes NULL as a nil byte slice.
+	b, ok := v.([]byte)
+	return v == nil || ok && b == nil
+}
+
+// matchArg reports whether re matches the text argument v, which must not be
+// NULL, in form, through the memo of c if it has one.
+func (c *Config) matchArg(re *regexp.Regexp, form textForm, v any) bool {
+	if c.Memo != nil {
+		return c.memoMatch(re, form, v)
+	}
+	return c.matchText(re, form, v)
+}
+
+// matchText is matchArg without the memo. A []byte, a BLOB, is read with the
+// BlobEncoding of c, and matched in place rather than converted to a string
+// if it is UTF-8.
+func (c *Config) matchText(re *regexp.Regexp, form textForm, v any) bool {
+	if b, ok := v.([]byte); ok {
+		if c.BlobEncoding == BlobLatin1 {
+			b = decodeLatin1(b)
+		}
+		return re.Match(form.bytes(b))
+	}
+	text, _ := TextArg(v)
+	return re.MatchString(form.text(text))
+}
+
+// decodeLatin1 returns the UTF-8 encoding of the Latin-1 text b, which is b
+// itself if it is ASCII.
+func decodeLatin1(b []byte) []byte {
+	for i, x := range b {
+		if x >= utf8.RuneSelf {
+			out := make([]byte, i, 2*len(b)-i)
+			copy(out, b[:i])
+			for _, x := range b[i:] {
+				out = utf8.AppendRune(out, rune(x))
+			}
+			return out
+		}
+	}
+	return b
+}
+
+// TextArg converts a SQLite function argument to text, following SQLite's own
+// conversion rules. It reports false for NULL.
+func TextArg(v any) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case []byte:
+		// go-sqlite3 passes NULL as a nil byte slice.
+		if v == nil {
+			return "", false
+		}
+		return string(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'g', 15, 64), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"testing"
+)
+
+func findFunction(funcs []Function, name string) Function {
+	for _, f := range funcs {
+		if f.Name == name {
+			return f
+		}
+	}
+	return Function{}
+}
+
+func TestPrepare(t *testing.T) {
+	cfg := DefaultConfig()
+	funcs := Functions(&cfg)
+
+	tests := []struct {
+		function string
+		args     []any
+		prepared bool
+		expected any
+	}{
+		{"regexp", []any{`^\d+$`, "123"}, true, int64(1)},
+		{"regexp", []any{`^a`, "ABC", "i"}, true, int64(1)},
+		{"regexp", []any{`^a`, nil}, true, nil},
+		{"regexp", []any{nil, "a"}, false, nil},
+		{"regexp", []any{`(`, "a"}, false, nil},
+		{"regexp", []any{`a`, "a", "z"}, false, nil},
+		{"regexp_like", []any{"ABC", `^a`, "i"}, true, int64(1)},
+		{"regexp_like", []any{"xyz", `^a`}, true, int64(0)},
+		{"regexp", []any{`^\d+$`, []byte("123")}, true, int64(1)},
+		{"regexp", []any{`^$`, []byte{}}, true, int64(1)},
+		{"regexp", []any{`^a`, []byte(nil)}, true, nil},
+		{"regexp_like", []any{[]byte("ABC"), `^a`, "i"}, true, int64(1)},
+		{"regexp_full_match", []any{"abc", `a|abc`}, true, int64(1)},
+		{"regexp_full_match", []any{"abcd", `abc`}, true, int64(0)},
+		{"regexp_full_match", []any{"x", `(`}, false, nil},
+	}
+	for _, tt := range tests {
+		f := findFunction(funcs, tt.function)
+		eval := f.Prepare(tt.args...)
+		if (eval != nil) != tt.prepared {
+			t.Errorf("%s%q: prepared %v, expected %v", tt.function, tt.args, eval != nil, tt.prepared)
+			continue
+		}
+		if eval == nil {
+			continue
+		}
+		result, err := eval(tt.args...)
+		if err != nil || result != tt.expected {
+			t.Errorf("%s%q = %v, %v; expected %v", tt.function, tt.args, result, err, tt.expected)
+		}
+		if impl, _ := f.Impl(tt.args...); impl != result {
+			t.Errorf("%s%q: Impl returned %v, prepared %v", tt.function, tt.args, impl, result)
+		}
+	}
+}
+
+// BenchmarkPrepare compares an evaluation looking the pattern up in the
+// cache with one of a prepared pattern, as done by the loadable extension
+// for "col REGEXP ?".
+func BenchmarkPrepare(b *testing.B) {
+	cfg := DefaultConfig()
+	f := findFunction(Functions(&cfg), "regexp")
+	args := []any{`^[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}$`, "someone@example.com"}
+
+	b.Run("cache", func(b *testing.B) {
+		for b.Loop() {
+			_, _ = f.Impl(args...)
+		}
+	})
+	b.Run("prepared", func(b *testing.B) {
+		eval := f.Prepare(args...)
+		for b.Loop() {
+			_, _ = eval(args...)
+		}
+	})
+}
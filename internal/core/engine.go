@@ -0,0 +1,190 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dlclark/regexp2"
+)
+
+// The engine tags a pattern can start with when Config.EngineTags is set:
+// re2: for Go's RE2 engine, the default, and pcre: for the backtracking
+// engine, which supports lookaround, backreferences and atomic groups in
+// exchange for worst-case exponential matching time.
+const (
+	RE2Tag  = "re2:"
+	PCRETag = "pcre:"
+)
+
+// DefaultBacktrackTimeout is the default time a match of the backtracking
+// engine may take before it fails, see Config.BacktrackTimeout.
+const DefaultBacktrackTimeout = time.Second
+
+// BacktrackingError is returned for a pattern the backtracking engine does
+// not compile.
+type BacktrackingError struct {
+	Pattern string
+	Err     error
+}
+
+func (e *BacktrackingError) Error() string {
+	return fmt.Sprintf("error parsing pcre regexp: %v", e.Err)
+}
+
+func (e *BacktrackingError) Unwrap() error { return e.Err }
+
+// engineTag returns pattern without its engine tag, reporting whether it is
+// tagged for the backtracking engine. Patterns are read as they are unless
+// c.EngineTags is set.
+func (c *Config) engineTag(pattern string) (string, bool) {
+	if !c.EngineTags {
+		return pattern, false
+	}
+	if body, ok := strings.CutPrefix(pattern, PCRETag); ok {
+		return body, true
+	}
+	return strings.TrimPrefix(pattern, RE2Tag), false
+}
+
+// backtrackingOnly is the error of the functions given a pattern tagged for
+// the backtracking engine, which only the match functions support.
+func backtrackingOnly(pattern string) error {
+	return fmt.Errorf("pattern %q: the pcre engine is only supported by REGEXP, regexp_like and regexp_full_match", pattern)
+}
+
+// backtrackingMatch evaluates a match function of pattern, anchored at both
+// ends if full is set, on the text argument v if pattern is tagged for the
+// backtracking engine, reporting false otherwise. Texts are matched as they
+// are, without the CaseFolding and Normalization of c.
+func (c *Config) backtrackingMatch(pattern string, flags Flags, full bool, v any) (any, bool, error) {
+	body, ok := c.engineTag(pattern)
+	if !ok {
+		return nil, false, nil
+	}
+	re, err := c.compileBacktracking(body, flags, full)
+	if err != nil {
+		return nil, true, err
+	}
+	var text string
+	if b, ok := v.([]byte); ok {
+		if c.BlobEncoding == BlobLatin1 {
+			b = decodeLatin1(b)
+		}
+		text = string(b)
+	} else {
+		text, _ = TextArg(v)
+	}
+	matched, err := re.MatchString(text)
+	if err != nil {
+		return nil, true, err
+	}
+	return boolResult(matched), true, nil
+}
+
+// compileBacktracking compiles pattern, without its tag, with flags for the
+// backtracking engine, through the cache of c. The flags without an
+// equivalent in the engine, U, l and p, are rejected.
+func (c *Config) compileBacktracking(pattern string, flags Flags, full bool) (*regexp2.Regexp, error) {
+	for _, f := range []struct {
+		flag Flags
+		r    rune
+	}{{FlagUngreedy, 'U'}, {FlagLongest, 'l'}, {FlagPOSIX, 'p'}} {
+		if flags&f.flag != 0 {
+			return nil, &FlagError{Flag: f.r, Engine: "pcre"}
+		}
+	}
+	if c.caseInsensitive(flags) {
+		flags |= FlagCaseInsensitive
+	}
+	flags &^= flagCaseSensitive
+	if c.Library != nil {
+		var err error
+		if pattern, err = c.Library.Expand(pattern); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.checkPolicy(PatternInfo{Pattern: pattern, Flags: flags, Backtracking: true}); err != nil {
+		return nil, err
+	}
+	key := backtrackKey{pattern: pattern, flags: flags, full: full, timeout: c.BacktrackTimeout}
+	return c.cache().backtracking.compile(key)
+}
+
+// backtrackKey identifies a pattern compiled by the backtracking engine.
+type backtrackKey struct {
+	pattern string
+	flags   Flags
+	full    bool
+	timeout time.Duration
+}
+
+// compile compiles the pattern of k.
+func (k backtrackKey) compile() (*regexp2.Regexp, error) {
+	var opts regexp2.RegexOptions
+	if k.flags&FlagCaseInsensitive != 0 {
+		opts |= regexp2.IgnoreCase
+	}
+	if k.flags&FlagMultiLine != 0 {
+		opts |= regexp2.Multiline
+	}
+	if k.flags&FlagDotNL != 0 {
+		opts |= regexp2.Singleline
+	}
+	if k.flags&FlagExtended != 0 {
+		opts |= regexp2.IgnorePatternWhitespace
+	}
+	expr := k.pattern
+	if k.full {
+		expr = `\A(?:` + expr + `)\z`
+	}
+	re, err := regexp2.Compile(expr, opts)
+	if err != nil {
+		return nil, &BacktrackingError{Pattern: k.pattern, Err: err}
+	}
+	if k.timeout > 0 {
+		re.MatchTimeout = k.timeout
+	}
+	return re, nil
+}
+
+// maxBacktrackEntries bounds the patterns of a backtrackCache, which is
+// emptied when it is full. The backtracking engine is meant for a few
+// patterns, so it does without the bookkeeping of the main cache.
+const maxBacktrackEntries = 1024
+
+// backtrackCache caches the patterns compiled by the backtracking engine.
+type backtrackCache struct {
+	mu      sync.RWMutex
+	entries map[backtrackKey]*regexp2.Regexp
+}
+
+// compile returns the compiled pattern of key, compiling it on a miss.
+func (c *backtrackCache) compile(key backtrackKey) (*regexp2.Regexp, error) {
+	c.mu.RLock()
+	re, ok := c.entries[key]
+	c.mu.RUnlock()
+	if ok {
+		return re, nil
+	}
+
+	re, err := key.compile()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil || len(c.entries) >= maxBacktrackEntries {
+		c.entries = make(map[backtrackKey]*regexp2.Regexp)
+	}
+	c.entries[key] = re
+	return re, nil
+}
+
+// clear removes every pattern.
+func (c *backtrackCache) clear() {
+	c.mu.Lock()
+	c.entries = nil
+	c.mu.Unlock()
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEngineTags(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Cache = NewCache()
+	cfg.EngineTags = true
+
+	tests := []struct {
+		fn       func(args ...any) (any, error)
+		args     []any
+		expected any
+	}{
+		{cfg.regexpFunc, []any{`pcre:(?<!test_)\w+\.go$`, "main.go"}, int64(1)},
+		{cfg.regexpFunc, []any{`pcre:(?<!test_)main\.go$`, "test_main.go"}, int64(0)},
+		{cfg.regexpFunc, []any{`pcre:(\w)\1`, "abba"}, int64(1)},
+		{cfg.regexpFunc, []any{`pcre:^B`, "a\nb", "mi"}, int64(1)},
+		{cfg.regexpFunc, []any{`re2:^a`, "abc"}, int64(1)},
+		{cfg.regexpFunc, []any{`^a`, "abc"}, int64(1)},
+		{cfg.regexpLike, []any{"foobar", `pcre:foo(?=bar)`}, int64(1)},
+		{cfg.regexpLike, []any{"foobaz", `pcre:foo(?=bar)`}, int64(0)},
+		{cfg.regexpFullMatch, []any{"abab", `pcre:(ab)\1`}, int64(1)},
+		{cfg.regexpFullMatch, []any{"ababx", `pcre:(ab)\1`}, int64(0)},
+		{cfg.regexpValid, []any{`pcre:(?<=a)b`}, int64(1)},
+		{cfg.regexpValid, []any{`(?<=a)b`}, int64(0)},
+		{cfg.regexpValid, []any{`pcre:(?<=a`}, int64(0)},
+	}
+	for _, test := range tests {
+		got, err := test.fn(test.args...)
+		if err != nil {
+			t.Errorf("%v: %v", test.args, err)
+		} else if got != test.expected {
+			t.Errorf("%v = %v, expected %v", test.args, got, test.expected)
+		}
+	}
+
+	for _, args := range [][]any{
+		{`pcre:a`, "a", "U"},
+		{`pcre:(?<=a`, "a"},
+	} {
+		if _, err := cfg.regexpFunc(args...); !IsPatternError(err) {
+			t.Errorf("%v: expected a pattern error, got %v", args, err)
+		}
+	}
+	// Flags valid for RE2 are reported as unsupported, not invalid.
+	for _, flag := range "Ulp" {
+		_, err := cfg.regexpFunc(`pcre:a`, "a", string(flag))
+		want := fmt.Sprintf("flag %q is not supported by the pcre engine", flag)
+		if err == nil || err.Error() != want || !IsPatternMessage(err.Error()) {
+			t.Errorf("Flag %q: expected %q, got %v", flag, want, err)
+		}
+	}
+	if _, err := cfg.regexpReplace("ab", `pcre:(?<=a)b`, "c"); err == nil || !strings.Contains(err.Error(), "only supported by") {
+		t.Errorf("Expected the pcre engine to be rejected, got %v", err)
+	}
+
+	// Without EngineTags, tags are part of the pattern.
+	plain := DefaultConfig()
+	if got, err := plain.regexpFunc(`pcre:a`, "pcre:a"); err != nil || got != int64(1) {
+		t.Errorf("Untagged match = %v, %v, expected 1", got, err)
+	}
+}
+
+func TestBacktrackTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Cache = NewCache()
+	cfg.EngineTags = true
+	cfg.BacktrackTimeout = 10 * time.Millisecond
+
+	text := strings.Repeat("a", 40) + "!"
+	if _, err := cfg.regexpFunc(`pcre:^(a+)+$`, text); err == nil {
+		t.Error("Expected a catastrophic pattern to time out")
+	}
+	// RE2 matches the same pattern in linear time.
+	if got, err := cfg.regexpFunc(`^(a+)+$`, text); err != nil || got != int64(0) {
+		t.Errorf("RE2 match = %v, %v, expected 0", got, err)
+	}
+}
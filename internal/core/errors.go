@@ -0,0 +1,74 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"regexp/syntax"
+	"strings"
+)
+
+// ErrUnknownPattern is wrapped by the errors for includes of patterns that
+// are not in the library.
+var ErrUnknownPattern = errors.New("unknown pattern")
+
+// FlagError is returned for an invalid matching flag.
+type FlagError struct {
+	Flag rune
+	// Postgres is set for the options of the PostgreSQL functions, reported
+	// in PostgreSQL's words.
+	Postgres bool
+	// Engine, if set, names the engine that does not support the flag,
+	// which is otherwise valid.
+	Engine string
+}
+
+func (e *FlagError) Error() string {
+	if e.Engine != "" {
+		return fmt.Sprintf("flag %q is not supported by the %s engine", e.Flag, e.Engine)
+	}
+	if e.Postgres {
+		return fmt.Sprintf("invalid regular expression option: %q", e.Flag)
+	}
+	return fmt.Sprintf("invalid flag %q", e.Flag)
+}
+
+// IsPatternError reports whether err is caused by an invalid pattern or
+// flags: a syntax error, an invalid flag, an unknown or cyclic library
+// include, or a pattern rejected by the PatternPolicy of the configuration.
+func IsPatternError(err error) bool {
+	var (
+		syntaxErr *syntax.Error
+		flagErr   *FlagError
+		cycleErr  *CycleError
+		btErr     *BacktrackingError
+		policyErr *PolicyError
+	)
+	return errors.As(err, &syntaxErr) || errors.As(err, &flagErr) ||
+		errors.As(err, &cycleErr) || errors.Is(err, ErrUnknownPattern) ||
+		errors.As(err, &btErr) || errors.As(err, &policyErr)
+}
+
+// patternMessages are fragments of the messages of the errors recognized by
+// IsPatternError.
+var patternMessages = []string{
+	"error parsing regexp: ",
+	"invalid flag ",
+	" is not supported by the ",
+	"invalid regular expression option: ",
+	"unknown pattern ",
+	"pattern library cycle: ",
+	"error parsing pcre regexp: ",
+	"pattern rejected by policy: ",
+}
+
+// IsPatternMessage reports whether msg is the message of an error recognized
+// by IsPatternError, for the errors only known by their text, such as those
+// SQLite returns for a failing function.
+func IsPatternMessage(msg string) bool {
+	for _, m := range patternMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// stripExtended rewrites a free-spacing pattern into plain RE2 syntax, as
+// Perl's /x modifier would read it: whitespace is dropped and '#' starts a
+// comment that runs to the end of the line. Both stay literal inside a
+// character class, after a backslash, and between \Q and \E, so a rule can
+// still match a space with "\ " or "[ ]" and a hash with "\#".
+func stripExtended(pattern string) string {
+	var b strings.Builder
+	b.Grow(len(pattern))
+
+	inClass := false
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+
+		switch {
+		case r == '\\' && i+size < len(pattern):
+			next, nextSize := utf8.DecodeRuneInString(pattern[i+size:])
+			switch {
+			case next == 'Q':
+				// Quoted text is copied verbatim up to and including \E.
+				end := strings.Index(pattern[i:], `\E`)
+				if end < 0 {
+					b.WriteString(pattern[i:])
+					return b.String()
+				}
+				b.WriteString(pattern[i : i+end+2])
+				i += end + 2
+				continue
+			case unicode.IsSpace(next):
+				// RE2 rejects escaped whitespace; outside of free-spacing
+				// mode the bare character is already literal.
+				b.WriteRune(next)
+			default:
+				b.WriteString(pattern[i : i+size+nextSize])
+			}
+			i += size + nextSize
+			continue
+
+		case inClass:
+			if r == ']' {
+				inClass = false
+			} else if r == '[' && strings.HasPrefix(pattern[i:], "[:") {
+				// POSIX class such as [:alpha:] inside a bracket expression.
+				if end := strings.Index(pattern[i+2:], ":]"); end >= 0 {
+					b.WriteString(pattern[i : i+2+end+2])
+					i += 2 + end + 2
+					continue
+				}
+			}
+			b.WriteRune(r)
+
+		case r == '[':
+			inClass = true
+			b.WriteRune(r)
+			// A ']' right after '[' or '[^' is a literal member of the class.
+			i += size
+			if strings.HasPrefix(pattern[i:], "^") {
+				b.WriteByte('^')
+				i++
+			}
+			if strings.HasPrefix(pattern[i:], "]") {
+				b.WriteByte(']')
+				i++
+			}
+			continue
+
+		case r == '#':
+			end := strings.IndexByte(pattern[i:], '\n')
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 1
+			continue
+
+		case unicode.IsSpace(r):
+			// Dropped.
+
+		default:
+			b.WriteRune(r)
+		}
+		i += size
+	}
+	return b.String()
+}
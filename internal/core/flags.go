@@ -0,0 +1,155 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Flags are the matching options that can be given to every function of the
+// suite as a trailing flags string. They are part of the cache key, so the
+// same pattern compiled with different flags is cached separately.
+type Flags uint8
+
+const (
+	// FlagCaseInsensitive ('i') ignores case.
+	FlagCaseInsensitive Flags = 1 << iota
+	// FlagMultiLine ('m') lets ^ and $ match at line boundaries.
+	FlagMultiLine
+	// FlagDotNL ('s', or Oracle's 'n') lets '.' match a newline.
+	FlagDotNL
+	// FlagUngreedy ('U') swaps the meaning of x* and x*?, x+ and x+?, etc.
+	FlagUngreedy
+	// FlagExtended ('x') ignores whitespace and #-comments in the pattern.
+	FlagExtended
+	// FlagLongest ('l') prefers the leftmost-longest match, as POSIX does,
+	// to the leftmost-first one.
+	FlagLongest
+	// FlagPOSIX ('p') translates the POSIX elements of bracket expressions,
+	// such as [[:alpha:]] and [[=e=]], see translatePOSIX.
+	FlagPOSIX
+	// flagCaseSensitive records a 'c' not followed by an 'i', which opts out
+	// of Config.CaseInsensitive. String leaves it out, as 'c' only cancels
+	// an 'i' otherwise.
+	flagCaseSensitive
+)
+
+// ParseFlags parses a flags string. It accepts:
+//
+//	i  case-insensitive
+//	c  case-sensitive (cancels an earlier 'i', as in Oracle)
+//	m  multi-line: ^ and $ match at line boundaries
+//	s  '.' matches newline (Oracle spells it 'n')
+//	U  ungreedy quantifiers
+//	x  free-spacing: whitespace and #-comments in the pattern are ignored
+//	l  leftmost-longest matches, as in POSIX
+//	p  POSIX bracket expressions: Unicode [[:alpha:]], [[=e=]] and [[.-.]]
+func ParseFlags(s string) (Flags, error) {
+	var flags Flags
+	for _, f := range s {
+		switch f {
+		case 'i':
+			flags = flags&^flagCaseSensitive | FlagCaseInsensitive
+		case 'c':
+			flags = flags&^FlagCaseInsensitive | flagCaseSensitive
+		case 'm':
+			flags |= FlagMultiLine
+		case 's', 'n':
+			flags |= FlagDotNL
+		case 'U':
+			flags |= FlagUngreedy
+		case 'x':
+			flags |= FlagExtended
+		case 'l':
+			flags |= FlagLongest
+		case 'p':
+			flags |= FlagPOSIX
+		default:
+			return 0, &FlagError{Flag: f}
+		}
+	}
+	return flags, nil
+}
+
+// String returns the canonical flags string.
+func (f Flags) String() string {
+	var b strings.Builder
+	if f&FlagCaseInsensitive != 0 {
+		b.WriteByte('i')
+	}
+	if f&FlagMultiLine != 0 {
+		b.WriteByte('m')
+	}
+	if f&FlagDotNL != 0 {
+		b.WriteByte('s')
+	}
+	if f&FlagUngreedy != 0 {
+		b.WriteByte('U')
+	}
+	if f&FlagExtended != 0 {
+		b.WriteByte('x')
+	}
+	if f&FlagLongest != 0 {
+		b.WriteByte('l')
+	}
+	if f&FlagPOSIX != 0 {
+		b.WriteByte('p')
+	}
+	return b.String()
+}
+
+// spec returns the flags string of f, which unlike String keeps a 'c'.
+func (f Flags) spec() string {
+	if f&flagCaseSensitive != 0 {
+		return "c" + f.String()
+	}
+	return f.String()
+}
+
+// apply returns pattern prefixed with the RE2 flag group for f. RE2 has no
+// free-spacing mode, so FlagExtended is applied by rewriting the pattern, and
+// FlagLongest and FlagPOSIX are applied by compile.
+func (f Flags) apply(pattern string) string {
+	f &^= FlagLongest | FlagPOSIX | flagCaseSensitive
+	if f&FlagExtended != 0 {
+		pattern = stripExtended(pattern)
+		f &^= FlagExtended
+	}
+	if f == 0 {
+		return pattern
+	}
+	return "(?" + f.String() + ")" + pattern
+}
+
+// compile compiles pattern with f.
+func (f Flags) compile(pattern string) (*regexp.Regexp, error) {
+	expr := f.apply(pattern)
+	if f&FlagPOSIX != 0 {
+		var err error
+		if expr, err = translatePOSIX(expr); err != nil {
+			return nil, err
+		}
+	}
+	re, err := regexp.Compile(expr)
+	if err == nil && f&FlagLongest != 0 {
+		re.Longest()
+	}
+	return re, err
+}
+
+// flagsArg parses the optional flags argument at position i. It reports false
+// if the argument is NULL; a missing argument means no flags.
+func flagsArg(function string, args []any, i int) (Flags, bool, error) {
+	if len(args) <= i {
+		return 0, true, nil
+	}
+	s, ok := TextArg(args[i])
+	if !ok {
+		return 0, false, nil
+	}
+	flags, err := ParseFlags(s)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", function, err)
+	}
+	return flags, true, nil
+}
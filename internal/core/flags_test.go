@@ -0,0 +1,135 @@
+package core
+
+import "testing"
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		flags    string
+		expected string
+	}{
+		{"", ""},
+		{"i", "i"},
+		{"si", "is"},
+		{"ic", ""},
+		{"ci", "i"},
+		{"n", "s"},
+		{"msiU", "imsU"},
+		{"xi", "ix"},
+		{"lxi", "ixl"},
+		{"pl", "lp"},
+	}
+	for _, test := range tests {
+		flags, err := ParseFlags(test.flags)
+		if err != nil {
+			t.Errorf("ParseFlags(%q) returned error: %v", test.flags, err)
+			continue
+		}
+		if flags.String() != test.expected {
+			t.Errorf("ParseFlags(%q) = %q, expected %q", test.flags, flags, test.expected)
+		}
+	}
+
+	if _, err := ParseFlags("q"); err == nil {
+		t.Error("Expected error for unknown flag, got nil")
+	}
+}
+
+func TestCompileFlagsCacheKey(t *testing.T) {
+	ClearCache()
+
+	plain, err := Compile("abc", 0)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	folded, err := Compile("abc", FlagCaseInsensitive)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if plain == folded {
+		t.Error("Expected distinct cache entries for different flags")
+	}
+	if CacheSize() != 2 {
+		t.Errorf("Expected cache size 2, got %d", CacheSize())
+	}
+	if !folded.MatchString("ABC") || plain.MatchString("ABC") {
+		t.Error("Flags were not applied to the compiled pattern")
+	}
+
+	// Equivalent flag strings share a cache entry.
+	is, _ := ParseFlags("is")
+	si, _ := ParseFlags("si")
+	first, _ := Compile("abc", is)
+	second, _ := Compile("abc", si)
+	if first != second {
+		t.Error("Expected equivalent flags to share a cache entry")
+	}
+}
+
+func TestDefaultCaseInsensitive(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CaseInsensitive = true
+	cfg.Cache = NewCache()
+	funcs := Functions(&cfg)
+
+	tests := []struct {
+		function string
+		args     []any
+		expected any
+	}{
+		{"regexp", []any{`^abc$`, "ABC"}, int64(1)},
+		{"regexp", []any{`^abc$`, "ABC", "c"}, int64(0)},
+		{"regexp", []any{`^abc$`, "ABC", "ci"}, int64(1)},
+		{"regexp", []any{`(?c)^abc$`, "ABC"}, int64(0)},
+		{"regexp", []any{`(?-i)^abc$`, "ABC"}, int64(0)},
+		{"regexp_like", []any{"ABC", `^abc$`, "m"}, int64(1)},
+		{"regexp_full_match", []any{"ABC", `abc`}, int64(1)},
+		{"regexp_full_match", []any{"ABC", `abc`, "c"}, int64(0)},
+		{"regexp_extract", []any{"xABCx", `abc`}, "ABC"},
+	}
+	for _, tt := range tests {
+		f := findFunction(funcs, tt.function)
+		result, err := f.Impl(tt.args...)
+		if err != nil || result != tt.expected {
+			t.Errorf("%s%q = %v, %v; expected %v", tt.function, tt.args, result, err, tt.expected)
+		}
+	}
+
+	// An explicit 'c' is kept apart from no flags, but not cached apart.
+	c, _ := ParseFlags("c")
+	if c == 0 || c.String() != "" || c.spec() != "c" {
+		t.Errorf(`ParseFlags("c") = %#x, String %q, spec %q`, uint8(c), c, c.spec())
+	}
+	cfg.Cache = NewCache()
+	if _, err := cfg.Compile("x", c); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.Compile("x", 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := cfg.Cache.Size(); n != 2 {
+		t.Errorf("Cache size = %d, expected 2: x and (?i)x", n)
+	}
+}
+
+func TestStripExtended(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected string
+	}{
+		{"a b\tc\nd", "abcd"},
+		{"\\d+ # digits\n - \\d+ # more digits", `\d+-\d+`},
+		{`a\ b`, "a b"},
+		{`a\#b # comment`, `a\#b`},
+		{"[ #] x", "[ #]x"},
+		{"[] ] x", "[] ]x"},
+		{"[^] ] x", "[^] ]x"},
+		{"[[:alpha:] ] x", "[[:alpha:] ]x"},
+		{`\Q a # b \E c`, `\Q a # b \E` + "c"},
+		{"é # accents", "é"},
+	}
+	for _, test := range tests {
+		if got := stripExtended(test.pattern); got != test.expected {
+			t.Errorf("stripExtended(%q) = %q, expected %q", test.pattern, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// never is a regular expression that matches nothing, used for GLOB patterns
+// that SQLite itself never matches.
+const never = `[^\x00-\x{10FFFF}]`
+
+// GlobToRegexp translates a SQLite GLOB pattern into an anchored regular
+// expression: '*' matches any sequence of characters, '?' exactly one
+// character, and [...] a character of the set, negated by a leading '^'. A
+// ']' right after '[' or '[^' is a member of the set and '-' denotes a range
+// unless it comes first or last. Like SQLite, GLOB has no escape character
+// and an unterminated '[' matches nothing.
+func GlobToRegexp(pattern string) string {
+	var b strings.Builder
+	b.WriteString(`^(?s:`)
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		i += size
+		switch r {
+		case '*':
+			b.WriteString(`.*`)
+		case '?':
+			b.WriteString(`.`)
+		case '[':
+			class, n, ok := globClass(pattern[i:])
+			if !ok {
+				b.WriteString(never)
+				i = len(pattern)
+				continue
+			}
+			b.WriteString(class)
+			i += n
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString(`)$`)
+	return b.String()
+}
+
+// globClass translates the set following a '[' in a GLOB pattern. It returns
+// the RE2 class, the number of bytes of s consumed including the closing ']'
+// and false if the set is unterminated.
+func globClass(s string) (string, int, bool) {
+	var items strings.Builder
+	i := 0
+	negate := strings.HasPrefix(s, "^")
+	if negate {
+		i++
+	}
+
+	first := true
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == ']' && !first {
+			i++
+			switch {
+			case items.Len() > 0 && negate:
+				return "[^" + items.String() + "]", i, true
+			case items.Len() > 0:
+				return "[" + items.String() + "]", i, true
+			case negate:
+				return `.`, i, true
+			default:
+				return never, i, true
+			}
+		}
+		first = false
+		i += size
+
+		// A '-' between two characters denotes a range.
+		if i+1 < len(s) && s[i] == '-' && s[i+1] != ']' {
+			hi, hiSize := utf8.DecodeRuneInString(s[i+1:])
+			i += 1 + hiSize
+			// SQLite matches nothing for a reversed range.
+			if r <= hi {
+				items.WriteString(classRune(r) + "-" + classRune(hi))
+			}
+			continue
+		}
+		items.WriteString(classRune(r))
+	}
+	return "", 0, false
+}
+
+// classRune escapes r for use inside an RE2 character class.
+func classRune(r rune) string {
+	switch r {
+	case '\\', ']', '[', '^', '-':
+		return `\` + string(r)
+	}
+	return string(r)
+}
+
+// CompileGlob compiles the GLOB pattern, translated with GlobToRegexp,
+// checking it against the policy of c.
+func (c *Config) CompileGlob(pattern string) (*regexp.Regexp, error) {
+	return c.compileTranslated(GlobToRegexp(pattern), 0, textForm{})
+}
+
+// glob implements glob(pattern, text [, flags]), the function SQLite calls
+// for "text GLOB pattern", when Config.UnicodeGlob replaces the built-in one.
+// The pattern is translated with GlobToRegexp and compiled like any other, so
+// GLOB shares REGEXP's Unicode handling and flags: glob(p, t, 'i') matches
+// with the Config.CaseFolding of c, and Config.Normalization applies to the
+// pattern and the text.
+func (c *Config) glob(args ...any) (any, error) {
+	pattern, ok := TextArg(args[0])
+	if !ok {
+		return nil, nil
+	}
+	text, ok := TextArg(args[1])
+	if !ok {
+		return nil, nil
+	}
+	flags, ok, err := flagsArg("glob", args, 2)
+	if !ok {
+		return nil, err
+	}
+	// GLOB patterns are not expanded with the pattern library, and 'x' would
+	// strip the spaces GlobToRegexp leaves unescaped.
+	form := c.translatedForm(flags)
+	re, err := c.compileTranslated(GlobToRegexp(pattern), flags&^FlagExtended, form)
+	if err != nil {
+		return nil, err
+	}
+	return boolResult(re.MatchString(form.text(text))), nil
+}
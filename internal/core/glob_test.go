@@ -0,0 +1,25 @@
+package core
+
+import "testing"
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob     string
+		expected string
+	}{
+		{"*.txt", `^(?s:.*\.txt)$`},
+		{"a?c", `^(?s:a.c)$`},
+		{"[a-c]x", `^(?s:[a-c]x)$`},
+		{"[^]a]", `^(?s:[^\]a])$`},
+		{"[a-]", `^(?s:[a\-])$`},
+		{"[z-a]", `^(?s:` + never + `)$`},
+		{"[^z-a]", `^(?s:.)$`},
+		{"a[b", `^(?s:a` + never + `)$`},
+		{`a\*`, `^(?s:a\\.*)$`},
+	}
+	for _, test := range tests {
+		if got := GlobToRegexp(test.glob); got != test.expected {
+			t.Errorf("GlobToRegexp(%q) = %q, expected %q", test.glob, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Version is one entry of the history of a library pattern.
+type Version struct {
+	Name string
+	// Version numbers start at 1 and increase with every change of Name.
+	Version int
+	// Pattern is the pattern as defined, or empty if Removed.
+	Pattern string
+	Removed bool
+	Author  string
+	Reason  string
+	Time    time.Time
+}
+
+// Change describes who makes a library change, and why.
+type Change struct {
+	Author string
+	Reason string
+}
+
+// ChangeOption sets the metadata recorded with a library change.
+type ChangeOption func(*Change)
+
+// ByAuthor records author as the author of a library change.
+func ByAuthor(author string) ChangeOption {
+	return func(c *Change) {
+		c.Author = author
+	}
+}
+
+// Because records reason as the reason for a library change.
+func Because(reason string) ChangeOption {
+	return func(c *Change) {
+		c.Reason = reason
+	}
+}
+
+// commit submits a validated change to the approver and, once approved,
+// applies it and records it as a new version. l.changeMu must be held.
+func (l *Library) commit(name, pattern string, removed bool, rollbackTo int, opts []ChangeOption) error {
+	var change Change
+	for _, opt := range opts {
+		opt(&change)
+	}
+
+	l.mu.RLock()
+	history := l.history[name]
+	req := ChangeRequest{
+		Proposed: Version{
+			Name:    name,
+			Version: len(history) + 1,
+			Pattern: pattern,
+			Removed: removed,
+			Author:  change.Author,
+			Reason:  change.Reason,
+			Time:    time.Now(),
+		},
+		RollbackTo: rollbackTo,
+	}
+	if len(history) > 0 {
+		current := history[len(history)-1]
+		req.Current = &current
+	}
+	l.mu.RUnlock()
+
+	// The approver runs without l.mu held so that it can read the library.
+	if l.approver != nil {
+		if err := l.approver.Approve(req); err != nil {
+			return &RejectedError{Request: req, Err: err}
+		}
+	}
+
+	l.mu.Lock()
+	if removed {
+		delete(l.patterns, name)
+	} else {
+		l.patterns[name] = pattern
+	}
+	l.history[name] = append(l.history[name], req.Proposed)
+	l.mu.Unlock()
+	return nil
+}
+
+// History returns the versions of the pattern called name, oldest first.
+func (l *Library) History(name string) []Version {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]Version(nil), l.history[name]...)
+}
+
+// Versions returns the versions of every pattern, including removed ones,
+// ordered by name and version.
+func (l *Library) Versions() []Version {
+	l.mu.RLock()
+	names := make([]string, 0, len(l.history))
+	for name := range l.history {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var versions []Version
+	for _, name := range names {
+		versions = append(versions, l.history[name]...)
+	}
+	l.mu.RUnlock()
+	return versions
+}
+
+// RollbackPattern restores the pattern called name as it was in version. The
+// rollback is itself recorded as a new version, so it can be rolled back too.
+// Rolling back to a version that removed the pattern removes it again.
+func (l *Library) RollbackPattern(name string, version int, opts ...ChangeOption) error {
+	l.changeMu.Lock()
+	defer l.changeMu.Unlock()
+
+	l.mu.RLock()
+	history := l.history[name]
+	if version < 1 || version > len(history) {
+		l.mu.RUnlock()
+		return fmt.Errorf("pattern %q has no version %d", name, version)
+	}
+	target := history[version-1]
+	var err error
+	if !target.Removed {
+		// Other patterns may have changed since, so the old definition is
+		// checked again.
+		err = l.validate(name, target.Pattern)
+	}
+	l.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	return l.commit(name, target.Pattern, target.Removed, version, opts)
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestLibraryHistory(t *testing.T) {
+	lib := NewLibrary()
+	steps := []func() error{
+		func() error { return lib.Define("invoice", `INV-\d+`, ByAuthor("alice")) },
+		func() error { return lib.Define("invoice", `INV-\d{6}`, ByAuthor("bob")) },
+		func() error { return lib.Remove("invoice", ByAuthor("carol")) },
+		func() error { return lib.RollbackPattern("invoice", 1, ByAuthor("dave")) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Step %d failed: %v", i, err)
+		}
+	}
+
+	history := lib.History("invoice")
+	if len(history) != 4 {
+		t.Fatalf("Expected 4 versions, got %d", len(history))
+	}
+	expected := []struct {
+		pattern string
+		removed bool
+		author  string
+	}{
+		{`INV-\d+`, false, "alice"},
+		{`INV-\d{6}`, false, "bob"},
+		{"", true, "carol"},
+		{`INV-\d+`, false, "dave"},
+	}
+	for i, e := range expected {
+		v := history[i]
+		if v.Version != i+1 || v.Pattern != e.pattern || v.Removed != e.removed || v.Author != e.author || v.Time.IsZero() {
+			t.Errorf("Unexpected version %d: %+v", i+1, v)
+		}
+	}
+	if p, _ := lib.Get("invoice"); p != `INV-\d+` {
+		t.Errorf("Rollback did not restore the pattern, got %q", p)
+	}
+
+	if err := lib.RollbackPattern("invoice", 3); err != nil {
+		t.Fatalf("Rollback to removal failed: %v", err)
+	}
+	if _, ok := lib.Get("invoice"); ok {
+		t.Error("Rollback to a removal should remove the pattern")
+	}
+
+	if err := lib.RollbackPattern("invoice", 9); err == nil {
+		t.Error("Expected error for unknown version, got nil")
+	}
+	if err := lib.RollbackPattern("missing", 1); err == nil {
+		t.Error("Expected error for unknown pattern, got nil")
+	}
+	// Removing an undefined pattern records nothing.
+	if err := lib.Remove("missing"); err != nil {
+		t.Errorf("Remove of an undefined pattern failed: %v", err)
+	}
+	if len(lib.Versions()) != 5 {
+		t.Errorf("Expected 5 versions in total, got %d", len(lib.Versions()))
+	}
+}
+
+func TestLibraryRollbackCycle(t *testing.T) {
+	lib := NewLibrary()
+	if err := lib.Define("a", `x`); err != nil {
+		t.Fatal(err)
+	}
+	if err := lib.Define("a", `{{b}}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := lib.Define("b", `y`); err != nil {
+		t.Fatal(err)
+	}
+	if err := lib.Define("b", `{{a}}`); err == nil {
+		t.Fatal("Expected cycle error")
+	}
+	if err := lib.Define("a", `z`); err != nil {
+		t.Fatal(err)
+	}
+	if err := lib.Define("b", `{{a}}`); err != nil {
+		t.Fatal(err)
+	}
+	// Version 2 of a includes b, which now includes a.
+	if err := lib.RollbackPattern("a", 2); err == nil {
+		t.Error("Expected cycle error on rollback, got nil")
+	}
+}
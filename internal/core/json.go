@@ -0,0 +1,361 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// ResultTooLargeError is returned by the JSON-returning functions when their
+// result would exceed the configured maximum size (see WithMaxResultSize).
+type ResultTooLargeError struct {
+	Function string
+	Limit    int
+}
+
+func (e *ResultTooLargeError) Error() string {
+	return fmt.Sprintf("%s: result exceeds maximum size of %d bytes", e.Function, e.Limit)
+}
+
+// InputTooLongError is returned by the JSON-returning functions when their
+// text argument is longer than the configured maximum (see
+// WithMaxInputLength).
+type InputTooLongError struct {
+	Function string
+	Limit    int
+}
+
+func (e *InputTooLongError) Error() string {
+	return fmt.Sprintf("%s: input exceeds maximum length of %d bytes", e.Function, e.Limit)
+}
+
+// jsonFunction describes a SQL function that returns a JSON array built from
+// the matches of a pattern in a text.
+type jsonFunction struct {
+	name  string
+	build func(b *jsonBuilder, re *regexp.Regexp, text string)
+}
+
+var jsonFunctions = []jsonFunction{
+	{name: "regexp_find_all", build: buildFindAll},
+	{name: "regexp_captures", build: buildCaptures},
+	{name: "regexp_tokenize", build: buildTokenize},
+}
+
+// sqlFunc returns the implementation registered with SQLite. It takes the
+// text, the pattern and optional flags, and returns NULL if any of them is
+// NULL.
+func (f jsonFunction) sqlFunc(cfg *Config) func(args ...any) (any, error) {
+	return func(args ...any) (any, error) {
+		t, ok := TextArg(args[0])
+		if !ok {
+			return nil, nil
+		}
+		p, ok := TextArg(args[1])
+		if !ok {
+			return nil, nil
+		}
+		flags, ok, err := flagsArg(f.name, args, 2)
+		if !ok {
+			return nil, err
+		}
+		return f.eval(cfg, t, p, flags)
+	}
+}
+
+func (f jsonFunction) eval(cfg *Config, text, pattern string, flags Flags) (any, error) {
+	re, err := cfg.Compile(pattern, flags)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &jsonBuilder{limit: cfg.MaxResultSize}
+	if text, ok := b.limitInput(cfg, text); ok {
+		f.build(b, re, text)
+	}
+	return b.result(cfg, f.name)
+}
+
+// buildFindAll writes every match of re in text as an array of strings.
+func buildFindAll(b *jsonBuilder, re *regexp.Regexp, text string) {
+	b.raw("[")
+	b.commit()
+	for i, loc := range re.FindAllStringIndex(text, -1) {
+		if i > 0 {
+			b.raw(",")
+		}
+		b.str(text[loc[0]:loc[1]])
+		if b.overflow {
+			return
+		}
+		b.commit()
+	}
+	b.raw("]")
+}
+
+// regexpExtractAll implements regexp_extract_all(text, pattern [, group
+// [, flags]]), the JSON array of the texts matched by group (a number or a
+// name; 0, the whole match, by default) in every match of pattern, with null
+// where the group did not participate. Its result is capped like those of
+// the other JSON functions.
+func (c *Config) regexpExtractAll(args ...any) (any, error) {
+	const name = "regexp_extract_all"
+	text, ok := TextArg(args[0])
+	if !ok {
+		return nil, nil
+	}
+	pattern, ok := TextArg(args[1])
+	if !ok {
+		return nil, nil
+	}
+	flags, ok, err := flagsArg(name, args, 3)
+	if !ok {
+		return nil, err
+	}
+	re, err := c.Compile(pattern, flags)
+	if err != nil {
+		return nil, err
+	}
+	group, ok, err := groupArg(name, re, args, 2)
+	if !ok {
+		return nil, err
+	}
+
+	b := &jsonBuilder{limit: c.MaxResultSize}
+	if text, ok := b.limitInput(c, text); ok {
+		buildExtractAll(b, re, text, group)
+	}
+	return b.result(c, name)
+}
+
+// buildExtractAll writes group of every match of re in text as an array of
+// strings, null where the group did not participate.
+func buildExtractAll(b *jsonBuilder, re *regexp.Regexp, text string, group int) {
+	b.raw("[")
+	b.commit()
+	for i, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+		if i > 0 {
+			b.raw(",")
+		}
+		if loc[2*group] < 0 {
+			b.raw("null")
+		} else {
+			b.str(text[loc[2*group]:loc[2*group+1]])
+		}
+		if b.overflow {
+			return
+		}
+		b.commit()
+	}
+	b.raw("]")
+}
+
+// buildCaptures writes one array per match, holding the full match followed
+// by every capture group. Groups that did not participate are null.
+func buildCaptures(b *jsonBuilder, re *regexp.Regexp, text string) {
+	b.raw("[")
+	b.commit()
+	for i, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+		if i > 0 {
+			b.raw(",")
+		}
+		b.groups(text, loc)
+		if b.overflow {
+			return
+		}
+		b.commit()
+	}
+	b.raw("]")
+}
+
+// buildTokenize uses re as a delimiter and writes the non-empty pieces of text
+// between matches.
+func buildTokenize(b *jsonBuilder, re *regexp.Regexp, text string) {
+	b.raw("[")
+	b.commit()
+	first := true
+	emit := func(tok string) {
+		if tok == "" {
+			return
+		}
+		if !first {
+			b.raw(",")
+		}
+		first = false
+		b.str(tok)
+		b.commit()
+	}
+
+	pos := 0
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		emit(text[pos:loc[0]])
+		pos = loc[1]
+		if b.overflow {
+			return
+		}
+	}
+	emit(text[pos:])
+	b.raw("]")
+}
+
+// jsonBuilder accumulates JSON output and stops growing once limit is
+// reached, so oversized results are detected before they are materialized.
+// Builders call commit after each complete array element so that, in
+// envelope mode, the elements that fit can be returned as a valid array.
+type jsonBuilder struct {
+	buf            []byte
+	limit          int
+	overflow       bool
+	committed      int
+	inputTruncated bool
+}
+
+// limitInput applies the configured maximum input length to text. In envelope
+// mode an overlong text is cut to the limit and the result marked as
+// truncated; in the other modes it reports false and the function should not
+// build anything.
+func (b *jsonBuilder) limitInput(cfg *Config, text string) (string, bool) {
+	if cfg.MaxInputLength <= 0 || len(text) <= cfg.MaxInputLength {
+		return text, true
+	}
+	b.inputTruncated = true
+	return truncateUTF8(text, cfg.MaxInputLength), cfg.OverflowMode == OverflowEnvelope
+}
+
+func (b *jsonBuilder) commit() {
+	if !b.overflow {
+		b.committed = len(b.buf)
+	}
+}
+
+// result returns the accumulated JSON, or applies the configured overflow mode
+// if one of the caps was reached.
+func (b *jsonBuilder) result(cfg *Config, function string) (any, error) {
+	if !b.overflow && !b.inputTruncated {
+		return string(b.buf), nil
+	}
+
+	switch cfg.OverflowMode {
+	case OverflowNull:
+		return nil, nil
+	case OverflowEnvelope:
+		if b.overflow {
+			return truncatedEnvelope("result_size", b.partial()), nil
+		}
+		if len(b.buf) == 0 {
+			// Nothing was built, such as by regexp_matches without a match,
+			// which returns NULL for a text within the limit.
+			return truncatedEnvelope("input_length", "null"), nil
+		}
+		return truncatedEnvelope("input_length", string(b.buf)), nil
+	case OverflowError:
+		if b.inputTruncated {
+			return nil, &InputTooLongError{Function: function, Limit: cfg.MaxInputLength}
+		}
+		return nil, &ResultTooLargeError{Function: function, Limit: cfg.MaxResultSize}
+	}
+	return nil, fmt.Errorf("%s: unknown overflow mode %d", function, cfg.OverflowMode)
+}
+
+// partial returns the committed elements as a closed JSON array.
+func (b *jsonBuilder) partial() string {
+	if b.committed == 0 {
+		return "[]"
+	}
+	return string(b.buf[:b.committed]) + "]"
+}
+
+// truncatedEnvelope wraps a partial result for OverflowEnvelope.
+func truncatedEnvelope(reason, partial string) string {
+	return `{"truncated":true,"reason":"` + reason + `","partial":` + partial + `}`
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func (b *jsonBuilder) fits(n int) bool {
+	if b.limit > 0 && len(b.buf)+n > b.limit {
+		b.overflow = true
+	}
+	return !b.overflow
+}
+
+func (b *jsonBuilder) raw(s string) {
+	if b.fits(len(s)) {
+		b.buf = append(b.buf, s...)
+	}
+}
+
+func (b *jsonBuilder) str(s string) {
+	// The encoded string is at least two bytes longer than its input.
+	if !b.fits(len(s) + 2) {
+		return
+	}
+	start := len(b.buf)
+	b.buf = appendJSONString(b.buf, s)
+	if !b.fits(0) {
+		b.buf = b.buf[:start]
+	}
+}
+
+// groups writes the submatches described by loc, as returned by
+// FindStringSubmatchIndex, as an array of strings. Groups that did not
+// participate in the match are written as null.
+func (b *jsonBuilder) groups(text string, loc []int) {
+	b.raw("[")
+	for g := 0; g < len(loc); g += 2 {
+		if g > 0 {
+			b.raw(",")
+		}
+		if loc[g] < 0 {
+			b.raw("null")
+		} else {
+			b.str(text[loc[g]:loc[g+1]])
+		}
+	}
+	b.raw("]")
+}
+
+// appendJSONString appends s to dst as a quoted JSON string. Invalid UTF-8 is
+// replaced by U+FFFD.
+func appendJSONString(dst []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	dst = append(dst, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				dst = append(dst, '\\', c)
+			case c == '\n':
+				dst = append(dst, '\\', 'n')
+			case c == '\r':
+				dst = append(dst, '\\', 'r')
+			case c == '\t':
+				dst = append(dst, '\\', 't')
+			case c < 0x20:
+				dst = append(dst, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			default:
+				dst = append(dst, c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, "\ufffd"...)
+		} else {
+			dst = append(dst, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(dst, '"')
+}
@@ -0,0 +1,77 @@
+package core
+
+import "testing"
+
+func TestJSONBuilderLimit(t *testing.T) {
+	fn := jsonFunction{name: "regexp_find_all", build: buildFindAll}
+
+	_, err := fn.eval(&Config{MaxResultSize: 10}, "aaaaaaaaaa", "a", 0)
+	tooLarge, ok := err.(*ResultTooLargeError)
+	if !ok {
+		t.Fatalf("Expected *ResultTooLargeError, got %v", err)
+	}
+	if tooLarge.Limit != 10 || tooLarge.Function != "regexp_find_all" {
+		t.Errorf("Unexpected error fields: %+v", tooLarge)
+	}
+
+	result, err := fn.eval(&Config{MaxResultSize: 0}, "aaaaaaaaaa", "a", 0)
+	if err != nil {
+		t.Fatalf("Unlimited eval failed: %v", err)
+	}
+	if len(result.(string)) != 41 {
+		t.Errorf("Unexpected unlimited result %q", result)
+	}
+}
+
+func TestJSONFunctionMaxInputLength(t *testing.T) {
+	fn := jsonFunction{name: "regexp_tokenize", build: buildTokenize}
+
+	_, err := fn.eval(&Config{MaxInputLength: 4}, "a b c", " ", 0)
+	tooLong, ok := err.(*InputTooLongError)
+	if !ok {
+		t.Fatalf("Expected *InputTooLongError, got %v", err)
+	}
+	if tooLong.Limit != 4 {
+		t.Errorf("Unexpected limit %d", tooLong.Limit)
+	}
+
+	result, err := fn.eval(&Config{MaxInputLength: 4, OverflowMode: OverflowNull}, "a b c", " ", 0)
+	if err != nil || result != nil {
+		t.Errorf("Expected NULL result, got %v, %v", result, err)
+	}
+
+	// Multi-byte runes are never split when the input is cut.
+	if got := truncateUTF8("aé", 2); got != "a" {
+		t.Errorf("truncateUTF8 = %q, expected %q", got, "a")
+	}
+}
+
+func TestFunctionsArgumentConversion(t *testing.T) {
+	var regexp Function
+	for _, fn := range Functions(&Config{}) {
+		if fn.Name == "regexp" {
+			regexp = fn
+		}
+	}
+
+	tests := []struct {
+		args     []any
+		expected any
+	}{
+		{[]any{`^\d+$`, int64(123)}, int64(1)},
+		{[]any{`^1\.5$`, 1.5}, int64(1)},
+		{[]any{`^ab$`, []byte("ab")}, int64(1)},
+		{[]any{`a`, []byte(nil)}, nil},
+		{[]any{nil, "a"}, nil},
+	}
+	for _, test := range tests {
+		result, err := regexp.Impl(test.args...)
+		if err != nil {
+			t.Errorf("regexp(%v) returned error: %v", test.args, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("regexp(%v) = %v, expected %v", test.args, result, test.expected)
+		}
+	}
+}
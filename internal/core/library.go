@@ -0,0 +1,260 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// includeRe matches a {{name}} reference to a library pattern.
+var includeRe = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+
+var nameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// CycleError is returned when library patterns include each other in a loop.
+type CycleError struct {
+	// Path lists the patterns of the loop, starting and ending with the same
+	// name.
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return "pattern library cycle: " + strings.Join(e.Path, " -> ")
+}
+
+// Library is a set of named patterns. Any pattern compiled with a library
+// configured may include a library pattern with {{name}}; includes are
+// expanded recursively before compiling, each one wrapped in a non-capturing
+// group so that {{name}}+ repeats the whole fragment.
+//
+// Every change is recorded as a new version of the pattern it affects, see
+// History and RollbackPattern.
+type Library struct {
+	// changeMu serializes changes, which are validated and approved before
+	// they are applied under mu.
+	changeMu sync.Mutex
+	approver Approver
+
+	mu       sync.RWMutex
+	patterns map[string]string
+	history  map[string][]Version
+}
+
+// NewLibrary returns an empty library.
+func NewLibrary() *Library {
+	return &Library{
+		patterns: make(map[string]string),
+		history:  make(map[string][]Version),
+	}
+}
+
+// Define adds or replaces the pattern called name. Patterns may include
+// names that are not defined yet; Define only fails if name is invalid, if
+// the definition would create an include cycle, if the pattern does not
+// compile, or if the change is not approved.
+func (l *Library) Define(name, pattern string, opts ...ChangeOption) error {
+	if !nameRe.MatchString(name) {
+		return fmt.Errorf("invalid pattern name %q", name)
+	}
+
+	l.changeMu.Lock()
+	defer l.changeMu.Unlock()
+
+	l.mu.RLock()
+	err := l.validate(name, pattern)
+	l.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return l.commit(name, pattern, false, 0, opts)
+}
+
+// validate checks that pattern can be stored as name. l.mu must be held.
+func (l *Library) validate(name, pattern string) error {
+	lookup := func(n string) (string, bool) {
+		if n == name {
+			return pattern, true
+		}
+		p, ok := l.patterns[n]
+		return p, ok
+	}
+	expanded, err := expand(pattern, lookup, []string{name}, false)
+	if err != nil {
+		return err
+	}
+	if _, err := regexp.Compile(expanded); err != nil {
+		return fmt.Errorf("pattern %q: %w", name, err)
+	}
+	return nil
+}
+
+// Remove deletes the pattern called name. Patterns including it fail to
+// compile until it is defined again. Removing an undefined pattern does
+// nothing.
+func (l *Library) Remove(name string, opts ...ChangeOption) error {
+	l.changeMu.Lock()
+	defer l.changeMu.Unlock()
+
+	if _, ok := l.Get(name); !ok {
+		return nil
+	}
+	return l.commit(name, "", true, 0, opts)
+}
+
+// Get returns the pattern called name, as defined, without expanding it.
+func (l *Library) Get(name string) (string, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	p, ok := l.patterns[name]
+	return p, ok
+}
+
+// Names returns the names of the library patterns in sorted order.
+func (l *Library) Names() []string {
+	l.mu.RLock()
+	names := make([]string, 0, len(l.patterns))
+	for name := range l.patterns {
+		names = append(names, name)
+	}
+	l.mu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// Expand replaces every {{name}} in pattern with the library pattern it
+// refers to, recursively. It fails on unknown names and include cycles.
+func (l *Library) Expand(pattern string) (string, error) {
+	if !strings.Contains(pattern, "{{") {
+		return pattern, nil
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	lookup := func(name string) (string, bool) {
+		p, ok := l.patterns[name]
+		return p, ok
+	}
+	return expand(pattern, lookup, nil, true)
+}
+
+// expand expands the includes of pattern. stack holds the names being
+// expanded, to detect cycles. Unless strict, unknown names are left as is.
+func expand(pattern string, lookup func(string) (string, bool), stack []string, strict bool) (string, error) {
+	var err error
+	expanded := includeRe.ReplaceAllStringFunc(pattern, func(ref string) string {
+		if err != nil {
+			return ref
+		}
+		name := includeRe.FindStringSubmatch(ref)[1]
+		for i, n := range stack {
+			if n == name {
+				path := append(append([]string{}, stack[i:]...), name)
+				err = &CycleError{Path: path}
+				return ref
+			}
+		}
+		p, ok := lookup(name)
+		if !ok {
+			if strict {
+				err = fmt.Errorf("%w %q", ErrUnknownPattern, name)
+			}
+			return ref
+		}
+		var sub string
+		sub, err = expand(p, lookup, append(stack, name), strict)
+		return "(?:" + sub + ")"
+	})
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
+
+// HasIncludes reports whether pattern includes library patterns, in which
+// case what it matches depends on the library it is compiled with.
+func HasIncludes(pattern string) bool {
+	return strings.Contains(pattern, "{{") && includeRe.MatchString(pattern)
+}
+
+// Compile compiles pattern with flags through the cache of c, reading regular
+// expression literals and a leading group of flags (see LiteralDialect), and
+// expanding library includes first.
+func (c *Config) Compile(pattern string, flags Flags) (*regexp.Regexp, error) {
+	return c.compileForm(pattern, flags, textForm{})
+}
+
+// compileMatch compiles pattern with flags like Compile, for the texts of
+// the match functions, which are in the form c.formOf(flags).
+func (c *Config) compileMatch(pattern string, flags Flags) (*regexp.Regexp, error) {
+	return c.compileForm(pattern, flags, c.formOf(flags))
+}
+
+// compileForm compiles pattern with flags like Compile, for texts in form.
+func (c *Config) compileForm(pattern string, flags Flags, form textForm) (*regexp.Regexp, error) {
+	pattern, flags, err := c.Literals.unwrap(pattern, flags)
+	if err != nil {
+		return nil, err
+	}
+	return c.compileUnwrapped(pattern, flags, form)
+}
+
+// compileUnwrapped is compileForm for a pattern already unwrapped.
+func (c *Config) compileUnwrapped(pattern string, flags Flags, form textForm) (*regexp.Regexp, error) {
+	pattern, backtracking := c.engineTag(pattern)
+	if backtracking {
+		return nil, backtrackingOnly(pattern)
+	}
+	var err error
+	if c.caseInsensitive(flags) {
+		flags |= FlagCaseInsensitive
+	}
+	flags &^= flagCaseSensitive
+	if c.Longest {
+		flags |= FlagLongest
+	}
+	if c.POSIXClasses {
+		flags |= FlagPOSIX
+	}
+	if c.Library != nil {
+		if pattern, err = c.Library.Expand(pattern); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.checkPolicy(PatternInfo{Pattern: pattern, Flags: flags}); err != nil {
+		return nil, err
+	}
+	return c.cache().compile(cacheKey{pattern: pattern, flags: flags, form: form})
+}
+
+// compileTranslated compiles pattern, translated from a GLOB, LIKE or
+// SIMILAR TO pattern, with flags for texts in form. Unlike compileForm, it
+// leaves out the pattern library, engine tags and default flags, which only
+// concern patterns written as regular expressions, but checks the policy.
+func (c *Config) compileTranslated(pattern string, flags Flags, form textForm) (*regexp.Regexp, error) {
+	if err := c.checkPolicy(PatternInfo{Pattern: pattern, Flags: flags}); err != nil {
+		return nil, err
+	}
+	return c.cache().compile(cacheKey{pattern: pattern, flags: flags, form: form})
+}
+
+// caseInsensitive reports whether patterns compiled with flags ignore case,
+// by their flags or by default.
+func (c *Config) caseInsensitive(flags Flags) bool {
+	return flags&FlagCaseInsensitive != 0 || c.CaseInsensitive && flags&flagCaseSensitive == 0
+}
+
+// CountRegistration records that the suite of c was registered on a
+// connection, in the Registrations of its cache.
+func (c *Config) CountRegistration() {
+	c.cache().registrations.Add(1)
+}
+
+// cache returns the cache of c, or the process-wide one.
+func (c *Config) cache() *Cache {
+	if c.Cache != nil {
+		return c.Cache
+	}
+	return sharedCache
+}
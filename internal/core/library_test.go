@@ -0,0 +1,87 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLibraryExpand(t *testing.T) {
+	lib := NewLibrary()
+	for name, pattern := range map[string]string{
+		"octet":    `\d{1,3}`,
+		"ipv4":     `{{octet}}(?:\.{{octet}}){3}`,
+		"port":     `\d{1,5}`,
+		"endpoint": `{{ ipv4 }}:{{port}}`,
+	} {
+		if err := lib.Define(name, pattern); err != nil {
+			t.Fatalf("Define(%q) failed: %v", name, err)
+		}
+	}
+
+	expanded, err := lib.Expand(`^{{endpoint}}$`)
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+	expected := `^(?:(?:(?:\d{1,3})(?:\.(?:\d{1,3})){3}):(?:\d{1,5}))$`
+	if expanded != expected {
+		t.Errorf("Expand = %q, expected %q", expanded, expected)
+	}
+
+	if _, err := lib.Expand(`{{missing}}`); err == nil {
+		t.Error("Expected error for unknown pattern, got nil")
+	}
+	if got, _ := lib.Expand(`a{2}`); got != `a{2}` {
+		t.Errorf("Expand changed a pattern without includes: %q", got)
+	}
+	if names := lib.Names(); !reflect.DeepEqual(names, []string{"endpoint", "ipv4", "octet", "port"}) {
+		t.Errorf("Unexpected names %v", names)
+	}
+}
+
+func TestLibraryDefineErrors(t *testing.T) {
+	lib := NewLibrary()
+
+	if err := lib.Define("a", `x{{b}}`); err != nil {
+		t.Fatalf("Forward reference rejected: %v", err)
+	}
+	if err := lib.Define("b", `y{{c}}`); err != nil {
+		t.Fatalf("Forward reference rejected: %v", err)
+	}
+
+	err := lib.Define("c", `{{a}}`)
+	var cycle *CycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("Expected *CycleError, got %v", err)
+	}
+	if !reflect.DeepEqual(cycle.Path, []string{"c", "a", "b", "c"}) {
+		t.Errorf("Unexpected cycle path %v", cycle.Path)
+	}
+	if _, ok := lib.Get("c"); ok {
+		t.Error("Cyclic definition was stored")
+	}
+
+	if err := lib.Define("self", `a{{self}}`); !errors.As(err, &cycle) {
+		t.Errorf("Expected *CycleError for self reference, got %v", err)
+	}
+	if err := lib.Define("bad name", `a`); err == nil {
+		t.Error("Expected error for invalid name, got nil")
+	}
+	if err := lib.Define("broken", `(`); err == nil {
+		t.Error("Expected error for invalid pattern, got nil")
+	}
+}
+
+func TestHasIncludes(t *testing.T) {
+	for pattern, expected := range map[string]bool{
+		`{{ipv4}}:\d+`: true,
+		`{{ port }}`:   true,
+		`a{2}`:         false,
+		`{{1}}`:        false,
+		`\{\{`:         false,
+	} {
+		if got := HasIncludes(pattern); got != expected {
+			t.Errorf("HasIncludes(%q) = %v, expected %v", pattern, got, expected)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// errLikeEscape is SQLite's own error for an invalid ESCAPE clause.
+var errLikeEscape = errors.New("ESCAPE expression must be a single character")
+
+// LikeToRegexp translates a SQL LIKE pattern into an anchored regular
+// expression: '%' matches any sequence of characters and '_' exactly one
+// character. If escape is not zero, it makes the character following it
+// literal; like in SQLite, a pattern ending with the escape character
+// matches nothing. The translation is case-sensitive, see FlagCaseInsensitive.
+func LikeToRegexp(pattern string, escape rune) string {
+	var b strings.Builder
+	b.WriteString(`^(?s:`)
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		i += size
+		switch {
+		case escape != 0 && r == escape:
+			if i == len(pattern) {
+				b.WriteString(never)
+				continue
+			}
+			r, size = utf8.DecodeRuneInString(pattern[i:])
+			i += size
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		case r == '%':
+			b.WriteString(`.*`)
+		case r == '_':
+			b.WriteString(`.`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString(`)$`)
+	return b.String()
+}
+
+// like implements like(pattern, text [, escape]), the function SQLite calls
+// for "text LIKE pattern [ESCAPE escape]", when Config.UnicodeLike replaces
+// the built-in one. Unlike SQLite's LIKE, which only folds ASCII letters, it
+// is case-insensitive for every Unicode letter unless
+// Config.LikeCaseSensitive is set. Without an ESCAPE clause,
+// Config.LikeEscape is used.
+func (c *Config) like(args ...any) (any, error) {
+	pattern, ok := TextArg(args[0])
+	if !ok {
+		return nil, nil
+	}
+	text, ok := TextArg(args[1])
+	if !ok {
+		return nil, nil
+	}
+
+	escape := c.LikeEscape
+	if len(args) > 2 {
+		e, ok := TextArg(args[2])
+		if !ok {
+			return nil, nil
+		}
+		if utf8.RuneCountInString(e) != 1 {
+			return nil, errLikeEscape
+		}
+		escape, _ = utf8.DecodeRuneInString(e)
+	}
+
+	var flags Flags
+	if !c.LikeCaseSensitive {
+		flags = FlagCaseInsensitive
+	}
+	form := c.translatedForm(flags)
+	re, err := c.compileTranslated(LikeToRegexp(pattern, escape), flags, form)
+	if err != nil {
+		return nil, err
+	}
+	return boolResult(re.MatchString(form.text(text))), nil
+}
@@ -0,0 +1,24 @@
+package core
+
+import "testing"
+
+func TestLikeToRegexp(t *testing.T) {
+	tests := []struct {
+		like     string
+		escape   rune
+		expected string
+	}{
+		{"abc%", 0, `^(?s:abc.*)$`},
+		{"a_c", 0, `^(?s:a.c)$`},
+		{"1.5%", 0, `^(?s:1\.5.*)$`},
+		{`100\%`, '\\', `^(?s:100%)$`},
+		{`a\_b\\`, '\\', `^(?s:a_b\\)$`},
+		{`100\%`, 0, `^(?s:100\\.*)$`},
+		{`ab!`, '!', `^(?s:ab` + never + `)$`},
+	}
+	for _, test := range tests {
+		if got := LikeToRegexp(test.like, test.escape); got != test.expected {
+			t.Errorf("LikeToRegexp(%q, %q) = %q, expected %q", test.like, test.escape, got, test.expected)
+		}
+	}
+}
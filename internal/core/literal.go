@@ -0,0 +1,92 @@
+package core
+
+import (
+	"strings"
+)
+
+// LiteralDialect selects whether and how patterns written as regular
+// expression literals, /body/flags, are read.
+type LiteralDialect int
+
+const (
+	// NoLiterals reads every pattern as is: "/a/i" matches the text "/a/i".
+	NoLiterals LiteralDialect = iota
+	// JavaScriptLiterals reads /body/flags with JavaScript's flags: i, m and
+	// s as the suite's, g, u and d ignored, y and v rejected.
+	JavaScriptLiterals
+	// RubyLiterals reads /body/flags with Ruby's flags and anchors: ^ and $
+	// always match at line boundaries, m lets '.' match a newline, i and x
+	// are the suite's, and o and the encodings n, e, s and u are ignored.
+	RubyLiterals
+)
+
+// literalFlags maps the flags of the literals of a dialect to the suite's
+// flags, "" for the ignored ones and "!" for the rejected ones.
+var literalFlags = map[LiteralDialect]map[rune]string{
+	JavaScriptLiterals: {'i': "i", 'm': "m", 's': "s", 'g': "", 'u': "", 'd': "", 'y': "!", 'v': "!"},
+	RubyLiterals:       {'i': "i", 'm': "s", 'x': "x", 'o': "", 'n': "", 'e': "", 's': "", 'u': ""},
+}
+
+// unwrap returns the pattern and flags that pattern stands for: the body
+// and flags of a literal of dialect d, then without a leading group of the
+// suite's flags, such as (?x) or (?il), which RE2 does not all accept. Its
+// flags apply after flags, so that (?c) cancels an i flag of the call.
+// Other patterns are returned unchanged.
+func (d LiteralDialect) unwrap(pattern string, flags Flags) (string, Flags, error) {
+	if body, letters, ok := d.literal(pattern); ok {
+		var suite strings.Builder
+		if d == RubyLiterals {
+			suite.WriteString("m")
+		}
+		for _, r := range letters {
+			switch f := literalFlags[d][r]; f {
+			case "!":
+				return "", 0, &FlagError{Flag: r}
+			default:
+				suite.WriteString(f)
+			}
+		}
+		pattern = body
+		flags, _ = ParseFlags(flags.spec() + suite.String())
+	}
+
+	if strings.HasPrefix(pattern, "(?") {
+		end := strings.IndexByte(pattern, ')')
+		if end > 2 {
+			if f, err := ParseFlags(flags.spec() + pattern[2:end]); err == nil {
+				return pattern[end+1:], f, nil
+			}
+		}
+	}
+	return pattern, flags, nil
+}
+
+// literal splits pattern into the body and flags of a literal of dialect d,
+// reporting false if it is not one: if it does not start with '/', or if
+// what follows its last unescaped '/' is not made of flags of d, as in
+// "/usr/local".
+func (d LiteralDialect) literal(pattern string) (string, string, bool) {
+	if d == NoLiterals || !strings.HasPrefix(pattern, "/") {
+		return "", "", false
+	}
+	end := strings.LastIndexByte(pattern, '/')
+	if end == 0 {
+		return "", "", false
+	}
+	// An escaped slash, after an odd number of backslashes, is part of the
+	// body; RE2 reads \/ as /.
+	escapes := 0
+	for i := end - 1; i > 0 && pattern[i] == '\\'; i-- {
+		escapes++
+	}
+	if escapes%2 == 1 {
+		return "", "", false
+	}
+	letters := pattern[end+1:]
+	for _, r := range letters {
+		if _, ok := literalFlags[d][r]; !ok {
+			return "", "", false
+		}
+	}
+	return pattern[1:end], letters, true
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUnwrap(t *testing.T) {
+	tests := []struct {
+		dialect LiteralDialect
+		pattern string
+		flags   Flags
+		body    string
+		want    string
+	}{
+		{NoLiterals, `/a/i`, 0, `/a/i`, ""},
+		{NoLiterals, `(?i)a`, 0, `a`, "i"},
+		{NoLiterals, `(?x) a b`, FlagCaseInsensitive, ` a b`, "ix"},
+		{NoLiterals, `(?c)a`, FlagCaseInsensitive, `a`, ""},
+		{NoLiterals, `(?i:a)`, 0, `(?i:a)`, ""},
+		{NoLiterals, `(?P<n>a)`, 0, `(?P<n>a)`, ""},
+		{NoLiterals, `(?-i)a`, 0, `(?-i)a`, ""},
+		{JavaScriptLiterals, `/a/i`, 0, `a`, "i"},
+		{JavaScriptLiterals, `/^a.b$/gims`, 0, `^a.b$`, "ims"},
+		{JavaScriptLiterals, `/a\/b/u`, 0, `a\/b`, ""},
+		{JavaScriptLiterals, `/a\/`, 0, `/a\/`, ""},
+		{JavaScriptLiterals, `/usr/local`, 0, `/usr/local`, ""},
+		{JavaScriptLiterals, `/(?x) a b/i`, 0, ` a b`, "ix"},
+		{JavaScriptLiterals, `a/i`, 0, `a/i`, ""},
+		{RubyLiterals, `/^a.b$/m`, 0, `^a.b$`, "ms"},
+		{RubyLiterals, `/ a  b /xi`, 0, ` a  b `, "imx"},
+		{RubyLiterals, `/a/`, 0, `a`, "m"},
+	}
+	for _, test := range tests {
+		body, flags, err := test.dialect.unwrap(test.pattern, test.flags)
+		if err != nil {
+			t.Errorf("unwrap(%q) returned error: %v", test.pattern, err)
+			continue
+		}
+		if body != test.body || flags.String() != test.want {
+			t.Errorf("unwrap(%q, %q) = %q, %q; expected %q, %q", test.pattern, test.flags, body, flags, test.body, test.want)
+		}
+	}
+
+	var flagErr *FlagError
+	if _, _, err := JavaScriptLiterals.unwrap(`/a/y`, 0); !errors.As(err, &flagErr) || flagErr.Flag != 'y' {
+		t.Errorf("Expected a FlagError for the sticky flag, got %v", err)
+	}
+}
+
+func TestLiteralPatterns(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Literals = JavaScriptLiterals
+	cfg.Cache = NewCache()
+	funcs := Functions(&cfg)
+
+	tests := []struct {
+		function string
+		args     []any
+		expected any
+	}{
+		{"regexp", []any{`/^abc$/i`, "ABC"}, int64(1)},
+		{"regexp", []any{`/^abc$/`, "ABC"}, int64(0)},
+		{"regexp", []any{`(?x) ^ a b c $`, "abc"}, int64(1)},
+		{"regexp_full_match", []any{"ABC", `/abc/i`}, int64(1)},
+		{"regexp_full_match", []any{"ABC", `(?c)abc`, "i"}, int64(0)},
+		{"regexp_replace", []any{"a.b", `/\./g`, "-"}, "a-b"},
+	}
+	for _, tt := range tests {
+		f := findFunction(funcs, tt.function)
+		result, err := f.Impl(tt.args...)
+		if err != nil || result != tt.expected {
+			t.Errorf("%s%q = %v, %v; expected %v", tt.function, tt.args, result, err, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultSlowCallThreshold is the default duration above which an
+// evaluation is logged as slow, see Config.SlowCallThreshold.
+const DefaultSlowCallThreshold = 100 * time.Millisecond
+
+// maxLoggedText is the number of bytes of text logged for slow evaluations.
+const maxLoggedText = 200
+
+var logger atomic.Pointer[slog.Logger]
+
+// SetLogger sets the logger receiving the events of the suite, or disables
+// them if l is nil, the default:
+//
+//   - a function failing because of an invalid pattern, with the function
+//     and the pattern (warning);
+//   - an evaluation taking longer than the SlowCallThreshold of its
+//     configuration, with the function, the pattern, the beginning of the
+//     text and the duration (warning);
+//   - a compiled pattern cache being cleared, patterns expiring or patterns
+//     evicted for its memory budget, with the number of patterns removed
+//     (info).
+func SetLogger(l *slog.Logger) {
+	logger.Store(l)
+}
+
+// logCall logs the evaluation of f with args described by e, if it failed
+// because of its pattern or took longer than slow.
+func logCall(l *slog.Logger, f Function, args []any, e CallEvent, slow time.Duration) {
+	switch {
+	case e.Err != nil && IsPatternError(e.Err):
+		l.Warn("regexp: invalid pattern", "function", e.Function, "pattern", e.Pattern, "error", e.Err)
+	case slow > 0 && e.Duration > slow:
+		var text string
+		if f.TextArg < len(args) {
+			text, _ = TextArg(args[f.TextArg])
+		}
+		l.Warn("regexp: slow evaluation", "function", e.Function, "pattern", e.Pattern,
+			"text", truncate(text, maxLoggedText), "text_length", len(text), "duration", e.Duration)
+	}
+}
+
+// truncate returns the first n bytes of s, cut at a character boundary,
+// followed by "..." if s is longer.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer SetLogger(nil)
+
+	var like Function
+	for _, fn := range Functions(&Config{}) {
+		if fn.Name == "regexp_like" {
+			like = fn
+		}
+	}
+	if _, err := like.Call("abc", "b"); err != nil {
+		t.Fatalf("regexp_like failed: %v", err)
+	}
+	if _, err := like.Call("abc", "(b"); err == nil {
+		t.Fatal("Invalid pattern accepted")
+	}
+	regexp := Function{Name: "regexp", TextArg: 1}
+	logCall(slog.New(slog.NewJSONHandler(&buf, nil)), regexp, []any{"a+", strings.Repeat("é", 150)},
+		CallEvent{Function: "regexp", Pattern: "a+", Duration: 2 * DefaultSlowCallThreshold}, DefaultSlowCallThreshold)
+	// Below the threshold, or with the log disabled, nothing is logged.
+	logCall(slog.New(slog.NewJSONHandler(&buf, nil)), regexp, []any{"a+", "aaa"},
+		CallEvent{Function: "regexp", Pattern: "a+", Duration: DefaultSlowCallThreshold / 2}, DefaultSlowCallThreshold)
+	logCall(slog.New(slog.NewJSONHandler(&buf, nil)), regexp, []any{"a+", "aaa"},
+		CallEvent{Function: "regexp", Pattern: "a+", Duration: time.Hour}, 0)
+	ClearCache()
+
+	var events []map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e map[string]any
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("Invalid log line: %v", err)
+		}
+		events = append(events, e)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Got %d events, expected 3: %v", len(events), events)
+	}
+	if e := events[0]; e["msg"] != "regexp: invalid pattern" || e["function"] != "regexp_like" || e["pattern"] != "(b" {
+		t.Errorf("Unexpected invalid pattern event %v", e)
+	}
+	if e := events[1]; e["msg"] != "regexp: slow evaluation" || e["function"] != "regexp" || e["pattern"] != "a+" ||
+		e["text"] != strings.Repeat("é", 100)+"..." || e["text_length"] != 300.0 {
+		t.Errorf("Unexpected slow evaluation event %v", e)
+	}
+	if e := events[2]; e["msg"] != "regexp: cache cleared" || e["evicted"] == nil {
+		t.Errorf("Unexpected cache event %v", e)
+	}
+}
+
+func TestLogCallIgnoresOtherErrors(t *testing.T) {
+	var buf bytes.Buffer
+	logCall(slog.New(slog.NewJSONHandler(&buf, nil)), Function{Name: "regexp"}, []any{"a", "b"},
+		CallEvent{Function: "regexp", Pattern: "a", Err: errors.New("boom")}, DefaultSlowCallThreshold)
+	if buf.Len() != 0 {
+		t.Errorf("Unexpected log %s", buf.String())
+	}
+}
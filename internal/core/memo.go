@@ -0,0 +1,173 @@
+package core
+
+import (
+	"hash/maphash"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// memoShards is the number of shards of a ResultMemo, for the same reason
+// as cacheShards.
+const memoShards = 16
+
+// MaxMemoText is the length in bytes above which a text is matched without
+// looking its result up in a ResultMemo: long texts seldom repeat, and
+// hashing them costs about as much as matching them.
+const MaxMemoText = 1024
+
+// ResultMemo memoizes the results of matching texts against patterns, for
+// joins matching the same short texts again and again, such as the values
+// of a denormalized column. A result is keyed by the compiled expression of
+// the pattern, which includes its flags, and by the text, so that it keeps
+// the pattern strings but not the compiled patterns evicted from the Cache.
+//
+// A ResultMemo holds at most about its size of results, evicting the least
+// recently used ones first. It is safe for concurrent use.
+type ResultMemo struct {
+	seed   maphash.Seed
+	shards [memoShards]memoShard
+	// limit is the number of results of a generation of a shard.
+	limit int
+
+	hits, misses atomic.Uint64
+}
+
+// memoShard keeps its results in two generations: results are added to
+// current, which replaces previous once it is full, and results found in
+// previous move back to current. This bounds the shard to two generations
+// and evicts the results not used for a generation, without the bookkeeping
+// of an exact LRU list.
+type memoShard struct {
+	mu       sync.Mutex
+	current  map[memoKey]memoEntry
+	previous map[memoKey]memoEntry
+}
+
+// memoKey identifies the result of matching a text against a pattern, by
+// the expression it was compiled to and a hash of the text. blob is 0 for
+// a TEXT, and 1 plus the BlobEncoding it is read with for a BLOB, which is
+// matched differently.
+type memoKey struct {
+	expr string
+	form textForm
+	blob int8
+	hash uint64
+}
+
+// memoEntry is a memoized result, with its text to tell it from the results
+// of other texts of the same hash.
+type memoEntry struct {
+	text   string
+	result bool
+}
+
+// MemoStats are the size and counters of a ResultMemo.
+type MemoStats struct {
+	// Size is the number of results in the memo.
+	Size int
+	// Hits counts the matches answered by the memo, and Misses the others,
+	// whose results were added to it. Texts longer than MaxMemoText are not
+	// counted.
+	Hits   uint64
+	Misses uint64
+}
+
+// NewResultMemo returns an empty memo holding about size results.
+func NewResultMemo(size int) *ResultMemo {
+	m := &ResultMemo{seed: maphash.MakeSeed(), limit: max(size/(2*memoShards), 1)}
+	for i := range m.shards {
+		m.shards[i].current = make(map[memoKey]memoEntry)
+	}
+	return m
+}
+
+// lookup returns the entry of key, reporting whether the memo has one.
+func (m *ResultMemo) lookup(key memoKey) (memoEntry, bool) {
+	shard := &m.shards[key.hash%memoShards]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	e, ok := shard.current[key]
+	if !ok {
+		if e, ok = shard.previous[key]; ok {
+			shard.add(key, e, m.limit)
+		}
+	}
+	return e, ok
+}
+
+// store adds the entry of key, replacing that of another text of the same
+// hash.
+func (m *ResultMemo) store(key memoKey, e memoEntry) {
+	shard := &m.shards[key.hash%memoShards]
+	shard.mu.Lock()
+	shard.add(key, e, m.limit)
+	shard.mu.Unlock()
+}
+
+// add adds an entry to the current generation, starting a new one if it is
+// full. shard.mu must be held.
+func (s *memoShard) add(key memoKey, e memoEntry, limit int) {
+	if len(s.current) >= limit {
+		s.previous, s.current = s.current, make(map[memoKey]memoEntry, limit)
+	}
+	s.current[key] = e
+}
+
+// Clear empties the memo.
+func (m *ResultMemo) Clear() {
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mu.Lock()
+		shard.current, shard.previous = make(map[memoKey]memoEntry), nil
+		shard.mu.Unlock()
+	}
+}
+
+// Stats returns the current statistics of the memo.
+func (m *ResultMemo) Stats() MemoStats {
+	stats := MemoStats{Hits: m.hits.Load(), Misses: m.misses.Load()}
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.mu.Lock()
+		stats.Size += len(shard.current) + len(shard.previous)
+		shard.mu.Unlock()
+	}
+	return stats
+}
+
+// memoMatch reports whether re matches the text argument v in form, like
+// matchArg, through the memo of c.
+func (c *Config) memoMatch(re *regexp.Regexp, form textForm, v any) bool {
+	key := memoKey{expr: re.String(), form: form}
+	b, isBlob := v.([]byte)
+	var text string
+	if isBlob {
+		if len(b) > MaxMemoText {
+			return c.matchText(re, form, v)
+		}
+		key.blob = int8(c.BlobEncoding) + 1
+		key.hash = maphash.Bytes(c.Memo.seed, b)
+	} else {
+		text, _ = TextArg(v)
+		if len(text) > MaxMemoText {
+			return c.matchText(re, form, v)
+		}
+		key.hash = maphash.String(c.Memo.seed, text)
+	}
+	if e, ok := c.Memo.lookup(key); ok && (isBlob && e.text == string(b) || !isBlob && e.text == text) {
+		c.Memo.hits.Add(1)
+		return e.result
+	}
+	c.Memo.misses.Add(1)
+	result := c.matchText(re, form, v)
+	// The text may point into SQLite's buffer, see Function.BorrowsText.
+	if isBlob {
+		text = string(b)
+	} else {
+		text = strings.Clone(text)
+	}
+	c.Memo.store(key, memoEntry{text: text, result: result})
+	return result
+}
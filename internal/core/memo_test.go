@@ -0,0 +1,170 @@
+package core
+
+import (
+	"fmt"
+	"hash/maphash"
+	"strings"
+	"testing"
+
+	"golang.org/x/sync/errgroup"
+)
+
+func TestResultMemo(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Memo = NewResultMemo(1000)
+	funcs := Functions(&cfg)
+	regexpFn := findFunction(funcs, "regexp").Impl
+
+	texts := []string{"apple", "banana", "apricot"}
+	for round := 0; round < 3; round++ {
+		for _, text := range texts {
+			got, err := regexpFn(`^ap`, text)
+			if err != nil {
+				t.Fatalf("regexp(%q) failed: %v", text, err)
+			}
+			if want := boolResult(strings.HasPrefix(text, "ap")); got != want {
+				t.Errorf("regexp(`^ap`, %q) = %v, expected %v", text, got, want)
+			}
+		}
+	}
+	stats := cfg.Memo.Stats()
+	if stats.Size != 3 || stats.Misses != 3 || stats.Hits != 6 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	// A BLOB is keyed apart from the TEXT of the same bytes, and flags
+	// apart from the same pattern without them.
+	if got, _ := regexpFn(`^ap`, []byte("apple")); got != int64(1) {
+		t.Errorf("Expected BLOB match, got %v", got)
+	}
+	if got, _ := regexpFn(`^AP`, "apple", "i"); got != int64(1) {
+		t.Errorf("Expected case-insensitive match, got %v", got)
+	}
+	if got, _ := regexpFn(`^AP`, "apple"); got != int64(0) {
+		t.Errorf("Expected no case-sensitive match, got %v", got)
+	}
+	if stats := cfg.Memo.Stats(); stats.Size != 6 || stats.Hits != 6 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	cfg.Memo.Clear()
+	if stats := cfg.Memo.Stats(); stats.Size != 0 {
+		t.Errorf("Expected empty memo, got %+v", stats)
+	}
+}
+
+func TestResultMemoLongText(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Memo = NewResultMemo(1000)
+	regexpFn := findFunction(Functions(&cfg), "regexp").Impl
+
+	long := strings.Repeat("x", MaxMemoText) + "y"
+	for i := 0; i < 2; i++ {
+		if got, _ := regexpFn(`y$`, long); got != int64(1) {
+			t.Errorf("Expected match, got %v", got)
+		}
+		if got, _ := regexpFn(`y$`, []byte(long)); got != int64(1) {
+			t.Errorf("Expected BLOB match, got %v", got)
+		}
+	}
+	if stats := cfg.Memo.Stats(); stats != (MemoStats{}) {
+		t.Errorf("Expected long texts to bypass the memo, got %+v", stats)
+	}
+}
+
+func TestResultMemoBounded(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Memo = NewResultMemo(320)
+	regexpFn := findFunction(Functions(&cfg), "regexp").Impl
+
+	for i := 0; i < 10000; i++ {
+		text := fmt.Sprintf("item-%d", i)
+		if got, _ := regexpFn(`7$`, text); got != boolResult(i%10 == 7) {
+			t.Fatalf("regexp(`7$`, %q) = %v", text, got)
+		}
+	}
+	if size := cfg.Memo.Stats().Size; size > 320 {
+		t.Errorf("Expected at most 320 results, got %d", size)
+	}
+
+	// A result used in each generation stays in the memo.
+	hits := cfg.Memo.Stats().Hits
+	for i := 0; i < 1000; i++ {
+		_, _ = regexpFn(`7$`, "hot")
+		_, _ = regexpFn(`7$`, fmt.Sprintf("cold-%d", i))
+	}
+	if got := cfg.Memo.Stats().Hits - hits; got != 999 {
+		t.Errorf("Expected 999 hits on the used result, got %d", got)
+	}
+}
+
+func TestResultMemoConcurrent(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Memo = NewResultMemo(1000)
+	regexpFn := findFunction(Functions(&cfg), "regexp").Impl
+
+	var g errgroup.Group
+	for w := 0; w < 16; w++ {
+		g.Go(func() error {
+			for i := 0; i < 500; i++ {
+				text := fmt.Sprintf("%d", i%50)
+				got, err := regexpFn(`^[0-4]$`, text)
+				if err != nil {
+					return err
+				}
+				if want := boolResult(i%50 < 5); got != want {
+					return fmt.Errorf("regexp(%q) = %v, expected %v", text, got, want)
+				}
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if stats := cfg.Memo.Stats(); stats.Size != 50 || stats.Hits+stats.Misses != 16*500 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestResultMemoCollision(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Memo = NewResultMemo(1000)
+	regexpFn := findFunction(Functions(&cfg), "regexp").Impl
+
+	// Plant the result of another text under the hash of "apple".
+	re, err := cfg.compileMatch(`^b`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := memoKey{expr: re.String(), hash: maphash.String(cfg.Memo.seed, "apple")}
+	cfg.Memo.store(key, memoEntry{text: "banana", result: true})
+
+	if got, _ := regexpFn(`^b`, "apple"); got != int64(0) {
+		t.Errorf("Expected the result of apple, got %v", got)
+	}
+	if stats := cfg.Memo.Stats(); stats.Hits != 0 || stats.Misses != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if got, _ := regexpFn(`^b`, "apple"); got != int64(0) {
+		t.Errorf("Expected the result of apple, got %v", got)
+	}
+	if stats := cfg.Memo.Stats(); stats.Hits != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestResultMemoOutlivesCache(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Cache = NewCache()
+	cfg.Memo = NewResultMemo(1000)
+	regexpFn := findFunction(Functions(&cfg), "regexp").Impl
+
+	_, _ = regexpFn(`^a`, "apple", "i")
+	cfg.Cache.Clear()
+	// The pattern compiled again shares the results of the evicted one.
+	_, _ = regexpFn(`^a`, "apple", "i")
+	if stats := cfg.Memo.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
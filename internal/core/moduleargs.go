@@ -0,0 +1,41 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseModuleArgs parses the arguments of a CREATE VIRTUAL TABLE statement,
+// as passed by SQLite, of the form key=value, where value is a string
+// literal in single or double quotes, or a bare word:
+//
+//	CREATE VIRTUAL TABLE logs USING regexp_log(file='app.log', flags=i)
+func ParseModuleArgs(args []string) (map[string]string, error) {
+	parsed := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid argument %q, expected key=value", arg)
+		}
+		if _, dup := parsed[key]; dup {
+			return nil, fmt.Errorf("duplicate argument %q", key)
+		}
+		parsed[key] = unquoteSQL(strings.TrimSpace(value))
+	}
+	return parsed, nil
+}
+
+// unquoteSQL returns the value of a SQL string literal or quoted identifier,
+// in which the quote is escaped by doubling it. Other values are returned as
+// is.
+func unquoteSQL(s string) string {
+	if len(s) < 2 {
+		return s
+	}
+	q := s[0]
+	if (q != '\'' && q != '"') || s[len(s)-1] != q {
+		return s
+	}
+	return strings.ReplaceAll(s[1:len(s)-1], string([]byte{q, q}), string(q))
+}
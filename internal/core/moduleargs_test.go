@@ -0,0 +1,35 @@
+package core
+
+import "testing"
+
+func TestParseModuleArgs(t *testing.T) {
+	args, err := ParseModuleArgs([]string{
+		` file = '/var/log/app''s.log'`,
+		`PATTERN="(?P<level>\w+) (?P<msg>.*)"`,
+		`flags=i`,
+		`empty=''`,
+	})
+	if err != nil {
+		t.Fatalf("ParseModuleArgs failed: %v", err)
+	}
+	expected := map[string]string{
+		"file":    "/var/log/app's.log",
+		"pattern": `(?P<level>\w+) (?P<msg>.*)`,
+		"flags":   "i",
+		"empty":   "",
+	}
+	if len(args) != len(expected) {
+		t.Errorf("Got %v, expected %v", args, expected)
+	}
+	for k, v := range expected {
+		if args[k] != v {
+			t.Errorf("%s = %q, expected %q", k, args[k], v)
+		}
+	}
+
+	for _, bad := range [][]string{{"file"}, {"=x"}, {"a=1", "A=2"}} {
+		if _, err := ParseModuleArgs(bad); err == nil {
+			t.Errorf("ParseModuleArgs(%q): expected an error, got nil", bad)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CallEvent describes one evaluation of a function of the suite.
+type CallEvent struct {
+	Function string
+	// Pattern is the pattern argument, empty if it is NULL.
+	Pattern  string
+	Duration time.Duration
+	Err      error
+}
+
+// Event returns the event of an evaluation of f with args.
+func (f Function) Event(args []any, d time.Duration, err error) CallEvent {
+	e := CallEvent{Function: f.Name, Duration: d, Err: err}
+	if f.PatternArg < len(args) {
+		e.Pattern, _ = TextArg(args[f.PatternArg])
+	}
+	return e
+}
+
+// Observer is notified of every evaluation of a function of the suite, see
+// AddObserver. ObserveCall runs on the evaluating goroutine, inside SQLite,
+// and must be fast and safe for concurrent use.
+type Observer interface {
+	ObserveCall(e CallEvent)
+}
+
+// ObserverFunc adapts a function to the Observer interface.
+type ObserverFunc func(e CallEvent)
+
+func (f ObserverFunc) ObserveCall(e CallEvent) { f(e) }
+
+type observerEntry struct {
+	id uint64
+	o  Observer
+}
+
+var (
+	observersMu    sync.Mutex
+	nextObserverID uint64
+	// observers holds the current []observerEntry, replaced on every change
+	// so that calls only pay for an atomic load.
+	observers atomic.Pointer[[]observerEntry]
+)
+
+// AddObserver registers o for the functions of every connection, including
+// those opened before, and returns a function removing it.
+func AddObserver(o Observer) func() {
+	observersMu.Lock()
+	defer observersMu.Unlock()
+	nextObserverID++
+	id := nextObserverID
+	var list []observerEntry
+	if cur := observers.Load(); cur != nil {
+		list = append(list, *cur...)
+	}
+	list = append(list, observerEntry{id: id, o: o})
+	observers.Store(&list)
+
+	return func() { removeObserver(id) }
+}
+
+func removeObserver(id uint64) {
+	observersMu.Lock()
+	defer observersMu.Unlock()
+	cur := observers.Load()
+	if cur == nil {
+		return
+	}
+	var list []observerEntry
+	for _, e := range *cur {
+		if e.id != id {
+			list = append(list, e)
+		}
+	}
+	if len(list) == 0 {
+		observers.Store(nil)
+		return
+	}
+	observers.Store(&list)
+}
+
+// observed wraps the implementation of f so that its evaluations are
+// reported to the observers and the logger, if there are any, evaluations
+// longer than slow being logged.
+func observed(f Function, slow time.Duration) func(args ...any) (any, error) {
+	impl := f.Impl
+	return func(args ...any) (any, error) {
+		list, l := observers.Load(), logger.Load()
+		if list == nil && l == nil {
+			return impl(args...)
+		}
+		start := time.Now()
+		result, err := impl(args...)
+		e := f.Event(args, time.Since(start), err)
+		if l != nil {
+			logCall(l, f, args, e, slow)
+		}
+		if list != nil {
+			for _, entry := range *list {
+				entry.o.ObserveCall(e)
+			}
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestObservers(t *testing.T) {
+	var regexp Function
+	for _, fn := range Functions(&Config{}) {
+		if fn.Name == "regexp" {
+			regexp = fn
+		}
+	}
+
+	var calls, errors atomic.Int64
+	remove := AddObserver(ObserverFunc(func(e CallEvent) {
+		if e.Function != "regexp" || (e.Pattern != "a" && e.Pattern != "(") || e.Duration < 0 {
+			t.Errorf("Unexpected event %+v", e)
+		}
+		calls.Add(1)
+		if e.Err != nil {
+			errors.Add(1)
+		}
+	}))
+	// The same function may be added twice, and removed separately.
+	removeAgain := AddObserver(ObserverFunc(func(e CallEvent) { calls.Add(1) }))
+
+	_, _ = regexp.Call("a", "abc")
+	_, _ = regexp.Call("(", "abc")
+	if calls.Load() != 4 || errors.Load() != 1 {
+		t.Errorf("Got %d calls and %d errors, expected 4 and 1", calls.Load(), errors.Load())
+	}
+
+	removeAgain()
+	remove()
+	remove()
+	_, _ = regexp.Call("a", "abc")
+	if calls.Load() != 4 {
+		t.Errorf("Removed observers still called: %d calls", calls.Load())
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	before := ReadCacheStats()
+	_, _ = Compile(`stats-test-\d+`, 0)
+	_, _ = Compile(`stats-test-\d+`, 0)
+	_, _ = Compile(`stats-test-(`, 0)
+	after := ReadCacheStats()
+
+	if after.Hits-before.Hits != 1 || after.Misses-before.Misses != 2 || after.CompileErrors-before.CompileErrors != 1 {
+		t.Errorf("Unexpected stats change from %+v to %+v", before, after)
+	}
+}
@@ -0,0 +1,29 @@
+package core
+
+// regexpLike implements Oracle's REGEXP_LIKE(source, pattern [, match_param]).
+// It returns 1 if pattern matches source, 0 otherwise, and NULL if any
+// argument is NULL.
+//
+// Oracle's match parameters are a subset of the suite's flags: 'i'
+// (case-insensitive), 'c' (case-sensitive, the last of 'i' and 'c' wins), 'n'
+// ('.' matches newline), 'm' (multi-line) and 'x' (ignore whitespace; here
+// '#' also starts a comment). Oracle's defaults are the same as RE2's.
+func (c *Config) regexpLike(args ...any) (any, error) {
+	pattern, ok := TextArg(args[1])
+	if !ok || isNull(args[0]) {
+		return nil, nil
+	}
+	flags, ok, err := flagsArg("regexp_like", args, 2)
+	if !ok {
+		return nil, err
+	}
+	if result, ok, err := c.backtrackingMatch(pattern, flags, false, args[0]); ok {
+		return result, err
+	}
+
+	re, err := c.compileMatch(pattern, flags)
+	if err != nil {
+		return nil, err
+	}
+	return boolResult(c.matchArg(re, c.formOf(flags), args[0])), nil
+}
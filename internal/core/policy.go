@@ -0,0 +1,132 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"regexp/syntax"
+)
+
+// PatternInfo describes a pattern about to be compiled, for a PatternPolicy.
+type PatternInfo struct {
+	// Pattern is the pattern as compiled: with its library includes
+	// expanded, and without the delimiters of a literal or an engine tag.
+	Pattern string
+	// Flags are the flags it is compiled with.
+	Flags Flags
+	// Backtracking is set for patterns of the backtracking engine, see
+	// Config.EngineTags.
+	Backtracking bool
+}
+
+// PatternPolicy decides whether a pattern may be compiled, returning nil to
+// allow it, or the reason it is rejected, which a *PolicyError reports. A
+// *PolicyError returned by the policy is reported as is, with the pattern
+// if it has none.
+type PatternPolicy func(p PatternInfo) error
+
+// PolicyError is returned for a pattern rejected by the PatternPolicy of the
+// configuration.
+type PolicyError struct {
+	Pattern string
+	// Rule names the rule the pattern breaks, such as "max_length" for the
+	// rules of PatternLimits, or is empty.
+	Rule string
+	Err  error
+}
+
+func (e *PolicyError) Error() string {
+	if e.Rule == "" {
+		return fmt.Sprintf("pattern rejected by policy: %v", e.Err)
+	}
+	return fmt.Sprintf("pattern rejected by policy: %s: %v", e.Rule, e.Err)
+}
+
+func (e *PolicyError) Unwrap() error { return e.Err }
+
+// checkPolicy consults the policy of c, if any, about p.
+func (c *Config) checkPolicy(p PatternInfo) error {
+	if c.Policy == nil {
+		return nil
+	}
+	err := c.Policy(p)
+	if err == nil {
+		return nil
+	}
+	var policyErr *PolicyError
+	if errors.As(err, &policyErr) {
+		// The policy may return a shared error, which is not ours to fill in.
+		copied := *policyErr
+		if copied.Pattern == "" {
+			copied.Pattern = p.Pattern
+		}
+		return &copied
+	}
+	return &PolicyError{Pattern: p.Pattern, Err: err}
+}
+
+// PatternLimits are the rules of a PatternPolicy bounding the size and shape
+// of patterns. Zero values leave a rule out.
+type PatternLimits struct {
+	// MaxLength is the maximum length of a pattern in bytes (rule
+	// "max_length").
+	MaxLength int
+	// MaxRepeatNesting is the maximum number of nested quantifiers, as in
+	// (a+)+ which has two (rule "max_repeat_nesting").
+	MaxRepeatNesting int
+	// MaxRepeatCount is the maximum count of a counted repetition such as
+	// a{1,500} (rule "max_repeat_count").
+	MaxRepeatCount int
+	// DenyBacktracking rejects the patterns of the backtracking engine (rule
+	// "backtracking").
+	DenyBacktracking bool
+}
+
+// Policy returns the PatternPolicy enforcing l. The shape of the patterns
+// of the backtracking engine, which RE2 does not parse, is not checked.
+func (l PatternLimits) Policy() PatternPolicy {
+	return func(p PatternInfo) error {
+		if l.MaxLength > 0 && len(p.Pattern) > l.MaxLength {
+			return &PolicyError{Rule: "max_length", Err: fmt.Errorf("length %d exceeds %d", len(p.Pattern), l.MaxLength)}
+		}
+		if p.Backtracking {
+			if l.DenyBacktracking {
+				return &PolicyError{Rule: "backtracking", Err: errors.New("the backtracking engine is not allowed")}
+			}
+			return nil
+		}
+		if l.MaxRepeatNesting <= 0 && l.MaxRepeatCount <= 0 {
+			return nil
+		}
+		parsed, err := syntax.Parse(p.Flags.apply(p.Pattern), syntax.Perl)
+		if err != nil {
+			// Compiling reports the error.
+			return nil
+		}
+		nesting, count := repeatShape(parsed)
+		if l.MaxRepeatNesting > 0 && nesting > l.MaxRepeatNesting {
+			return &PolicyError{Rule: "max_repeat_nesting", Err: fmt.Errorf("%d nested quantifiers exceed %d", nesting, l.MaxRepeatNesting)}
+		}
+		if l.MaxRepeatCount > 0 && count > l.MaxRepeatCount {
+			return &PolicyError{Rule: "max_repeat_count", Err: fmt.Errorf("repetition count %d exceeds %d", count, l.MaxRepeatCount)}
+		}
+		return nil
+	}
+}
+
+// repeatShape returns the maximum number of nested quantifiers of re and the
+// largest count of its counted repetitions.
+func repeatShape(re *syntax.Regexp) (int, int) {
+	var nesting, count int
+	for _, sub := range re.Sub {
+		n, c := repeatShape(sub)
+		nesting, count = max(nesting, n), max(count, c)
+	}
+	switch re.Op {
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest:
+		nesting++
+	case syntax.OpRepeat:
+		nesting++
+		count = max(count, re.Min, re.Max)
+	}
+	return nesting, count
+}
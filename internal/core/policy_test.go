@@ -0,0 +1,127 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPatternLimits(t *testing.T) {
+	policy := PatternLimits{MaxLength: 20, MaxRepeatNesting: 2, MaxRepeatCount: 100, DenyBacktracking: true}.Policy()
+
+	tests := []struct {
+		info PatternInfo
+		rule string
+	}{
+		{PatternInfo{Pattern: `^ORD-\d+$`}, ""},
+		{PatternInfo{Pattern: `(a+)+`}, ""},
+		{PatternInfo{Pattern: `((a+)+)*`}, "max_repeat_nesting"},
+		{PatternInfo{Pattern: `(?:x(?:y*)?)+`}, "max_repeat_nesting"},
+		{PatternInfo{Pattern: `a{1,100}`}, ""},
+		{PatternInfo{Pattern: `a{101}`}, "max_repeat_count"},
+		{PatternInfo{Pattern: `abcdefghijklmnopqrstu`}, "max_length"},
+		{PatternInfo{Pattern: `a  b # c`, Flags: FlagExtended}, ""},
+		{PatternInfo{Pattern: `(?<=a)b`, Backtracking: true}, "backtracking"},
+		{PatternInfo{Pattern: `(`}, ""},
+	}
+	for _, test := range tests {
+		err := policy(test.info)
+		var policyErr *PolicyError
+		switch {
+		case test.rule == "" && err != nil:
+			t.Errorf("%q rejected: %v", test.info.Pattern, err)
+		case test.rule != "" && (!errors.As(err, &policyErr) || policyErr.Rule != test.rule):
+			t.Errorf("%q: got %v, expected a %s violation", test.info.Pattern, err, test.rule)
+		}
+	}
+}
+
+func TestPatternPolicy(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Cache = NewCache()
+	var seen []string
+	cfg.Policy = func(p PatternInfo) error {
+		seen = append(seen, p.Pattern)
+		if p.Pattern == "banned" {
+			return errors.New("banned word")
+		}
+		return nil
+	}
+	cfg.Library = NewLibrary()
+	if err := cfg.Library.Define("id", `\d+`); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := cfg.regexpFunc(`ID-{{id}}`, "ID-42"); err != nil || got != int64(1) {
+		t.Errorf("regexp = %v, %v, expected 1", got, err)
+	}
+	_, err := cfg.regexpFunc("banned", "banned")
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) || policyErr.Pattern != "banned" || policyErr.Error() != "pattern rejected by policy: banned word" {
+		t.Errorf("Expected a policy error, got %v", err)
+	}
+	if !IsPatternError(err) || !IsPatternMessage(err.Error()) {
+		t.Errorf("Expected %v to be a pattern error", err)
+	}
+	if got, err := cfg.regexpValid("banned"); err != nil || got != int64(0) {
+		t.Errorf("regexp_valid = %v, %v, expected 0", got, err)
+	}
+	if len(seen) == 0 || seen[0] != `ID-(?:\d+)` {
+		t.Errorf("Policy saw %q, expected the expanded pattern first", seen)
+	}
+}
+
+func TestPatternPolicySharedError(t *testing.T) {
+	errDenied := &PolicyError{Rule: "denied", Err: errors.New("not on the allow list")}
+	cfg := DefaultConfig()
+	cfg.Cache = NewCache()
+	cfg.Policy = func(PatternInfo) error { return errDenied }
+
+	for _, pattern := range []string{"a", "b"} {
+		_, err := cfg.regexpFunc(pattern, "x")
+		var policyErr *PolicyError
+		if !errors.As(err, &policyErr) || policyErr.Pattern != pattern || policyErr.Rule != "denied" {
+			t.Errorf("Expected a policy error for %q, got %#v", pattern, err)
+		}
+	}
+	if errDenied.Pattern != "" {
+		t.Errorf("The policy's error was modified: %+v", errDenied)
+	}
+}
+
+func TestPatternPolicyTranslated(t *testing.T) {
+	nesting := DefaultConfig()
+	nesting.Cache = NewCache()
+	nesting.Policy = PatternLimits{MaxRepeatNesting: 1}.Policy()
+	length := DefaultConfig()
+	length.Cache = NewCache()
+	length.Policy = PatternLimits{MaxLength: 12}.Policy()
+
+	tests := []struct {
+		name    string
+		fn      func(args ...any) (any, error)
+		args    []any
+		allowed bool
+	}{
+		{"similar_to", nesting.similarTo, []any{"aaa", "a*"}, true},
+		{"similar_to", nesting.similarTo, []any{"aaa", "(a*)*"}, false},
+		{"glob", length.glob, []any{"a*", "aaa"}, true},
+		{"glob", length.glob, []any{"aaaaaaaaa*", "aaaaaaaaaa"}, false},
+		{"like", length.like, []any{"a%", "aaa"}, true},
+		{"like", length.like, []any{"aaaaaaaaa%", "aaaaaaaaaa"}, false},
+	}
+	for _, test := range tests {
+		got, err := test.fn(test.args...)
+		var policyErr *PolicyError
+		if test.allowed && (err != nil || got != int64(1)) {
+			t.Errorf("%s%q = %v, %v, expected 1", test.name, test.args, got, err)
+		} else if !test.allowed && !errors.As(err, &policyErr) {
+			t.Errorf("%s%q: expected a policy error, got %v", test.name, test.args, err)
+		}
+	}
+	if _, err := length.CompileGlob("a*"); err != nil {
+		t.Errorf("CompileGlob failed for a short pattern: %v", err)
+	}
+	if _, err := length.CompileGlob("aaaaaaaaa*"); !IsPatternError(err) {
+		t.Errorf("CompileGlob: expected a policy error, got %v", err)
+	}
+}
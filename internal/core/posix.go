@@ -0,0 +1,185 @@
+package core
+
+import (
+	"regexp/syntax"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// posixClasses are the Unicode translations of the POSIX character classes,
+// as grep reads them in a UTF-8 locale. RE2 knows the class names but
+// restricts them to ASCII, so that [[:alpha:]] does not match é.
+var posixClasses = map[string]string{
+	"alnum":  `\p{L}\p{Nd}`,
+	"alpha":  `\p{L}`,
+	"blank":  ` \t\p{Zs}`,
+	"cntrl":  `\p{Cc}`,
+	"digit":  `0-9`,
+	"graph":  `\p{L}\p{M}\p{N}\p{P}\p{S}`,
+	"lower":  `\p{Ll}`,
+	"print":  `\p{L}\p{M}\p{N}\p{P}\p{S}\p{Zs}`,
+	"punct":  `\p{P}\p{S}`,
+	"space":  `\t\n\v\f\r\p{Z}`,
+	"upper":  `\p{Lu}`,
+	"word":   `\p{L}\p{N}_`,
+	"xdigit": `0-9A-Fa-f`,
+}
+
+// Error codes of translatePOSIX, in the style of those of regexp/syntax.
+const (
+	errBareClass        syntax.ErrorCode = "character class outside of a bracket expression"
+	errCollatingElement syntax.ErrorCode = "unsupported collating element"
+	errEquivalenceClass syntax.ErrorCode = "unsupported equivalence class"
+)
+
+// translatePOSIX rewrites the POSIX elements of the bracket expressions of
+// expr, which RE2 either restricts to ASCII or reads as plain characters:
+//
+//	[[:alpha:]]  character classes, made Unicode-aware (see posixClasses)
+//	[[=e=]]      equivalence classes: e and its accented forms
+//	[[.-.]]      collating elements of a single character
+//
+// A class written without its brackets, [:digit:], which RE2 reads as a set
+// of characters, is reported as an error, as GNU grep does. Unknown class
+// names are left for RE2 to report.
+func translatePOSIX(expr string) (string, error) {
+	if !strings.Contains(expr, "[:") && !strings.Contains(expr, "[=") && !strings.Contains(expr, "[.") {
+		return expr, nil
+	}
+
+	var b strings.Builder
+	b.Grow(len(expr))
+	inClass := false
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == '\\' && i+1 < len(expr):
+			if !inClass && expr[i+1] == 'Q' {
+				// Quoted text is copied verbatim up to and including \E.
+				end := strings.Index(expr[i:], `\E`)
+				if end < 0 {
+					b.WriteString(expr[i:])
+					return b.String(), nil
+				}
+				b.WriteString(expr[i : i+end+2])
+				i += end + 2
+				continue
+			}
+			_, size := utf8.DecodeRuneInString(expr[i+1:])
+			b.WriteString(expr[i : i+1+size])
+			i += 1 + size
+		case !inClass && c == '[':
+			if name, ok := posixElement(expr[i:], ':'); ok {
+				if _, known := posixClasses[name]; known {
+					return "", &syntax.Error{Code: errBareClass, Expr: expr[i : i+len(name)+4]}
+				}
+			}
+			inClass = true
+			b.WriteByte('[')
+			i++
+			if i < len(expr) && expr[i] == '^' {
+				b.WriteByte('^')
+				i++
+			}
+			// A leading ']' is a literal.
+			if i < len(expr) && expr[i] == ']' {
+				b.WriteString(`\]`)
+				i++
+			}
+		case inClass && c == ']':
+			inClass = false
+			b.WriteByte(']')
+			i++
+		case inClass && c == '[' && i+1 < len(expr):
+			delim := expr[i+1]
+			content, ok := posixElement(expr[i:], delim)
+			if !ok {
+				b.WriteByte('[')
+				i++
+				continue
+			}
+			element := expr[i : i+len(content)+4]
+			switch delim {
+			case ':':
+				if class, known := posixClasses[content]; known {
+					b.WriteString(class)
+				} else {
+					b.WriteString(element)
+				}
+			case '=':
+				r, size := utf8.DecodeRuneInString(content)
+				if size != len(content) || r == utf8.RuneError {
+					return "", &syntax.Error{Code: errEquivalenceClass, Expr: element}
+				}
+				for _, e := range equivalents(r) {
+					writeClassRune(&b, e)
+				}
+			case '.':
+				r, size := utf8.DecodeRuneInString(content)
+				if size != len(content) || r == utf8.RuneError {
+					return "", &syntax.Error{Code: errCollatingElement, Expr: element}
+				}
+				writeClassRune(&b, r)
+			}
+			i += len(element)
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String(), nil
+}
+
+// posixElement returns the content of the element [<delim>...<delim>] that s
+// starts with, if delim is one of ':', '=' and '.' and the element is
+// closed.
+func posixElement(s string, delim byte) (string, bool) {
+	if len(s) < 2 || s[0] != '[' || s[1] != delim || !strings.ContainsRune(":=.", rune(delim)) {
+		return "", false
+	}
+	end := strings.Index(s[2:], string(delim)+"]")
+	if end < 1 {
+		return "", false
+	}
+	return s[2 : 2+end], true
+}
+
+// writeClassRune writes r as a member of a character class.
+func writeClassRune(b *strings.Builder, r rune) {
+	if r < utf8.RuneSelf && (unicode.IsPunct(r) || unicode.IsSymbol(r)) {
+		b.WriteByte('\\')
+	}
+	b.WriteRune(r)
+}
+
+// equivalenceTable maps the base characters of the canonical decompositions
+// of the Latin, Greek and Cyrillic letters to the letters decomposing to
+// them, such as e to é, è, ê and ë.
+var equivalenceTable = sync.OnceValue(func() map[rune][]rune {
+	table := map[rune][]rune{}
+	for r := rune(0); r < 0x2000; r++ {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		d := norm.NFD.String(string(r))
+		base, size := utf8.DecodeRuneInString(d)
+		if size == len(d) {
+			continue
+		}
+		table[base] = append(table[base], r)
+	}
+	return table
+})
+
+// equivalents returns r and the characters of its equivalence class, those
+// with the same base character.
+func equivalents(r rune) []rune {
+	d := norm.NFD.String(string(r))
+	base, _ := utf8.DecodeRuneInString(d)
+	runes := []rune{base}
+	return append(runes, equivalenceTable()[base]...)
+}
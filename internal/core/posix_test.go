@@ -0,0 +1,79 @@
+package core
+
+import (
+	"regexp/syntax"
+	"testing"
+)
+
+func TestTranslatePOSIX(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{`abc`, `abc`},
+		{`[[:digit:]]+`, `[0-9]+`},
+		{`^[[:alpha:]_][[:alnum:]_]*$`, `^[\p{L}_][\p{L}\p{Nd}_]*$`},
+		{`[^[:space:]]`, `[^\t\n\v\f\r\p{Z}]`},
+		{`[[=n=]]`, `[nñńņňǹṅṇṉṋ]`},
+		{`[[.-.][.].]a]`, `[\-\]a]`},
+		{`[]:][[:digit:]]`, `[\]:][0-9]`},
+		{`[[:foo:]]`, `[[:foo:]]`},
+		// Escapes and quoted text are left alone.
+		{`\[[[:digit:]]\]`, `\[[0-9]\]`},
+		{`\Q[[:digit:]]\E[[:digit:]]`, `\Q[[:digit:]]\E[0-9]`},
+		{`[a\][:digit:]]`, `[a\]0-9]`},
+	}
+	for _, test := range tests {
+		got, err := translatePOSIX(test.expr)
+		if err != nil {
+			t.Errorf("translatePOSIX(%q) returned error: %v", test.expr, err)
+			continue
+		}
+		if got != test.expected {
+			t.Errorf("translatePOSIX(%q) = %q, expected %q", test.expr, got, test.expected)
+		}
+	}
+
+	for _, test := range []struct {
+		expr string
+		code syntax.ErrorCode
+	}{
+		{`[:digit:]+`, errBareClass},
+		{`[[=ab=]]`, errEquivalenceClass},
+		{`[[.hyphen.]]`, errCollatingElement},
+	} {
+		_, err := translatePOSIX(test.expr)
+		if serr, ok := err.(*syntax.Error); !ok || serr.Code != test.code {
+			t.Errorf("translatePOSIX(%q) returned %v, expected %q", test.expr, err, test.code)
+		}
+	}
+}
+
+func TestPOSIXFlag(t *testing.T) {
+	tests := []struct {
+		pattern string
+		flags   Flags
+		text    string
+		match   bool
+	}{
+		{`^[[:alpha:]]+$`, FlagPOSIX, "école", true},
+		{`^[[:alpha:]]+$`, 0, "école", false},
+		{`^[[:upper:]]+$`, FlagPOSIX | FlagCaseInsensitive, "école", true},
+		{`^caf[[=e=]]$`, FlagPOSIX, "café", true},
+		{`^caf[[=e=]]$`, FlagPOSIX, "cafE", false},
+		{`^caf[[=e=]]$`, FlagPOSIX | FlagCaseInsensitive, "cafÉ", true},
+		{`^[[=é=]]$`, FlagPOSIX, "e", true},
+		{`^[[:digit:]]{3} # area
+			[[:digit:]]{4}$`, FlagPOSIX | FlagExtended, "5551234", true},
+	}
+	for _, test := range tests {
+		re, err := test.flags.compile(test.pattern)
+		if err != nil {
+			t.Errorf("compile(%q, %q) returned error: %v", test.pattern, test.flags, err)
+			continue
+		}
+		if got := re.MatchString(test.text); got != test.match {
+			t.Errorf("%q with %q matches %q = %v, expected %v", test.pattern, test.flags, test.text, got, test.match)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package core
+
+import "regexp"
+
+// PostgreSQL compatibility functions, enabled by Config.Postgres.
+//
+// PostgreSQL's advanced regular expressions default to letting '.' match a
+// newline and anchoring ^ and $ only at the ends of the string. The functions
+// below compile patterns with those defaults so that queries ported from
+// PostgreSQL behave identically, as long as the patterns themselves stay
+// within the RE2 syntax.
+
+// postgresFlags translates PostgreSQL regexp flags into the suite's flags. It
+// also reports whether the 'g' (global) flag was given.
+func postgresFlags(s string) (Flags, bool, error) {
+	flags, global := FlagDotNL, false
+	for _, f := range s {
+		switch f {
+		case 'c':
+			flags = flags&^FlagCaseInsensitive | flagCaseSensitive
+		case 'i':
+			flags = flags&^flagCaseSensitive | FlagCaseInsensitive
+		case 'n', 'm':
+			flags = flags&^FlagDotNL | FlagMultiLine
+		case 'p':
+			flags &^= FlagDotNL | FlagMultiLine
+		case 'w':
+			flags |= FlagDotNL | FlagMultiLine
+		case 's':
+			flags = flags&^FlagMultiLine | FlagDotNL
+		case 'x':
+			flags |= FlagExtended
+		case 'g':
+			global = true
+		default:
+			return 0, false, &FlagError{Flag: f, Postgres: true}
+		}
+	}
+	return flags, global, nil
+}
+
+func (c *Config) compilePostgres(pattern, s string) (*regexp.Regexp, bool, error) {
+	flags, global, err := postgresFlags(s)
+	if err != nil {
+		return nil, false, err
+	}
+	re, err := c.Compile(pattern, flags)
+	return re, global, err
+}
+
+// pgMatchFunction returns the implementation of ~ (or ~* with flags "i"),
+// negated for !~ and !~*.
+func pgMatchFunction(cfg *Config, flags string, negate bool) func(args ...any) (any, error) {
+	return func(args ...any) (any, error) {
+		t, ok := TextArg(args[0])
+		if !ok {
+			return nil, nil
+		}
+		p, ok := TextArg(args[1])
+		if !ok {
+			return nil, nil
+		}
+		re, _, err := cfg.compilePostgres(p, flags)
+		if err != nil {
+			return nil, err
+		}
+		return boolResult(re.MatchString(t) != negate), nil
+	}
+}
+
+// pgSubstring implements substring(text from pattern): the part of text
+// matched by the first parenthesized subexpression if there is one, the whole
+// match otherwise, or NULL when the pattern does not match.
+func (c *Config) pgSubstring(args ...any) (any, error) {
+	t, ok := TextArg(args[0])
+	if !ok {
+		return nil, nil
+	}
+	p, ok := TextArg(args[1])
+	if !ok {
+		return nil, nil
+	}
+	re, _, err := c.compilePostgres(p, "")
+	if err != nil {
+		return nil, err
+	}
+
+	loc := re.FindStringSubmatchIndex(t)
+	if loc == nil {
+		return nil, nil
+	}
+	if re.NumSubexp() > 0 {
+		loc = loc[2:4]
+	}
+	if loc[0] < 0 {
+		return nil, nil
+	}
+	return t[loc[0]:loc[1]], nil
+}
+
+// regexpMatchesFunction implements regexp_matches(text, pattern [, flags]).
+// Each match is returned as a JSON array of the captured substrings, or of
+// the whole match when the pattern has no capture groups. Without the 'g'
+// flag the first match is returned; with it, an array of all matches. NULL is
+// returned when nothing matches.
+func regexpMatchesFunction(cfg *Config) func(args ...any) (any, error) {
+	return func(args ...any) (any, error) {
+		t, ok := TextArg(args[0])
+		if !ok {
+			return nil, nil
+		}
+		p, ok := TextArg(args[1])
+		if !ok {
+			return nil, nil
+		}
+		flags := ""
+		if len(args) == 3 {
+			if flags, ok = TextArg(args[2]); !ok {
+				return nil, nil
+			}
+		}
+
+		re, global, err := cfg.compilePostgres(p, flags)
+		if err != nil {
+			return nil, err
+		}
+
+		b := &jsonBuilder{limit: cfg.MaxResultSize}
+		t, ok = b.limitInput(cfg, t)
+		if !ok {
+			return b.result(cfg, "regexp_matches")
+		}
+
+		n := 1
+		if global {
+			n = -1
+		}
+		matches := re.FindAllStringSubmatchIndex(t, n)
+		if matches == nil && !b.inputTruncated {
+			return nil, nil
+		}
+
+		if global {
+			b.raw("[")
+			b.commit()
+		}
+		for i, loc := range matches {
+			if i > 0 {
+				b.raw(",")
+			}
+			if len(loc) > 2 {
+				loc = loc[2:]
+			}
+			b.groups(t, loc)
+			if b.overflow {
+				break
+			}
+			if global {
+				b.commit()
+			}
+		}
+		if global {
+			b.raw("]")
+		}
+		return b.result(cfg, "regexp_matches")
+	}
+}
+
+// postgresFunctions returns the PostgreSQL compatibility functions:
+//
+//	pg_match(text, pattern)          -- text ~ pattern
+//	pg_imatch(text, pattern)         -- text ~* pattern
+//	pg_not_match(text, pattern)      -- text !~ pattern
+//	pg_not_imatch(text, pattern)     -- text !~* pattern
+//	pg_substring(text, pattern)      -- substring(text from pattern)
+//	regexp_matches(text, pattern [, flags])
+func postgresFunctions(cfg *Config) []Function {
+	return []Function{
+		{Name: "pg_match", PatternArg: 1, MinArgs: 2, MaxArgs: 2, Deterministic: true, Impl: pgMatchFunction(cfg, "", false)},
+		{Name: "pg_imatch", PatternArg: 1, MinArgs: 2, MaxArgs: 2, Deterministic: true, Impl: pgMatchFunction(cfg, "i", false)},
+		{Name: "pg_not_match", PatternArg: 1, MinArgs: 2, MaxArgs: 2, Deterministic: true, Impl: pgMatchFunction(cfg, "", true)},
+		{Name: "pg_not_imatch", PatternArg: 1, MinArgs: 2, MaxArgs: 2, Deterministic: true, Impl: pgMatchFunction(cfg, "i", true)},
+		{Name: "pg_substring", PatternArg: 1, MinArgs: 2, MaxArgs: 2, Deterministic: true, Impl: cfg.pgSubstring},
+		{Name: "regexp_matches", PatternArg: 1, MinArgs: 2, MaxArgs: 3, Deterministic: true, Impl: regexpMatchesFunction(cfg)},
+	}
+}
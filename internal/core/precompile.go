@@ -0,0 +1,18 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Precompile compiles patterns with flags into the cache of c, expanding
+// library includes, and returns an error listing every invalid pattern.
+func (c *Config) Precompile(patterns []string, flags Flags) error {
+	var errs []error
+	for _, p := range patterns {
+		if _, err := c.Compile(p, flags); err != nil {
+			errs = append(errs, fmt.Errorf("pattern %q: %w", p, err))
+		}
+	}
+	return errors.Join(errs...)
+}
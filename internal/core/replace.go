@@ -0,0 +1,47 @@
+package core
+
+import "strings"
+
+// ReplacementSyntax selects how regexp_replace reads references to capture
+// groups in its replacement.
+type ReplacementSyntax int
+
+const (
+	// ReplaceDollar reads Go's $1, ${1} and ${name}, and $$ for a dollar
+	// sign. As in Go, $1x refers to a group named 1x; ${1}x is group 1
+	// followed by x.
+	ReplaceDollar ReplacementSyntax = iota
+	// ReplaceBackslash reads \0 to \9, as sed and MariaDB do, and \\ for a
+	// backslash; a dollar sign is literal.
+	ReplaceBackslash
+	// ReplaceDollarOrBackslash reads both syntaxes.
+	ReplaceDollarOrBackslash
+)
+
+// template returns replacement written in Go's template syntax, that of
+// regexp.Regexp.Expand, read with syntax s.
+func (s ReplacementSyntax) template(replacement string) string {
+	if s == ReplaceDollar || s == ReplaceDollarOrBackslash && !strings.Contains(replacement, `\`) {
+		return replacement
+	}
+	var b strings.Builder
+	b.Grow(len(replacement))
+	for i := 0; i < len(replacement); i++ {
+		ch := replacement[i]
+		switch {
+		case ch == '$' && s == ReplaceBackslash:
+			b.WriteString("$$")
+		case ch == '\\' && i+1 < len(replacement) && replacement[i+1] >= '0' && replacement[i+1] <= '9':
+			b.WriteString("${")
+			b.WriteByte(replacement[i+1])
+			b.WriteByte('}')
+			i++
+		case ch == '\\' && i+1 < len(replacement) && replacement[i+1] == '\\':
+			b.WriteByte('\\')
+			i++
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
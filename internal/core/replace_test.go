@@ -0,0 +1,23 @@
+package core
+
+import "testing"
+
+func TestReplacementTemplate(t *testing.T) {
+	tests := []struct {
+		syntax      ReplacementSyntax
+		replacement string
+		expected    string
+	}{
+		{ReplaceDollar, `$1 \1`, `$1 \1`},
+		{ReplaceBackslash, `\1x`, `${1}x`},
+		{ReplaceBackslash, `$1 \\1 \`, `$$1 \1 \`},
+		{ReplaceBackslash, `\12`, `${1}2`},
+		{ReplaceDollarOrBackslash, `$1\2`, `$1${2}`},
+		{ReplaceDollarOrBackslash, `$$`, `$$`},
+	}
+	for _, test := range tests {
+		if got := test.syntax.template(test.replacement); got != test.expected {
+			t.Errorf("Template of %q with syntax %d is %q, expected %q", test.replacement, test.syntax, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// regexpReplace implements regexp_replace(text, pattern, replacement [, flags]).
+// Every match of pattern is replaced by replacement, in which $1 or ${name},
+// or \1, refer to capture groups, depending on c.Replacement.
+func (c *Config) regexpReplace(args ...any) (any, error) {
+	text, ok := TextArg(args[0])
+	if !ok {
+		return nil, nil
+	}
+	pattern, ok := TextArg(args[1])
+	if !ok {
+		return nil, nil
+	}
+	replacement, ok := TextArg(args[2])
+	if !ok {
+		return nil, nil
+	}
+	flags, ok, err := flagsArg("regexp_replace", args, 3)
+	if !ok {
+		return nil, err
+	}
+
+	re, err := c.Compile(pattern, flags)
+	if err != nil {
+		return nil, err
+	}
+	return re.ReplaceAllString(text, c.Replacement.template(replacement)), nil
+}
+
+// regexpExtract implements regexp_extract(text, pattern [, group [, flags]]).
+// It returns the text matched by group (a number or a name; 0, the whole
+// match, by default) in the first match of pattern, or NULL if pattern does
+// not match or the group did not participate in the match.
+func (c *Config) regexpExtract(args ...any) (any, error) {
+	text, ok := TextArg(args[0])
+	if !ok {
+		return nil, nil
+	}
+	pattern, ok := TextArg(args[1])
+	if !ok {
+		return nil, nil
+	}
+	flags, ok, err := flagsArg("regexp_extract", args, 3)
+	if !ok {
+		return nil, err
+	}
+
+	re, err := c.Compile(pattern, flags)
+	if err != nil {
+		return nil, err
+	}
+
+	group, ok, err := groupArg("regexp_extract", re, args, 2)
+	if !ok {
+		return nil, err
+	}
+
+	loc := re.FindStringSubmatchIndex(text)
+	if loc == nil || loc[2*group] < 0 {
+		return nil, nil
+	}
+	return text[loc[2*group]:loc[2*group+1]], nil
+}
+
+// groupArg returns the capture group of re given by argument i of a call
+// to function, a number or a name, 0 if there is no such argument. It
+// reports false if the argument is NULL or invalid.
+func groupArg(function string, re *regexp.Regexp, args []any, i int) (int, bool, error) {
+	group := 0
+	if len(args) > i {
+		if isNull(args[i]) {
+			return 0, false, nil
+		}
+		switch g := args[i].(type) {
+		case int64:
+			group = int(g)
+		case string:
+			if group = re.SubexpIndex(g); group < 0 {
+				return 0, false, fmt.Errorf("%s: no capture group named %q", function, g)
+			}
+		default:
+			return 0, false, fmt.Errorf("%s: group must be an integer or a name, got %T", function, g)
+		}
+	}
+	if group < 0 || group > re.NumSubexp() {
+		return 0, false, fmt.Errorf("%s: group %d out of range, pattern has %d groups", function, group, re.NumSubexp())
+	}
+	return group, true, nil
+}
+
+// regexpFullMatch implements regexp_full_match(text, pattern [, flags]). It
+// returns 1 if pattern matches the whole of text, as if anchored with \A and
+// \z, 0 otherwise, and NULL if any argument is NULL.
+func (c *Config) regexpFullMatch(args ...any) (any, error) {
+	pattern, ok := TextArg(args[1])
+	if !ok || isNull(args[0]) {
+		return nil, nil
+	}
+	flags, ok, err := flagsArg("regexp_full_match", args, 2)
+	if !ok {
+		return nil, err
+	}
+	if result, ok, err := c.backtrackingMatch(pattern, flags, true, args[0]); ok {
+		return result, err
+	}
+	re, err := c.compileFull(pattern, flags)
+	if err != nil {
+		return nil, err
+	}
+	return boolResult(c.matchArg(re, c.formOf(flags), args[0])), nil
+}
+
+// compileFull compiles pattern with flags anchored at both ends of the text.
+// The pattern is compiled alone first, so that it is checked and expanded
+// as written, before its compiled form is wrapped: "a)|(b" is an error, not
+// an alternation.
+func (c *Config) compileFull(pattern string, flags Flags) (*regexp.Regexp, error) {
+	form := c.formOf(flags)
+	pattern, flags, err := c.Literals.unwrap(pattern, flags)
+	if err != nil {
+		return nil, err
+	}
+	var fold Flags
+	if c.caseInsensitive(flags) {
+		fold = FlagCaseInsensitive
+	}
+	// The pattern alone is compiled case-sensitively, 'c' overriding the
+	// default, so that the case of its literals is kept for the wrapper.
+	re, err := c.compileUnwrapped(pattern, flags&^FlagCaseInsensitive|flagCaseSensitive, textForm{})
+	if err != nil {
+		return nil, err
+	}
+	return c.cache().compile(cacheKey{pattern: `\A(?:` + re.String() + `)\z`, flags: fold, form: form})
+}
+
+// regexpCaptureCount implements regexp_capture_count(pattern [, flags]), the
+// number of capture groups of pattern, named or not, so that SQL generators
+// know how many groups they can extract.
+func (c *Config) regexpCaptureCount(args ...any) (any, error) {
+	pattern, ok := TextArg(args[0])
+	if !ok {
+		return nil, nil
+	}
+	flags, ok, err := flagsArg("regexp_capture_count", args, 1)
+	if !ok {
+		return nil, err
+	}
+
+	re, err := c.Compile(pattern, flags)
+	if err != nil {
+		return nil, err
+	}
+	return int64(re.NumSubexp()), nil
+}
+
+// patternError returns the message of the error compiling the pattern of
+// args[0] with the flags of args[1], if any: "" if it compiles, and nil if
+// either argument is NULL. Errors other than those of invalid patterns and
+// flags are returned as errors.
+func (c *Config) patternError(args []any) (any, error) {
+	pattern, ok := TextArg(args[0])
+	if !ok {
+		return nil, nil
+	}
+	var flags Flags
+	if len(args) > 1 {
+		s, ok := TextArg(args[1])
+		if !ok {
+			return nil, nil
+		}
+		var err error
+		if flags, err = ParseFlags(s); err != nil {
+			return err.Error(), nil
+		}
+	}
+	var err error
+	if body, backtracking := c.engineTag(pattern); backtracking {
+		_, err = c.compileBacktracking(body, flags, false)
+	} else {
+		_, err = c.Compile(pattern, flags)
+	}
+	if err != nil {
+		if !IsPatternError(err) {
+			return nil, err
+		}
+		return err.Error(), nil
+	}
+	return "", nil
+}
+
+// regexpValid implements regexp_valid(pattern [, flags]), 1 if pattern
+// compiles with flags and 0 otherwise, so that the broken rows of a table of
+// patterns can be found before they fail a query. It returns NULL if any
+// argument is NULL.
+func (c *Config) regexpValid(args ...any) (any, error) {
+	msg, err := c.patternError(args)
+	if msg == nil {
+		return nil, err
+	}
+	return boolResult(msg == ""), nil
+}
+
+// regexpError implements regexp_error(pattern [, flags]), the message of
+// the error compiling pattern with flags, or NULL if it compiles or any
+// argument is NULL.
+func (c *Config) regexpError(args ...any) (any, error) {
+	msg, err := c.patternError(args)
+	if msg == "" {
+		return nil, err
+	}
+	return msg, err
+}
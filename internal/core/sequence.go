@@ -0,0 +1,97 @@
+package core
+
+import "regexp"
+
+// SequenceRule describes a row matching First followed by a later row
+// matching Then, at most WithinRows rows and WithinSeconds seconds after it.
+// A zero limit is not checked, but at least one of them should be set, as
+// the rows matching First are held until they expire.
+type SequenceRule struct {
+	First         *regexp.Regexp
+	Then          *regexp.Regexp
+	WithinRows    int
+	WithinSeconds float64
+}
+
+// SequenceMatch is a pair of rows matching a SequenceRule.
+type SequenceMatch struct {
+	// StartKey and EndKey are the keys of the rows matching First and Then.
+	StartKey any
+	EndKey   any
+	// StartText and EndText are the texts of these rows.
+	StartText string
+	EndText   string
+	// Rows is the number of rows spanned, both included.
+	Rows int
+	// Seconds is the time elapsed between the two rows.
+	Seconds float64
+}
+
+type sequenceRow struct {
+	key  any
+	text string
+	n    int
+	time float64
+}
+
+// SequenceMatcher finds the matches of a SequenceRule in a sequence of rows
+// pushed in order. Each row matching First is paired with the first row
+// matching Then within the limits, if any; a row matching both patterns
+// closes earlier sequences before opening its own.
+type SequenceMatcher struct {
+	rule    SequenceRule
+	pending []sequenceRow
+	n       int
+}
+
+// NewSequenceMatcher returns a matcher for rule.
+func NewSequenceMatcher(rule SequenceRule) *SequenceMatcher {
+	return &SequenceMatcher{rule: rule}
+}
+
+// Push adds the next row, at time t in seconds, and returns the sequences it
+// completes. t is only used if rule.WithinSeconds is set, and must not
+// decrease from one row to the next.
+func (m *SequenceMatcher) Push(key any, text string, t float64) []SequenceMatch {
+	m.n++
+	row := sequenceRow{key: key, text: text, n: m.n, time: t}
+
+	expired := 0
+	for _, p := range m.pending {
+		if !m.expired(p, row) {
+			break
+		}
+		expired++
+	}
+	m.pending = m.pending[expired:]
+
+	var matches []SequenceMatch
+	if len(m.pending) > 0 && m.rule.Then.MatchString(text) {
+		for _, p := range m.pending {
+			matches = append(matches, SequenceMatch{
+				StartKey:  p.key,
+				EndKey:    key,
+				StartText: p.text,
+				EndText:   text,
+				Rows:      row.n - p.n + 1,
+				Seconds:   row.time - p.time,
+			})
+		}
+		m.pending = m.pending[:0]
+	}
+
+	if m.rule.First.MatchString(text) {
+		m.pending = append(m.pending, row)
+	}
+	return matches
+}
+
+// expired reports whether row is too far from the pending row p to complete
+// its sequence. Pending rows are in order, so once one has not expired, none
+// of the following ones has.
+func (m *SequenceMatcher) expired(p, row sequenceRow) bool {
+	if m.rule.WithinRows > 0 && row.n-p.n > m.rule.WithinRows {
+		return true
+	}
+	return m.rule.WithinSeconds > 0 && row.time-p.time > m.rule.WithinSeconds
+}
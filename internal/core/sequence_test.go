@@ -0,0 +1,69 @@
+package core
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSequenceMatcher(t *testing.T) {
+	rows := []struct {
+		text string
+		time float64
+	}{
+		{"login failed alice", 0},
+		{"noise", 10},
+		{"login failed bob", 20},
+		{"login ok", 30},
+		{"login failed carol", 40},
+		{"noise", 50},
+		{"noise", 60},
+		{"login ok", 200},
+	}
+
+	run := func(rule SequenceRule) []SequenceMatch {
+		m := NewSequenceMatcher(rule)
+		var matches []SequenceMatch
+		for i, row := range rows {
+			matches = append(matches, m.Push(int64(i+1), row.text, row.time)...)
+		}
+		return matches
+	}
+	first := regexp.MustCompile(`failed`)
+	then := regexp.MustCompile(`ok`)
+
+	matches := run(SequenceRule{First: first, Then: then, WithinRows: 3})
+	expected := []SequenceMatch{
+		{StartKey: int64(1), EndKey: int64(4), StartText: "login failed alice", EndText: "login ok", Rows: 4, Seconds: 30},
+		{StartKey: int64(3), EndKey: int64(4), StartText: "login failed bob", EndText: "login ok", Rows: 2, Seconds: 10},
+		{StartKey: int64(5), EndKey: int64(8), StartText: "login failed carol", EndText: "login ok", Rows: 4, Seconds: 160},
+	}
+	if len(matches) != len(expected) {
+		t.Fatalf("Got %d matches, expected %d: %+v", len(matches), len(expected), matches)
+	}
+	for i := range expected {
+		if matches[i] != expected[i] {
+			t.Errorf("Match %d = %+v, expected %+v", i, matches[i], expected[i])
+		}
+	}
+
+	// Limits are inclusive, and both apply when set.
+	if matches := run(SequenceRule{First: first, Then: then, WithinRows: 2}); len(matches) != 1 || matches[0].StartKey != int64(3) {
+		t.Errorf("WithinRows 2: unexpected matches %+v", matches)
+	}
+	if matches := run(SequenceRule{First: first, Then: then, WithinSeconds: 30}); len(matches) != 2 {
+		t.Errorf("WithinSeconds 30: unexpected matches %+v", matches)
+	}
+	if matches := run(SequenceRule{First: first, Then: then, WithinRows: 3, WithinSeconds: 20}); len(matches) != 1 {
+		t.Errorf("WithinRows 3, WithinSeconds 20: unexpected matches %+v", matches)
+	}
+
+	// A row matching both patterns closes earlier sequences, then opens its own.
+	m := NewSequenceMatcher(SequenceRule{First: regexp.MustCompile(`a`), Then: regexp.MustCompile(`b`), WithinRows: 5})
+	m.Push(1, "a", 0)
+	if matches := m.Push(2, "ab", 0); len(matches) != 1 || matches[0].StartKey != 1 {
+		t.Errorf("Unexpected matches %+v", matches)
+	}
+	if matches := m.Push(3, "b", 0); len(matches) != 1 || matches[0].StartKey != 2 {
+		t.Errorf("Unexpected matches %+v", matches)
+	}
+}
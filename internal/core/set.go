@@ -0,0 +1,222 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SetPattern is a pattern of a pattern set, with the ID regexp_set_match
+// returns when it is the first of the set to match.
+type SetPattern struct {
+	ID      string
+	Pattern string
+	Flags   string
+}
+
+// PatternSets holds named sets of patterns, compiled when they are defined,
+// for regexp_set_match(text, set), which returns the ID of the first pattern
+// of a set matching text: one call per row instead of a join with a table
+// of patterns, see also SetCombined. PatternSets is safe for concurrent
+// use, and sets may be redefined while queries run.
+type PatternSets struct {
+	cfg *Config
+
+	mu      sync.RWMutex
+	sets    map[string]*patternSet
+	combine bool
+}
+
+// patternSet is a compiled pattern set.
+type patternSet struct {
+	entries []setEntry
+	// combined, if set, matches a text against every entry at once, see
+	// combine; markers are the indexes of its groups telling which entry
+	// matched, and form the form of the texts it matches.
+	combined *regexp.Regexp
+	markers  []int
+	form     textForm
+}
+
+// setEntry is a compiled pattern of a set.
+type setEntry struct {
+	id      string
+	re      *regexp.Regexp
+	form    textForm
+	longest bool
+}
+
+// NewPatternSets returns an empty collection of pattern sets, compiling
+// their patterns with cfg.
+func NewPatternSets(cfg *Config) *PatternSets {
+	return &PatternSets{cfg: cfg, sets: make(map[string]*patternSet)}
+}
+
+// Define adds or replaces the set called name, whose patterns are tried in
+// order. It fails, leaving the set as it was, if name is invalid or if any
+// pattern does not compile; the error lists every invalid pattern.
+func (s *PatternSets) Define(name string, patterns []SetPattern) error {
+	if !nameRe.MatchString(name) {
+		return fmt.Errorf("invalid pattern set name %q", name)
+	}
+	entries := make([]setEntry, 0, len(patterns))
+	var errs []error
+	for _, p := range patterns {
+		flags, err := ParseFlags(p.Flags)
+		if err == nil {
+			var re *regexp.Regexp
+			if re, err = s.cfg.compileMatch(p.Pattern, flags); err == nil {
+				longest := s.cfg.Longest || flags&FlagLongest != 0
+				entries = append(entries, setEntry{id: p.ID, re: re, form: s.cfg.formOf(flags), longest: longest})
+				continue
+			}
+		}
+		errs = append(errs, fmt.Errorf("pattern set %s: %s %q: %w", name, p.ID, p.Pattern, err))
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	set := &patternSet{entries: entries}
+	s.mu.RLock()
+	combine := s.combine
+	s.mu.RUnlock()
+	if combine {
+		set.combine()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sets[name] = set
+	return nil
+}
+
+// SetCombined sets whether the sets defined later are compiled into a
+// single alternation of their patterns, matched in one pass over the text
+// instead of one pass per pattern until one matches. Go's regular
+// expressions run the alternation without a DFA, tracking every pattern at
+// every position of the text, so this is often slower than matching the
+// patterns one by one: measure it with the workload before enabling it.
+func (s *PatternSets) SetCombined(combined bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.combine = combined
+}
+
+// combine compiles the entries of set into a single alternation,
+//
+//	\A(?:(?s:.*?)(?:p1)()|(?s:.*?)(?:p2)()|...)
+//
+// whose leftmost-first match takes the first branch that matches anywhere
+// in the text, its empty group telling which one it is. This is the first
+// matching entry, found in one pass over the text instead of one per entry.
+// Entries matching leftmost-longest, or texts in different forms, cannot
+// be combined; their set is matched one entry at a time.
+func (set *patternSet) combine() {
+	if len(set.entries) < 2 {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(`\A(?:`)
+	markers := make([]int, len(set.entries))
+	group := 0
+	for i, e := range set.entries {
+		if e.longest || e.form != set.entries[0].form {
+			return
+		}
+		if i > 0 {
+			b.WriteByte('|')
+		}
+		b.WriteString(`(?s:.*?)(?:` + e.re.String() + `)()`)
+		group += e.re.NumSubexp() + 1
+		markers[i] = group
+	}
+	b.WriteByte(')')
+	combined, err := regexp.Compile(b.String())
+	if err != nil {
+		// Too large or too deeply nested once combined.
+		return
+	}
+	set.combined, set.markers, set.form = combined, markers, set.entries[0].form
+}
+
+// match returns the first entry of set matching the text v, as read by c,
+// or nil.
+func (set *patternSet) match(c *Config, v any) *setEntry {
+	if set.combined == nil {
+		for i := range set.entries {
+			if c.matchArg(set.entries[i].re, set.entries[i].form, v) {
+				return &set.entries[i]
+			}
+		}
+		return nil
+	}
+
+	var loc []int
+	if b, ok := v.([]byte); ok {
+		if c.BlobEncoding == BlobLatin1 {
+			b = decodeLatin1(b)
+		}
+		loc = set.combined.FindSubmatchIndex(set.form.bytes(b))
+	} else {
+		text, _ := TextArg(v)
+		loc = set.combined.FindStringSubmatchIndex(set.form.text(text))
+	}
+	if loc == nil {
+		return nil
+	}
+	for i, g := range set.markers {
+		if loc[2*g] >= 0 {
+			return &set.entries[i]
+		}
+	}
+	return nil
+}
+
+// Remove deletes the set called name; regexp_set_match fails for it until
+// it is defined again.
+func (s *PatternSets) Remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sets, name)
+}
+
+// Names returns the names of the sets in sorted order.
+func (s *PatternSets) Names() []string {
+	s.mu.RLock()
+	names := make([]string, 0, len(s.sets))
+	for name := range s.sets {
+		names = append(names, name)
+	}
+	s.mu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// get returns the set called name.
+func (s *PatternSets) get(name string) (*patternSet, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	set, ok := s.sets[name]
+	return set, ok
+}
+
+// regexpSetMatch implements regexp_set_match(text, set), the ID of the first
+// pattern of set matching text, or NULL if none does or any argument is
+// NULL.
+func (c *Config) regexpSetMatch(args ...any) (any, error) {
+	name, ok := TextArg(args[1])
+	if !ok || isNull(args[0]) {
+		return nil, nil
+	}
+	set, ok := c.Sets.get(name)
+	if !ok {
+		return nil, fmt.Errorf("regexp_set_match: no pattern set named %q", name)
+	}
+	if e := set.match(c, args[0]); e != nil {
+		return e.id, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,174 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPatternSets(t *testing.T) {
+	cfg := DefaultConfig()
+	sets := NewPatternSets(&cfg)
+	cfg.Sets = sets
+	if err := sets.Define("merchants", []SetPattern{
+		{ID: "coffee", Pattern: `starbucks|costa`, Flags: "i"},
+		{ID: "travel", Pattern: `^(uber|lyft)\b`},
+		{ID: "any-card", Pattern: `card`},
+	}); err != nil {
+		t.Fatalf("Define failed: %v", err)
+	}
+
+	tests := []struct {
+		text     any
+		set      any
+		expected any
+	}{
+		{"STARBUCKS #12 card", "merchants", "coffee"},
+		{"uber trip", "merchants", "travel"},
+		{"Uber trip", "merchants", nil},
+		{[]byte("card payment"), "merchants", "any-card"},
+		{nil, "merchants", nil},
+		{"uber", nil, nil},
+	}
+	for _, tt := range tests {
+		got, err := cfg.regexpSetMatch(tt.text, tt.set)
+		if err != nil {
+			t.Errorf("regexpSetMatch(%v, %v) failed: %v", tt.text, tt.set, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("regexpSetMatch(%v, %v) = %v, expected %v", tt.text, tt.set, got, tt.expected)
+		}
+	}
+
+	if _, err := cfg.regexpSetMatch("x", "missing"); err == nil {
+		t.Error("Expected an error for an unknown set")
+	}
+
+	err := sets.Define("merchants", []SetPattern{{ID: "ok", Pattern: `a`}, {ID: "bad", Pattern: `(`}, {ID: "flags", Pattern: `b`, Flags: "q"}})
+	if err == nil || !strings.Contains(err.Error(), "bad") || !strings.Contains(err.Error(), "flags") {
+		t.Errorf("Got %v, expected an error listing both invalid patterns", err)
+	}
+	if got, _ := cfg.regexpSetMatch("uber", "merchants"); got != "travel" {
+		t.Errorf("A failed Define changed the set: got %v", got)
+	}
+	if err := sets.Define("bad name", nil); err == nil {
+		t.Error("Expected an error for an invalid set name")
+	}
+
+	if err := sets.Define("empty", nil); err != nil {
+		t.Fatalf("Define failed: %v", err)
+	}
+	if set, _ := sets.get("merchants"); set.combined != nil {
+		t.Error("Sets are combined by default")
+	}
+	if names := sets.Names(); !reflect.DeepEqual(names, []string{"empty", "merchants"}) {
+		t.Errorf("Unexpected names %v", names)
+	}
+	sets.Remove("empty")
+	if _, err := cfg.regexpSetMatch("x", "empty"); err == nil {
+		t.Error("Expected an error for a removed set")
+	}
+}
+
+func TestPatternSetCombined(t *testing.T) {
+	patterns := []SetPattern{
+		{ID: "late", Pattern: `z$`},
+		{ID: "groups", Pattern: `(a)(b)?c`},
+		{ID: "anchored", Pattern: `^b`},
+		{ID: "lines", Pattern: `^d`, Flags: "m"},
+		{ID: "word", Pattern: `\bword\b`, Flags: "i"},
+		{ID: "dot", Pattern: `x.y`},
+		{ID: "empty", Pattern: ``},
+	}
+	texts := []string{"", "abc z", "bz", "ac", "b", "c\nd", "WORD", "swordfish", "x\ny", "xay", "nothing"}
+
+	cfg := DefaultConfig()
+	sets := NewPatternSets(&cfg)
+	sets.SetCombined(true)
+	cfg.Sets = sets
+	// Every prefix of the patterns, so that each one is the first to match
+	// some text.
+	for n := 2; n <= len(patterns); n++ {
+		if err := sets.Define("set", patterns[:n]); err != nil {
+			t.Fatalf("Define failed: %v", err)
+		}
+		set, _ := sets.get("set")
+		if set.combined == nil {
+			t.Fatalf("The set of %d patterns is not combined", n)
+		}
+		for _, text := range texts {
+			var expected any
+			for _, p := range patterns[:n] {
+				flags, _ := ParseFlags(p.Flags)
+				if re, _ := cfg.compileMatch(p.Pattern, flags); re.MatchString(text) {
+					expected = p.ID
+					break
+				}
+			}
+			got, err := cfg.regexpSetMatch(text, "set")
+			if err != nil {
+				t.Fatalf("regexpSetMatch failed: %v", err)
+			}
+			if got != expected {
+				t.Errorf("%d patterns, text %q: got %v, expected %v", n, text, got, expected)
+			}
+		}
+	}
+}
+
+func TestPatternSetNotCombined(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CaseFolding = FoldFull
+	sets := NewPatternSets(&cfg)
+	sets.SetCombined(true)
+	cfg.Sets = sets
+
+	// Leftmost-longest matching and texts of different forms keep the
+	// entries apart.
+	for name, patterns := range map[string][]SetPattern{
+		"longest": {{ID: "a", Pattern: `a`}, {ID: "b", Pattern: `b`, Flags: "l"}},
+		"forms":   {{ID: "strasse", Pattern: `STRASSE`, Flags: "i"}, {ID: "b", Pattern: `b`}},
+	} {
+		if err := sets.Define(name, patterns); err != nil {
+			t.Fatalf("Define(%s) failed: %v", name, err)
+		}
+		if set, _ := sets.get(name); set.combined != nil {
+			t.Errorf("Set %s is combined", name)
+		}
+	}
+	if got, _ := cfg.regexpSetMatch("xb", "longest"); got != "b" {
+		t.Errorf("Got %v, expected b", got)
+	}
+	if got, _ := cfg.regexpSetMatch("Straße", "forms"); got != "strasse" {
+		t.Errorf("Got %v, expected strasse", got)
+	}
+}
+
+func BenchmarkPatternSet(b *testing.B) {
+	cfg := DefaultConfig()
+	var patterns []SetPattern
+	for i := range 50 {
+		patterns = append(patterns, SetPattern{ID: fmt.Sprint(i), Pattern: fmt.Sprintf(`merchant%d\b|vendor-%d-\d+`, i, i)})
+	}
+	text := "card payment to vendor-49-1234 on 2024-01-01"
+
+	b.Run("combined", func(b *testing.B) {
+		sets := NewPatternSets(&cfg)
+		sets.SetCombined(true)
+		_ = sets.Define("set", patterns)
+		set, _ := sets.get("set")
+		for b.Loop() {
+			_ = set.match(&cfg, text)
+		}
+	})
+	b.Run("sequential", func(b *testing.B) {
+		sets := NewPatternSets(&cfg)
+		_ = sets.Define("set", patterns)
+		set, _ := sets.get("set")
+		for b.Loop() {
+			_ = set.match(&cfg, text)
+		}
+	})
+}
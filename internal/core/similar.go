@@ -0,0 +1,121 @@
+package core
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// errSimilarEscape is PostgreSQL's error for a pattern ending with the
+// escape character or an escape of more than one character.
+var errSimilarEscape = errors.New("invalid escape string")
+
+// SimilarToRegexp translates a SQL SIMILAR TO pattern into a regular
+// expression matching the whole text, as PostgreSQL does: '%' matches any
+// sequence of characters and '_' exactly one character, as in LIKE; '|',
+// '*', '+', '?', {m,n} repetitions, parentheses and [...] bracket
+// expressions are those of regular expressions; every other character,
+// including '.', '^' and '$', is literal. If escape is not zero, it makes
+// the character following it literal.
+func SimilarToRegexp(pattern string, escape rune) (string, error) {
+	var b strings.Builder
+	b.WriteString(`^(?s:`)
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		i += size
+		switch {
+		case escape != 0 && r == escape:
+			if i == len(pattern) {
+				return "", errSimilarEscape
+			}
+			r, size = utf8.DecodeRuneInString(pattern[i:])
+			i += size
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		case r == '%':
+			b.WriteString(`.*`)
+		case r == '_':
+			b.WriteString(`.`)
+		case r == '(':
+			b.WriteString(`(?:`)
+		case strings.ContainsRune(`|*+?{}),`, r) || r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '[':
+			n := similarClass(pattern[i:])
+			b.WriteByte('[')
+			b.WriteString(strings.ReplaceAll(pattern[i:i+n], `\`, `\\`))
+			i += n
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString(`)$`)
+	return b.String(), nil
+}
+
+// similarClass returns the length of the bracket expression following a
+// '[', including its closing ']', or the rest of s if it is unterminated. A
+// ']' right after '[' or '[^' is a member, and [:alpha:] a class.
+func similarClass(s string) int {
+	i := 0
+	if strings.HasPrefix(s, "^") {
+		i++
+	}
+	if strings.HasPrefix(s[i:], "]") {
+		i++
+	}
+	for i < len(s) {
+		switch {
+		case s[i] == ']':
+			return i + 1
+		case strings.HasPrefix(s[i:], "[:"):
+			if end := strings.Index(s[i+2:], ":]"); end >= 0 {
+				i += end + 4
+				continue
+			}
+		}
+		i++
+	}
+	return len(s)
+}
+
+// similarTo implements similar_to(text, pattern [, escape]), the SQL
+// standard's "text SIMILAR TO pattern [ESCAPE escape]". The escape
+// character is '\' by default, as in PostgreSQL, and an empty escape
+// disables it. It returns NULL if any argument is NULL.
+func (c *Config) similarTo(args ...any) (any, error) {
+	text, ok := TextArg(args[0])
+	if !ok {
+		return nil, nil
+	}
+	pattern, ok := TextArg(args[1])
+	if !ok {
+		return nil, nil
+	}
+
+	escape := '\\'
+	if len(args) > 2 {
+		e, ok := TextArg(args[2])
+		if !ok {
+			return nil, nil
+		}
+		switch utf8.RuneCountInString(e) {
+		case 0:
+			escape = 0
+		case 1:
+			escape, _ = utf8.DecodeRuneInString(e)
+		default:
+			return nil, errSimilarEscape
+		}
+	}
+
+	expr, err := SimilarToRegexp(pattern, escape)
+	if err != nil {
+		return nil, err
+	}
+	re, err := c.compileTranslated(expr, 0, textForm{})
+	if err != nil {
+		return nil, err
+	}
+	return boolResult(re.MatchString(text)), nil
+}
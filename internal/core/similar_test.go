@@ -0,0 +1,53 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestSimilarTo(t *testing.T) {
+	cfg := DefaultConfig()
+	f := findFunction(Functions(&cfg), "similar_to")
+
+	tests := []struct {
+		args     []any
+		expected any
+	}{
+		{[]any{"abc", "abc"}, int64(1)},
+		{[]any{"abc", "a"}, int64(0)},
+		{[]any{"abc", "%(b|d)%"}, int64(1)},
+		{[]any{"abc", "(b|c)%"}, int64(0)},
+		{[]any{"abc", "_b_"}, int64(1)},
+		{[]any{"aaab", "a+b"}, int64(1)},
+		{[]any{"ab", "a{2,}b"}, int64(0)},
+		{[]any{"aab", "a{2,}b"}, int64(1)},
+		{[]any{"b2", "[a-c][[:digit:]]"}, int64(1)},
+		{[]any{"]", "[]]"}, int64(1)},
+		{[]any{`\`, `[\]`}, int64(1)},
+		// Regular expression characters outside of SIMILAR TO are literal.
+		{[]any{"a.c", "a.c"}, int64(1)},
+		{[]any{"abc", "a.c"}, int64(0)},
+		{[]any{"^a$", "^a$"}, int64(1)},
+		{[]any{"a" + "\n" + "b", "a%b"}, int64(1)},
+		// Escapes.
+		{[]any{"100%", `100\%`}, int64(1)},
+		{[]any{"1000", `100\%`}, int64(0)},
+		{[]any{"a|b", `a#|b`, "#"}, int64(1)},
+		{[]any{`a\b`, `a\b`, ""}, int64(1)},
+		{[]any{"ABC", "abc"}, int64(0)},
+		{[]any{nil, "a"}, nil},
+		{[]any{"a", nil}, nil},
+		{[]any{"a", "a", nil}, nil},
+	}
+	for _, tt := range tests {
+		result, err := f.Call(tt.args...)
+		if err != nil || result != tt.expected {
+			t.Errorf("similar_to%q = %v, %v; expected %v", tt.args, result, err, tt.expected)
+		}
+	}
+
+	for _, args := range [][]any{{"a", `a\`}, {"a", "a", "ab"}, {"a", "(a"}} {
+		if _, err := f.Call(args...); err == nil {
+			t.Errorf("similar_to%q: expected an error", args)
+		}
+	}
+}
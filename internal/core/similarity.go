@@ -0,0 +1,357 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// String similarity functions, enabled by Config.Similarity.
+//
+// They complement the pattern functions with the usual fuzzy fallbacks for
+// the texts no pattern matches, under the names and semantics of
+// PostgreSQL's fuzzystrmatch module:
+//
+//	levenshtein(a, b [, insert_cost, delete_cost, substitute_cost])
+//	soundex(text)
+//	difference(a, b)
+//	metaphone(text [, max_length])
+
+// similarityFunctions returns the string similarity functions.
+func similarityFunctions() []Function {
+	return []Function{
+		{Name: "levenshtein", MinArgs: 2, MaxArgs: 5, Deterministic: true, Impl: levenshteinFunc},
+		{Name: "soundex", MinArgs: 1, MaxArgs: 1, Deterministic: true, Impl: soundexFunc},
+		{Name: "difference", MinArgs: 2, MaxArgs: 2, Deterministic: true, Impl: differenceFunc},
+		{Name: "metaphone", MinArgs: 1, MaxArgs: 2, Deterministic: true, Impl: metaphoneFunc},
+	}
+}
+
+// Levenshtein returns the edit distance between a and b, counted in
+// characters: the least total cost of the insertions, deletions and
+// substitutions turning a into b.
+func Levenshtein(a, b string, insertCost, deleteCost, substituteCost int) int {
+	s, t := []rune(a), []rune(b)
+	// prev and cur are the rows of the distances from the prefixes of s to
+	// the prefixes of t.
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j * insertCost
+	}
+	for i := range s {
+		cur[0] = (i + 1) * deleteCost
+		for j := range t {
+			d := prev[j]
+			if s[i] != t[j] {
+				d += substituteCost
+			}
+			d = min(d, prev[j+1]+deleteCost, cur[j]+insertCost)
+			cur[j+1] = d
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(t)]
+}
+
+// levenshteinFunc implements levenshtein(a, b [, insert_cost, delete_cost,
+// substitute_cost]), every operation costing 1 by default. It returns NULL
+// if any argument is NULL.
+func levenshteinFunc(args ...any) (any, error) {
+	a, ok := TextArg(args[0])
+	if !ok {
+		return nil, nil
+	}
+	b, ok := TextArg(args[1])
+	if !ok {
+		return nil, nil
+	}
+	costs := []int{1, 1, 1}
+	if len(args) > 2 {
+		if len(args) != 5 {
+			return nil, fmt.Errorf("levenshtein: expected 2 or 5 arguments, got %d", len(args))
+		}
+		for i, v := range args[2:] {
+			switch v := v.(type) {
+			case nil:
+				return nil, nil
+			case int64:
+				if v < 0 {
+					return nil, fmt.Errorf("levenshtein: negative cost %d", v)
+				}
+				costs[i] = int(v)
+			default:
+				return nil, fmt.Errorf("levenshtein: costs must be integers, got %T", v)
+			}
+		}
+	}
+	return int64(Levenshtein(a, b, costs[0], costs[1], costs[2])), nil
+}
+
+// soundexCodes are the Soundex digits of the letters A to Z; '0' marks the
+// vowels and Y, which separate equal digits, and '-' H and W, which do not.
+const soundexCodes = "0123012-02245501262301-202"
+
+// Soundex returns the American Soundex code of s: its first letter followed
+// by three digits coding the following consonants, such as R163 for both
+// Robert and Rupert. Accented letters are read as their base letter and
+// other characters are ignored; s without letters has an empty code.
+func Soundex(s string) string {
+	letters := asciiLetters(s)
+	if len(letters) == 0 {
+		return ""
+	}
+	code := []byte{letters[0]}
+	last := soundexCodes[letters[0]-'A']
+	for _, l := range letters[1:] {
+		digit := soundexCodes[l-'A']
+		switch {
+		case digit == '-':
+			continue
+		case digit != '0' && digit != last:
+			code = append(code, digit)
+			if len(code) == 4 {
+				return string(code)
+			}
+		}
+		last = digit
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code)
+}
+
+// soundexFunc implements soundex(text). It returns NULL for NULL.
+func soundexFunc(args ...any) (any, error) {
+	s, ok := TextArg(args[0])
+	if !ok {
+		return nil, nil
+	}
+	return Soundex(s), nil
+}
+
+// differenceFunc implements difference(a, b), the number of positions, from
+// 0 to 4, at which the Soundex codes of a and b agree. It returns NULL if
+// any argument is NULL.
+func differenceFunc(args ...any) (any, error) {
+	a, ok := TextArg(args[0])
+	if !ok {
+		return nil, nil
+	}
+	b, ok := TextArg(args[1])
+	if !ok {
+		return nil, nil
+	}
+	ca, cb := Soundex(a), Soundex(b)
+	n := int64(0)
+	for i := 0; i < len(ca) && i < len(cb); i++ {
+		if ca[i] == cb[i] {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Metaphone returns the Metaphone key of s, Lawrence Philips' original
+// phonetic code, such as NT for Knight and FLP for Philip; '0' stands for
+// "th" and 'X' for "sh". Accented letters are read as their base letter and
+// other characters are ignored. If maxLen is positive, the key is cut to
+// maxLen characters.
+func Metaphone(s string, maxLen int) string {
+	w := asciiLetters(s)
+	at := func(i int) byte {
+		if i < 0 || i >= len(w) {
+			return 0
+		}
+		return w[i]
+	}
+	isVowel := func(c byte) bool {
+		return c != 0 && strings.IndexByte("AEIOU", c) >= 0
+	}
+	isSoft := func(c byte) bool {
+		return c != 0 && strings.IndexByte("EIY", c) >= 0
+	}
+
+	var key []byte
+	i := 0
+	// Initial letters.
+	switch at(0) {
+	case 'A':
+		if at(1) == 'E' {
+			key, i = append(key, 'E'), 2
+		} else {
+			key, i = append(key, 'A'), 1
+		}
+	case 'G', 'K', 'P':
+		if at(1) == 'N' {
+			key, i = append(key, 'N'), 2
+		}
+	case 'W':
+		switch {
+		case at(1) == 'R':
+			key, i = append(key, 'R'), 2
+		case at(1) == 'H' || isVowel(at(1)):
+			key, i = append(key, 'W'), 2
+		}
+	case 'X':
+		key, i = append(key, 'S'), 1
+	case 'E', 'I', 'O', 'U':
+		key, i = append(key, w[0]), 1
+	}
+
+	for ; i < len(w) && (maxLen <= 0 || len(key) < maxLen); i++ {
+		c, prev, next, after := w[i], at(i-1), at(i+1), at(i+2)
+		if c == prev && c != 'C' {
+			continue
+		}
+		switch c {
+		case 'B':
+			// Silent in a final "mb", as in "dumb".
+			if !(prev == 'M' && next == 0) {
+				key = append(key, 'B')
+			}
+		case 'C':
+			switch {
+			case isSoft(next):
+				switch {
+				case next == 'I' && after == 'A':
+					key = append(key, 'X')
+				case prev != 'S':
+					key = append(key, 'S')
+				}
+			case next == 'H':
+				if after == 'R' || prev == 'S' {
+					key = append(key, 'K')
+				} else {
+					key = append(key, 'X')
+				}
+				i++
+			default:
+				key = append(key, 'K')
+			}
+		case 'D':
+			if next == 'G' && isSoft(after) {
+				key = append(key, 'J')
+				i++
+			} else {
+				key = append(key, 'T')
+			}
+		case 'G':
+			switch {
+			case next == 'H':
+				// Hard before a vowel, as in "ghost", silent otherwise, as
+				// in "night".
+				if isVowel(after) {
+					key = append(key, 'K')
+				}
+			case next == 'N':
+				// Silent in a final "gn" or "gned", as in "sign".
+				if !(after == 0 || after == 'E' && at(i+3) == 'D' && at(i+4) == 0) {
+					key = append(key, 'K')
+				}
+			case isSoft(next):
+				key = append(key, 'J')
+			default:
+				key = append(key, 'K')
+			}
+		case 'H':
+			if isVowel(next) && strings.IndexByte("CGPST", prev) < 0 {
+				key = append(key, 'H')
+			}
+		case 'K':
+			if prev != 'C' {
+				key = append(key, 'K')
+			}
+		case 'P':
+			if next == 'H' {
+				key = append(key, 'F')
+			} else {
+				key = append(key, 'P')
+			}
+		case 'Q':
+			key = append(key, 'K')
+		case 'S':
+			switch {
+			case next == 'I' && (after == 'O' || after == 'A'):
+				key = append(key, 'X')
+			case next == 'H':
+				key = append(key, 'X')
+				i++
+			case next == 'C' && after == 'H':
+				key = append(key, 'S', 'K')
+				i += 2
+			default:
+				key = append(key, 'S')
+			}
+		case 'T':
+			switch {
+			case next == 'I' && (after == 'O' || after == 'A'):
+				key = append(key, 'X')
+			case next == 'H':
+				key = append(key, '0')
+				i++
+			case !(next == 'C' && after == 'H'):
+				key = append(key, 'T')
+			}
+		case 'V':
+			key = append(key, 'F')
+		case 'W', 'Y':
+			if isVowel(next) {
+				key = append(key, c)
+			}
+		case 'X':
+			key = append(key, 'K', 'S')
+		case 'Z':
+			key = append(key, 'S')
+		case 'F', 'J', 'L', 'M', 'N', 'R':
+			key = append(key, c)
+		}
+	}
+	if maxLen > 0 && len(key) > maxLen {
+		key = key[:maxLen]
+	}
+	return string(key)
+}
+
+// metaphoneFunc implements metaphone(text [, max_length]). It returns NULL
+// if any argument is NULL.
+func metaphoneFunc(args ...any) (any, error) {
+	s, ok := TextArg(args[0])
+	if !ok {
+		return nil, nil
+	}
+	maxLen := 0
+	if len(args) > 1 {
+		switch v := args[1].(type) {
+		case nil:
+			return nil, nil
+		case int64:
+			if v <= 0 {
+				return nil, fmt.Errorf("metaphone: max_length must be positive, got %d", v)
+			}
+			maxLen = int(v)
+		default:
+			return nil, fmt.Errorf("metaphone: max_length must be an integer, got %T", v)
+		}
+	}
+	return Metaphone(s, maxLen), nil
+}
+
+// asciiLetters returns the letters of s in upper case, reading accented
+// letters as their base letter, é as E, and dropping the other characters.
+func asciiLetters(s string) []byte {
+	letters := make([]byte, 0, len(s))
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case r >= 'a' && r <= 'z':
+			letters = append(letters, byte(r)-'a'+'A')
+		case r >= 'A' && r <= 'Z':
+			letters = append(letters, byte(r))
+		case r == 'ß':
+			letters = append(letters, 'S', 'S')
+		}
+	}
+	return letters
+}
@@ -0,0 +1,128 @@
+package core
+
+import "testing"
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		costs    [3]int
+		expected int
+	}{
+		{"kitten", "sitting", [3]int{1, 1, 1}, 3},
+		{"", "abc", [3]int{1, 1, 1}, 3},
+		{"abc", "", [3]int{1, 1, 1}, 3},
+		{"abc", "abc", [3]int{1, 1, 1}, 0},
+		// Characters, not bytes.
+		{"café", "cafe", [3]int{1, 1, 1}, 1},
+		// A substitution costing more than a deletion and an insertion.
+		{"GUMBO", "GAMBOL", [3]int{2, 1, 5}, 5},
+		{"abc", "", [3]int{1, 4, 1}, 12},
+	}
+	for _, tt := range tests {
+		if d := Levenshtein(tt.a, tt.b, tt.costs[0], tt.costs[1], tt.costs[2]); d != tt.expected {
+			t.Errorf("Levenshtein(%q, %q, %v) = %d, expected %d", tt.a, tt.b, tt.costs, d, tt.expected)
+		}
+	}
+}
+
+func TestSoundex(t *testing.T) {
+	tests := map[string]string{
+		"Robert":   "R163",
+		"Rupert":   "R163",
+		"Rubin":    "R150",
+		"Ashcraft": "A261",
+		"Tymczak":  "T522",
+		"Pfister":  "P236",
+		"Honeyman": "H555",
+		"Lee":      "L000",
+		"Müller":   "M460",
+		"o'Hara":   "O600",
+		"123":      "",
+		"":         "",
+	}
+	for s, expected := range tests {
+		if code := Soundex(s); code != expected {
+			t.Errorf("Soundex(%q) = %q, expected %q", s, code, expected)
+		}
+	}
+}
+
+func TestMetaphone(t *testing.T) {
+	tests := []struct {
+		s        string
+		maxLen   int
+		expected string
+	}{
+		{"Knight", 0, "NT"},
+		{"Philip", 0, "FLP"},
+		{"Thomas", 0, "0MS"},
+		{"Schmidt", 0, "SKMTT"},
+		{"dumb", 0, "TM"},
+		{"ghost", 0, "KST"},
+		{"sign", 0, "SN"},
+		{"signal", 0, "SKNL"},
+		{"edge", 0, "EJ"},
+		{"science", 0, "SNS"},
+		{"Xavier", 0, "SFR"},
+		{"Wright", 0, "RT"},
+		{"Aeon", 0, "EN"},
+		{"nation", 0, "NXN"},
+		{"extraordinary", 0, "EKSTRRTNR"},
+		{"extraordinary", 4, "EKST"},
+		{"", 0, ""},
+	}
+	for _, tt := range tests {
+		if key := Metaphone(tt.s, tt.maxLen); key != tt.expected {
+			t.Errorf("Metaphone(%q, %d) = %q, expected %q", tt.s, tt.maxLen, key, tt.expected)
+		}
+	}
+}
+
+func TestSimilarityFunctions(t *testing.T) {
+	cfg := DefaultConfig()
+	if findFunction(Functions(&cfg), "levenshtein").Impl != nil {
+		t.Fatal("levenshtein registered without Similarity")
+	}
+	cfg.Similarity = true
+	funcs := Functions(&cfg)
+
+	tests := []struct {
+		function string
+		args     []any
+		expected any
+	}{
+		{"levenshtein", []any{"kitten", "sitting"}, int64(3)},
+		{"levenshtein", []any{"GUMBO", "GAMBOL", int64(2), int64(1), int64(5)}, int64(5)},
+		{"levenshtein", []any{nil, "a"}, nil},
+		{"levenshtein", []any{"a", "b", int64(1), nil, int64(1)}, nil},
+		{"soundex", []any{"Robert"}, "R163"},
+		{"soundex", []any{nil}, nil},
+		{"difference", []any{"Robert", "Rupert"}, int64(4)},
+		{"difference", []any{"Anothers", "Brothers"}, int64(2)},
+		{"difference", []any{"a", nil}, nil},
+		{"metaphone", []any{"Thompson"}, "0MPSN"},
+		{"metaphone", []any{"Thompson", int64(2)}, "0M"},
+		{"metaphone", []any{nil}, nil},
+	}
+	for _, tt := range tests {
+		result, err := findFunction(funcs, tt.function).Call(tt.args...)
+		if err != nil || result != tt.expected {
+			t.Errorf("%s%q = %v, %v; expected %v", tt.function, tt.args, result, err, tt.expected)
+		}
+	}
+
+	errors := []struct {
+		function string
+		args     []any
+	}{
+		{"levenshtein", []any{"a", "b", int64(1)}},
+		{"levenshtein", []any{"a", "b", int64(1), int64(-1), int64(1)}},
+		{"levenshtein", []any{"a", "b", int64(1), "x", int64(1)}},
+		{"metaphone", []any{"a", int64(0)}},
+	}
+	for _, tt := range errors {
+		if _, err := findFunction(funcs, tt.function).Call(tt.args...); err == nil {
+			t.Errorf("%s%q: expected an error", tt.function, tt.args)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"unicode/utf8"
+)
+
+// Splitter splits a text at the matches of a pattern one part at a time,
+// finding each match only when the part before it is asked for, so that
+// splitting a large text does not hold all its parts, or all its matches,
+// at once. The parts are those of regexp.Regexp.Split with n < 0.
+type Splitter struct {
+	re   *regexp.Regexp
+	text string
+	// lazy is set if the pattern can be searched from any offset as if
+	// the text started there; otherwise matches holds all the matches.
+	lazy    bool
+	matches [][]int
+
+	// beg is the start of the next part and end that of the last match;
+	// pos and prevMatchEnd are the state of the search, as in
+	// regexp.Regexp's own loop over all matches.
+	beg, end     int
+	pos          int
+	prevMatchEnd int
+	done         bool
+}
+
+// NewSplitter returns a Splitter of text at the matches of re.
+func NewSplitter(re *regexp.Regexp, text string) *Splitter {
+	s := &Splitter{re: re, text: text, prevMatchEnd: -1, lazy: searchableFromAnyOffset(re)}
+	if !s.lazy {
+		// The matches are found at once, which only keeps their offsets.
+		s.matches = re.FindAllStringIndex(text, -1)
+	}
+	return s
+}
+
+// searchableFromAnyOffset reports whether searching re in text[pos:] finds
+// the matches it finds from pos in text, which is the case unless it has
+// assertions about the character before the match: ^, \A, \b or \B.
+func searchableFromAnyOffset(re *regexp.Regexp) bool {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return false
+	}
+	var walk func(*syntax.Regexp) bool
+	walk = func(r *syntax.Regexp) bool {
+		switch r.Op {
+		case syntax.OpBeginLine, syntax.OpBeginText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+			return false
+		}
+		for _, sub := range r.Sub {
+			if !walk(sub) {
+				return false
+			}
+		}
+		return true
+	}
+	return walk(parsed)
+}
+
+// nextMatch returns the offsets of the next match, or nil after the last.
+func (s *Splitter) nextMatch() []int {
+	if !s.lazy {
+		if len(s.matches) == 0 {
+			return nil
+		}
+		m := s.matches[0]
+		s.matches = s.matches[1:]
+		return m
+	}
+
+	for s.pos <= len(s.text) {
+		m := s.re.FindStringIndex(s.text[s.pos:])
+		if m == nil {
+			s.pos = len(s.text) + 1
+			return nil
+		}
+		m[0] += s.pos
+		m[1] += s.pos
+
+		accept := true
+		if m[1] == s.pos {
+			// An empty match right after the previous match is ignored, and
+			// the search moves on by a character.
+			if m[0] == s.prevMatchEnd {
+				accept = false
+			}
+			if _, width := utf8.DecodeRuneInString(s.text[s.pos:]); width > 0 {
+				s.pos += width
+			} else {
+				s.pos = len(s.text) + 1
+			}
+		} else {
+			s.pos = m[1]
+		}
+		s.prevMatchEnd = m[1]
+		if accept {
+			return m
+		}
+	}
+	return nil
+}
+
+// Next returns the byte offsets of the next part of the text, reporting
+// false after the last one.
+func (s *Splitter) Next() (int, int, bool) {
+	if s.done {
+		return 0, 0, false
+	}
+	if s.re.String() != "" && s.text == "" {
+		// The only part of the empty text is itself.
+		s.done = true
+		return 0, 0, true
+	}
+	for {
+		m := s.nextMatch()
+		if m == nil {
+			s.done = true
+			if s.end != len(s.text) {
+				return s.beg, len(s.text), true
+			}
+			return 0, 0, false
+		}
+		s.end = m[0]
+		beg := s.beg
+		s.beg = m[1]
+		// An empty match at the start of the text does not split it.
+		if m[1] != 0 {
+			return beg, m[0], true
+		}
+	}
+}
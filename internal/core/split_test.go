@@ -0,0 +1,42 @@
+package core
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestSplitterMatchesSplit(t *testing.T) {
+	patterns := []string{`,`, `\s*,\s*`, `a*`, `x*`, ``, `(?m)$`, `$`, `\b`, `^a`, `(?m)^`, `é|ß`, `\d+`}
+	texts := []string{"", "a", "a,b,,c", " a , b ,c, ", "abaabaccadaaae", "héllo wörld ß", "one\ntwo\nthree\n", "aaa", "a1b22c333"}
+
+	for _, p := range patterns {
+		re := regexp.MustCompile(p)
+		for _, text := range texts {
+			expected := re.Split(text, -1)
+			s := NewSplitter(re, text)
+			var got []string
+			for {
+				start, end, ok := s.Next()
+				if !ok {
+					break
+				}
+				got = append(got, text[start:end])
+			}
+			if len(expected) == 0 && len(got) == 0 {
+				continue
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("Split of %q at %q: got %q, expected %q", text, p, got, expected)
+			}
+		}
+	}
+}
+
+func TestSplitterSearchesLazily(t *testing.T) {
+	for pattern, lazy := range map[string]bool{`,`: true, `a$`: true, `^a`: false, `\bx`: false, `(?m)^`: false, `(a|\B)`: false} {
+		if got := NewSplitter(regexp.MustCompile(pattern), "").lazy; got != lazy {
+			t.Errorf("Splitter of %q lazy = %v, expected %v", pattern, got, lazy)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"bytes"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// CaseFolding selects how the match functions compare texts with the i flag.
+type CaseFolding int
+
+const (
+	// FoldSimple is the case folding of Go's regular expressions, which maps
+	// one character to one: ß does not match "ss", nor ı "I".
+	FoldSimple CaseFolding = iota
+	// FoldFull is Unicode full case folding, which maps characters to
+	// sequences: "straße" matches "STRASSE" and "ﬁle" matches "FILE".
+	FoldFull
+	// FoldTurkic is full case folding with the Turkish and Azerbaijani rules
+	// for the dotted and dotless i: I matches ı, and İ matches i.
+	FoldTurkic
+)
+
+// Normalization selects the Unicode normalization form the match functions
+// bring texts and patterns to before matching.
+type Normalization int
+
+const (
+	// NormNone matches texts as they are: "é" written as e and a combining
+	// accent does not match the single character é.
+	NormNone Normalization = iota
+	// NormNFC composes characters canonically, so that both spellings of é
+	// match each other.
+	NormNFC
+	// NormNFKC also replaces compatibility characters, such as ligatures,
+	// full-width letters and superscripts, with their plain equivalents.
+	NormNFKC
+)
+
+// textForm is how a match function transforms its text before matching it.
+// Its pattern is compiled with the same transformation applied to its
+// literals, so that both compare alike.
+type textForm struct {
+	norm Normalization
+	fold CaseFolding
+}
+
+// formOf returns the textForm of the calls of the match functions with
+// flags. Full case folding only applies with the i flag, given to the call
+// or by default, rather than inline in the pattern.
+func (c *Config) formOf(flags Flags) textForm {
+	form := textForm{norm: c.Normalization}
+	if c.caseInsensitive(flags) {
+		form.fold = c.CaseFolding
+	}
+	return form
+}
+
+// translatedForm returns the textForm of GLOB and LIKE, whose case
+// sensitivity is only set by flags, not by Config.CaseInsensitive.
+func (c *Config) translatedForm(flags Flags) textForm {
+	form := textForm{norm: c.Normalization}
+	if flags&FlagCaseInsensitive != 0 {
+		form.fold = c.CaseFolding
+	}
+	return form
+}
+
+// text returns s in form f.
+func (f textForm) text(s string) string {
+	switch f.norm {
+	case NormNFC:
+		s = norm.NFC.String(s)
+	case NormNFKC:
+		s = norm.NFKC.String(s)
+	}
+	switch f.fold {
+	case FoldFull:
+		s = folder.String(s)
+	case FoldTurkic:
+		s = folder.String(strings.Map(turkic, s))
+	}
+	return s
+}
+
+// bytes returns b in form f, which may be b itself.
+func (f textForm) bytes(b []byte) []byte {
+	switch f.norm {
+	case NormNFC:
+		b = norm.NFC.Bytes(b)
+	case NormNFKC:
+		b = norm.NFKC.Bytes(b)
+	}
+	switch f.fold {
+	case FoldFull:
+		b = folder.Bytes(b)
+	case FoldTurkic:
+		b = folder.Bytes(bytes.Map(turkic, b))
+	}
+	return b
+}
+
+// folder implements full case folding; it is stateless and can be shared.
+var folder = cases.Fold()
+
+// turkic maps the letters whose Turkic case folding differs from the
+// default one to the result of the former.
+func turkic(r rune) rune {
+	switch r {
+	case 'I':
+		return 'ı'
+	case 'İ':
+		return 'i'
+	}
+	return r
+}
+
+// compile compiles pattern with flags for texts in form f, transforming its
+// literals like the texts. With full case folding, the pattern is parsed
+// without the i flag, so that its literals are folded as written rather
+// than as Go's case folding records them ('i' as 'I'), and compiled with it,
+// so that its character classes match the folded texts.
+func (f textForm) compile(pattern string, flags Flags) (*regexp.Regexp, error) {
+	parseFlags := flags
+	if f.fold != FoldSimple {
+		parseFlags &^= FlagCaseInsensitive
+	}
+	// Compiling first reports the errors of the pattern as written.
+	re, err := parseFlags.compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	f.literals(parsed)
+	return (flags & (FlagCaseInsensitive | FlagLongest)).compile(parsed.String())
+}
+
+// literals transforms the literals of re in place.
+func (f textForm) literals(re *syntax.Regexp) {
+	if re.Op == syntax.OpLiteral {
+		re.Rune = []rune(f.text(string(re.Rune)))
+	}
+	for _, sub := range re.Sub {
+		f.literals(sub)
+	}
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestTextForm(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CaseFolding = FoldFull
+	cfg.Normalization = NormNFC
+	cfg.Cache = NewCache()
+	funcs := Functions(&cfg)
+
+	tests := []struct {
+		function string
+		args     []any
+		expected any
+	}{
+		{"regexp", []any{`^straße$`, "STRASSE", "i"}, int64(1)},
+		{"regexp", []any{`^straße$`, []byte("STRASSE"), "i"}, int64(1)},
+		{"regexp", []any{`^straße$`, "STRASSE"}, int64(0)},
+		// Escapes are left alone: \S is not folded to \s.
+		{"regexp", []any{`^\S+ß$`, "GROSS", "i"}, int64(1)},
+		{"regexp", []any{`^(?-i:ABC)$`, "abc", "i"}, int64(1)},
+		{"regexp", []any{"^é$", "é"}, int64(1)},
+		{"regexp_like", []any{"MASSE", `^maße$`, "i"}, int64(1)},
+		{"regexp_full_match", []any{"STRASSE", `straße|x`, "i"}, int64(1)},
+		{"regexp_full_match", []any{"STRASSEN", `straße`, "i"}, int64(0)},
+	}
+	for _, tt := range tests {
+		f := findFunction(funcs, tt.function)
+		result, err := f.Impl(tt.args...)
+		if err != nil || result != tt.expected {
+			t.Errorf("%s%q = %v, %v; expected %v", tt.function, tt.args, result, err, tt.expected)
+		}
+		eval := f.Prepare(tt.args...)
+		if eval == nil {
+			t.Errorf("%s%q was not prepared", tt.function, tt.args)
+			continue
+		}
+		if prepared, _ := eval(tt.args...); prepared != result {
+			t.Errorf("%s%q: prepared %v, Impl returned %v", tt.function, tt.args, prepared, result)
+		}
+	}
+
+	// The forms are cached apart from the plain patterns.
+	if _, err := cfg.compileMatch(`straße`, FlagCaseInsensitive); err != nil {
+		t.Fatal(err)
+	}
+	re, _ := cfg.Compile(`straße`, FlagCaseInsensitive)
+	if re.MatchString("STRASSE") {
+		t.Errorf("Compile returned the pattern compiled for folded texts")
+	}
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+)
+
+// WindowMatch is a match of a pattern across consecutive rows.
+type WindowMatch struct {
+	// StartKey and EndKey are the keys of the first and last rows spanned by
+	// the match.
+	StartKey any
+	EndKey   any
+	// Rows is the number of rows spanned by the match.
+	Rows int
+	// Text is the matched text, rows being joined with "\n".
+	Text string
+}
+
+type windowRow struct {
+	key  any
+	text string
+}
+
+// WindowMatcher finds matches of a pattern in the text of a sequence of rows
+// joined with "\n", looking at most Size rows ahead of the row a match starts
+// in. Rows are pushed in order and only the last Size rows are kept, so
+// arbitrarily long sequences can be scanned.
+type WindowMatcher struct {
+	re   *regexp.Regexp
+	size int
+	rows []windowRow
+}
+
+// NewWindowMatcher returns a matcher for re over windows of size rows.
+func NewWindowMatcher(re *regexp.Regexp, size int) *WindowMatcher {
+	if size < 1 {
+		size = 1
+	}
+	return &WindowMatcher{re: re, size: size}
+}
+
+// Push adds the next row and returns the matches starting in the row that
+// left the window, if any.
+func (w *WindowMatcher) Push(key any, text string) []WindowMatch {
+	w.rows = append(w.rows, windowRow{key: key, text: text})
+	if len(w.rows) < w.size {
+		return nil
+	}
+	matches := w.matchFirst()
+	w.rows = w.rows[1:]
+	return matches
+}
+
+// Flush returns the matches starting in the rows still in the window, once
+// every row has been pushed.
+func (w *WindowMatcher) Flush() []WindowMatch {
+	var matches []WindowMatch
+	for len(w.rows) > 0 {
+		matches = append(matches, w.matchFirst()...)
+		w.rows = w.rows[1:]
+	}
+	return matches
+}
+
+// matchFirst returns the matches of the window starting in its first row.
+// Later rows are only context: matches starting there are found once they
+// become the first row themselves.
+func (w *WindowMatcher) matchFirst() []WindowMatch {
+	offsets := make([]int, len(w.rows))
+	var b strings.Builder
+	for i, row := range w.rows {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		offsets[i] = b.Len()
+		b.WriteString(row.text)
+	}
+	text := b.String()
+
+	firstEnd := len(w.rows[0].text) + 1
+	var matches []WindowMatch
+	for _, loc := range w.re.FindAllStringIndex(text, -1) {
+		if loc[0] >= firstEnd {
+			break
+		}
+		if loc[0] == loc[1] {
+			continue
+		}
+		last := 0
+		for i, off := range offsets {
+			if off <= loc[1]-1 {
+				last = i
+			}
+		}
+		matches = append(matches, WindowMatch{
+			StartKey: w.rows[0].key,
+			EndKey:   w.rows[last].key,
+			Rows:     last + 1,
+			Text:     text[loc[0]:loc[1]],
+		})
+	}
+	return matches
+}
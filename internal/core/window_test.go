@@ -0,0 +1,48 @@
+package core
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestWindowMatcher(t *testing.T) {
+	lines := []string{
+		"INFO start",
+		"ERROR Exception: boom",
+		"  at a()",
+		"  at b()",
+		"INFO recovered",
+		"ERROR Exception: again",
+	}
+
+	re := regexp.MustCompile(`Exception[^\n]*(\n  at [^\n]*)*`)
+	w := NewWindowMatcher(re, 3)
+	var matches []WindowMatch
+	for i, line := range lines {
+		matches = append(matches, w.Push(int64(i+1), line)...)
+	}
+	matches = append(matches, w.Flush()...)
+
+	expected := []WindowMatch{
+		{StartKey: int64(2), EndKey: int64(4), Rows: 3, Text: "Exception: boom\n  at a()\n  at b()"},
+		{StartKey: int64(6), EndKey: int64(6), Rows: 1, Text: "Exception: again"},
+	}
+	if len(matches) != len(expected) {
+		t.Fatalf("Got %d matches, expected %d: %+v", len(matches), len(expected), matches)
+	}
+	for i := range expected {
+		if matches[i] != expected[i] {
+			t.Errorf("Match %d = %+v, expected %+v", i, matches[i], expected[i])
+		}
+	}
+
+	// A window of 2 rows cuts the first trace short.
+	w = NewWindowMatcher(re, 2)
+	matches = nil
+	for i, line := range lines {
+		matches = append(matches, w.Push(int64(i+1), line)...)
+	}
+	if len(matches) == 0 || matches[0].Rows != 2 {
+		t.Errorf("Expected the first match to span 2 rows, got %+v", matches)
+	}
+}
@@ -0,0 +1,188 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestJSONFunctions(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT regexp_find_all('a1 b22 c333', '\d+')`, `["1","22","333"]`},
+		{`SELECT regexp_find_all('abc', '\d+')`, `[]`},
+		{`SELECT regexp_find_all('say "hi"', '".*"')`, `["\"hi\""]`},
+		{`SELECT regexp_captures('k1=v1 k2=', '(\w+)=(\w+)?')`, `[["k1=v1","k1","v1"],["k2=","k2",null]]`},
+		{`SELECT regexp_tokenize('a, b,,c ', '[,\s]+')`, `["a","b","c"]`},
+		{`SELECT regexp_find_all(12345, '\d{2}')`, `["12","34"]`},
+		{`SELECT regexp_extract_all('a1 b22 c333', '[a-z](\d+)')`, `["a1","b22","c333"]`},
+		{`SELECT regexp_extract_all('a1 b22 c333', '[a-z](\d+)', 1)`, `["1","22","333"]`},
+		{`SELECT regexp_extract_all('k1=v1 k2=', '(?P<k>\w+)=(?P<v>\w+)?', 'v')`, `["v1",null]`},
+		{`SELECT regexp_extract_all('A1 a2', 'a(\d)', 1, 'i')`, `["1","2"]`},
+		{`SELECT regexp_extract_all('abc', '\d')`, `[]`},
+		{`SELECT group_concat(value, '+') FROM json_each(regexp_extract_all('x=1, y=22', '=(\d+)', 1))`, `1+22`},
+	}
+
+	for _, test := range tests {
+		var result string
+		if err := db.QueryRow(test.query).Scan(&result); err != nil {
+			t.Errorf("%s failed: %v", test.query, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("%s = %s, expected %s", test.query, result, test.expected)
+		}
+	}
+
+	var result sql.NullString
+	if err := db.QueryRow(`SELECT regexp_find_all(NULL, 'a')`).Scan(&result); err != nil {
+		t.Fatalf("NULL query failed: %v", err)
+	}
+	if result.Valid {
+		t.Errorf("Expected NULL for NULL text, got %q", result.String)
+	}
+	if err := db.QueryRow(`SELECT regexp_extract_all('a', '(a)', NULL)`).Scan(&result); err != nil || result.Valid {
+		t.Errorf("Expected NULL for NULL group, got %+v, %v", result, err)
+	}
+	for _, query := range []string{
+		`SELECT regexp_extract_all('a', '(a)', 2)`,
+		`SELECT regexp_extract_all('a', '(a)', 'nope')`,
+	} {
+		if err := db.QueryRow(query).Scan(&result); err == nil {
+			t.Errorf("%s: expected an error for an invalid group", query)
+		}
+	}
+}
+
+func TestJSONFunctionMaxResultSize(t *testing.T) {
+	text := strings.Repeat("a", 100)
+
+	db, err := OpenWithRegexp(":memory:", WithMaxResultSize(64))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var result sql.NullString
+	err = db.QueryRow(`SELECT regexp_find_all(?, '.')`, text).Scan(&result)
+	if err == nil {
+		t.Fatalf("Expected error for oversized result, got %q", result.String)
+	}
+	if !strings.Contains(err.Error(), "regexp_find_all: result exceeds maximum size of 64 bytes") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Small results are unaffected by the limit.
+	if err := db.QueryRow(`SELECT regexp_find_all('abc', '.')`).Scan(&result); err != nil {
+		t.Fatalf("Small result failed: %v", err)
+	}
+	if result.String != `["a","b","c"]` {
+		t.Errorf("Unexpected small result %q", result.String)
+	}
+}
+
+func TestJSONFunctionOverflowNull(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:", WithMaxResultSize(16), WithOverflowMode(OverflowNull))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var result sql.NullString
+	if err := db.QueryRow(`SELECT regexp_tokenize(?, ' ')`, strings.Repeat("word ", 20)).Scan(&result); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result.Valid {
+		t.Errorf("Expected NULL for oversized result, got %q", result.String)
+	}
+}
+
+func TestJSONFunctionOverflowEnvelope(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:",
+		WithMaxResultSize(20),
+		WithMaxInputLength(64),
+		WithOverflowMode(OverflowEnvelope),
+	)
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tests := []struct {
+		query    string
+		args     []any
+		expected string
+	}{
+		// Results within the caps are returned unchanged.
+		{`SELECT regexp_find_all('a1 b2', '\d')`, nil, `["1","2"]`},
+		{`SELECT regexp_find_all('abc', '\d')`, nil, `[]`},
+		{`SELECT regexp_find_all(?, '\w+')`, []any{"one two three four five"},
+			`{"truncated":true,"reason":"result_size","partial":["one","two","three"]}`},
+		{`SELECT regexp_captures(?, '(\w)\w+')`, []any{"one two three"},
+			`{"truncated":true,"reason":"result_size","partial":[["one","o"]]}`},
+		{`SELECT regexp_find_all(?, 'x')`, []any{strings.Repeat("-", 63) + "xx"},
+			`{"truncated":true,"reason":"input_length","partial":["x"]}`},
+	}
+
+	for _, test := range tests {
+		var result string
+		if err := db.QueryRow(test.query, test.args...).Scan(&result); err != nil {
+			t.Errorf("%s failed: %v", test.query, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("%s = %s, expected %s", test.query, result, test.expected)
+		}
+	}
+}
+
+func TestJSONFunctionTruncatedInputNoMatch(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:",
+		WithPostgresCompat(),
+		WithMaxInputLength(3),
+		WithOverflowMode(OverflowEnvelope),
+	)
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// 'x' only occurs past the cut, so nothing matches in the input kept.
+	for _, query := range []string{
+		`SELECT regexp_find_all('abcdefxyz', 'x')`,
+		`SELECT regexp_captures('abcdefxyz', '(x)')`,
+		`SELECT regexp_tokenize('abcdefxyz', 'x')`,
+		`SELECT regexp_extract_all('abcdefxyz', '(x)', 1)`,
+		`SELECT regexp_matches('abcdefxyz', 'x')`,
+		`SELECT regexp_matches('abcdefxyz', 'x', 'g')`,
+		`SELECT regexp_agg_json('abcdefxyz', 'x')`,
+	} {
+		var result string
+		var valid int
+		if err := db.QueryRow(`SELECT r, json_valid(r) FROM (`+query+` AS r)`).Scan(&result, &valid); err != nil {
+			t.Errorf("%s failed: %v", query, err)
+			continue
+		}
+		if valid != 1 || !strings.Contains(result, `"reason":"input_length"`) {
+			t.Errorf("%s = %s, expected a valid input_length envelope", query, result)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package sqlite_regexp
+
+import "github.com/go-go-golems/go-sqlite-regexp/internal/core"
+
+// PatternLibrary is a set of named patterns that other patterns can include
+// with {{name}}, so that shared fragments are written once:
+//
+//	lib := sqlite_regexp.NewPatternLibrary()
+//	_ = lib.Define("ipv4", `\d{1,3}(?:\.\d{1,3}){3}`)
+//	_ = lib.Define("port", `\d{1,5}`)
+//	_ = lib.Define("endpoint", `{{ipv4}}:{{port}}`)
+//
+//	db, err := sqlite_regexp.OpenWithRegexp("app.db", sqlite_regexp.WithPatternLibrary(lib))
+//	// SELECT * FROM conns WHERE addr REGEXP '^{{endpoint}}$'
+//
+// Includes are expanded when a pattern is compiled, each wrapped in a
+// non-capturing group; groups captured inside an included pattern still count
+// when numbering groups. Include cycles are reported as a *PatternCycleError.
+// A PatternLibrary is safe for concurrent use and may be changed while
+// queries run.
+//
+// Every Define, Remove and RollbackPattern is recorded as a new version of
+// the pattern, with its author and time. When built with the sqlite_vtable
+// tag, the history can be queried from the regexp_pattern_history table.
+type PatternLibrary = core.Library
+
+// PatternCycleError is returned when library patterns include each other in
+// a loop. Path lists the names of the loop.
+type PatternCycleError = core.CycleError
+
+// NewPatternLibrary returns an empty pattern library.
+func NewPatternLibrary() *PatternLibrary {
+	return core.NewLibrary()
+}
+
+// PatternVersion is one entry of the history of a library pattern, as
+// returned by PatternLibrary.History and Versions.
+type PatternVersion = core.Version
+
+// PatternChangeOption sets the metadata recorded with a change of a
+// PatternLibrary.
+type PatternChangeOption = core.ChangeOption
+
+// ByAuthor records author as the author of a library change:
+//
+//	err := lib.Define("invoice", `INV-\d{6}`, sqlite_regexp.ByAuthor("alice"))
+func ByAuthor(author string) PatternChangeOption {
+	return core.ByAuthor(author)
+}
+
+// Because records reason as the reason for a library change.
+func Because(reason string) PatternChangeOption {
+	return core.Because(reason)
+}
+
+// Approver approves changes of a PatternLibrary, see
+// PatternLibrary.SetApprover. Define, Remove and RollbackPattern only apply a
+// change once Approve returns nil; otherwise they return a *RejectedError
+// wrapping its error.
+type Approver = core.Approver
+
+// ApproverFunc adapts a function to the Approver interface.
+type ApproverFunc = core.ApproverFunc
+
+// PatternChangeRequest describes a library change submitted to an Approver:
+// the version that would be recorded, with its author and reason, and the
+// current version of the pattern.
+type PatternChangeRequest = core.ChangeRequest
+
+// RejectedError is returned when an Approver rejects a library change.
+type RejectedError = core.RejectedError
@@ -0,0 +1,63 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestPatternLibrary(t *testing.T) {
+	lib := NewPatternLibrary()
+	for _, def := range [][2]string{
+		{"ipv4", `\d{1,3}(?:\.\d{1,3}){3}`},
+		{"port", `\d{1,5}`},
+		{"endpoint", `{{ipv4}}:{{port}}`},
+	} {
+		if err := lib.Define(def[0], def[1]); err != nil {
+			t.Fatalf("Define(%q) failed: %v", def[0], err)
+		}
+	}
+
+	db, err := OpenWithRegexp(":memory:", WithPatternLibrary(lib))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tests := []struct {
+		query    string
+		expected sql.NullString
+	}{
+		{`SELECT '10.0.0.1:8080' REGEXP '^{{endpoint}}$'`, sql.NullString{String: "1", Valid: true}},
+		{`SELECT 'localhost:8080' REGEXP '^{{endpoint}}$'`, sql.NullString{String: "0", Valid: true}},
+		{`SELECT regexp_extract('peer 10.0.0.1:53 up', '{{ipv4}}:({{port}})', 1)`, sql.NullString{String: "53", Valid: true}},
+		{`SELECT regexp_find_all('1.2.3.4 and 5.6.7.8', '{{ipv4}}')`, sql.NullString{String: `["1.2.3.4","5.6.7.8"]`, Valid: true}},
+	}
+	for _, test := range tests {
+		var result sql.NullString
+		if err := db.QueryRow(test.query).Scan(&result); err != nil {
+			t.Errorf("%s failed: %v", test.query, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("%s = %+v, expected %+v", test.query, result, test.expected)
+		}
+	}
+
+	var result int
+	if err := db.QueryRow(`SELECT 'x' REGEXP '{{nope}}'`).Scan(&result); err == nil {
+		t.Error("Expected error for unknown pattern, got nil")
+	}
+
+	// Redefining a pattern takes effect on the next query.
+	if err := lib.Define("port", `80`); err != nil {
+		t.Fatalf("Define failed: %v", err)
+	}
+	if err := db.QueryRow(`SELECT '10.0.0.1:8080' REGEXP '^{{endpoint}}$'`).Scan(&result); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result != 0 {
+		t.Error("Expected redefined pattern to be used")
+	}
+}
@@ -0,0 +1,136 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestUnicodeLike(t *testing.T) {
+	builtin, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() {
+		_ = builtin.Close()
+	}()
+
+	db, err := OpenWithRegexp(":memory:", WithUnicodeLike())
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// The replacement agrees with SQLite's LIKE on ASCII text.
+	cases := [][3]string{
+		{"Report.TXT", "%.txt", ""},
+		{"abc", "a_c", ""},
+		{"ac", "a_c", ""},
+		{"a\nb", "a%b", ""},
+		{"100%", "100!%", "!"},
+		{"1000", "100!%", "!"},
+		{"a_b", "a!_b", "!"},
+		{"axb", "a!_b", "!"},
+		{"ab", "ab!", "!"},
+	}
+	for _, c := range cases {
+		query, args := `SELECT ? LIKE ?`, []any{c[0], c[1]}
+		if c[2] != "" {
+			query, args = `SELECT ? LIKE ? ESCAPE ?`, []any{c[0], c[1], c[2]}
+		}
+		var expected, got int
+		if err := builtin.QueryRow(query, args...).Scan(&expected); err != nil {
+			t.Fatalf("Built-in LIKE failed: %v", err)
+		}
+		if err := db.QueryRow(query, args...).Scan(&got); err != nil {
+			t.Errorf("%v failed: %v", args, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("%v: got %d, SQLite returns %d", args, got, expected)
+		}
+	}
+
+	// Unlike the built-in LIKE, non-ASCII letters are folded too.
+	var result int
+	if err := db.QueryRow(`SELECT 'ÉCOLE' LIKE 'école'`).Scan(&result); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if result != 1 {
+		t.Error("Expected LIKE to fold non-ASCII letters")
+	}
+
+	if err := db.QueryRow(`SELECT 'a' LIKE 'a' ESCAPE '!!'`).Scan(&result); err == nil {
+		t.Error("Expected error for multi-character ESCAPE, got nil")
+	}
+}
+
+func TestUnicodeLikeOptions(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:", WithUnicodeLike(), WithLikeEscape('\\'), WithCaseSensitiveLike())
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{`SELECT '100%' LIKE '100\%'`, 1},
+		{`SELECT '1000' LIKE '100\%'`, 0},
+		{`SELECT 'École' LIKE 'école'`, 0},
+		{`SELECT 'École' LIKE 'École'`, 1},
+		// An ESCAPE clause overrides the default escape character.
+		{`SELECT 'a\b' LIKE 'a\b' ESCAPE '!'`, 1},
+	}
+	for _, test := range tests {
+		var result int
+		if err := db.QueryRow(test.query).Scan(&result); err != nil {
+			t.Errorf("%s failed: %v", test.query, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("%s = %d, expected %d", test.query, result, test.expected)
+		}
+	}
+}
+
+func TestUnicodeLikeGlobTextForm(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:", WithUnicodeLike(), WithUnicodeGlob(),
+		WithCaseFolding(FoldFull), WithNormalization(NormNFC))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// LIKE and GLOB agree with REGEXP on the case folding and normalization.
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{`SELECT regexp('STRASSE', 'straße', 'i')`, 1},
+		{`SELECT 'straße' LIKE 'STRASSE'`, 1},
+		{`SELECT 'STRASSE' LIKE '%ß%'`, 1},
+		{`SELECT glob('STRASSE', 'straße', 'i')`, 1},
+		{`SELECT 'straße' GLOB 'STRASSE'`, 0},
+		{`SELECT regexp('e' || char(769), char(233))`, 1},
+		{`SELECT char(233) GLOB 'e' || char(769)`, 1},
+		{`SELECT 'e' || char(769) || 'cole' GLOB char(233) || '*'`, 1},
+		{`SELECT char(233) LIKE 'E' || char(769)`, 1},
+	}
+	for _, test := range tests {
+		var result int
+		if err := db.QueryRow(test.query).Scan(&result); err != nil {
+			t.Errorf("%s failed: %v", test.query, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("%s = %d, expected %d", test.query, result, test.expected)
+		}
+	}
+}
@@ -1,3 +1,3 @@
 package sqlite_regexp
 
-//go:generate go tool logcopter-gen -area-prefix go-go-golems.go-sqlite-regexp -strip-prefix github.com/go-go-golems/go-sqlite-regexp . ./extension
+//go:generate go tool logcopter-gen -area-prefix go-go-golems.go-sqlite-regexp -strip-prefix github.com/go-go-golems/go-sqlite-regexp . ./internal/core ./driver ./engine ./entregexp ./expvarmetrics ./gormregexp ./metrics ./sampledata ./sqlitetest ./sqlxregexp ./tracing ./cmd/regexp-extension
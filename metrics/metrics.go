@@ -0,0 +1,154 @@
+// Package metrics exports Prometheus metrics for the regexp function suite:
+//
+//	collector := metrics.NewCollector()
+//	defer collector.Close()
+//	prometheus.MustRegister(collector)
+//
+// The collector reports, for every database using the suite in the process,
+// the cache statistics being those of the process-wide cache (see
+// sqlite_regexp.WithCache):
+//
+//	sqlite_regexp_cache_size                  patterns in the compiled pattern cache
+//	sqlite_regexp_cache_hits_total            cache lookups that found the pattern
+//	sqlite_regexp_cache_misses_total          cache lookups that compiled it
+//	sqlite_regexp_compile_errors_total        cache misses for invalid patterns
+//	sqlite_regexp_cache_expired_total         patterns unused for the cache TTL
+//	sqlite_regexp_cache_bytes                 estimated memory of the cache
+//	sqlite_regexp_cache_evicted_total         patterns evicted for the memory budget
+//	sqlite_regexp_registrations_total         connections the suite was registered on
+//	sqlite_regexp_calls_total{function}       evaluations of each function
+//	sqlite_regexp_call_errors_total{function} evaluations that failed
+//	sqlite_regexp_call_duration_seconds{function}
+//
+// The cache hit ratio is
+//
+//	rate(sqlite_regexp_cache_hits_total[5m])
+//	  / (rate(sqlite_regexp_cache_hits_total[5m]) + rate(sqlite_regexp_cache_misses_total[5m]))
+package metrics
+
+import (
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "sqlite_regexp"
+
+var (
+	cacheSizeDesc = prometheus.NewDesc(namespace+"_cache_size",
+		"Number of patterns in the compiled pattern cache.", nil, nil)
+	cacheHitsDesc = prometheus.NewDesc(namespace+"_cache_hits_total",
+		"Compiled pattern cache lookups that found the pattern.", nil, nil)
+	cacheMissesDesc = prometheus.NewDesc(namespace+"_cache_misses_total",
+		"Compiled pattern cache lookups that had to compile the pattern.", nil, nil)
+	compileErrorsDesc = prometheus.NewDesc(namespace+"_compile_errors_total",
+		"Patterns that failed to compile.", nil, nil)
+	cacheExpiredDesc = prometheus.NewDesc(namespace+"_cache_expired_total",
+		"Patterns removed from the cache after their time-to-live.", nil, nil)
+	cacheBytesDesc = prometheus.NewDesc(namespace+"_cache_bytes",
+		"Estimated memory used by the compiled patterns of the cache.", nil, nil)
+	cacheEvictedDesc = prometheus.NewDesc(namespace+"_cache_evicted_total",
+		"Patterns evicted to keep the cache within its memory budget.", nil, nil)
+	registrationsDesc = prometheus.NewDesc(namespace+"_registrations_total",
+		"Connections the function suite was registered on.", nil, nil)
+)
+
+// Collector is a prometheus.Collector for the function suite.
+type Collector struct {
+	calls    *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	remove   func()
+}
+
+var _ prometheus.Collector = &Collector{}
+
+// Option configures a Collector.
+type Option func(*options)
+
+type options struct {
+	buckets []float64
+}
+
+// WithBuckets sets the buckets, in seconds, of the call duration histogram.
+// The default ones range from 1µs to about 1s.
+func WithBuckets(buckets []float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// NewCollector returns a collector observing every evaluation of the
+// functions of the suite until it is closed. Timing evaluations has a small
+// cost, so only create a collector if its metrics are used.
+func NewCollector(opts ...Option) *Collector {
+	o := options{buckets: prometheus.ExponentialBuckets(1e-6, 4, 11)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := &Collector{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "calls_total",
+			Help:      "Evaluations of the regexp functions.",
+		}, []string{"function"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "call_errors_total",
+			Help:      "Evaluations of the regexp functions that failed.",
+		}, []string{"function"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "call_duration_seconds",
+			Help:      "Duration of the evaluations of the regexp functions.",
+			Buckets:   o.buckets,
+		}, []string{"function"}),
+	}
+	c.remove = core.AddObserver(c)
+	return c
+}
+
+// ObserveCall implements core.Observer.
+func (c *Collector) ObserveCall(e core.CallEvent) {
+	c.calls.WithLabelValues(e.Function).Inc()
+	if e.Err != nil {
+		c.errors.WithLabelValues(e.Function).Inc()
+	}
+	c.duration.WithLabelValues(e.Function).Observe(e.Duration.Seconds())
+}
+
+// Close stops observing evaluations. The metrics collected so far are kept.
+func (c *Collector) Close() {
+	c.remove()
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheSizeDesc
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+	ch <- compileErrorsDesc
+	ch <- cacheExpiredDesc
+	ch <- cacheBytesDesc
+	ch <- cacheEvictedDesc
+	ch <- registrationsDesc
+	c.calls.Describe(ch)
+	c.errors.Describe(ch)
+	c.duration.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	stats := core.ReadCacheStats()
+	ch <- prometheus.MustNewConstMetric(cacheSizeDesc, prometheus.GaugeValue, float64(stats.Size))
+	ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(compileErrorsDesc, prometheus.CounterValue, float64(stats.CompileErrors))
+	ch <- prometheus.MustNewConstMetric(cacheExpiredDesc, prometheus.CounterValue, float64(stats.Expired))
+	ch <- prometheus.MustNewConstMetric(cacheBytesDesc, prometheus.GaugeValue, float64(stats.Bytes))
+	ch <- prometheus.MustNewConstMetric(cacheEvictedDesc, prometheus.CounterValue, float64(stats.Evicted))
+	ch <- prometheus.MustNewConstMetric(registrationsDesc, prometheus.CounterValue, float64(stats.Registrations))
+	c.calls.Collect(ch)
+	c.errors.Collect(ch)
+	c.duration.Collect(ch)
+}
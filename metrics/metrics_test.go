@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"testing"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	collector := NewCollector()
+	defer collector.Close()
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(collector); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	db, err := sqlite_regexp.OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var matched bool
+	if err := db.QueryRow(`SELECT 'abc' REGEXP 'b+'`).Scan(&matched); err != nil || !matched {
+		t.Fatalf("REGEXP failed: %v, %v", matched, err)
+	}
+	if _, err := db.Exec(`SELECT regexp_like('abc', '(')`); err == nil {
+		t.Fatal("Invalid pattern accepted")
+	}
+
+	if got := testutil.ToFloat64(collector.calls.WithLabelValues("regexp")); got != 1 {
+		t.Errorf("Got %v regexp calls, expected 1", got)
+	}
+	if got := testutil.ToFloat64(collector.errors.WithLabelValues("regexp_like")); got != 1 {
+		t.Errorf("Got %v regexp_like errors, expected 1", got)
+	}
+	if got := testutil.CollectAndCount(collector, "sqlite_regexp_call_duration_seconds"); got != 2 {
+		t.Errorf("Got %d duration histograms, expected 2", got)
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, f := range families {
+		names[f.GetName()] = true
+	}
+	for _, name := range []string{
+		"sqlite_regexp_cache_size",
+		"sqlite_regexp_cache_hits_total",
+		"sqlite_regexp_cache_misses_total",
+		"sqlite_regexp_compile_errors_total",
+		"sqlite_regexp_cache_expired_total",
+		"sqlite_regexp_cache_bytes",
+		"sqlite_regexp_cache_evicted_total",
+		"sqlite_regexp_registrations_total",
+		"sqlite_regexp_calls_total",
+		"sqlite_regexp_call_errors_total",
+		"sqlite_regexp_call_duration_seconds",
+	} {
+		if !names[name] {
+			t.Errorf("Metric %s not gathered", name)
+		}
+	}
+
+	collector.Close()
+	if _, err := db.Exec(`SELECT 'abc' REGEXP 'c'`); err != nil {
+		t.Fatalf("REGEXP failed: %v", err)
+	}
+	if got := testutil.ToFloat64(collector.calls.WithLabelValues("regexp")); got != 1 {
+		t.Errorf("Closed collector still counting: %v regexp calls", got)
+	}
+}
@@ -0,0 +1,86 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+	"github.com/mattn/go-sqlite3"
+)
+
+// CallEvent describes one evaluation of a function of the suite: the
+// function, its pattern argument, its duration and its error.
+type CallEvent = core.CallEvent
+
+// Observer is notified of evaluations of the functions of the suite, see
+// ObserveConn. ObserveCall runs inside SQLite and must be fast.
+type Observer = core.Observer
+
+// ObserverFunc adapts a function to the Observer interface.
+type ObserverFunc = core.ObserverFunc
+
+var (
+	// connObservers maps the *sqlite3.SQLiteConn being observed to their
+	// Observer.
+	connObservers     sync.Map
+	connObserverCount atomic.Int64
+)
+
+// ObserveConn reports the evaluations of the functions of the suite on conn
+// to o, until the function it returns is called. As a connection runs one statement at a
+// time, this attributes evaluations to the statements run on conn, unlike
+// the process-wide metrics:
+//
+//	conn, err := db.Conn(ctx)
+//	remove, err := sqlite_regexp.ObserveConn(conn, observer)
+//	_, err = conn.ExecContext(ctx, query)
+//	remove()
+//
+// A connection has at most one observer.
+func ObserveConn(conn *sql.Conn, o Observer) (func(), error) {
+	var sc *sqlite3.SQLiteConn
+	err := conn.Raw(func(driverConn any) error {
+		var ok bool
+		if sc, ok = driverConn.(*sqlite3.SQLiteConn); !ok {
+			return errors.New("not a go-sqlite3 connection")
+		}
+		if _, loaded := connObservers.LoadOrStore(sc, o); loaded {
+			return errors.New("connection already observed")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	connObserverCount.Add(1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			connObservers.Delete(sc)
+			connObserverCount.Add(-1)
+		})
+	}, nil
+}
+
+// connObserved wraps the implementation of fn registered on conn so that
+// its evaluations are reported to the observer of conn, if any.
+func connObserved(conn *sqlite3.SQLiteConn, fn core.Function) func(args ...any) (any, error) {
+	impl := fn.Impl
+	return func(args ...any) (any, error) {
+		if connObserverCount.Load() == 0 {
+			return impl(args...)
+		}
+		o, ok := connObservers.Load(conn)
+		if !ok {
+			return impl(args...)
+		}
+		start := time.Now()
+		result, err := impl(args...)
+		o.(Observer).ObserveCall(fn.Event(args, time.Since(start), err))
+		return result, err
+	}
+}
@@ -0,0 +1,74 @@
+package sqlite_regexp
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObserveConn(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn failed: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	var events []CallEvent
+	remove, err := ObserveConn(conn, ObserverFunc(func(e CallEvent) { events = append(events, e) }))
+	if err != nil {
+		t.Fatalf("ObserveConn failed: %v", err)
+	}
+	if _, err := ObserveConn(conn, ObserverFunc(func(CallEvent) {})); err == nil {
+		t.Error("Expected an error observing a connection twice")
+	}
+
+	if _, err := conn.ExecContext(ctx, `SELECT regexp_replace('abc', 'b', 'x')`); err != nil {
+		t.Fatalf("regexp_replace failed: %v", err)
+	}
+	remove()
+	remove()
+	if _, err := conn.ExecContext(ctx, `SELECT 'abc' REGEXP 'b'`); err != nil {
+		t.Fatalf("REGEXP failed: %v", err)
+	}
+
+	if len(events) != 1 || events[0].Function != "regexp_replace" || events[0].Pattern != "b" {
+		t.Errorf("Unexpected events %+v", events)
+	}
+}
+
+func TestSlowCallLog(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer SetLogger(nil)
+
+	db, err := OpenWithRegexp(":memory:", WithSlowCallThreshold(time.Nanosecond))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	if _, err := db.Exec(`SELECT regexp_extract('order 12345', '\d+')`); err != nil {
+		t.Fatalf("regexp_extract failed: %v", err)
+	}
+	log := buf.String()
+	if !strings.Contains(log, "regexp: slow evaluation") || !strings.Contains(log, `text="order 12345"`) ||
+		!strings.Contains(log, `pattern=\d+`) {
+		t.Errorf("Unexpected log %q", log)
+	}
+}
@@ -0,0 +1,602 @@
+package sqlite_regexp
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+)
+
+// DefaultMaxResultSize is the default upper bound, in bytes, for the result
+// of functions that return JSON arrays (regexp_find_all, regexp_captures,
+// regexp_tokenize, regexp_extract_all).
+const DefaultMaxResultSize = core.DefaultMaxResultSize
+
+// OverflowMode controls what a function returns when one of its caps (see
+// WithMaxResultSize and WithMaxInputLength) is reached.
+type OverflowMode = core.OverflowMode
+
+const (
+	// OverflowError makes the function fail with a *ResultTooLargeError or
+	// *InputTooLongError.
+	OverflowError = core.OverflowError
+	// OverflowNull makes the function return NULL.
+	OverflowNull = core.OverflowNull
+	// OverflowEnvelope makes the function return a JSON object of the form
+	// {"truncated":true,"reason":"result_size","partial":[...]}, where partial
+	// holds the elements computed before the cap was reached and reason is
+	// "result_size" or "input_length". Results within the caps are returned
+	// unchanged, so callers can tell "no match" apart from "gave up".
+	OverflowEnvelope = core.OverflowEnvelope
+)
+
+// ResultTooLargeError is returned by the JSON-returning functions when their
+// result would exceed the configured maximum size (see WithMaxResultSize).
+type ResultTooLargeError = core.ResultTooLargeError
+
+// InputTooLongError is returned by the JSON-returning functions when their
+// text argument is longer than the configured maximum (see
+// WithMaxInputLength).
+type InputTooLongError = core.InputTooLongError
+
+// BlobEncoding selects how REGEXP reads BLOB texts (see WithBlobEncoding).
+type BlobEncoding = core.BlobEncoding
+
+const (
+	// BlobUTF8 reads BLOBs as UTF-8, like texts; invalid bytes only match
+	// patterns that match U+FFFD, such as '.'.
+	BlobUTF8 = core.BlobUTF8
+	// BlobLatin1 reads every byte of a BLOB as the character of the same
+	// code point, so that '.' matches any byte and \xff the byte 0xff.
+	BlobLatin1 = core.BlobLatin1
+)
+
+// CaseFolding selects how REGEXP compares texts with the i flag (see
+// WithCaseFolding).
+type CaseFolding = core.CaseFolding
+
+const (
+	// FoldSimple is the case folding of Go's regular expressions, which maps
+	// one character to one: ß does not match "ss", nor ı "I".
+	FoldSimple = core.FoldSimple
+	// FoldFull is Unicode full case folding, which maps characters to
+	// sequences: "straße" matches "STRASSE" and "ﬁle" matches "FILE".
+	FoldFull = core.FoldFull
+	// FoldTurkic is full case folding with the Turkish and Azerbaijani rules
+	// for the dotted and dotless i: I matches ı, and İ matches i.
+	FoldTurkic = core.FoldTurkic
+)
+
+// Normalization selects the Unicode normalization form REGEXP brings texts
+// and patterns to before matching (see WithNormalization).
+type Normalization = core.Normalization
+
+const (
+	// NormNone matches texts as they are: "é" written as e and a combining
+	// accent does not match the single character é.
+	NormNone = core.NormNone
+	// NormNFC composes characters canonically, so that both spellings of é
+	// match each other.
+	NormNFC = core.NormNFC
+	// NormNFKC also replaces compatibility characters, such as ligatures,
+	// full-width letters and superscripts, with their plain equivalents.
+	NormNFKC = core.NormNFKC
+)
+
+// LiteralDialect selects whether and how patterns written as regular
+// expression literals, /body/flags, are read (see WithRegexLiterals).
+type LiteralDialect = core.LiteralDialect
+
+const (
+	// NoLiterals reads every pattern as is: "/a/i" matches the text "/a/i".
+	NoLiterals = core.NoLiterals
+	// JavaScriptLiterals reads /body/flags with JavaScript's flags: i, m and
+	// s as the suite's, g, u and d ignored, y and v rejected.
+	JavaScriptLiterals = core.JavaScriptLiterals
+	// RubyLiterals reads /body/flags with Ruby's flags and anchors: ^ and $
+	// always match at line boundaries, m lets '.' match a newline, i and x
+	// are the suite's, and o and the encodings n, e, s and u are ignored.
+	RubyLiterals = core.RubyLiterals
+)
+
+// ReplacementSyntax selects how regexp_replace reads references to capture
+// groups in its replacement (see WithReplacementSyntax).
+type ReplacementSyntax = core.ReplacementSyntax
+
+const (
+	// ReplaceDollar reads Go's $1, ${1} and ${name}, and $$ for a dollar
+	// sign. As in Go, $1x refers to a group named 1x; ${1}x is group 1
+	// followed by x.
+	ReplaceDollar = core.ReplaceDollar
+	// ReplaceBackslash reads \0 to \9, as sed and MariaDB do, and \\ for a
+	// backslash; a dollar sign is literal.
+	ReplaceBackslash = core.ReplaceBackslash
+	// ReplaceDollarOrBackslash reads both syntaxes.
+	ReplaceDollarOrBackslash = core.ReplaceDollarOrBackslash
+)
+
+// PatternInfo describes a pattern about to be compiled, for a PatternPolicy.
+type PatternInfo = core.PatternInfo
+
+// PatternPolicy decides whether a pattern may be compiled, returning nil to
+// allow it, or the reason it is rejected (see WithPatternPolicy).
+type PatternPolicy = core.PatternPolicy
+
+// PatternLimits are the rules of a PatternPolicy bounding the size and shape
+// of patterns, see PatternLimits.Policy.
+type PatternLimits = core.PatternLimits
+
+// PolicyError is returned for a pattern rejected by the PatternPolicy of
+// the configuration. IsPatternError reports true for it.
+type PolicyError = core.PolicyError
+
+// Option configures how the REGEXP function suite is registered.
+type Option func(*config)
+
+type config struct {
+	core.Config
+	fileTables bool
+	readOnly   bool
+	// only, if set, holds the names of the functions to register, without
+	// the virtual tables, see WithOnlyFunctions.
+	only map[string]bool
+	// collations are the collations of WithCollation.
+	collations []namedCollation
+	// err is the first invalid option, returned by the registration.
+	err error
+}
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{
+		Config: core.DefaultConfig(),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.readOnly && cfg.fileTables && cfg.err == nil {
+		cfg.err = errors.New("file tables cannot be registered in read-only mode")
+	}
+	if err := cfg.resolveOnly(); err != nil && cfg.err == nil {
+		cfg.err = err
+	}
+	return cfg
+}
+
+// WithMaxResultSize sets the maximum size in bytes of the JSON produced by
+// regexp_find_all, regexp_captures, regexp_tokenize and regexp_extract_all. A
+// value of zero or less disables the limit.
+func WithMaxResultSize(n int) Option {
+	return func(c *config) {
+		c.MaxResultSize = n
+	}
+}
+
+// WithMaxInputLength sets the maximum length in bytes of the text processed by
+// the JSON-returning functions. Longer texts trigger the overflow mode; under
+// OverflowEnvelope only their first n bytes are searched. A value of zero or
+// less, the default, disables the limit.
+func WithMaxInputLength(n int) Option {
+	return func(c *config) {
+		c.MaxInputLength = n
+	}
+}
+
+// WithOverflowMode selects what JSON-returning functions do once their input
+// or result exceeds the configured limits.
+func WithOverflowMode(mode OverflowMode) Option {
+	return func(c *config) {
+		c.OverflowMode = mode
+	}
+}
+
+// WithPostgresCompat registers PostgreSQL-style matching functions:
+//
+//	pg_match(text, pattern)          -- text ~ pattern
+//	pg_imatch(text, pattern)         -- text ~* pattern
+//	pg_not_match(text, pattern)      -- text !~ pattern
+//	pg_not_imatch(text, pattern)     -- text !~* pattern
+//	pg_substring(text, pattern)      -- substring(text from pattern)
+//	regexp_matches(text, pattern [, flags])
+//
+// Like PostgreSQL, these functions let '.' match a newline and anchor ^ and $
+// only at the ends of the string unless flags say otherwise.
+func WithPostgresCompat() Option {
+	return func(c *config) {
+		c.Postgres = true
+	}
+}
+
+// WithSimilarityFunctions registers the string similarity functions of
+// PostgreSQL's fuzzystrmatch module, as fuzzy fallbacks for the texts no
+// pattern matches:
+//
+//	levenshtein(a, b [, insert_cost, delete_cost, substitute_cost])
+//	soundex(text)
+//	difference(a, b)                 -- agreeing Soundex positions, 0 to 4
+//	metaphone(text [, max_length])
+func WithSimilarityFunctions() Option {
+	return func(c *config) {
+		c.Similarity = true
+	}
+}
+
+// WithReplacementSyntax sets how regexp_replace reads references to capture
+// groups in its replacement, so that replacements migrated from sed or
+// MariaDB work unchanged:
+//
+//	SELECT regexp_replace('2024-01-31', '(\d+)-(\d+)-(\d+)', '\3/\2/\1');  -- 31/01/2024 with ReplaceBackslash
+//
+// The default, ReplaceDollar, reads Go's $1 and ${name}.
+func WithReplacementSyntax(s ReplacementSyntax) Option {
+	return func(c *config) {
+		c.Replacement = s
+	}
+}
+
+// DefaultBacktrackTimeout is the default time a match of the backtracking
+// engine may take, see WithEngineTags.
+const DefaultBacktrackTimeout = core.DefaultBacktrackTimeout
+
+// WithEngineTags reads a leading re2: or pcre: tag of a pattern as the engine
+// to compile it with, so that most patterns stay on Go's RE2 engine, which
+// matches in linear time, while the few needing lookaround or
+// backreferences use a backtracking engine (github.com/dlclark/regexp2,
+// with Perl and .NET syntax):
+//
+//	SELECT * FROM files WHERE name REGEXP 'pcre:^(?!test_)\w+\.go$'
+//
+// Untagged patterns, and those tagged re2:, use RE2. Only REGEXP,
+// regexp_like, regexp_full_match and the aliases of regexp accept pcre:
+// patterns; the other functions report an error. The backtracking engine
+// does not support the U, l and p flags, nor the CaseFolding and
+// Normalization options, and a match running longer than the timeout set by
+// WithBacktrackTimeout fails the query.
+func WithEngineTags() Option {
+	return func(c *config) {
+		c.EngineTags = true
+	}
+}
+
+// WithBacktrackTimeout sets how long a match of the backtracking engine may
+// take (DefaultBacktrackTimeout by default), guarding against patterns whose
+// matching time grows exponentially. Zero or less disables the limit.
+func WithBacktrackTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.BacktrackTimeout = d
+	}
+}
+
+// WithPatternPolicy consults p before compiling every pattern given to the
+// functions and virtual tables of the suite, failing the call with a
+// *PolicyError for those it rejects, so that services running the SQL of
+// their tenants can bound what they match:
+//
+//	db, err := sqlite_regexp.OpenWithRegexp("tenant.db", sqlite_regexp.WithPatternPolicy(
+//		sqlite_regexp.PatternLimits{MaxLength: 256, MaxRepeatNesting: 2}.Policy()))
+//
+// p sees the pattern as compiled, with its library includes expanded, and
+// the patterns of GLOB, LIKE and SIMILAR TO translated to regular
+// expressions. It is consulted before every lookup of a pattern, cached or
+// not, so it should be fast. regexp_valid and regexp_error report the
+// patterns it rejects as invalid.
+func WithPatternPolicy(p PatternPolicy) Option {
+	return func(c *config) {
+		c.Policy = p
+	}
+}
+
+// WithBlobEncoding sets how REGEXP, regexp_like and the aliases of regexp
+// read BLOB texts, such as binary payloads, which are matched as they are
+// stored rather than through hex(). With BlobLatin1, bytes are matched with
+// escapes:
+//
+//	SELECT * FROM packets WHERE payload REGEXP '^\x16\x03[\x00-\x03]'
+//
+// The default, BlobUTF8, reads them like texts. Patterns are always texts.
+func WithBlobEncoding(enc BlobEncoding) Option {
+	return func(c *config) {
+		c.BlobEncoding = enc
+	}
+}
+
+// WithCaseFolding sets how REGEXP, regexp_like, regexp_full_match and the
+// aliases of regexp compare texts when called with the i flag, as do the
+// GLOB of WithUnicodeGlob with the i flag and the case-insensitive LIKE of
+// WithUnicodeLike. Go's default,
+// FoldSimple, maps one character to one, so that German ß never matches
+// "SS"; FoldFull applies Unicode full case folding to the text and to the
+// literals of the pattern, and FoldTurkic the Turkish rules for i:
+//
+//	SELECT regexp('straße', 'STRASSE', 'i');  -- 1 with FoldFull
+//	SELECT regexp('ırmak', 'IRMAK', 'i');     -- 1 with FoldTurkic
+//
+// Full folding applies to the whole pattern, including its (?-i) groups,
+// and only with the i flag of the call, not an inline (?i). Character
+// classes still match one character: [ß] does not match "ss". The other
+// functions, whose results are parts of the text, keep Go's case folding.
+func WithCaseFolding(f CaseFolding) Option {
+	return func(c *config) {
+		c.CaseFolding = f
+	}
+}
+
+// WithNormalization makes REGEXP, regexp_like, regexp_full_match, the
+// aliases of regexp and the GLOB and LIKE of WithUnicodeGlob and
+// WithUnicodeLike bring texts and the literals of patterns to the Unicode
+// normalization form n before matching, so that texts spelling the same
+// characters differently, such as é as e and a combining accent, match
+// alike. The other functions, whose results are parts of the text, match
+// the text as it is.
+func WithNormalization(n Normalization) Option {
+	return func(c *config) {
+		c.Normalization = n
+	}
+}
+
+// WithLongestMatch makes every pattern prefer the leftmost-longest match, as
+// POSIX regular expressions do, to the leftmost-first one of Perl and RE2,
+// like the l flag does for a single call:
+//
+//	SELECT regexp_extract('abcd', 'a|ab|abc');        -- a, or abc with the option
+//	SELECT regexp_extract('abcd', 'a|ab|abc', 0, 'l'); -- abc
+//
+// Only the matches are affected, not the syntax, which stays that of RE2
+// rather than POSIX ERE; non-greedy quantifiers such as x*? then match as
+// much as x*. Whether a pattern matches does not change.
+func WithLongestMatch() Option {
+	return func(c *config) {
+		c.Longest = true
+	}
+}
+
+// WithPOSIXClasses translates the POSIX elements of the bracket expressions
+// of every pattern, as the p flag does for a single call, so that patterns
+// copied from grep or awk match as they did there:
+//
+//	SELECT regexp('^[[:alpha:]]+$', 'école');     -- 1, 0 without the option
+//	SELECT regexp('^caf[[=e=]]$', 'café');         -- 1, 0 without the option
+//
+// The classes, such as [[:alpha:]], become Unicode-aware instead of ASCII
+// only; equivalence classes, such as [[=e=]], match the letter and its
+// accented forms; and collating elements of one character, such as [[.-.]],
+// match the character. A class written without its brackets, [:digit:],
+// is reported as an error instead of matching its own letters.
+func WithPOSIXClasses() Option {
+	return func(c *config) {
+		c.POSIXClasses = true
+	}
+}
+
+// WithRegexLiterals reads the patterns written as regular expression
+// literals of dialect d, such as /^ab+c$/i, as their body with their flags,
+// so that pattern tables migrated from JavaScript or Ruby keep working
+// unchanged:
+//
+//	SELECT regexp('/^ord-\d+$/i', 'ORD-42');   -- 1 with JavaScriptLiterals
+//
+// A pattern is a literal if it starts with '/' and its last unescaped '/' is
+// only followed by flags of d; "/usr/local" stays a plain pattern. Literal
+// flags add to those of the call. Whatever the dialect, a group of the
+// suite's flags leading a pattern, such as (?x) or (?il), is read as flags
+// too, although RE2 itself does not accept all of them.
+func WithRegexLiterals(d LiteralDialect) Option {
+	return func(c *config) {
+		c.Literals = d
+	}
+}
+
+// WithDefaultCaseInsensitive compiles every pattern ignoring case, as if
+// it were called with the i flag, so that patterns matched against
+// case-normalized text need no (?i) prefix. A pattern opts out with the c
+// flag, given to the call or leading the pattern:
+//
+//	SELECT 'ABC' REGEXP 'abc';             -- 1
+//	SELECT regexp('abc', 'ABC', 'c');      -- 0
+//	SELECT 'ABC' REGEXP '(?c)abc';         -- 0
+//
+// The other flags of a call do not opt out: regexp('^abc', 'ABC', 'm') is 1.
+func WithDefaultCaseInsensitive() Option {
+	return func(c *config) {
+		c.CaseInsensitive = true
+	}
+}
+
+// WithUnicodeGlob replaces SQLite's built-in GLOB operator with an
+// implementation that translates the pattern to a regular expression, so that
+// GLOB and REGEXP agree on what a character is and on case rules, including
+// those of WithCaseFolding and WithNormalization. The replacement also
+// accepts a flags argument, e.g. glob('*é*', name, 'i') for a Unicode
+// case-insensitive GLOB.
+//
+// SQLite only uses indexes to speed up GLOB 'prefix*' with its built-in
+// implementation, so such queries may get slower.
+func WithUnicodeGlob() Option {
+	return func(c *config) {
+		c.UnicodeGlob = true
+	}
+}
+
+// WithUnicodeLike replaces SQLite's built-in LIKE operator with an
+// implementation sharing REGEXP's Unicode handling, including
+// WithCaseFolding and WithNormalization: it is case-insensitive for every
+// Unicode letter (SQLite's only folds ASCII) unless WithCaseSensitiveLike is
+// given, and it honours ESCAPE clauses as well as a default escape character
+// set with WithLikeEscape.
+//
+// SQLite only uses indexes to speed up LIKE 'prefix%' with its built-in
+// implementation, and PRAGMA case_sensitive_like reinstalls the built-in
+// LIKE on the connection it runs on.
+func WithUnicodeLike() Option {
+	return func(c *config) {
+		c.UnicodeLike = true
+	}
+}
+
+// WithLikeEscape sets the escape character used by the LIKE replacement (see
+// WithUnicodeLike) when a query has no ESCAPE clause, e.g. '\\' so that
+// name LIKE '100\%' matches "100%".
+func WithLikeEscape(escape rune) Option {
+	return func(c *config) {
+		c.LikeEscape = escape
+	}
+}
+
+// WithCaseSensitiveLike makes the LIKE replacement (see WithUnicodeLike)
+// case-sensitive.
+func WithCaseSensitiveLike() Option {
+	return func(c *config) {
+		c.LikeCaseSensitive = true
+	}
+}
+
+// WithPatternLibrary lets patterns include the named patterns of lib with
+// {{name}}, e.g. regexp('^{{ipv4}}:{{port}}$', addr). Without a library,
+// "{{" has no special meaning.
+func WithPatternLibrary(lib *PatternLibrary) Option {
+	return func(c *config) {
+		c.Library = lib
+	}
+}
+
+// WithCache makes the functions of the database cache their compiled
+// patterns in c instead of the process-wide cache, so that databases with
+// unrelated workloads do not share, or clear, each other's patterns:
+//
+//	db, err := sqlite_regexp.OpenWithRegexp(dsn, sqlite_regexp.WithCache(sqlite_regexp.NewCache()))
+//
+// A cache may be shared by several databases. ClearRegexpCache and
+// GetCacheSize only concern the process-wide cache, as do the helpers of
+// this package, which compile their patterns before running any SQL.
+func WithCache(c *Cache) Option {
+	return func(cfg *config) {
+		cfg.Cache = c
+	}
+}
+
+// WithResultMemo makes the match functions (REGEXP, regexp_like,
+// regexp_full_match, the aliases of regexp and regexp_set_match) memoize
+// their results in m, by pattern, flags and text, so that a join matching
+// the same short texts again and again, such as the values of a
+// denormalized column, matches each pair once:
+//
+//	memo := sqlite_regexp.NewResultMemo(1 << 20)
+//	db, err := sqlite_regexp.OpenWithRegexp(dsn, sqlite_regexp.WithResultMemo(memo))
+//	...
+//	stats := memo.Stats() // a low Hits/Misses ratio means the memo does not pay off
+//
+// It is off by default, since looking the results up costs more than
+// matching a text seen once. Texts longer than MaxMemoText, patterns of the
+// backtracking engine and combined pattern sets are not memoized. A memo
+// may be shared by several databases.
+func WithResultMemo(m *ResultMemo) Option {
+	return func(cfg *config) {
+		cfg.Memo = m
+	}
+}
+
+// WithSlowCallThreshold sets the duration above which an evaluation is
+// logged as slow through the logger set with SetLogger, with its function,
+// pattern, duration and the beginning of its text: the way to find the one
+// pattern slowing down a join over millions of rows. It is 100ms by default;
+// zero or less disables the slow evaluation log.
+func WithSlowCallThreshold(d time.Duration) Option {
+	return func(c *config) {
+		c.SlowCallThreshold = d
+	}
+}
+
+// WithFileTables registers the virtual tables that read files, regexp_log
+// and regexp_grep. They are off by default, as they let any SQL run on the
+// connection read any file the process can. They need go-sqlite3's virtual
+// table support, enabled with the sqlite_vtable build tag; without it, this
+// option has no effect.
+func WithFileTables() Option {
+	return func(c *config) {
+		c.fileTables = true
+	}
+}
+
+// WithReadOnly hardens the database for services that let untrusted users
+// run queries: OpenWithRegexp opens it read-only, and every connection
+// refuses to write, to change the schema, even of temporary tables, to
+// attach other databases or to change settings with PRAGMA, failing such
+// statements with "not authorized". Only the side-effect-free part of the
+// suite is registered: the functions, aggregates and the regexp_split and
+// regexp_pattern_history tables, but not regexp_sequence, which runs the
+// query it is given, nor regexp_scrape, which is created with CREATE
+// VIRTUAL TABLE. Combined with WithFileTables, the registration fails.
+//
+// Connections of drivers registered with RegisterDriver or
+// AdoptExistingDriver are hardened the same way, but their DSN is the
+// application's: it should open the database with mode=ro too. Pair the
+// option with WithPatternPolicy to also bound the patterns users may run.
+func WithReadOnly() Option {
+	return func(c *config) {
+		c.readOnly = true
+	}
+}
+
+// AliasOption configures a name registered with WithFunctionAlias.
+type AliasOption func(*aliasConfig)
+
+type aliasConfig struct {
+	textFirst bool
+	flags     string
+}
+
+// AliasTextFirst makes the alias take the text before the pattern, like
+// regexp_like and MySQL's REGEXP_LIKE, instead of the pattern first, like
+// regexp.
+func AliasTextFirst() AliasOption {
+	return func(c *aliasConfig) {
+		c.textFirst = true
+	}
+}
+
+// AliasFlags sets the flags used by the calls of the alias that give none,
+// e.g. "i" to match case-insensitively like MySQL's RLIKE on a
+// case-insensitive collation.
+func AliasFlags(flags string) AliasOption {
+	return func(c *aliasConfig) {
+		c.flags = flags
+	}
+}
+
+// WithFunctionAlias also registers the matcher of REGEXP under name, for SQL
+// generated with another dialect's function name:
+//
+//	db, err := sqlite_regexp.OpenWithRegexp(dsn,
+//		sqlite_regexp.WithFunctionAlias("rlike", sqlite_regexp.AliasTextFirst(), sqlite_regexp.AliasFlags("i")))
+//	// SELECT * FROM users WHERE rlike(name, '^j')
+//
+// SQLite function names are case-insensitive, so "RLIKE" and "rlike" are the
+// same alias. SQLite only parses REGEXP and MATCH as operators: "text MATCH
+// pattern" calls an alias named match with the pattern first, but RLIKE can
+// only be called as a function. An invalid name or flags make the
+// registration fail.
+func WithFunctionAlias(name string, opts ...AliasOption) Option {
+	var ac aliasConfig
+	for _, opt := range opts {
+		opt(&ac)
+	}
+	return func(c *config) {
+		if c.err != nil {
+			return
+		}
+		if !identRe.MatchString(name) {
+			c.err = fmt.Errorf("function alias %q is not a valid name", name)
+			return
+		}
+		flags, err := core.ParseFlags(ac.flags)
+		if err != nil {
+			c.err = fmt.Errorf("function alias %s: %w", name, err)
+			return
+		}
+		c.Aliases = append(c.Aliases, core.Alias{Name: name, TextFirst: ac.textFirst, Flags: flags})
+	}
+}
+
+// identRe matches the names SQLite accepts unquoted.
+var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
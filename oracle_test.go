@@ -0,0 +1,48 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestRegexpLike(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tests := []struct {
+		query    string
+		expected sql.NullInt64
+	}{
+		{`SELECT REGEXP_LIKE('Steven', '^Ste(v|ph)en$')`, sql.NullInt64{Int64: 1, Valid: true}},
+		{`SELECT REGEXP_LIKE('steven', '^Ste(v|ph)en$')`, sql.NullInt64{Int64: 0, Valid: true}},
+		{`SELECT REGEXP_LIKE('steven', '^Ste(v|ph)en$', 'i')`, sql.NullInt64{Int64: 1, Valid: true}},
+		{`SELECT REGEXP_LIKE('steven', '^Ste(v|ph)en$', 'ic')`, sql.NullInt64{Int64: 0, Valid: true}},
+		{`SELECT REGEXP_LIKE('a' || char(10) || 'b', 'a.b')`, sql.NullInt64{Int64: 0, Valid: true}},
+		{`SELECT REGEXP_LIKE('a' || char(10) || 'b', 'a.b', 'n')`, sql.NullInt64{Int64: 1, Valid: true}},
+		{`SELECT REGEXP_LIKE('a' || char(10) || 'b', '^b$')`, sql.NullInt64{Int64: 0, Valid: true}},
+		{`SELECT REGEXP_LIKE('a' || char(10) || 'b', '^b$', 'm')`, sql.NullInt64{Int64: 1, Valid: true}},
+		{`SELECT REGEXP_LIKE(NULL, 'a')`, sql.NullInt64{}},
+		{`SELECT REGEXP_LIKE('a', 'a', NULL)`, sql.NullInt64{}},
+	}
+
+	for _, test := range tests {
+		var result sql.NullInt64
+		if err := db.QueryRow(test.query).Scan(&result); err != nil {
+			t.Errorf("%s failed: %v", test.query, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("%s = %+v, expected %+v", test.query, result, test.expected)
+		}
+	}
+
+	var result sql.NullInt64
+	if err := db.QueryRow(`SELECT REGEXP_LIKE('a', 'a', 'z')`).Scan(&result); err == nil {
+		t.Error("Expected error for invalid match parameter, got nil")
+	}
+}
@@ -0,0 +1,48 @@
+package sqlite_regexp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPatternPolicy(t *testing.T) {
+	limits := PatternLimits{MaxLength: 32, MaxRepeatNesting: 1}
+	db, err := OpenWithRegexp(":memory:", WithPatternPolicy(limits.Policy()))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var matched bool
+	if err := db.QueryRow(`SELECT 'ORD-42' REGEXP '^ORD-\d+$'`).Scan(&matched); err != nil || !matched {
+		t.Errorf("Allowed pattern = %v, %v, expected a match", matched, err)
+	}
+	for _, query := range []string{
+		`SELECT 'aaa' REGEXP '(a+)+$'`,
+		`SELECT regexp_replace('aaa', '(a*)*', 'b')`,
+		`SELECT regexp_find_all('x', '` + strings.Repeat("x", 33) + `')`,
+	} {
+		err := db.QueryRow(query).Scan(new(any))
+		if err == nil || !strings.Contains(err.Error(), "pattern rejected by policy") || !IsPatternError(err) {
+			t.Errorf("%s: expected a policy error, got %v", query, err)
+		}
+	}
+
+	var valid bool
+	var msg string
+	if err := db.QueryRow(`SELECT regexp_valid('(a+)+'), regexp_error('(a+)+')`).Scan(&valid, &msg); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if valid || msg != "pattern rejected by policy: max_repeat_nesting: 2 nested quantifiers exceed 1" {
+		t.Errorf("Got valid %v and message %q", valid, msg)
+	}
+
+	// The policy can be called on its own, reporting the rule broken.
+	policyErr := &PolicyError{}
+	if err := limits.Policy()(PatternInfo{Pattern: "(a+)+"}); !errors.As(err, &policyErr) || policyErr.Rule != "max_repeat_nesting" {
+		t.Errorf("Expected a max_repeat_nesting violation, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// TestConnectionChurn checks that every connection of a pool recreating its
+// connections all the time has the functions before serving a query, and
+// that the registrations show the churn.
+func TestConnectionChurn(t *testing.T) {
+	cache := NewCache()
+	path := filepath.Join(t.TempDir(), "churn.db")
+	db, err := OpenWithRegexp(path, WithCache(cache))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	if _, err := db.Exec(`CREATE TABLE items (name TEXT);
+		INSERT INTO items VALUES ('apple'), ('banana'), ('cherry')`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	db.SetMaxOpenConns(4)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxIdleTime(time.Millisecond)
+	db.SetConnMaxLifetime(5 * time.Millisecond)
+
+	var g errgroup.Group
+	for range 8 {
+		g.Go(func() error {
+			for range 50 {
+				var n int
+				if err := db.QueryRow(`SELECT count(*) FROM items WHERE name REGEXP '^[ab]'`).Scan(&n); err != nil {
+					return err
+				}
+				if n != 2 {
+					t.Errorf("Matched %d items, expected 2", n)
+				}
+				time.Sleep(100 * time.Microsecond)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatalf("Query failed on a recreated connection: %v", err)
+	}
+
+	stats := db.Stats()
+	closed := stats.MaxIdleClosed + stats.MaxIdleTimeClosed + stats.MaxLifetimeClosed
+	if closed == 0 {
+		t.Fatal("The pool closed no connection, the test does not exercise churn")
+	}
+	// Every connection ever opened was registered: those still open and
+	// those closed.
+	if got, want := cache.Stats().Registrations, uint64(closed)+uint64(stats.OpenConnections); got != want {
+		t.Errorf("Registrations = %d, expected %d", got, want)
+	}
+}
+
+// TestRegisterRegexpFunctionSingleConnection documents why RegisterRegexpFunction
+// does not suit pools: a connection opened after it lacks the functions.
+func TestRegisterRegexpFunctionSingleConnection(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "single.db"))
+	if err != nil {
+		t.Fatalf("sql.Open failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	if err := RegisterRegexpFunction(db); err != nil {
+		t.Fatalf("RegisterRegexpFunction failed: %v", err)
+	}
+
+	db.SetMaxIdleConns(0)
+	if _, err := db.Exec(`SELECT 'a' REGEXP 'a'`); err == nil {
+		t.Error("Expected REGEXP to be missing from a new connection")
+	}
+}
@@ -0,0 +1,71 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestPostgresCompat(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:", WithPostgresCompat())
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tests := []struct {
+		query    string
+		expected sql.NullString
+	}{
+		{`SELECT pg_match('Hello', '^H')`, sql.NullString{String: "1", Valid: true}},
+		{`SELECT pg_match('Hello', '^h')`, sql.NullString{String: "0", Valid: true}},
+		{`SELECT pg_imatch('Hello', '^h')`, sql.NullString{String: "1", Valid: true}},
+		{`SELECT pg_not_match('Hello', '^h')`, sql.NullString{String: "1", Valid: true}},
+		{`SELECT pg_not_imatch('Hello', '^h')`, sql.NullString{String: "0", Valid: true}},
+		// '.' matches newlines and $ only anchors at the end of the string.
+		{`SELECT pg_match('a' || char(10) || 'b', 'a.b')`, sql.NullString{String: "1", Valid: true}},
+		{`SELECT pg_match('a' || char(10) || 'b', 'a$')`, sql.NullString{String: "0", Valid: true}},
+		{`SELECT pg_substring('foobar', 'o.b')`, sql.NullString{String: "oob", Valid: true}},
+		{`SELECT pg_substring('foobar', 'o(.)b')`, sql.NullString{String: "o", Valid: true}},
+		{`SELECT pg_substring('foobar', 'x')`, sql.NullString{}},
+		{`SELECT regexp_matches('foobarbequebaz', 'ba.')`, sql.NullString{String: `["bar"]`, Valid: true}},
+		{`SELECT regexp_matches('foobarbequebaz', '(bar)(beque)')`, sql.NullString{String: `["bar","beque"]`, Valid: true}},
+		{`SELECT regexp_matches('foobarbequebazilbarfbonk', '(b[^b]+)(b[^b]+)', 'g')`,
+			sql.NullString{String: `[["bar","beque"],["bazil","barf"]]`, Valid: true}},
+		{`SELECT regexp_matches('ABC', 'b', 'i')`, sql.NullString{String: `["B"]`, Valid: true}},
+		{`SELECT regexp_matches('a' || char(10) || 'b', '^b$', 'n')`, sql.NullString{String: `["b"]`, Valid: true}},
+		{`SELECT regexp_matches('abc', 'x', 'g')`, sql.NullString{}},
+	}
+
+	for _, test := range tests {
+		var result sql.NullString
+		if err := db.QueryRow(test.query).Scan(&result); err != nil {
+			t.Errorf("%s failed: %v", test.query, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("%s = %+v, expected %+v", test.query, result, test.expected)
+		}
+	}
+
+	var result sql.NullString
+	if err := db.QueryRow(`SELECT regexp_matches('abc', 'b', 'q')`).Scan(&result); err == nil {
+		t.Error("Expected error for invalid flag, got nil")
+	}
+}
+
+func TestPostgresCompatNotRegisteredByDefault(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var result int
+	if err := db.QueryRow(`SELECT pg_match('a', 'a')`).Scan(&result); err == nil {
+		t.Error("Expected pg_match to be unavailable without WithPostgresCompat")
+	}
+}
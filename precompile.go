@@ -0,0 +1,59 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// PrecompilePatterns compiles patterns into the cache used by the databases
+// opened with opts, so that a service fails at startup on invalid patterns
+// rather than during the first query using them:
+//
+//	if err := sqlite_regexp.PrecompilePatterns(patterns, opts...); err != nil {
+//		log.Fatal(err)
+//	}
+//	db, err := sqlite_regexp.OpenWithRegexp(dsn, opts...)
+//
+// Only the options choosing the cache and the pattern library matter. The
+// patterns are compiled without flags, as for the REGEXP operator. The
+// error lists every invalid pattern, and the valid ones are compiled anyway.
+func PrecompilePatterns(patterns []string, opts ...Option) error {
+	return newConfig(opts...).Precompile(patterns, 0)
+}
+
+// PrecompilePatternTable is like PrecompilePatterns, for the distinct
+// non-NULL values of column in table, and returns their number.
+func PrecompilePatternTable(db *sql.DB, table, column string, opts ...Option) (int, error) {
+	return PrecompilePatternTableContext(context.Background(), db, table, column, opts...)
+}
+
+// PrecompilePatternTableContext is like PrecompilePatternTable, but stops,
+// interrupting the query, once ctx is done.
+func PrecompilePatternTableContext(ctx context.Context, db *sql.DB, table, column string, opts ...Option) (int, error) {
+	if err := checkColumn(ctx, db, table, column); err != nil {
+		return 0, fmt.Errorf("%s.%s: %w", table, column, err)
+	}
+	query := fmt.Sprintf(`SELECT DISTINCT %s FROM %s WHERE %s IS NOT NULL`,
+		quoteIdent(column), quoteIdent(table), quoteIdent(column))
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var patterns []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return 0, err
+		}
+		patterns = append(patterns, p)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	return len(patterns), PrecompilePatterns(patterns, opts...)
+}
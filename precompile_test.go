@@ -0,0 +1,74 @@
+package sqlite_regexp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrecompilePatterns(t *testing.T) {
+	cache := NewCache()
+	lib := NewPatternLibrary()
+	if err := lib.Define("digits", `\d+`); err != nil {
+		t.Fatalf("Define failed: %v", err)
+	}
+
+	err := PrecompilePatterns([]string{`^a`, `{{digits}}-x`, `(`, `[z-a]`}, WithCache(cache), WithPatternLibrary(lib))
+	if err == nil {
+		t.Fatal("Expected an error for invalid patterns")
+	}
+	if !IsPatternError(err) || !strings.Contains(err.Error(), `"("`) || !strings.Contains(err.Error(), `"[z-a]"`) {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if cache.Size() != 2 {
+		t.Errorf("Expected the 2 valid patterns in the cache, got %d", cache.Size())
+	}
+
+	// Queries reuse the precompiled patterns.
+	db, err := OpenWithRegexp(":memory:", WithCache(cache), WithPatternLibrary(lib))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	before := cache.Stats()
+	var matched bool
+	if err := db.QueryRow(`SELECT '12-x' REGEXP '{{digits}}-x'`).Scan(&matched); err != nil || !matched {
+		t.Fatalf("REGEXP failed: %v, %v", matched, err)
+	}
+	if after := cache.Stats(); after.Misses != before.Misses {
+		t.Errorf("Precompiled pattern compiled again: %+v", after)
+	}
+}
+
+func TestPrecompilePatternTable(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	_, err = db.Exec(`CREATE TABLE rules (category TEXT, pattern TEXT);
+		INSERT INTO rules VALUES ('billing', '(?i)invoice'), ('billing', '(?i)invoice'), ('bugs', 'crash'), ('none', NULL)`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	cache := NewCache()
+	n, err := PrecompilePatternTable(db, "rules", "pattern", WithCache(cache))
+	if err != nil || n != 2 || cache.Size() != 2 {
+		t.Errorf("Got %d patterns, %d cached, %v", n, cache.Size(), err)
+	}
+
+	if _, err := PrecompilePatternTable(db, "rules", "patern"); err == nil || !strings.Contains(err.Error(), "no such column") {
+		t.Errorf("Expected a missing column error, got %v", err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO rules VALUES ('broken', 'a(')`); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if _, err := PrecompilePatternTable(db, "rules", "pattern", WithCache(cache)); !IsPatternError(err) {
+		t.Errorf("Expected a pattern error, got %v", err)
+	}
+}
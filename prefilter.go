@@ -0,0 +1,154 @@
+package sqlite_regexp
+
+import (
+	"regexp/syntax"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+)
+
+// PrefilterPredicate returns a SQL predicate matching the SQL expression expr
+// against pattern with flags, with its arguments. If pattern is anchored to
+// a literal prefix, the predicate first tests the prefix with GLOB, which
+// SQLite answers with an index on expr, so that the regular expression only
+// runs on the candidate rows:
+//
+//	where, args, err := sqlite_regexp.PrefilterPredicate("sku", `^ORD-\d+$`, "")
+//	// where: sku GLOB ? AND sku REGEXP ?
+//	// args:  "ORD-*", `^ORD-\d+$`
+//	rows, err := db.Query("SELECT * FROM orders WHERE "+where, args...)
+//
+// A case-insensitive prefix is tested with LIKE instead, as long as it is
+// ASCII; SQLite only uses an index for it if the index has the NOCASE
+// collation. Patterns without such a prefix, such as "ORD-" without '^' or
+// with the m flag, get the REGEXP test alone. opts are those of the database,
+// for patterns including library patterns. The prefix tests are those of
+// SQLite's own GLOB and LIKE, not those of WithUnicodeGlob or WithUnicodeLike,
+// whose replacements SQLite cannot answer with an index.
+func PrefilterPredicate(expr, pattern, flags string, opts ...Option) (string, []any, error) {
+	cfg := newConfig(opts...)
+	if cfg.err != nil {
+		return "", nil, cfg.err
+	}
+	f, err := core.ParseFlags(flags)
+	if err != nil {
+		return "", nil, err
+	}
+	re, err := cfg.Compile(pattern, f)
+	if err != nil {
+		return "", nil, err
+	}
+
+	match, args := expr+" REGEXP ?", []any{pattern}
+	if flags != "" {
+		match, args = "regexp(?, "+expr+", ?)", []any{pattern, flags}
+	}
+	// The compiled form has the flags applied and library patterns expanded.
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return "", nil, err
+	}
+	prefix, fold := anchoredPrefix(parsed.Simplify())
+	switch {
+	case prefix == "":
+		return match, args, nil
+	case fold:
+		return expr + ` LIKE ? ESCAPE '\' AND ` + match, append([]any{likePrefix(prefix)}, args...), nil
+	default:
+		return expr + " GLOB ? AND " + match, append([]any{globPrefix(prefix)}, args...), nil
+	}
+}
+
+// anchoredPrefix returns the literal text re requires at the start of the
+// text, and whether it is tested ignoring case, which is if it starts
+// ignoring case. A case-insensitive prefix is cut before its first letter
+// with non-ASCII case variants, such as 'k' (the Kelvin sign), since LIKE
+// only folds ASCII letters.
+func anchoredPrefix(re *syntax.Regexp) (string, bool) {
+	parts := []*syntax.Regexp{re}
+	if re.Op == syntax.OpConcat {
+		parts = re.Sub
+	}
+	if len(parts) == 0 || parts[0].Op != syntax.OpBeginText {
+		return "", false
+	}
+
+	var b strings.Builder
+	fold, first := false, true
+	var walk func(parts []*syntax.Regexp) bool
+	walk = func(parts []*syntax.Regexp) bool {
+		for _, part := range parts {
+			switch part.Op {
+			case syntax.OpCapture, syntax.OpConcat:
+				if !walk(part.Sub) {
+					return false
+				}
+			case syntax.OpLiteral:
+				partFold := part.Flags&syntax.FoldCase != 0
+				if first {
+					fold, first = partFold, false
+				}
+				for _, r := range part.Rune {
+					// LIKE folds the ASCII letters, GLOB none.
+					if partFold && hasCaseVariants(r) && (!fold || !asciiFold(r)) {
+						return false
+					}
+					b.WriteRune(r)
+				}
+			default:
+				return false
+			}
+		}
+		return true
+	}
+	walk(parts[1:])
+	return b.String(), fold
+}
+
+// asciiFold reports whether r and its case variants are all ASCII.
+func asciiFold(r rune) bool {
+	if r >= utf8.RuneSelf {
+		return false
+	}
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func hasCaseVariants(r rune) bool {
+	return unicode.SimpleFold(r) != r
+}
+
+// globPrefix returns the GLOB pattern of the texts starting with prefix.
+func globPrefix(prefix string) string {
+	var b strings.Builder
+	for _, r := range prefix {
+		switch r {
+		case '*', '?', '[':
+			b.WriteString("[" + string(r) + "]")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('*')
+	return b.String()
+}
+
+// likePrefix returns the LIKE pattern, with '\' as escape character, of the
+// texts starting with prefix.
+func likePrefix(prefix string) string {
+	var b strings.Builder
+	for _, r := range prefix {
+		if r == '%' || r == '_' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('%')
+	return b.String()
+}
@@ -0,0 +1,121 @@
+package sqlite_regexp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPrefilterPredicate(t *testing.T) {
+	tests := []struct {
+		pattern, flags string
+		where          string
+		args           []any
+	}{
+		{`^ORD-\d+$`, "", `sku GLOB ? AND sku REGEXP ?`, []any{"ORD-*", `^ORD-\d+$`}},
+		{`^(ORD)-(\d+)`, "", `sku GLOB ? AND sku REGEXP ?`, []any{"ORD-*", `^(ORD)-(\d+)`}},
+		{`^a*b`, "", `sku REGEXP ?`, []any{`^a*b`}},
+		{`^a[*]?`, "", `sku GLOB ? AND sku REGEXP ?`, []any{"a*", `^a[*]?`}},
+		{`^a\*\?\[`, "", `sku GLOB ? AND sku REGEXP ?`, []any{"a[*][?][[]*", `^a\*\?\[`}},
+		{`ORD-`, "", `sku REGEXP ?`, []any{`ORD-`}},
+		{`^ORD-`, "m", `regexp(?, sku, ?)`, []any{`^ORD-`, "m"}},
+		{`(?m)^ORD-`, "", `sku REGEXP ?`, []any{`(?m)^ORD-`}},
+		{`^ORD|^INV`, "", `sku REGEXP ?`, []any{`^ORD|^INV`}},
+		{`^ord-`, "i", `sku LIKE ? ESCAPE '\' AND regexp(?, sku, ?)`, []any{"ORD-%", `^ord-`, "i"}},
+		{`^50%_off`, "i", `sku LIKE ? ESCAPE '\' AND regexp(?, sku, ?)`, []any{`50\%\_OFF%`, `^50%_off`, "i"}},
+		// LIKE does not fold the Kelvin sign to k.
+		{`(?i)^bkz`, "", `sku LIKE ? ESCAPE '\' AND sku REGEXP ?`, []any{"B%", `(?i)^bkz`}},
+		{`(?i)^kz`, "", `sku REGEXP ?`, []any{`(?i)^kz`}},
+		// GLOB cannot test a case-insensitive letter.
+		{`^ab(?i)cd`, "", `sku GLOB ? AND sku REGEXP ?`, []any{"ab*", `^ab(?i)cd`}},
+		{`^€ (?i)€x`, "", `sku GLOB ? AND sku REGEXP ?`, []any{"€ €*", `^€ (?i)€x`}},
+		{"^ORD- # order", "x", `sku GLOB ? AND regexp(?, sku, ?)`, []any{"ORD-*", "^ORD- # order", "x"}},
+	}
+	for _, test := range tests {
+		where, args, err := PrefilterPredicate("sku", test.pattern, test.flags)
+		if err != nil {
+			t.Errorf("PrefilterPredicate(%q, %q) failed: %v", test.pattern, test.flags, err)
+			continue
+		}
+		if where != test.where || !reflect.DeepEqual(args, test.args) {
+			t.Errorf("PrefilterPredicate(%q, %q) = %s %q, expected %s %q",
+				test.pattern, test.flags, where, args, test.where, test.args)
+		}
+	}
+
+	for _, bad := range [][2]string{{"(", ""}, {"a", "q"}} {
+		if _, _, err := PrefilterPredicate("sku", bad[0], bad[1]); err == nil {
+			t.Errorf("PrefilterPredicate(%q, %q) should fail", bad[0], bad[1])
+		}
+	}
+}
+
+// TestPrefilterPredicateQuery checks that the prefilter keeps the results of
+// REGEXP alone, and lets SQLite use an index.
+func TestPrefilterPredicateQuery(t *testing.T) {
+	lib := NewPatternLibrary()
+	if err := lib.Define("order", `ORD-\d+`); err != nil {
+		t.Fatalf("Define failed: %v", err)
+	}
+	db, err := OpenWithRegexp(":memory:", WithPatternLibrary(lib))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	if _, err := db.Exec(`CREATE TABLE orders (sku TEXT);
+		CREATE INDEX orders_sku ON orders (sku);
+		INSERT INTO orders VALUES ('ORD-1'), ('ord-2'), ('ORD-x'), ('INV-3'), ('xORD-4'), (NULL), (5)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	query := func(where string, args ...any) []string {
+		rows, err := db.Query(`SELECT sku FROM orders WHERE `+where+` ORDER BY sku`, args...)
+		if err != nil {
+			t.Fatalf("%s failed: %v", where, err)
+		}
+		defer func() {
+			_ = rows.Close()
+		}()
+		var skus []string
+		for rows.Next() {
+			var sku string
+			if err := rows.Scan(&sku); err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+			skus = append(skus, sku)
+		}
+		return skus
+	}
+
+	for _, test := range [][2]string{
+		{`^ORD-\d+$`, ""},
+		{`^ord-`, "i"},
+		{`^{{order}}`, ""},
+		{`^5`, ""},
+	} {
+		where, args, err := PrefilterPredicate("sku", test[0], test[1], WithPatternLibrary(lib))
+		if err != nil {
+			t.Fatalf("PrefilterPredicate(%q) failed: %v", test[0], err)
+		}
+		expected := query(`regexp(?, sku, ?)`, test[0], test[1])
+		if got := query(where, args...); !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s %q returned %q, expected %q", where, args, got, expected)
+		}
+	}
+
+	where, args, err := PrefilterPredicate("sku", `^ORD-\d+$`, "")
+	if err != nil {
+		t.Fatalf("PrefilterPredicate failed: %v", err)
+	}
+	var id, parent, notused int
+	var detail string
+	if err := db.QueryRow(`EXPLAIN QUERY PLAN SELECT sku FROM orders WHERE `+where, args...).
+		Scan(&id, &parent, &notused, &detail); err != nil {
+		t.Fatalf("EXPLAIN QUERY PLAN failed: %v", err)
+	}
+	if !strings.Contains(detail, "orders_sku (sku>? AND sku<?)") {
+		t.Errorf("Expected a range search on the index, got %q", detail)
+	}
+}
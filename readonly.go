@@ -0,0 +1,70 @@
+package sqlite_regexp
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteRecursive is SQLITE_RECURSIVE, the authorizer action of recursive
+// common table expressions, which go-sqlite3 does not export.
+const sqliteRecursive = 33
+
+// hardenConnection makes conn refuse anything but reading: query_only stops
+// writes to the database files, and an authorizer denies the statements
+// that could lift it or reach other files, such as ATTACH and PRAGMA
+// assignments, as well as every write and schema change, including to the
+// temp schema, which query_only allows.
+func hardenConnection(conn *sqlite3.SQLiteConn) error {
+	if _, err := conn.Exec("PRAGMA query_only = ON", nil); err != nil {
+		return err
+	}
+	conn.RegisterAuthorizer(readOnlyAuthorizer)
+	return nil
+}
+
+// readOnlyAuthorizer allows the actions of queries only.
+func readOnlyAuthorizer(action int, arg1, arg2, arg3 string) int {
+	switch action {
+	case sqlite3.SQLITE_SELECT, sqlite3.SQLITE_READ, sqliteRecursive,
+		sqlite3.SQLITE_TRANSACTION, sqlite3.SQLITE_SAVEPOINT:
+		return sqlite3.SQLITE_OK
+	case sqlite3.SQLITE_FUNCTION:
+		// arg2 is the name of the function.
+		if strings.EqualFold(arg2, "load_extension") {
+			return sqlite3.SQLITE_DENY
+		}
+		return sqlite3.SQLITE_OK
+	case sqlite3.SQLITE_PRAGMA:
+		// arg1 is the name of the pragma and arg2 its argument: pragmas
+		// reading a setting are allowed, not those changing one.
+		if arg2 == "" {
+			return sqlite3.SQLITE_OK
+		}
+	}
+	return sqlite3.SQLITE_DENY
+}
+
+// readOnlyDSN returns dsn opening its database read-only, as a file: URI
+// with mode=ro, keeping its parameters. In-memory databases are left as
+// they are: they start empty, and query_only keeps them so.
+func readOnlyDSN(dsn string) (string, error) {
+	path, rawQuery, _ := strings.Cut(dsn, "?")
+	if path == "" || path == ":memory:" || strings.HasPrefix(path, "file::memory:") {
+		return dsn, nil
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", err
+	}
+	if query.Get("mode") == "memory" || query.Get("vfs") == "memdb" {
+		return dsn, nil
+	}
+	query.Set("mode", "ro")
+	if !strings.HasPrefix(path, "file:") {
+		// go-sqlite3 only passes the parameters of file: URIs to SQLite.
+		path = "file:" + (&url.URL{Path: path}).EscapedPath()
+	}
+	return path + "?" + query.Encode(), nil
+}
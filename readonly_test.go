@@ -0,0 +1,121 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.db")
+	setup, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if _, err := setup.Exec(`CREATE TABLE users (email TEXT); INSERT INTO users VALUES ('ann@example.com'), ('bob@test.org')`); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	_ = setup.Close()
+
+	db, err := OpenWithRegexp(path+"?_busy_timeout=1000", WithReadOnly())
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var count int
+	if err := db.QueryRow(`WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 2)
+SELECT count(*) FROM users, n WHERE email REGEXP '@example\.com$'`).Scan(&count); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Got %d matches, expected 2", count)
+	}
+	var journal string
+	if err := db.QueryRow(`PRAGMA journal_mode`).Scan(&journal); err != nil {
+		t.Errorf("Reading a pragma failed: %v", err)
+	}
+	if _, err := Audit(db, []ColumnRule{{Table: "users", Column: "email", Pattern: `@`}}, 1); err != nil {
+		t.Errorf("Audit failed: %v", err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	if err := tx.QueryRow(`SELECT count(*) FROM users`).Scan(&count); err != nil {
+		t.Errorf("Query in a transaction failed: %v", err)
+	}
+	_ = tx.Rollback()
+
+	for _, stmt := range []string{
+		`INSERT INTO users VALUES ('eve@example.com')`,
+		`UPDATE users SET email = ''`,
+		`DELETE FROM users`,
+		`CREATE TABLE t (x)`,
+		`CREATE TEMP TABLE t (x)`,
+		`CREATE TEMP VIEW v AS SELECT 1`,
+		`DROP TABLE users`,
+		`PRAGMA query_only = OFF`,
+		`PRAGMA writable_schema = ON`,
+		`ATTACH DATABASE '` + filepath.Join(t.TempDir(), "other.db") + `' AS other`,
+		`SELECT load_extension('x')`,
+		`VACUUM`,
+	} {
+		if _, err := db.Exec(stmt); err == nil {
+			t.Errorf("Expected %q to fail in read-only mode", stmt)
+		}
+	}
+}
+
+func TestReadOnlyFileTables(t *testing.T) {
+	_, err := OpenWithRegexp(":memory:", WithReadOnly(), WithFileTables())
+	if err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Errorf("Got %v, expected an error combining read-only mode and file tables", err)
+	}
+}
+
+func TestReadOnlyMemory(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:", WithReadOnly())
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	var matched int
+	if err := db.QueryRow(`SELECT regexp_like('abc', '^A', 'i')`).Scan(&matched); err != nil || matched != 1 {
+		t.Errorf("Got %d, %v, expected 1", matched, err)
+	}
+	if _, err := db.Exec(`CREATE TABLE t (x)`); err == nil {
+		t.Error("Expected CREATE TABLE to fail in read-only mode")
+	}
+}
+
+func TestReadOnlyDSN(t *testing.T) {
+	tests := []struct {
+		dsn      string
+		expected string
+	}{
+		{"app.db", "file:app.db?mode=ro"},
+		{"/data/app.db?_busy_timeout=500", "file:/data/app.db?_busy_timeout=500&mode=ro"},
+		{"/data/a#b.db", "file:/data/a%23b.db?mode=ro"},
+		{"file:app.db?mode=rwc&cache=shared", "file:app.db?cache=shared&mode=ro"},
+		{"file:app.db", "file:app.db?mode=ro"},
+		{":memory:", ":memory:"},
+		{"file::memory:?cache=shared", "file::memory:?cache=shared"},
+		{"file:mem?mode=memory", "file:mem?mode=memory"},
+	}
+	for _, tt := range tests {
+		got, err := readOnlyDSN(tt.dsn)
+		if err != nil {
+			t.Errorf("readOnlyDSN(%q) failed: %v", tt.dsn, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("readOnlyDSN(%q) = %q, expected %q", tt.dsn, got, tt.expected)
+		}
+	}
+}
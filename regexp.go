@@ -7,54 +7,52 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
-	"regexp"
-	"sync"
+	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
 	"github.com/mattn/go-sqlite3"
 )
 
-// regexpCache caches compiled regular expressions to improve performance
-var regexpCache = struct {
-	sync.RWMutex
-	cache map[string]*regexp.Regexp
-}{
-	cache: make(map[string]*regexp.Regexp),
-}
-
 // regexpFunction implements the REGEXP function for SQLite.
 // It takes two arguments: the text to match and the pattern.
 // Returns 1 if the pattern matches, 0 otherwise.
 func regexpFunction(pattern, text string) (int, error) {
-	// Check cache first
-	regexpCache.RLock()
-	re, exists := regexpCache.cache[pattern]
-	regexpCache.RUnlock()
-
-	if !exists {
-		// Compile the regex and cache it
-		var err error
-		re, err = regexp.Compile(pattern)
-		if err != nil {
-			return 0, err
-		}
-
-		regexpCache.Lock()
-		regexpCache.cache[pattern] = re
-		regexpCache.Unlock()
+	matched, err := core.Match(pattern, text)
+	if err != nil {
+		return 0, err
 	}
 
-	if re.MatchString(text) {
+	if matched {
 		return 1, nil
 	}
 	return 0, nil
 }
 
-// RegisterRegexpFunction registers the REGEXP function with a SQLite connection.
-// This function should be called after opening a database connection but before
-// executing any queries that use REGEXP.
-func RegisterRegexpFunction(db *sql.DB) error {
-	// Get the underlying SQLite connection with proper context
-	ctx := context.Background()
+// RegisterRegexpFunction registers the REGEXP function, together with the
+// extended function suite (regexp_like, regexp_replace, regexp_extract,
+// regexp_find_all, regexp_captures, regexp_tokenize), with a SQLite
+// connection. This function should be called
+// after opening a database connection but before executing any queries that
+// use REGEXP.
+//
+// Only one connection of db gets the functions, so they are missing from
+// the other connections of a pool, and from those it opens later to replace
+// idle or expired ones. Use OpenWithRegexp, or a driver with ConnectHook,
+// unless db is limited to a single connection that is never closed.
+func RegisterRegexpFunction(db *sql.DB, opts ...Option) error {
+	return RegisterRegexpFunctionContext(context.Background(), db, opts...)
+}
+
+// RegisterRegexpFunctionContext is like RegisterRegexpFunction, but gives up
+// once ctx is done, such as while waiting for a connection of a busy pool.
+func RegisterRegexpFunctionContext(ctx context.Context, db *sql.DB, opts ...Option) error {
+	return register(ctx, db, newConfig(opts...))
+}
+
+// register installs the functions of cfg on one connection of db.
+func register(ctx context.Context, db *sql.DB, cfg *config) error {
 	conn, err := db.Conn(ctx)
 	if err != nil {
 		return err
@@ -69,40 +67,229 @@ func RegisterRegexpFunction(db *sql.DB) error {
 			return driver.ErrBadConn
 		}
 
-		// Register the REGEXP function
-		return sqliteConn.RegisterFunc("regexp", regexpFunction, true)
+		return registerFunctions(sqliteConn, cfg)
 	})
 }
 
-// OpenWithRegexp opens a SQLite database connection and automatically registers
-// the REGEXP function. This is a convenience function that combines sql.Open
-// with RegisterRegexpFunction.
-func OpenWithRegexp(dataSourceName string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", dataSourceName)
-	if err != nil {
-		return nil, err
+// registerFunctions installs every function of the suite on a raw connection.
+func registerFunctions(conn *sqlite3.SQLiteConn, cfg *config) error {
+	if cfg.err != nil {
+		return cfg.err
+	}
+	if cfg.readOnly {
+		if err := hardenConnection(conn); err != nil {
+			return err
+		}
+	}
+	for _, fn := range core.Functions(&cfg.Config) {
+		if cfg.only != nil && !cfg.only[fn.Name] {
+			continue
+		}
+		fn.Impl = connObserved(conn, fn)
+		if fn.MaxArgs < 0 {
+			if err := conn.RegisterFunc(fn.Name, fn.Call, fn.Deterministic); err != nil {
+				return err
+			}
+			continue
+		}
+		for n := fn.MinArgs; n <= fn.MaxArgs; n++ {
+			if err := conn.RegisterFunc(fn.Name, fixedArity(fn.Impl, n), fn.Deterministic); err != nil {
+				return err
+			}
+		}
+	}
+	for _, agg := range core.Aggregates(&cfg.Config) {
+		if cfg.only != nil && !cfg.only[agg.Name] {
+			continue
+		}
+		for n := agg.MinArgs; n <= agg.MaxArgs; n++ {
+			if err := conn.RegisterAggregator(agg.Name, fixedArityAggregator(agg.New, n), true); err != nil {
+				return err
+			}
+		}
+	}
+	if err := registerCollations(conn, cfg); err != nil {
+		return err
+	}
+	if cfg.only == nil {
+		if err := registerModules(conn, cfg); err != nil {
+			return err
+		}
 	}
+	cfg.CountRegistration()
+	return nil
+}
+
+// fixedArity adapts a core function to a Go signature taking exactly n
+// arguments, so that SQLite rejects calls with a wrong number of arguments
+// just like it does for the loadable extension. go-sqlite3 registers variadic
+// Go functions as accepting any number of arguments.
+func fixedArity(impl func(...any) (any, error), n int) any {
+	switch n {
+	case 1:
+		return func(a any) (any, error) { return impl(a) }
+	case 2:
+		return func(a, b any) (any, error) { return impl(a, b) }
+	case 3:
+		return func(a, b, c any) (any, error) { return impl(a, b, c) }
+	case 4:
+		return func(a, b, c, d any) (any, error) { return impl(a, b, c, d) }
+	case 5:
+		return func(a, b, c, d, e any) (any, error) { return impl(a, b, c, d, e) }
+	default:
+		panic(fmt.Sprintf("sqlite_regexp: unsupported argument count %d", n))
+	}
+}
+
+// fixedArityAggregator returns a constructor for RegisterAggregator whose
+// Step method takes exactly n arguments, for the same reason as fixedArity.
+func fixedArityAggregator(newState func() core.AggregateState, n int) any {
+	switch n {
+	case 1:
+		return func() *aggregator1 { return &aggregator1{newState()} }
+	case 2:
+		return func() *aggregator2 { return &aggregator2{newState()} }
+	case 3:
+		return func() *aggregator3 { return &aggregator3{newState()} }
+	case 4:
+		return func() *aggregator4 { return &aggregator4{newState()} }
+	default:
+		panic(fmt.Sprintf("sqlite_regexp: unsupported argument count %d", n))
+	}
+}
+
+type aggregator1 struct{ state core.AggregateState }
 
-	if err := RegisterRegexpFunction(db); err != nil {
+func (a *aggregator1) Step(x any) error   { return a.state.Step(x) }
+func (a *aggregator1) Done() (any, error) { return a.state.Final() }
+
+type aggregator2 struct{ state core.AggregateState }
+
+func (a *aggregator2) Step(x, y any) error { return a.state.Step(x, y) }
+func (a *aggregator2) Done() (any, error)  { return a.state.Final() }
+
+type aggregator3 struct{ state core.AggregateState }
+
+func (a *aggregator3) Step(x, y, z any) error { return a.state.Step(x, y, z) }
+func (a *aggregator3) Done() (any, error)     { return a.state.Final() }
+
+type aggregator4 struct{ state core.AggregateState }
+
+func (a *aggregator4) Step(x, y, z, w any) error { return a.state.Step(x, y, z, w) }
+func (a *aggregator4) Done() (any, error)        { return a.state.Final() }
+
+// OpenWithRegexp opens a SQLite database whose connections all have the REGEXP
+// function suite configured by opts. Unlike sql.Open followed by
+// RegisterRegexpFunction, the functions are installed on every connection of
+// the pool before it serves a query, however often the pool recreates them
+// (see SetConnMaxIdleTime and SetConnMaxLifetime). The first connection is
+// opened right away, to report errors such as an invalid option.
+func OpenWithRegexp(dataSourceName string, opts ...Option) (*sql.DB, error) {
+	return OpenWithRegexpContext(context.Background(), dataSourceName, opts...)
+}
+
+// OpenWithRegexpContext is like OpenWithRegexp, but gives up once ctx is
+// done while opening the first connection, such as on a locked database.
+func OpenWithRegexpContext(ctx context.Context, dataSourceName string, opts ...Option) (*sql.DB, error) {
+	cfg := newConfig(opts...)
+	if cfg.err != nil {
+		return nil, cfg.err
+	}
+	if cfg.readOnly {
+		var err error
+		if dataSourceName, err = readOnlyDSN(dataSourceName); err != nil {
+			return nil, err
+		}
+	}
+	db := sql.OpenDB(&connector{
+		dsn:    dataSourceName,
+		driver: &sqlite3.SQLiteDriver{ConnectHook: connectHook(cfg)},
+	})
+	if err := db.PingContext(ctx); err != nil {
 		_ = db.Close()
 		return nil, err
 	}
-
 	return db, nil
 }
 
+// Cache is a cache of compiled patterns, see WithCache.
+type Cache = core.Cache
+
+// CacheStats are the size and counters of a Cache, returned by its Stats
+// method.
+type CacheStats = core.CacheStats
+
+// NewCache returns an empty cache of compiled patterns.
+func NewCache() *Cache {
+	return core.NewCache()
+}
+
+// ResultMemo memoizes the results of the match functions by pattern and
+// text, see WithResultMemo.
+type ResultMemo = core.ResultMemo
+
+// MemoStats are the size and counters of a ResultMemo, returned by its Stats
+// method.
+type MemoStats = core.MemoStats
+
+// MaxMemoText is the length in bytes above which texts are matched without
+// going through a ResultMemo.
+const MaxMemoText = core.MaxMemoText
+
+// NewResultMemo returns an empty memo holding about size results, evicting
+// the least recently used ones beyond.
+func NewResultMemo(size int) *ResultMemo {
+	return core.NewResultMemo(size)
+}
+
 // ClearRegexpCache clears the internal regexp cache. This can be useful
 // for memory management in long-running applications.
 func ClearRegexpCache() {
-	regexpCache.Lock()
-	regexpCache.cache = make(map[string]*regexp.Regexp)
-	regexpCache.Unlock()
+	core.ClearCache()
+}
+
+// SetCacheTTL makes the patterns of the process-wide cache that are not used
+// for ttl expire, so that a rotating pattern set does not accumulate stale
+// compiled patterns; zero or less, the default, keeps them until the cache
+// is cleared. Expired patterns are removed during later lookups. Use the
+// SetTTL method of a Cache for a private one.
+func SetCacheTTL(ttl time.Duration) {
+	core.SetCacheTTL(ttl)
+}
+
+// SetCacheMaxBytes bounds the estimated memory of the process-wide cache to
+// n bytes, evicting the least recently used patterns beyond it; zero or less,
+// the default, leaves it unbounded. The estimate weighs patterns by the size
+// of their compiled program, so one huge alternation counts for many small
+// patterns. Use the SetMaxBytes method of a Cache for a private one.
+func SetCacheMaxBytes(n int64) {
+	core.SetCacheMaxBytes(n)
+}
+
+// CachedPattern describes a pattern of a compiled pattern cache: when it was
+// compiled and how long it took, how often and when it was last found in the
+// cache, and its estimated memory.
+type CachedPattern = core.CachedPattern
+
+// ListCachedPatterns lists the patterns of the process-wide cache, most used
+// first, to see which patterns of a set are actually exercised before pruning
+// it. Use the Patterns method of a Cache for a private one.
+func ListCachedPatterns() []CachedPattern {
+	return core.CachedPatterns()
 }
 
 // GetCacheSize returns the number of compiled regular expressions in the cache.
 func GetCacheSize() int {
-	regexpCache.RLock()
-	size := len(regexpCache.cache)
-	regexpCache.RUnlock()
-	return size
+	return core.CacheSize()
+}
+
+// SetLogger sets the logger receiving the events of the function suite, for
+// every database of the process, or disables them if l is nil, the default.
+// It logs, with the function and pattern involved, evaluations failing on an
+// invalid pattern and evaluations slower than 100ms (see
+// WithSlowCallThreshold), which is how to find the bad pattern among the
+// rows of a JOIN, as well as the cache being cleared or patterns expiring or
+// being evicted.
+func SetLogger(l *slog.Logger) {
+	core.SetLogger(l)
 }
@@ -2,6 +2,7 @@ package sqlite_regexp
 
 import (
 	"database/sql"
+	"strings"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -182,6 +183,41 @@ func TestRegexpCache(t *testing.T) {
 	}
 }
 
+func TestPrivateCache(t *testing.T) {
+	cache := NewCache()
+	db, err := OpenWithRegexp(":memory:", WithCache(cache))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	ClearRegexpCache()
+	for i := 0; i < 2; i++ {
+		if _, err := db.Exec(`SELECT 'abc' REGEXP 'private\d', regexp_like('abc', 'b', 'i')`); err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+	}
+
+	stats := cache.Stats()
+	if stats.Size != 2 || stats.Misses != 2 || stats.Hits != 2 {
+		t.Errorf("Unexpected private cache stats %+v", stats)
+	}
+	if GetCacheSize() != 0 {
+		t.Errorf("Process-wide cache used: size %d", GetCacheSize())
+	}
+
+	ClearRegexpCache()
+	if cache.Size() != 2 {
+		t.Error("ClearRegexpCache cleared a private cache")
+	}
+	cache.Clear()
+	if cache.Size() != 0 {
+		t.Errorf("Expected empty cache, got %d", cache.Size())
+	}
+}
+
 func TestRegisterRegexpFunction(t *testing.T) {
 	db, err := sql.Open("sqlite3", ":memory:")
 	if err != nil {
@@ -207,3 +243,351 @@ func TestRegisterRegexpFunction(t *testing.T) {
 		t.Errorf("Expected 1, got %d", result)
 	}
 }
+
+func TestFunctionAlias(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:",
+		WithFunctionAlias("RLIKE", AliasTextFirst(), AliasFlags("i")),
+		WithFunctionAlias("match"))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tests := []struct {
+		query    string
+		expected sql.NullInt64
+	}{
+		{`SELECT rlike('John', '^j')`, sql.NullInt64{Int64: 1, Valid: true}},
+		{`SELECT RLIKE('John', '^j', '')`, sql.NullInt64{Int64: 0, Valid: true}},
+		{`SELECT rlike(NULL, '^j')`, sql.NullInt64{}},
+		{`SELECT 'abc123' MATCH '\d+$'`, sql.NullInt64{Int64: 1, Valid: true}},
+		{`SELECT match('^x', 'abc')`, sql.NullInt64{Int64: 0, Valid: true}},
+	}
+	for _, test := range tests {
+		var result sql.NullInt64
+		if err := db.QueryRow(test.query).Scan(&result); err != nil {
+			t.Errorf("%s failed: %v", test.query, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("%s = %+v, expected %+v", test.query, result, test.expected)
+		}
+	}
+
+	for _, opt := range []Option{
+		WithFunctionAlias("r like"),
+		WithFunctionAlias("rlike", AliasFlags("q")),
+	} {
+		if db, err := OpenWithRegexp(":memory:", opt); err == nil {
+			_ = db.Close()
+			t.Error("Expected an error for an invalid alias")
+		}
+	}
+}
+
+func TestBlobEncoding(t *testing.T) {
+	utf8DB, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = utf8DB.Close()
+	}()
+	latin1DB, err := OpenWithRegexp(":memory:", WithBlobEncoding(BlobLatin1))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = latin1DB.Close()
+	}()
+
+	tests := []struct {
+		query  string
+		utf8   int64
+		latin1 int64
+	}{
+		{`SELECT x'16030100' REGEXP '^\x16\x03[\x00-\x03]'`, 1, 1},
+		{`SELECT x'ff00' REGEXP '^\xff'`, 0, 1},
+		{`SELECT x'ff00' REGEXP '^.\x00$'`, 1, 1},
+		{`SELECT x'c3a9' REGEXP '^é$'`, 1, 0},
+		{`SELECT x'c3a9' REGEXP '^\xc3\xa9$'`, 0, 1},
+		{`SELECT x'' REGEXP '^$'`, 1, 1},
+		{`SELECT regexp_like(x'C9', '^\xe9$', 'i')`, 0, 1},
+		// Texts are always UTF-8.
+		{`SELECT 'é' REGEXP '^é$'`, 1, 1},
+	}
+	for _, test := range tests {
+		for _, db := range []struct {
+			name     string
+			db       *sql.DB
+			expected int64
+		}{{"UTF-8", utf8DB, test.utf8}, {"Latin-1", latin1DB, test.latin1}} {
+			var result int64
+			if err := db.db.QueryRow(test.query).Scan(&result); err != nil {
+				t.Errorf("%s failed with %s: %v", test.query, db.name, err)
+				continue
+			}
+			if result != db.expected {
+				t.Errorf("%s = %d with %s, expected %d", test.query, result, db.name, db.expected)
+			}
+		}
+	}
+}
+
+func TestUnicodeComparison(t *testing.T) {
+	names := []string{"default", "FoldFull", "FoldTurkic", "NFC", "NFKC"}
+	dbs := make([]*sql.DB, len(names))
+	for i, opts := range [][]Option{
+		nil,
+		{WithCaseFolding(FoldFull)},
+		{WithCaseFolding(FoldTurkic)},
+		{WithNormalization(NormNFC)},
+		{WithNormalization(NormNFKC), WithCaseFolding(FoldFull)},
+	} {
+		db, err := OpenWithRegexp(":memory:", opts...)
+		if err != nil {
+			t.Fatalf("OpenWithRegexp failed: %v", err)
+		}
+		defer func() {
+			_ = db.Close()
+		}()
+		dbs[i] = db
+	}
+
+	tests := []struct {
+		query    string
+		expected [5]int64 // in the order of names
+	}{
+		{`SELECT regexp('STRASSE', 'Straße', 'i')`, [5]int64{0, 1, 1, 0, 1}},
+		{`SELECT regexp('^stra(ss|ß)e$', 'STRASSE', 'i')`, [5]int64{1, 1, 1, 1, 1}},
+		{`SELECT regexp_like('Straße', 'STRASSE', 'i')`, [5]int64{0, 1, 1, 0, 1}},
+		{`SELECT regexp_full_match('STRASSE', 'straße', 'i')`, [5]int64{0, 1, 1, 0, 1}},
+		// Full folding only applies with the i flag of the call.
+		{`SELECT 'STRASSE' REGEXP '(?i)straße'`, [5]int64{0, 0, 0, 0, 0}},
+		{`SELECT 'Straße' REGEXP 'STRASSE'`, [5]int64{0, 0, 0, 0, 0}},
+		// Character classes match the folded text.
+		{`SELECT regexp('^[A-Z]+$', 'Straße', 'i')`, [5]int64{0, 1, 1, 0, 1}},
+		// Turkish: I and ı, İ and i.
+		{`SELECT regexp('ırmak', 'IRMAK', 'i')`, [5]int64{0, 0, 1, 0, 0}},
+		{`SELECT regexp('irmak', 'IRMAK', 'i')`, [5]int64{1, 1, 0, 1, 1}},
+		{`SELECT regexp('istanbul', 'İSTANBUL', 'i')`, [5]int64{0, 0, 1, 0, 0}},
+		// é as one character and as e with a combining acute accent.
+		{`SELECT regexp('^caf` + "\u00e9" + `$', 'cafe` + "\u0301" + `')`, [5]int64{0, 0, 0, 1, 1}},
+		{`SELECT regexp('^cafe` + "\u0301" + `$', 'caf` + "\u00e9" + `')`, [5]int64{0, 0, 0, 1, 1}},
+		{`SELECT regexp('^café$', CAST('cafe` + "\u0301" + `' AS BLOB))`, [5]int64{0, 0, 0, 1, 1}},
+		// Compatibility characters: a ligature and full-width digits.
+		{`SELECT regexp('^file$', '` + "\ufb01" + `le')`, [5]int64{0, 0, 0, 0, 1}},
+		{`SELECT regexp('^\d{3}$', '` + "\uff11\uff12\uff13" + `')`, [5]int64{0, 0, 0, 0, 1}},
+		// In Turkish, the folded I is ı.
+		{`SELECT regexp('FILE', '` + "\ufb01" + `le', 'i')`, [5]int64{0, 1, 0, 0, 1}},
+	}
+	for _, test := range tests {
+		for i, db := range dbs {
+			var result int64
+			if err := db.QueryRow(test.query).Scan(&result); err != nil {
+				t.Errorf("%s failed with %s: %v", test.query, names[i], err)
+				continue
+			}
+			if result != test.expected[i] {
+				t.Errorf("%s = %d with %s, expected %d", test.query, result, names[i], test.expected[i])
+			}
+		}
+	}
+}
+
+func TestPOSIXClasses(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:", WithPOSIXClasses())
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	plain, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = plain.Close()
+	}()
+
+	tests := []struct {
+		query string
+		posix int64
+		plain int64
+	}{
+		{`SELECT 'école' REGEXP '^[[:alpha:]]+$'`, 1, 0},
+		{`SELECT regexp('^[[:alpha:]]+$', 'école', 'p')`, 1, 1},
+		{`SELECT 'café' REGEXP '^caf[[=e=]]$'`, 1, 0},
+		{`SELECT '2024-01' REGEXP '^[[:digit:]]{4}[[.-.]][[:digit:]]{2}$'`, 1, 0},
+	}
+	for _, test := range tests {
+		for _, db := range []struct {
+			name     string
+			db       *sql.DB
+			expected int64
+		}{{"POSIX", db, test.posix}, {"plain", plain, test.plain}} {
+			var result int64
+			if err := db.db.QueryRow(test.query).Scan(&result); err != nil {
+				t.Errorf("%s failed with %s: %v", test.query, db.name, err)
+				continue
+			}
+			if result != db.expected {
+				t.Errorf("%s = %d with %s, expected %d", test.query, result, db.name, db.expected)
+			}
+		}
+	}
+
+	if _, err := db.Exec(`SELECT '7' REGEXP '[:digit:]'`); err == nil ||
+		!strings.Contains(err.Error(), "outside of a bracket expression") {
+		t.Errorf("Expected an error for a class without brackets, got %v", err)
+	}
+}
+
+func TestRegexLiterals(t *testing.T) {
+	tests := []struct {
+		dialect  LiteralDialect
+		query    string
+		expected int64
+	}{
+		{NoLiterals, `SELECT '/a/i' REGEXP '/a/i'`, 1},
+		{NoLiterals, `SELECT 'AB' REGEXP '(?ix) a b'`, 1},
+		{JavaScriptLiterals, `SELECT 'ORD-42' REGEXP '/^ord-\d+$/i'`, 1},
+		{JavaScriptLiterals, `SELECT '/usr/local' REGEXP '/usr/local'`, 1},
+		{JavaScriptLiterals, `SELECT regexp_like('a' || char(10) || 'b', '/^b$/m')`, 1},
+		{RubyLiterals, `SELECT regexp_like('a' || char(10) || 'b', '/^b$/')`, 1},
+		{RubyLiterals, `SELECT regexp_like('a' || char(10) || 'b', '/a.b/m')`, 1},
+	}
+	for _, test := range tests {
+		db, err := OpenWithRegexp(":memory:", WithRegexLiterals(test.dialect))
+		if err != nil {
+			t.Fatalf("OpenWithRegexp failed: %v", err)
+		}
+		var result int64
+		if err := db.QueryRow(test.query).Scan(&result); err != nil {
+			t.Errorf("%s failed: %v", test.query, err)
+		} else if result != test.expected {
+			t.Errorf("%s = %d, expected %d", test.query, result, test.expected)
+		}
+		_ = db.Close()
+	}
+}
+
+func TestDefaultCaseInsensitive(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:", WithDefaultCaseInsensitive(),
+		WithFunctionAlias("rlike_cs", AliasFlags("c")))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tests := []struct {
+		query    string
+		expected int64
+	}{
+		{`SELECT 'ABC' REGEXP 'abc'`, 1},
+		{`SELECT regexp('abc', 'ABC', 'c')`, 0},
+		{`SELECT 'ABC' REGEXP '(?c)abc'`, 0},
+		{`SELECT regexp_like('ABC', '^abc$', 'm')`, 1},
+		{`SELECT rlike_cs('abc', 'ABC')`, 0},
+		{`SELECT json_array_length(regexp_find_all('aAa', 'a'))`, 3},
+	}
+	for _, test := range tests {
+		var result int64
+		if err := db.QueryRow(test.query).Scan(&result); err != nil {
+			t.Errorf("%s failed: %v", test.query, err)
+		} else if result != test.expected {
+			t.Errorf("%s = %d, expected %d", test.query, result, test.expected)
+		}
+	}
+}
+
+func TestConvertedPatternJoin(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	if _, err := db.Exec(`CREATE TABLE patterns (kind TEXT, pattern TEXT, category TEXT);
+		INSERT INTO patterns VALUES ('glob', '*.txt', 'text'), ('like', 'IMG\_%', 'image'), ('regexp', '^\d+$', 'number');
+		CREATE TABLE items (item TEXT);
+		INSERT INTO items VALUES ('notes.txt'), ('img_001.png'), ('imgX001.png'), ('12345'), ('other')`); err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	rows, err := db.Query(`SELECT i.item, p.category
+		FROM items i JOIN patterns p ON i.item REGEXP CASE p.kind
+			WHEN 'glob' THEN glob_to_regexp(p.pattern)
+			WHEN 'like' THEN like_to_regexp(p.pattern, '\')
+			ELSE p.pattern END
+		ORDER BY i.item`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var item, category string
+		if err := rows.Scan(&item, &category); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		got = append(got, item+":"+category)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Rows failed: %v", err)
+	}
+	want := []string{"12345:number", "img_001.png:image", "notes.txt:text"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Join returned %v, expected %v", got, want)
+	}
+
+	// Each conversion matches what the original operator matches.
+	for _, q := range []string{
+		`SELECT count(*) FROM items WHERE (item GLOB '*.txt') != (item REGEXP glob_to_regexp('*.txt'))`,
+		`SELECT count(*) FROM items WHERE (item LIKE 'IMG%') != (item REGEXP like_to_regexp('IMG%'))`,
+	} {
+		var n int
+		if err := db.QueryRow(q).Scan(&n); err != nil || n != 0 {
+			t.Errorf("%s = %d, %v; expected 0", q, n, err)
+		}
+	}
+}
+
+func TestResultMemoJoin(t *testing.T) {
+	memo := NewResultMemo(1000)
+	db, err := OpenWithRegexp(":memory:", WithResultMemo(memo))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	db.SetMaxOpenConns(1)
+	// A denormalized column: 300 rows of 3 distinct values.
+	if _, err := db.Exec(`CREATE TABLE patterns (pattern TEXT);
+		INSERT INTO patterns VALUES ('^a'), ('an'), ('y$');
+		CREATE TABLE orders (fruit TEXT);
+		WITH RECURSIVE n(i) AS (SELECT 0 UNION ALL SELECT i + 1 FROM n WHERE i < 299)
+		INSERT INTO orders SELECT CASE i % 3 WHEN 0 THEN 'apple' WHEN 1 THEN 'banana' ELSE 'cherry' END FROM n`); err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	var n int
+	if err := db.QueryRow(`SELECT count(*) FROM orders o JOIN patterns p ON o.fruit REGEXP p.pattern`).Scan(&n); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	// apple ^a, banana an, cherry y$.
+	if n != 300 {
+		t.Errorf("Matched %d pairs, expected 300", n)
+	}
+	stats := memo.Stats()
+	if stats.Misses != 9 || stats.Hits != 900-9 || stats.Size != 9 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
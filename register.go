@@ -0,0 +1,144 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sort"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+	"github.com/mattn/go-sqlite3"
+)
+
+// RegisterAllFunctions registers the whole function suite with one
+// connection of db, like RegisterRegexpFunction: the functions of every
+// granular Register function below, those enabled by options such as
+// WithPostgresCompat or WithUnicodeLike, and the virtual tables.
+func RegisterAllFunctions(db *sql.DB, opts ...Option) error {
+	return RegisterRegexpFunction(db, opts...)
+}
+
+// RegisterOnConn registers the function suite with conn, for code managing
+// its own connections, such as migrations holding one for their duration.
+// The connection must come from go-sqlite3.
+func RegisterOnConn(conn *sql.Conn, opts ...Option) error {
+	cfg := newConfig(opts...)
+	return conn.Raw(func(driverConn any) error {
+		sc, ok := driverConn.(*sqlite3.SQLiteConn)
+		if !ok {
+			return driver.ErrBadConn
+		}
+		return registerFunctions(sc, cfg)
+	})
+}
+
+// RegisterOnSQLiteConn registers the function suite with a raw go-sqlite3
+// connection, such as one opened by an embedded server or passed to a
+// connect hook; see also ConnectHook.
+func RegisterOnSQLiteConn(conn *sqlite3.SQLiteConn, opts ...Option) error {
+	return registerFunctions(conn, newConfig(opts...))
+}
+
+// WithOnlyFunctions restricts the registration to the functions and
+// aggregates named names, and to the aliases of regexp along with it,
+// without the virtual tables. Unlike the granular Register functions below,
+// it applies to every connection of OpenWithRegexp, ConnectHook or
+// RegisterDriver:
+//
+//	db, err := sqlite_regexp.OpenWithRegexp("app.db",
+//		sqlite_regexp.WithOnlyFunctions("regexp", "regexp_replace"))
+//
+// Several WithOnlyFunctions add up. The registration fails for a name that
+// is not a function of the suite as configured, such as pg_match without
+// WithPostgresCompat.
+func WithOnlyFunctions(names ...string) Option {
+	return func(c *config) {
+		if c.only == nil {
+			c.only = make(map[string]bool)
+		}
+		for _, name := range names {
+			c.only[name] = true
+		}
+	}
+}
+
+// resolveOnly adds the aliases of regexp to the functions of WithOnlyFunctions
+// and checks that they all exist.
+func (c *config) resolveOnly() error {
+	if c.only == nil {
+		return nil
+	}
+	if c.only["regexp"] {
+		for _, a := range c.Aliases {
+			c.only[a.Name] = true
+		}
+	}
+	known := make(map[string]bool)
+	for _, fn := range core.Functions(&c.Config) {
+		known[fn.Name] = true
+	}
+	for _, agg := range core.Aggregates(&c.Config) {
+		known[agg.Name] = true
+	}
+	names := make([]string, 0, len(c.only))
+	for name := range c.only {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !known[name] {
+			return fmt.Errorf("unknown function %q", name)
+		}
+	}
+	return nil
+}
+
+// RegisterMatchFunctions registers the matching functions: regexp, which
+// implements the REGEXP operator, regexp_like, regexp_full_match,
+// similar_to and the aliases set with WithFunctionAlias.
+//
+// Like RegisterRegexpFunction, this and the other granular Register
+// functions register with one connection of db, so they only suit a db
+// limited to a single connection that is never closed. Pass the same names
+// to WithOnlyFunctions to select them on every connection of a pool.
+func RegisterMatchFunctions(db *sql.DB, opts ...Option) error {
+	return registerOnly(db, opts, "regexp", "regexp_like", "regexp_full_match", "similar_to")
+}
+
+// RegisterReplaceFunctions registers regexp_replace, see
+// RegisterMatchFunctions.
+func RegisterReplaceFunctions(db *sql.DB, opts ...Option) error {
+	return registerOnly(db, opts, "regexp_replace")
+}
+
+// RegisterExtractFunctions registers regexp_extract and
+// regexp_capture_count, see RegisterMatchFunctions.
+func RegisterExtractFunctions(db *sql.DB, opts ...Option) error {
+	return registerOnly(db, opts, "regexp_extract", "regexp_capture_count")
+}
+
+// RegisterJSONFunctions registers the functions returning JSON arrays:
+// regexp_find_all, regexp_captures, regexp_tokenize and regexp_extract_all,
+// see RegisterMatchFunctions.
+func RegisterJSONFunctions(db *sql.DB, opts ...Option) error {
+	return registerOnly(db, opts, "regexp_find_all", "regexp_captures", "regexp_tokenize", "regexp_extract_all")
+}
+
+// RegisterAggregateFunctions registers the aggregates regexp_agg,
+// regexp_agg_json and count_matching, see RegisterMatchFunctions.
+func RegisterAggregateFunctions(db *sql.DB, opts ...Option) error {
+	return registerOnly(db, opts, "regexp_agg", "regexp_agg_json", "count_matching")
+}
+
+// RegisterUtilityFunctions registers the functions working on patterns
+// rather than matching them: glob_to_regexp, like_to_regexp, regexp_valid
+// and regexp_error, see RegisterMatchFunctions.
+func RegisterUtilityFunctions(db *sql.DB, opts ...Option) error {
+	return registerOnly(db, opts, "glob_to_regexp", "like_to_regexp", "regexp_valid", "regexp_error")
+}
+
+// registerOnly registers the functions named names with one connection of
+// db.
+func registerOnly(db *sql.DB, opts []Option, names ...string) error {
+	return RegisterRegexpFunction(db, append([]Option{WithOnlyFunctions(names...)}, opts...)...)
+}
@@ -0,0 +1,160 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+func TestRegisterGranular(t *testing.T) {
+	tests := []struct {
+		register func(*sql.DB, ...Option) error
+		present  []string
+		absent   []string
+	}{
+		{RegisterMatchFunctions,
+			[]string{`SELECT 'a' REGEXP 'a'`, `SELECT regexp_like('a', 'a')`, `SELECT rlike('a', 'a')`},
+			[]string{`SELECT regexp_replace('a', 'a', 'b')`, `SELECT regexp_agg('a', 'a')`}},
+		{RegisterReplaceFunctions,
+			[]string{`SELECT regexp_replace('a', 'a', 'b')`},
+			[]string{`SELECT 'a' REGEXP 'a'`, `SELECT rlike('a', 'a')`}},
+		{RegisterExtractFunctions,
+			[]string{`SELECT regexp_extract('a', 'a')`, `SELECT regexp_capture_count('(a)')`},
+			[]string{`SELECT regexp_find_all('a', 'a')`}},
+		{RegisterJSONFunctions,
+			[]string{`SELECT regexp_find_all('a', 'a')`, `SELECT regexp_captures('a', 'a')`, `SELECT regexp_tokenize('a', 'a')`},
+			[]string{`SELECT regexp_extract('a', 'a')`}},
+		{RegisterAggregateFunctions,
+			[]string{`SELECT regexp_agg('a', 'a')`, `SELECT count_matching('a', 'a')`},
+			[]string{`SELECT 'a' REGEXP 'a'`}},
+		{RegisterUtilityFunctions,
+			[]string{`SELECT glob_to_regexp('*.txt')`, `SELECT like_to_regexp('a%')`,
+				`SELECT regexp_valid('(')`, `SELECT regexp_error('(')`},
+			[]string{`SELECT 'a' REGEXP 'a'`}},
+		{RegisterAllFunctions,
+			[]string{`SELECT 'a' REGEXP 'a'`, `SELECT rlike('a', 'a')`, `SELECT regexp_agg('a', 'a')`, `SELECT regexp_tokenize('a', 'a')`},
+			nil},
+	}
+
+	for i, tt := range tests {
+		db, err := sql.Open("sqlite3", ":memory:")
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		db.SetMaxOpenConns(1)
+		if err := tt.register(db, WithFunctionAlias("rlike", AliasTextFirst())); err != nil {
+			t.Fatalf("Case %d: registration failed: %v", i, err)
+		}
+		var result any
+		for _, query := range tt.present {
+			if err := db.QueryRow(query).Scan(&result); err != nil {
+				t.Errorf("Case %d: %s failed: %v", i, query, err)
+			}
+		}
+		for _, query := range tt.absent {
+			if err := db.QueryRow(query).Scan(&result); err == nil {
+				t.Errorf("Case %d: %s succeeded, expected an unregistered function", i, query)
+			}
+		}
+		_ = db.Close()
+	}
+}
+
+func TestRegisterOnConn(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn failed: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if err := RegisterOnConn(conn, WithFunctionAlias("rlike")); err != nil {
+		t.Fatalf("RegisterOnConn failed: %v", err)
+	}
+	var result int
+	if err := conn.QueryRowContext(ctx, `SELECT rlike('^a', 'abc') + regexp_like('abc', 'c$')`).Scan(&result); err != nil || result != 2 {
+		t.Errorf("Expected 2, got %d, %v", result, err)
+	}
+}
+
+func TestRegisterOnSQLiteConn(t *testing.T) {
+	sql.Register("sqlite3_register_on_sqlite_conn_test", &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return RegisterOnSQLiteConn(conn)
+		},
+	})
+	db, err := sql.Open("sqlite3_register_on_sqlite_conn_test", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var result string
+	if err := db.QueryRow(`SELECT regexp_replace('a1b2', '\d', '')`).Scan(&result); err != nil || result != "ab" {
+		t.Errorf("Expected ab, got %q, %v", result, err)
+	}
+}
+
+func TestWithOnlyFunctions(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:", WithOnlyFunctions("regexp", "regexp_agg"),
+		WithFunctionAlias("rlike", AliasTextFirst()))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// Every connection of the pool gets the selection.
+	ctx := context.Background()
+	var conns []*sql.Conn
+	for range 3 {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("Conn failed: %v", err)
+		}
+		conns = append(conns, conn)
+	}
+	for i, conn := range conns {
+		var result any
+		for _, query := range []string{`SELECT 'a' REGEXP 'a'`, `SELECT rlike('a', 'a')`, `SELECT regexp_agg('a', 'a')`} {
+			if err := conn.QueryRowContext(ctx, query).Scan(&result); err != nil {
+				t.Errorf("Connection %d: %s failed: %v", i, query, err)
+			}
+		}
+		for _, query := range []string{`SELECT regexp_replace('a', 'a', 'b')`, `SELECT * FROM regexp_split('a', ',')`} {
+			if err := conn.QueryRowContext(ctx, query).Scan(&result); err == nil {
+				t.Errorf("Connection %d: %s succeeded, expected an unregistered function", i, query)
+			}
+		}
+		_ = conn.Close()
+	}
+
+	for _, opts := range [][]Option{
+		{WithOnlyFunctions("regexp_nope")},
+		{WithOnlyFunctions("pg_match")},
+	} {
+		if _, err := OpenWithRegexp(":memory:", opts...); err == nil {
+			t.Errorf("Expected an error for an unknown function")
+		}
+	}
+	db2, err := OpenWithRegexp(":memory:", WithOnlyFunctions("pg_match"), WithPostgresCompat())
+	if err != nil {
+		t.Errorf("OpenWithRegexp failed for an enabled function: %v", err)
+	} else {
+		_ = db2.Close()
+	}
+}
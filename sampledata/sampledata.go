@@ -0,0 +1,230 @@
+// Package sampledata generates deterministic datasets for benchmarking and
+// demonstrating the regexp function suite, in the shape of the categorization
+// example: a patterns table and an items table to join on REGEXP.
+//
+//	db, _ := sql.Open(driver.DriverName, ":memory:")
+//	db.SetMaxOpenConns(1)
+//	if err := sampledata.Populate(db, 100_000, 42); err != nil {
+//		log.Fatal(err)
+//	}
+//	rows, _ := db.Query(`SELECT p.category, count(*)
+//		FROM items i JOIN patterns p ON i.item REGEXP p.pattern
+//		GROUP BY p.category`)
+//
+// The same size, seed and options always produce the same rows.
+package sampledata
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+)
+
+// DefaultPatterns is the number of patterns created by default.
+const DefaultPatterns = 8
+
+// DefaultMatchDensity is the default fraction of items generated to match a
+// pattern.
+const DefaultMatchDensity = 0.5
+
+// Option configures Populate and PopulateContext.
+type Option func(*options)
+
+type options struct {
+	patterns int
+	density  float64
+}
+
+// WithPatterns sets the number of patterns, DefaultPatterns by default. The
+// patterns cycle through a fixed set of shapes (order numbers, e-mail
+// addresses, dates, ...), made distinct by a numeric suffix from the second
+// cycle on.
+func WithPatterns(n int) Option {
+	return func(o *options) {
+		o.patterns = n
+	}
+}
+
+// WithMatchDensity sets the fraction of items, between 0 and 1, generated to
+// match one of the patterns, DefaultMatchDensity by default. The other items
+// are filler text that matches none.
+func WithMatchDensity(d float64) Option {
+	return func(o *options) {
+		o.density = d
+	}
+}
+
+// Populate creates and fills two tables in db:
+//
+//	patterns (pattern TEXT, category TEXT)
+//	items    (item TEXT, amount REAL, expected TEXT)
+//
+// items holds n rows. expected is the category of the pattern an item was
+// generated to match, or NULL for filler; every item matches the pattern of
+// its category and no other. Populate fails if either table exists.
+func Populate(db *sql.DB, n int, seed int64, opts ...Option) error {
+	return PopulateContext(context.Background(), db, n, seed, opts...)
+}
+
+// PopulateContext is like Populate, but stops, rolling back the tables,
+// once ctx is done.
+func PopulateContext(ctx context.Context, db *sql.DB, n int, seed int64, opts ...Option) error {
+	o := options{patterns: DefaultPatterns, density: DefaultMatchDensity}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if n < 0 {
+		return fmt.Errorf("invalid number of items %d", n)
+	}
+	if o.patterns < 1 {
+		return fmt.Errorf("invalid number of patterns %d", o.patterns)
+	}
+	if o.density < 0 || o.density > 1 {
+		return fmt.Errorf("invalid match density %g", o.density)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `CREATE TABLE patterns (pattern TEXT, category TEXT);
+		CREATE TABLE items (item TEXT, amount REAL, expected TEXT)`); err != nil {
+		return err
+	}
+
+	rules := make([]rule, o.patterns)
+	for i := range rules {
+		rules[i] = newRule(i)
+		if _, err := tx.ExecContext(ctx, `INSERT INTO patterns (pattern, category) VALUES (?, ?)`,
+			rules[i].pattern, rules[i].category); err != nil {
+			return err
+		}
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO items (item, amount, expected) VALUES (?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	r := rand.New(rand.NewPCG(uint64(seed), 0))
+	for range n {
+		amount := float64(r.IntN(100_000)) / 100
+		if r.Float64() < o.density {
+			rule := rules[r.IntN(len(rules))]
+			_, err = stmt.ExecContext(ctx, rule.generate(r), amount, rule.category)
+		} else {
+			// Lowercase words match no pattern.
+			_, err = stmt.ExecContext(ctx, sentence(r), amount, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+type rule struct {
+	category string
+	pattern  string
+	generate func(r *rand.Rand) string
+}
+
+// shape generates a pattern and matching texts, distinguished by tag.
+type shape struct {
+	name     string
+	pattern  func(tag string) string
+	generate func(r *rand.Rand, tag string) string
+}
+
+// shapes are the kinds of patterns, anchored and with literal parts no
+// filler contains, so that every text matches its own pattern only.
+var shapes = []shape{
+	{"order",
+		func(tag string) string { return `^ORD` + tag + `-\d{6}$` },
+		func(r *rand.Rand, tag string) string { return fmt.Sprintf("ORD%s-%06d", tag, r.IntN(1_000_000)) }},
+	{"email",
+		func(tag string) string { return `^[a-z]+\.[a-z]+@mail` + tag + `\.example\.(com|org)$` },
+		func(r *rand.Rand, tag string) string {
+			return word(r) + "." + word(r) + "@mail" + tag + ".example." + pick(r, "com", "org")
+		}},
+	{"phone",
+		func(tag string) string { return `^\+1` + tag + ` \d{3}-\d{3}-\d{4}$` },
+		func(r *rand.Rand, tag string) string {
+			return fmt.Sprintf("+1%s %03d-%03d-%04d", tag, r.IntN(1000), r.IntN(1000), r.IntN(10000))
+		}},
+	{"date",
+		func(tag string) string { return `^D` + tag + `:\d{4}-(0[1-9]|1[0-2])-(0[1-9]|[12]\d|3[01])$` },
+		func(r *rand.Rand, tag string) string {
+			return fmt.Sprintf("D%s:%d-%02d-%02d", tag, 2000+r.IntN(30), 1+r.IntN(12), 1+r.IntN(28))
+		}},
+	{"ip",
+		func(tag string) string { return `^IP` + tag + `=(\d{1,3}\.){3}\d{1,3}$` },
+		func(r *rand.Rand, tag string) string {
+			return fmt.Sprintf("IP%s=%d.%d.%d.%d", tag, r.IntN(256), r.IntN(256), r.IntN(256), r.IntN(256))
+		}},
+	{"url",
+		func(tag string) string { return `^https?://[a-z]+\.site` + tag + `\.example/[a-z/]*$` },
+		func(r *rand.Rand, tag string) string {
+			return pick(r, "http", "https") + "://" + word(r) + ".site" + tag + ".example/" + word(r) + "/" + word(r)
+		}},
+	{"error",
+		func(tag string) string { return `(?i)^\[ERROR` + tag + `\] ` },
+		func(r *rand.Rand, tag string) string {
+			return pick(r, "[ERROR", "[error", "[Error") + tag + "] " + sentence(r)
+		}},
+	{"sku",
+		func(tag string) string { return `^SKU` + tag + `/[A-Z]{3}-[A-Z0-9]{4}$` },
+		func(r *rand.Rand, tag string) string {
+			const letters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+			const alnum = letters + "0123456789"
+			var b strings.Builder
+			b.WriteString("SKU" + tag + "/")
+			for range 3 {
+				b.WriteByte(letters[r.IntN(len(letters))])
+			}
+			b.WriteByte('-')
+			for range 4 {
+				b.WriteByte(alnum[r.IntN(len(alnum))])
+			}
+			return b.String()
+		}},
+}
+
+// newRule returns the i-th rule: the shapes in order, then again with the
+// tag "1", "2", ...
+func newRule(i int) rule {
+	s := shapes[i%len(shapes)]
+	tag, category := "", s.name
+	if cycle := i / len(shapes); cycle > 0 {
+		tag = fmt.Sprint(cycle)
+		category = fmt.Sprintf("%s_%d", s.name, cycle)
+	}
+	return rule{
+		category: category,
+		pattern:  s.pattern(tag),
+		generate: func(r *rand.Rand) string { return s.generate(r, tag) },
+	}
+}
+
+var words = strings.Fields(`alpha bravo cedar delta ember falcon garnet harbor
+	indigo juniper kestrel lumen meadow nectar orchid pebble quartz raven
+	sierra tundra umber velvet willow yarrow zephyr`)
+
+func word(r *rand.Rand) string {
+	return words[r.IntN(len(words))]
+}
+
+func pick(r *rand.Rand, choices ...string) string {
+	return choices[r.IntN(len(choices))]
+}
+
+func sentence(r *rand.Rand) string {
+	parts := make([]string, 3+r.IntN(6))
+	for i := range parts {
+		parts[i] = word(r)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,134 @@
+package sampledata
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/go-sqlite-regexp/driver"
+)
+
+func open(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open(driver.DriverName, ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func TestPopulate(t *testing.T) {
+	db := open(t)
+	const n = 2000
+	if err := Populate(db, n, 7, WithPatterns(20), WithMatchDensity(0.3)); err != nil {
+		t.Fatalf("Populate failed: %v", err)
+	}
+
+	var items, patterns, expected int
+	if err := db.QueryRow(`SELECT (SELECT count(*) FROM items), (SELECT count(*) FROM patterns),
+		(SELECT count(expected) FROM items)`).Scan(&items, &patterns, &expected); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if items != n || patterns != 20 {
+		t.Errorf("got %d items and %d patterns, expected %d and 20", items, patterns, n)
+	}
+	if density := float64(expected) / n; math.Abs(density-0.3) > 0.05 {
+		t.Errorf("match density %.3f, expected about 0.3", density)
+	}
+
+	// Every item matches the pattern of its category, and no other.
+	var wrong int
+	if err := db.QueryRow(`SELECT count(*) FROM items i
+		WHERE (SELECT group_concat(p.category) FROM patterns p WHERE i.item REGEXP p.pattern)
+			IS NOT i.expected`).Scan(&wrong); err != nil {
+		t.Fatalf("Failed to match items: %v", err)
+	}
+	if wrong != 0 {
+		t.Errorf("%d items do not match exactly the pattern of their category", wrong)
+	}
+}
+
+func TestPopulateDeterministic(t *testing.T) {
+	dump := func(seed int64) []string {
+		db := open(t)
+		if err := Populate(db, 100, seed); err != nil {
+			t.Fatalf("Populate failed: %v", err)
+		}
+		rows, err := db.Query(`SELECT item || '|' || amount || '|' || ifnull(expected, '') FROM items ORDER BY rowid`)
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		defer func() {
+			_ = rows.Close()
+		}()
+		var out []string
+		for rows.Next() {
+			var row string
+			if err := rows.Scan(&row); err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+			out = append(out, row)
+		}
+		return out
+	}
+
+	a, b, c := dump(1), dump(1), dump(2)
+	if len(a) != 100 {
+		t.Fatalf("got %d rows, expected 100", len(a))
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("row %d differs for the same seed: %q and %q", i, a[i], b[i])
+		}
+	}
+	same := 0
+	for i := range a {
+		if a[i] == c[i] {
+			same++
+		}
+	}
+	if same == len(a) {
+		t.Error("different seeds produced the same rows")
+	}
+}
+
+func TestPopulateInvalid(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithPatterns(0)},
+		{WithMatchDensity(1.5)},
+		{WithMatchDensity(-0.1)},
+	} {
+		if err := Populate(open(t), 10, 1, opts...); err == nil {
+			t.Error("Expected an error for invalid options")
+		}
+	}
+
+	db := open(t)
+	if err := Populate(db, 10, 1); err != nil {
+		t.Fatalf("Populate failed: %v", err)
+	}
+	if err := Populate(db, 10, 1); err == nil {
+		t.Error("Expected an error for existing tables")
+	}
+}
+
+func TestPopulateContext(t *testing.T) {
+	db := open(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := PopulateContext(ctx, db, 100_000_000, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	// The tables are rolled back.
+	var tables int
+	if err := db.QueryRow(`SELECT count(*) FROM sqlite_master`).Scan(&tables); err != nil || tables != 0 {
+		t.Errorf("Found %d tables after cancellation, %v", tables, err)
+	}
+}
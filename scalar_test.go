@@ -0,0 +1,239 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestFlagsArgument(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tests := []struct {
+		query    string
+		expected sql.NullString
+	}{
+		{`SELECT regexp('^hello', 'HELLO world')`, sql.NullString{String: "0", Valid: true}},
+		{`SELECT regexp('^hello', 'HELLO world', 'i')`, sql.NullString{String: "1", Valid: true}},
+		{`SELECT regexp('^b$', 'a' || char(10) || 'b', 'm')`, sql.NullString{String: "1", Valid: true}},
+		{`SELECT regexp('a.b', 'a' || char(10) || 'b', 's')`, sql.NullString{String: "1", Valid: true}},
+		{`SELECT regexp('a', 'a', NULL)`, sql.NullString{}},
+		{"SELECT regexp('^ \\d{4}  # year\n - \\d{2}  # month\n$', '2024-01', 'x')", sql.NullString{String: "1", Valid: true}},
+		{`SELECT regexp_extract('a b', 'a \ (b)', 1, 'x')`, sql.NullString{String: "b", Valid: true}},
+		{`SELECT regexp_like('ABC', 'b', 'i')`, sql.NullString{String: "1", Valid: true}},
+		{`SELECT regexp_extract('<a><b>', '<.+>', 0, 'U')`, sql.NullString{String: "<a>", Valid: true}},
+		{`SELECT regexp_replace('Cat cat', 'cat', 'dog', 'i')`, sql.NullString{String: "dog dog", Valid: true}},
+		{`SELECT regexp_find_all('A a', 'a', 'i')`, sql.NullString{String: `["A","a"]`, Valid: true}},
+		{`SELECT regexp_captures('Ab', '(a)(b)', 'i')`, sql.NullString{String: `[["Ab","A","b"]]`, Valid: true}},
+		{`SELECT regexp_tokenize('1a2A3', 'a', 'i')`, sql.NullString{String: `["1","2","3"]`, Valid: true}},
+		{`SELECT regexp_extract('abcd', 'a|ab|abc')`, sql.NullString{String: "a", Valid: true}},
+		{`SELECT regexp_extract('abcd', 'a|ab|abc', 0, 'l')`, sql.NullString{String: "abc", Valid: true}},
+		{`SELECT regexp_replace('xaby', 'a|ab', '-', 'l')`, sql.NullString{String: "x-y", Valid: true}},
+	}
+
+	for _, test := range tests {
+		var result sql.NullString
+		if err := db.QueryRow(test.query).Scan(&result); err != nil {
+			t.Errorf("%s failed: %v", test.query, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("%s = %+v, expected %+v", test.query, result, test.expected)
+		}
+	}
+
+	var result sql.NullString
+	if err := db.QueryRow(`SELECT regexp('a', 'a', 'q')`).Scan(&result); err == nil {
+		t.Error("Expected error for invalid flag, got nil")
+	}
+	if err := db.QueryRow(`SELECT regexp('a', 'a', 'i', 'x')`).Scan(&result); err == nil {
+		t.Error("Expected error for too many arguments, got nil")
+	}
+}
+
+func TestRegexpReplaceAndExtract(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tests := []struct {
+		query    string
+		expected sql.NullString
+	}{
+		{`SELECT regexp_replace('2024-01-31', '(\d+)-(\d+)-(\d+)', '$3/$2/$1')`, sql.NullString{String: "31/01/2024", Valid: true}},
+		{`SELECT regexp_replace('a1b2', '\d', '')`, sql.NullString{String: "ab", Valid: true}},
+		{`SELECT regexp_replace(NULL, 'a', 'b')`, sql.NullString{}},
+		{`SELECT regexp_extract('order ORD-123 shipped', 'ORD-\d+')`, sql.NullString{String: "ORD-123", Valid: true}},
+		{`SELECT regexp_extract('order ORD-123 shipped', 'ORD-(\d+)', 1)`, sql.NullString{String: "123", Valid: true}},
+		{`SELECT regexp_extract('key=value', '(?P<k>\w+)=(?P<v>\w+)', 'v')`, sql.NullString{String: "value", Valid: true}},
+		{`SELECT regexp_extract('no match', '\d+')`, sql.NullString{}},
+		{`SELECT regexp_extract('ab', 'a(x)?', 1)`, sql.NullString{}},
+		{`SELECT regexp_extract('ab', 'a', NULL)`, sql.NullString{}},
+		{`SELECT regexp_capture_count('(\d+)-(?P<month>\d+)-(?:\d+)')`, sql.NullString{String: "2", Valid: true}},
+		{`SELECT regexp_capture_count('abc')`, sql.NullString{String: "0", Valid: true}},
+		{"SELECT regexp_capture_count('(a) # (b)', 'x')", sql.NullString{String: "1", Valid: true}},
+		{`SELECT regexp_capture_count(NULL)`, sql.NullString{}},
+		{`SELECT regexp_valid('ORD-\d+')`, sql.NullString{String: "1", Valid: true}},
+		{`SELECT regexp_valid('(')`, sql.NullString{String: "0", Valid: true}},
+		{`SELECT regexp_valid('a', 'q')`, sql.NullString{String: "0", Valid: true}},
+		{`SELECT regexp_valid(NULL)`, sql.NullString{}},
+		{`SELECT regexp_valid('a', NULL)`, sql.NullString{}},
+		{`SELECT regexp_error('ORD-\d+')`, sql.NullString{}},
+		{`SELECT regexp_error('(')`, sql.NullString{String: "error parsing regexp: missing closing ): `(`", Valid: true}},
+		{`SELECT regexp_error('a', 'q')`, sql.NullString{String: `invalid flag 'q'`, Valid: true}},
+		{`SELECT regexp_full_match('ORD-1234', 'ORD-\d+')`, sql.NullString{String: "1", Valid: true}},
+		{`SELECT regexp_full_match('ORD-1234x', 'ORD-\d+')`, sql.NullString{String: "0", Valid: true}},
+		{`SELECT regexp_full_match('ab', 'a|ab')`, sql.NullString{String: "1", Valid: true}},
+		{`SELECT regexp_full_match('ABC', 'abc', 'i')`, sql.NullString{String: "1", Valid: true}},
+		{"SELECT regexp_full_match('a' || char(10), 'a', 'm')", sql.NullString{String: "0", Valid: true}},
+		{"SELECT regexp_full_match('ab', 'a b # comment', 'x')", sql.NullString{String: "1", Valid: true}},
+		{`SELECT regexp_full_match('', '')`, sql.NullString{String: "1", Valid: true}},
+		{`SELECT regexp_full_match(NULL, 'a')`, sql.NullString{}},
+		{`SELECT regexp_full_match('a', NULL)`, sql.NullString{}},
+	}
+
+	for _, test := range tests {
+		var result sql.NullString
+		if err := db.QueryRow(test.query).Scan(&result); err != nil {
+			t.Errorf("%s failed: %v", test.query, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("%s = %+v, expected %+v", test.query, result, test.expected)
+		}
+	}
+
+	var result sql.NullString
+	if err := db.QueryRow(`SELECT regexp_extract('ab', 'a', 2)`).Scan(&result); err == nil {
+		t.Error("Expected error for out of range group, got nil")
+	}
+	if err := db.QueryRow(`SELECT regexp_capture_count('(')`).Scan(&result); err == nil {
+		t.Error("Expected error for invalid pattern, got nil")
+	}
+	// The pattern is checked before it is anchored.
+	if err := db.QueryRow(`SELECT regexp_full_match('a', 'a)|(b')`).Scan(&result); err == nil {
+		t.Error("Expected error for unbalanced parentheses, got nil")
+	}
+}
+
+func TestLongestMatch(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:", WithLongestMatch(), WithCache(NewCache()))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT regexp_extract('abcd', 'a|ab|abc')`, "abc"},
+		{`SELECT regexp_find_all('if ifdef', 'if|ifdef')`, `["if","ifdef"]`},
+		{`SELECT regexp_extract('<a><b>', '<.+?>')`, "<a><b>"},
+		{`SELECT 'abc' REGEXP 'a|ab'`, "1"},
+	}
+	for _, test := range tests {
+		var result string
+		if err := db.QueryRow(test.query).Scan(&result); err != nil {
+			t.Errorf("%s failed: %v", test.query, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("%s = %q, expected %q", test.query, result, test.expected)
+		}
+	}
+}
+
+func TestPatternValidation(t *testing.T) {
+	lib := NewPatternLibrary()
+	if err := lib.Define("order", `ORD-\d+`); err != nil {
+		t.Fatal(err)
+	}
+	db, err := OpenWithRegexp(":memory:", WithPatternLibrary(lib))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	if _, err := db.Exec(`CREATE TABLE patterns (id INTEGER PRIMARY KEY, pattern TEXT, flags TEXT);
+		INSERT INTO patterns (pattern, flags) VALUES
+			('{{order}}', ''), ('{{invoice}}', ''), ('a(b', ''), ('^x$', 'mq'), ('\w+', 'i')`); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query(`SELECT id, regexp_error(pattern, flags) FROM patterns
+		WHERE NOT regexp_valid(pattern, flags) ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var broken []string
+	for rows.Next() {
+		var id int
+		var msg string
+		if err := rows.Scan(&id, &msg); err != nil {
+			t.Fatal(err)
+		}
+		broken = append(broken, msg)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`unknown pattern "invoice"`,
+		"error parsing regexp: missing closing ): `a(b`",
+		`invalid flag 'q'`,
+	}
+	if len(broken) != len(expected) {
+		t.Fatalf("Broken patterns %q, expected %q", broken, expected)
+	}
+	for i := range expected {
+		if !strings.Contains(broken[i], expected[i]) {
+			t.Errorf("Broken pattern %d reported %q, expected %q", i, broken[i], expected[i])
+		}
+	}
+}
+
+func TestReplacementSyntax(t *testing.T) {
+	tests := []struct {
+		syntax   ReplacementSyntax
+		query    string
+		expected string
+	}{
+		{ReplaceDollar, `SELECT regexp_replace('2024-01-31', '(\d+)-(\d+)-(\d+)', '\3/\2/\1')`, `\3/\2/\1`},
+		{ReplaceDollar, `SELECT regexp_replace('ab', '(?P<x>a)', '${x}$$')`, "a$b"},
+		{ReplaceBackslash, `SELECT regexp_replace('2024-01-31', '(\d+)-(\d+)-(\d+)', '\3/\2/\1')`, "31/01/2024"},
+		{ReplaceBackslash, `SELECT regexp_replace('ab', '(a)', '\1x\0$1\\')`, `axa$1\b`},
+		{ReplaceBackslash, `SELECT regexp_replace('ab', 'a', '\n')`, `\nb`},
+		{ReplaceDollarOrBackslash, `SELECT regexp_replace('ab', '(a)(b)', '\2${1}\\')`, `ba\`},
+		{ReplaceDollarOrBackslash, `SELECT regexp_replace('ab', '(a)(b)', '$2$1')`, "ba"},
+	}
+	for _, test := range tests {
+		db, err := OpenWithRegexp(":memory:", WithReplacementSyntax(test.syntax))
+		if err != nil {
+			t.Fatalf("OpenWithRegexp failed: %v", err)
+		}
+		var result string
+		if err := db.QueryRow(test.query).Scan(&result); err != nil {
+			t.Errorf("%s failed: %v", test.query, err)
+		} else if result != test.expected {
+			t.Errorf("%s = %q with syntax %d, expected %q", test.query, result, test.syntax, test.expected)
+		}
+		_ = db.Close()
+	}
+}
@@ -0,0 +1,128 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+)
+
+// PatternSets holds named sets of patterns, compiled when they are defined,
+// for the regexp_set_match(text, set) function registered by
+// WithPatternSets. It returns the ID of the first pattern of the set
+// matching text, or NULL, which categorizes a table with one call per row
+// instead of a join with a table of patterns:
+//
+//	sets, err := sqlite_regexp.NewPatternSets()
+//	...
+//	err = sets.Define("merchants", []sqlite_regexp.SetPattern{
+//		{ID: "coffee", Pattern: `starbucks|costa`, Flags: "i"},
+//		{ID: "travel", Pattern: `^(uber|lyft)\b`},
+//	})
+//	db, err := sqlite_regexp.OpenWithRegexp("app.db", sqlite_regexp.WithPatternSets(sets))
+//	// SELECT description, regexp_set_match(description, 'merchants') FROM transactions
+//
+// SetCombined compiles the sets defined afterwards into a single
+// alternation of their patterns, tracking which one matched, so that each
+// text is searched once rather than once per pattern. A PatternSets is safe
+// for concurrent use, and sets may be redefined or removed while queries
+// run.
+type PatternSets = core.PatternSets
+
+// SetPattern is a pattern of a pattern set, with the ID regexp_set_match
+// returns for it and its flags.
+type SetPattern = core.SetPattern
+
+// NewPatternSets returns an empty collection of pattern sets. Their patterns
+// are compiled with opts, which should be those of the databases using
+// them, for the options affecting patterns, such as WithPatternLibrary or
+// WithCaseFolding.
+func NewPatternSets(opts ...Option) (*PatternSets, error) {
+	cfg := newConfig(opts...)
+	if cfg.err != nil {
+		return nil, cfg.err
+	}
+	return core.NewPatternSets(&cfg.Config), nil
+}
+
+// WithPatternSets registers regexp_set_match(text, set), which matches text
+// against the sets of sets, see PatternSets.
+func WithPatternSets(sets *PatternSets) Option {
+	return func(c *config) {
+		c.Sets = sets
+	}
+}
+
+// PatternSetTable describes a table of patterns to load as a pattern set,
+// see LoadPatternSet.
+type PatternSetTable struct {
+	// Table is the table of the patterns. PatternColumn and IDColumn are its
+	// columns holding the patterns and the IDs returned for them, pattern
+	// and category by default.
+	Table         string
+	PatternColumn string
+	IDColumn      string
+	// FlagsColumn, if set, holds the flags of each pattern.
+	FlagsColumn string
+	// PriorityColumn, if set, orders the patterns, lowest first; they are
+	// in rowid order otherwise, or among patterns of equal priority.
+	PriorityColumn string
+}
+
+// LoadPatternSet defines the set called name of sets with the patterns of a
+// table, skipping NULL patterns, and returns their number. As with Define,
+// the set is left as it was if any pattern does not compile. Loading the
+// table again picks up its changes.
+func LoadPatternSet(db *sql.DB, sets *PatternSets, name string, src PatternSetTable) (int, error) {
+	return LoadPatternSetContext(context.Background(), db, sets, name, src)
+}
+
+// LoadPatternSetContext is like LoadPatternSet, but stops, interrupting the
+// query, once ctx is done.
+func LoadPatternSetContext(ctx context.Context, db *sql.DB, sets *PatternSets, name string, src PatternSetTable) (int, error) {
+	if src.Table == "" {
+		return 0, errors.New("pattern set table is not set")
+	}
+	if src.PatternColumn == "" {
+		src.PatternColumn = "pattern"
+	}
+	if src.IDColumn == "" {
+		src.IDColumn = "category"
+	}
+	flags := "''"
+	if src.FlagsColumn != "" {
+		flags = fmt.Sprintf("coalesce(%s, '')", quoteIdent(src.FlagsColumn))
+	}
+	order := "rowid"
+	if src.PriorityColumn != "" {
+		order = quoteIdent(src.PriorityColumn) + ", rowid"
+	}
+	query := fmt.Sprintf(`SELECT coalesce(%s, ''), %s, %s FROM %s WHERE %s IS NOT NULL ORDER BY %s`,
+		quoteIdent(src.IDColumn), quoteIdent(src.PatternColumn), flags, quoteIdent(src.Table),
+		quoteIdent(src.PatternColumn), order)
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var patterns []SetPattern
+	for rows.Next() {
+		var p SetPattern
+		if err := rows.Scan(&p.ID, &p.Pattern, &p.Flags); err != nil {
+			return 0, err
+		}
+		patterns = append(patterns, p)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return len(patterns), sets.Define(name, patterns)
+}
@@ -0,0 +1,147 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestPatternSets(t *testing.T) {
+	sets, err := NewPatternSets()
+	if err != nil {
+		t.Fatalf("NewPatternSets failed: %v", err)
+	}
+	db, err := OpenWithRegexp(":memory:", WithPatternSets(sets))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	if _, err := db.Exec(`
+CREATE TABLE rules (category TEXT, pattern TEXT, flags TEXT, priority INTEGER);
+INSERT INTO rules VALUES
+	('card', 'card', NULL, 2),
+	('coffee', 'starbucks|costa', 'i', 1),
+	('ignored', NULL, NULL, 0),
+	('travel', '^(uber|lyft)\b', NULL, 1);
+CREATE TABLE transactions (id INTEGER PRIMARY KEY, description TEXT);
+INSERT INTO transactions (description) VALUES
+	('STARBUCKS card payment'), ('uber trip'), ('card fee'), ('rent'), (NULL);`); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	n, err := LoadPatternSet(db, sets, "merchants", PatternSetTable{Table: "rules", FlagsColumn: "flags", PriorityColumn: "priority"})
+	if err != nil {
+		t.Fatalf("LoadPatternSet failed: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Loaded %d patterns, expected 3", n)
+	}
+
+	rows, err := db.Query(`SELECT regexp_set_match(description, 'merchants') FROM transactions ORDER BY id`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	var got []sql.NullString
+	for rows.Next() {
+		var category sql.NullString
+		if err := rows.Scan(&category); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		got = append(got, category)
+	}
+	_ = rows.Close()
+	expected := []sql.NullString{{String: "coffee", Valid: true}, {String: "travel", Valid: true}, {String: "card", Valid: true}, {}, {}}
+	if len(got) != len(expected) {
+		t.Fatalf("Got %v, expected %v", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Row %d: got %v, expected %v", i+1, got[i], expected[i])
+		}
+	}
+
+	// A set with an invalid pattern is not loaded.
+	if _, err := db.Exec(`INSERT INTO rules VALUES ('broken', '(', NULL, 0)`); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if _, err := LoadPatternSet(db, sets, "merchants", PatternSetTable{Table: "rules"}); err == nil {
+		t.Error("Expected an error loading an invalid pattern")
+	}
+	var category string
+	if err := db.QueryRow(`SELECT regexp_set_match('lyft', 'merchants')`).Scan(&category); err != nil || category != "travel" {
+		t.Errorf("Got %q, %v, expected the set to be unchanged", category, err)
+	}
+
+	if _, err := db.Exec(`SELECT regexp_set_match('x', 'unknown')`); err == nil {
+		t.Error("Expected an error for an unknown set")
+	}
+}
+
+func TestPatternSetsOptions(t *testing.T) {
+	lib := NewPatternLibrary()
+	if err := lib.Define("order", `ORD-\d+`); err != nil {
+		t.Fatalf("Define failed: %v", err)
+	}
+	sets, err := NewPatternSets(WithPatternLibrary(lib), WithDefaultCaseInsensitive())
+	if err != nil {
+		t.Fatalf("NewPatternSets failed: %v", err)
+	}
+	if err := sets.Define("refs", []SetPattern{{ID: "order", Pattern: `^{{order}}$`}}); err != nil {
+		t.Fatalf("Define failed: %v", err)
+	}
+	db, err := OpenWithRegexp(":memory:", WithPatternSets(sets))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	var id string
+	if err := db.QueryRow(`SELECT regexp_set_match('ord-42', 'refs')`).Scan(&id); err != nil || id != "order" {
+		t.Errorf("Got %q, %v, expected \"order\"", id, err)
+	}
+
+	if _, err := NewPatternSets(WithFunctionAlias("bad name")); err == nil {
+		t.Error("Expected an error for an invalid option")
+	}
+}
+
+func TestPatternSetsCombined(t *testing.T) {
+	sets, err := NewPatternSets()
+	if err != nil {
+		t.Fatalf("NewPatternSets failed: %v", err)
+	}
+	sets.SetCombined(true)
+	db, err := OpenWithRegexp(":memory:", WithPatternSets(sets))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	if _, err := db.Exec(`
+CREATE TABLE rules (category TEXT, pattern TEXT);
+INSERT INTO rules VALUES ('total', 'total: (\d+)$'), ('order', '^ORD-\d+'), ('any-digit', '\d');`); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if _, err := LoadPatternSet(db, sets, "lines", PatternSetTable{Table: "rules"}); err != nil {
+		t.Fatalf("LoadPatternSet failed: %v", err)
+	}
+	for text, expected := range map[string]sql.NullString{
+		"ORD-1 total: 12": {String: "total", Valid: true},
+		"ORD-1 total: x":  {String: "order", Valid: true},
+		"x ORD-1":         {String: "any-digit", Valid: true},
+		"none":            {},
+	} {
+		var got sql.NullString
+		if err := db.QueryRow(`SELECT regexp_set_match(?, 'lines')`, text).Scan(&got); err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		if got != expected {
+			t.Errorf("regexp_set_match(%q) = %v, expected %v", text, got, expected)
+		}
+	}
+}
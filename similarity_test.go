@@ -0,0 +1,97 @@
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestSimilarityFunctions(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	var distance int
+	if err := db.QueryRow(`SELECT levenshtein('a', 'b')`).Scan(&distance); err == nil {
+		t.Error("levenshtein registered without WithSimilarityFunctions")
+	}
+	_ = db.Close()
+
+	db, err = OpenWithRegexp(":memory:", WithSimilarityFunctions())
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	if _, err := db.Exec(`
+		CREATE TABLE categories (name TEXT, pattern TEXT, vendor TEXT);
+		INSERT INTO categories VALUES
+			('cloud', '(?i)invoice.*aws', 'amazon'),
+			('office', '(?i)staples', 'staples');
+		CREATE TABLE messages (id INTEGER PRIMARY KEY, subject TEXT, vendor TEXT);
+		INSERT INTO messages (subject, vendor) VALUES
+			('Invoice from AWS', 'amazon'),
+			('Your receipt', 'Amazn'),
+			('Order shipped', 'Stapels'),
+			('Hello', NULL)`); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.Query(`SELECT m.id, coalesce(
+			(SELECT c.name FROM categories c WHERE m.subject REGEXP c.pattern),
+			(SELECT name FROM (SELECT c.name, min(levenshtein(lower(m.vendor), c.vendor)) AS distance
+				FROM categories c) WHERE distance <= 2)
+		) FROM messages m ORDER BY m.id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var categories []sql.NullString
+	for rows.Next() {
+		var id int
+		var category sql.NullString
+		if err := rows.Scan(&id, &category); err != nil {
+			t.Fatal(err)
+		}
+		categories = append(categories, category)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []sql.NullString{
+		{String: "cloud", Valid: true},
+		{String: "cloud", Valid: true},
+		{String: "office", Valid: true},
+		{},
+	}
+	if len(categories) != len(expected) {
+		t.Fatalf("Categories %v, expected %v", categories, expected)
+	}
+	for i := range expected {
+		if categories[i] != expected[i] {
+			t.Errorf("Message %d categorized %+v, expected %+v", i+1, categories[i], expected[i])
+		}
+	}
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT soundex('Rupert') = soundex('Robert')`, "1"},
+		{`SELECT difference('Anne', 'Ann')`, "4"},
+		{`SELECT metaphone('Philip', 2)`, "FL"},
+	}
+	for _, test := range tests {
+		var result string
+		if err := db.QueryRow(test.query).Scan(&result); err != nil {
+			t.Errorf("%s failed: %v", test.query, err)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("%s = %q, expected %q", test.query, result, test.expected)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Package sqlitetest provides the scaffolding of unit tests for code using
+// the regexp function suite: an in-memory database with the suite
+// registered, fixtures of pattern tables, and match assertions.
+//
+//	func TestCategorize(t *testing.T) {
+//		db := sqlitetest.NewTestDB(t)
+//		sqlitetest.LoadPatternsFixture(t, db, "testdata/patterns.yaml")
+//		sqlitetest.AssertMatches(t, db, `^ORD-\d+$`, "ORD-42")
+//		...
+//	}
+//
+// Every helper fails the test on errors, so tests need no error handling of
+// their own.
+package sqlitetest
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+	"go.yaml.in/yaml/v3"
+)
+
+// NewTestDB opens an in-memory database with the function suite configured
+// by opts and closes it when the test ends. The pool is limited to one
+// connection, since every connection to ":memory:" opens a database of its
+// own.
+func NewTestDB(t testing.TB, opts ...sqlite_regexp.Option) *sql.DB {
+	t.Helper()
+	db, err := sqlite_regexp.OpenWithRegexp(":memory:", opts...)
+	if err != nil {
+		t.Fatalf("sqlitetest: opening database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+// LoadPatternsFixture loads the YAML file at path into db. The file maps
+// table names to their rows, each a mapping of column names to values:
+//
+//	patterns:
+//	  - pattern: '^ORD-\d+$'
+//	    category: orders
+//	  - pattern: '(?i)refund'
+//	    category: billing
+//	items:
+//	  - item: ORD-42
+//
+// Tables that do not exist are created, without column types, with the
+// columns of their rows in order of appearance. The values of the columns
+// named pattern must be valid patterns for db: the test fails on the first
+// invalid one, reporting its message, rather than in the middle of a join.
+func LoadPatternsFixture(t testing.TB, db *sql.DB, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("sqlitetest: %v", err)
+	}
+	tables, err := parseFixture(data)
+	if err != nil {
+		t.Fatalf("sqlitetest: %s: %v", path, err)
+	}
+	for _, table := range tables {
+		if err := table.load(db); err != nil {
+			t.Fatalf("sqlitetest: %s: table %s: %v", path, table.name, err)
+		}
+	}
+	for _, table := range tables {
+		if msg, row := table.invalidPattern(db); msg != "" {
+			t.Fatalf("sqlitetest: %s: table %s, row %d: invalid pattern: %s", path, table.name, row, msg)
+		}
+	}
+}
+
+// AssertMatches reports an error if pattern, with flags if given, does not
+// match text in db, through the regexp function like the REGEXP operator.
+func AssertMatches(t testing.TB, db *sql.DB, pattern, text string, flags ...string) {
+	t.Helper()
+	if !match(t, db, pattern, text, flags) {
+		t.Errorf("sqlitetest: pattern %q%s does not match %q", pattern, flagsSuffix(flags), text)
+	}
+}
+
+// AssertNotMatches reports an error if pattern, with flags if given,
+// matches text in db.
+func AssertNotMatches(t testing.TB, db *sql.DB, pattern, text string, flags ...string) {
+	t.Helper()
+	if match(t, db, pattern, text, flags) {
+		t.Errorf("sqlitetest: pattern %q%s matches %q", pattern, flagsSuffix(flags), text)
+	}
+}
+
+// match evaluates regexp(pattern, text [, flags]) in db.
+func match(t testing.TB, db *sql.DB, pattern, text string, flags []string) bool {
+	t.Helper()
+	if len(flags) > 1 {
+		t.Fatalf("sqlitetest: expected at most one flags argument, got %d", len(flags))
+	}
+	query, args := `SELECT regexp(?, ?)`, []any{pattern, text}
+	if len(flags) == 1 {
+		query, args = `SELECT regexp(?, ?, ?)`, append(args, flags[0])
+	}
+	var matched bool
+	if err := db.QueryRow(query, args...).Scan(&matched); err != nil {
+		t.Fatalf("sqlitetest: matching %q against %q: %v", pattern, text, err)
+	}
+	return matched
+}
+
+func flagsSuffix(flags []string) string {
+	if len(flags) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" with flags %q", flags[0])
+}
+
+// fixtureTable is a table of a fixture, its columns in order of appearance.
+type fixtureTable struct {
+	name    string
+	columns []string
+	rows    []map[string]any
+}
+
+// parseFixture reads the tables of a fixture, in the order of the file.
+func parseFixture(data []byte) ([]fixtureTable, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected a mapping of table names to rows", root.Line)
+	}
+
+	var tables []fixtureTable
+	for i := 0; i < len(root.Content); i += 2 {
+		name, rows := root.Content[i], root.Content[i+1]
+		if rows.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("line %d: expected the rows of table %s", rows.Line, name.Value)
+		}
+		table := fixtureTable{name: name.Value}
+		seen := map[string]bool{}
+		for _, row := range rows.Content {
+			if row.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("line %d: expected a mapping of columns to values", row.Line)
+			}
+			values := map[string]any{}
+			for j := 0; j < len(row.Content); j += 2 {
+				column := row.Content[j].Value
+				var v any
+				if err := row.Content[j+1].Decode(&v); err != nil {
+					return nil, err
+				}
+				switch v.(type) {
+				case nil, string, int, float64, bool:
+				default:
+					return nil, fmt.Errorf("line %d: column %s: expected a scalar value", row.Content[j+1].Line, column)
+				}
+				values[column] = v
+				if !seen[column] {
+					seen[column] = true
+					table.columns = append(table.columns, column)
+				}
+			}
+			table.rows = append(table.rows, values)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// load creates the table if needed and inserts its rows.
+func (table fixtureTable) load(db *sql.DB) error {
+	if len(table.columns) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(table.columns))
+	for i, column := range table.columns {
+		quoted[i] = quoteIdent(column)
+	}
+	columns := strings.Join(quoted, ", ")
+	if _, err := db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (%s)`, quoteIdent(table.name), columns)); err != nil {
+		return err
+	}
+
+	insert := fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s)`, quoteIdent(table.name), columns,
+		strings.TrimSuffix(strings.Repeat("?, ", len(table.columns)), ", "))
+	for _, row := range table.rows {
+		args := make([]any, len(table.columns))
+		for i, column := range table.columns {
+			args[i] = row[column]
+		}
+		if _, err := db.Exec(insert, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// invalidPattern returns the message of the first invalid value of the
+// pattern column of the table, if it has one, and its row number, from 1.
+func (table fixtureTable) invalidPattern(db *sql.DB) (string, int) {
+	for i, row := range table.rows {
+		pattern, ok := row["pattern"].(string)
+		if !ok {
+			continue
+		}
+		var msg sql.NullString
+		if err := db.QueryRow(`SELECT regexp_error(?)`, pattern).Scan(&msg); err != nil {
+			return err.Error(), i + 1
+		}
+		if msg.Valid {
+			return msg.String, i + 1
+		}
+	}
+	return "", 0
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
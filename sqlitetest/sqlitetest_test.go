@@ -0,0 +1,132 @@
+package sqlitetest
+
+import (
+	"database/sql"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+)
+
+func TestLoadPatternsFixture(t *testing.T) {
+	db := NewTestDB(t)
+	LoadPatternsFixture(t, db, "testdata/patterns.yaml")
+
+	rows, err := db.Query(`SELECT i.item, p.category, p.priority
+		FROM items i JOIN patterns p ON i.item REGEXP p.pattern ORDER BY i.rowid`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var got []string
+	for rows.Next() {
+		var item, category string
+		var priority sql.NullInt64
+		if err := rows.Scan(&item, &category, &priority); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%s:%s:%v", item, category, priority.Int64))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	expected := "ORD-42:orders:0 Refund please:billing:2"
+	if strings.Join(got, " ") != expected {
+		t.Errorf("Joined %q, expected %q", strings.Join(got, " "), expected)
+	}
+
+	var amount float64
+	if err := db.QueryRow(`SELECT amount FROM items WHERE item = 'ORD-42'`).Scan(&amount); err != nil || amount != 12.5 {
+		t.Errorf("amount = %v, %v; expected 12.5", amount, err)
+	}
+}
+
+func TestAssertMatches(t *testing.T) {
+	lib := sqlite_regexp.NewPatternLibrary()
+	if err := lib.Define("order", `ORD-\d+`); err != nil {
+		t.Fatal(err)
+	}
+	db := NewTestDB(t, sqlite_regexp.WithPatternLibrary(lib))
+
+	AssertMatches(t, db, `^{{order}}$`, "ORD-42")
+	AssertMatches(t, db, `^ord`, "ORD-42", "i")
+	AssertNotMatches(t, db, `^ord`, "ORD-42")
+
+	rec := &recorder{TB: t}
+	AssertMatches(rec, db, `^ord`, "ORD-42")
+	AssertNotMatches(rec, db, `^ord`, "ORD-42", "i")
+	expected := []string{
+		`sqlitetest: pattern "^ord" does not match "ORD-42"`,
+		`sqlitetest: pattern "^ord" with flags "i" matches "ORD-42"`,
+	}
+	if strings.Join(rec.errors, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Reported %q, expected %q", rec.errors, expected)
+	}
+}
+
+func TestInvalidFixture(t *testing.T) {
+	db := NewTestDB(t)
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"testdata/invalid.yaml", "table patterns, row 2: invalid pattern: error parsing regexp: missing closing ): `a(b`"},
+		{"testdata/missing.yaml", "no such file or directory"},
+	}
+	for _, tt := range tests {
+		rec := &recorder{TB: t}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			LoadPatternsFixture(rec, db, tt.path)
+		}()
+		<-done
+		if len(rec.fatals) != 1 || !strings.Contains(rec.fatals[0], tt.expected) {
+			t.Errorf("Loading %s reported %q, expected %q", tt.path, rec.fatals, tt.expected)
+		}
+	}
+}
+
+func TestParseFixture(t *testing.T) {
+	tests := []struct {
+		yaml     string
+		expected string
+	}{
+		{"- a", "expected a mapping of table names to rows"},
+		{"t: 1", "expected the rows of table t"},
+		{"t: [1]", "expected a mapping of columns to values"},
+		{"t: [{a: [1]}]", "column a: expected a scalar value"},
+	}
+	for _, tt := range tests {
+		if _, err := parseFixture([]byte(tt.yaml)); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("parseFixture(%q) = %v, expected %q", tt.yaml, err, tt.expected)
+		}
+	}
+	if tables, err := parseFixture(nil); err != nil || tables != nil {
+		t.Errorf("parseFixture(nil) = %v, %v; expected no tables", tables, err)
+	}
+}
+
+var _ testing.TB = &recorder{}
+
+// recorder records the failures reported by the helpers instead of failing
+// the test. Fatalf ends the goroutine, like testing.T does.
+type recorder struct {
+	testing.TB
+	errors, fatals []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.fatals = append(r.fatals, fmt.Sprintf(format, args...))
+	runtime.Goexit()
+}
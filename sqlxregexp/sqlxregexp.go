@@ -0,0 +1,72 @@
+// Package sqlxregexp opens sqlx databases with the regexp function suite on
+// every connection, and builds REGEXP predicates with named parameters for
+// sqlx's named queries:
+//
+//	db, err := sqlxregexp.Open("app.db")
+//	...
+//	query := `SELECT * FROM users WHERE ` + sqlxregexp.Regexp("email", "domain")
+//	rows, err := db.NamedQuery(query, map[string]any{"domain": `@example\.com$`})
+//
+// The predicate builders take the column as an SQL expression, such as
+// "u.email" or "lower(email)", written into the query as is, and the names
+// of the parameters holding the pattern and flags.
+package sqlxregexp
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+)
+
+// DriverName is the driver name of the databases opened by the package,
+// which sqlx uses to pick the bind variables of the queries it rebinds.
+const DriverName = "sqlite3"
+
+// Open opens the SQLite database dsn with the function suite configured by
+// opts on every connection, like sqlite_regexp.OpenWithRegexp.
+func Open(dsn string, opts ...sqlite_regexp.Option) (*sqlx.DB, error) {
+	return OpenContext(context.Background(), dsn, opts...)
+}
+
+// OpenContext is like Open, but gives up once ctx is done while opening the
+// first connection.
+func OpenContext(ctx context.Context, dsn string, opts ...sqlite_regexp.Option) (*sqlx.DB, error) {
+	db, err := sqlite_regexp.OpenWithRegexpContext(ctx, dsn, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return sqlx.NewDb(db, DriverName), nil
+}
+
+// MustOpen is like Open, but panics on error, like sqlx.MustOpen.
+func MustOpen(dsn string, opts ...sqlite_regexp.Option) *sqlx.DB {
+	db, err := Open(dsn, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+// Regexp returns the predicate "column REGEXP :pattern".
+func Regexp(column, pattern string) string {
+	return column + " REGEXP :" + pattern
+}
+
+// NotRegexp returns the predicate "column NOT REGEXP :pattern".
+func NotRegexp(column, pattern string) string {
+	return column + " NOT REGEXP :" + pattern
+}
+
+// RegexpFlags returns the predicate "regexp(:pattern, column, :flags)", the
+// REGEXP operator with matching flags such as "i".
+func RegexpFlags(column, pattern, flags string) string {
+	return "regexp(:" + pattern + ", " + column + ", :" + flags + ")"
+}
+
+// Extract returns the expression "regexp_extract(column, :pattern, :group)",
+// the given group of the first match of the pattern in column.
+func Extract(column, pattern, group string) string {
+	return "regexp_extract(" + column + ", :" + pattern + ", :" + group + ")"
+}
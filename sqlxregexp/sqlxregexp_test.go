@@ -0,0 +1,102 @@
+package sqlxregexp
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+)
+
+func TestNamedQueries(t *testing.T) {
+	// A file, since every connection to ":memory:" has a database of its own.
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	db.MustExec(`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT, note TEXT)`)
+	for _, u := range []map[string]any{
+		{"email": "ann@example.com", "note": "invoice INV-123456"},
+		{"email": "bob@EXAMPLE.org", "note": nil},
+		{"email": "eve@evil.test", "note": "INV-7"},
+	} {
+		if _, err := db.NamedExec(`INSERT INTO users (email, note) VALUES (:email, :note)`, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type user struct {
+		ID    int
+		Email string
+	}
+	tests := []struct {
+		where    string
+		args     map[string]any
+		expected []string
+	}{
+		{Regexp("email", "domain"), map[string]any{"domain": `@example\.(com|org)$`}, []string{"ann@example.com"}},
+		{RegexpFlags("u.email", "domain", "flags"), map[string]any{"domain": `@example\.`, "flags": "i"},
+			[]string{"ann@example.com", "bob@EXAMPLE.org"}},
+		{NotRegexp("email", "p"), map[string]any{"p": `@example`}, []string{"bob@EXAMPLE.org", "eve@evil.test"}},
+		{Regexp("lower(email)", "p") + " AND " + NotRegexp("email", "q"), map[string]any{"p": `example`, "q": `^a`},
+			[]string{"bob@EXAMPLE.org"}},
+	}
+	for _, tt := range tests {
+		query, args, err := db.BindNamed(`SELECT id, email FROM users u WHERE `+tt.where+` ORDER BY id`, tt.args)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.where, err)
+		}
+		var users []user
+		if err := db.Select(&users, query, args...); err != nil {
+			t.Errorf("%s failed: %v", tt.where, err)
+			continue
+		}
+		var emails []string
+		for _, u := range users {
+			emails = append(emails, u.Email)
+		}
+		if strings.Join(emails, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("%s found %q, expected %q", tt.where, emails, tt.expected)
+		}
+	}
+
+	rows, err := db.NamedQuery(`SELECT `+Extract("note", "pattern", "group")+` AS number FROM users
+		WHERE `+Regexp("note", "pattern")+` ORDER BY id`,
+		map[string]any{"pattern": `INV-(\d{6})`, "group": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var numbers []string
+	for rows.Next() {
+		var number string
+		if err := rows.Scan(&number); err != nil {
+			t.Fatal(err)
+		}
+		numbers = append(numbers, number)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(numbers) != 1 || numbers[0] != "123456" {
+		t.Errorf("Extracted %q, expected [123456]", numbers)
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	if _, err := Open(":memory:", sqlite_regexp.WithFunctionAlias("not a name")); err == nil {
+		t.Error("Expected an error for an invalid option")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustOpen did not panic for an invalid option")
+		}
+	}()
+	MustOpen(":memory:", sqlite_regexp.WithFunctionAlias("not a name"))
+}
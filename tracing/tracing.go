@@ -0,0 +1,206 @@
+// Package tracing records the evaluations of the regexp function suite in
+// OpenTelemetry traces, aggregated per statement, so that regex-heavy
+// queries stand out:
+//
+//	err := tracing.Do(ctx, db, func(ctx context.Context, conn *sql.Conn) error {
+//		_, err := conn.ExecContext(ctx, `UPDATE tickets SET category = ...`)
+//		return err
+//	})
+//
+// Do runs the statements on a connection reserved for them, in a span named
+// sqlite_regexp.statement with the attributes:
+//
+//	sqlite_regexp.evaluations  number of evaluations
+//	sqlite_regexp.errors       number of failed evaluations
+//	sqlite_regexp.duration_ms  total time spent in the functions
+//	sqlite_regexp.patterns     number of distinct patterns
+//
+// and one sqlite_regexp.pattern event per pattern, for the patterns taking
+// the most time, with the function, the evaluation count and total time,
+// and pattern.hash, the FNV-1a hash of the pattern: patterns may hold
+// sensitive literals, so they are not recorded.
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/go-go-golems/go-sqlite-regexp/tracing"
+
+// DefaultMaxPatterns is the default number of pattern events of a span.
+const DefaultMaxPatterns = 20
+
+// Option configures Do.
+type Option func(*options)
+
+type options struct {
+	provider    trace.TracerProvider
+	spanName    string
+	maxPatterns int
+}
+
+// WithTracerProvider sets the provider of the tracer creating the spans,
+// instead of the global one.
+func WithTracerProvider(p trace.TracerProvider) Option {
+	return func(o *options) {
+		o.provider = p
+	}
+}
+
+// WithSpanName sets the name of the span, sqlite_regexp.statement by
+// default.
+func WithSpanName(name string) Option {
+	return func(o *options) {
+		o.spanName = name
+	}
+}
+
+// WithMaxPatterns sets the maximum number of pattern events of a span,
+// DefaultMaxPatterns by default.
+func WithMaxPatterns(n int) Option {
+	return func(o *options) {
+		o.maxPatterns = n
+	}
+}
+
+// Do runs fn on a connection of db reserved for it, recording the
+// evaluations of the functions on that connection in a new span. fn must
+// close the rows it queries before returning. The error of fn is recorded
+// on the span and returned.
+func Do(ctx context.Context, db *sql.DB, fn func(ctx context.Context, conn *sql.Conn) error, opts ...Option) error {
+	o := options{
+		provider:    otel.GetTracerProvider(),
+		spanName:    "sqlite_regexp.statement",
+		maxPatterns: DefaultMaxPatterns,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	ctx, span := o.provider.Tracer(instrumentationName).Start(ctx, o.spanName)
+	defer span.End()
+
+	err := do(ctx, db, fn, span, o.maxPatterns)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+func do(ctx context.Context, db *sql.DB, fn func(ctx context.Context, conn *sql.Conn) error, span trace.Span, maxPatterns int) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	s := &statement{patterns: make(map[patternKey]*patternStats)}
+	remove, err := sqlite_regexp.ObserveConn(conn, s)
+	if err != nil {
+		return err
+	}
+	err = fn(ctx, conn)
+	remove()
+	s.record(span, maxPatterns)
+	return err
+}
+
+type patternKey struct {
+	function string
+	pattern  string
+}
+
+type patternStats struct {
+	evaluations int64
+	duration    time.Duration
+}
+
+// statement aggregates the evaluations of a statement.
+type statement struct {
+	mu          sync.Mutex
+	evaluations int64
+	errors      int64
+	duration    time.Duration
+	patterns    map[patternKey]*patternStats
+}
+
+var _ sqlite_regexp.Observer = &statement{}
+
+func (s *statement) ObserveCall(e sqlite_regexp.CallEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evaluations++
+	if e.Err != nil {
+		s.errors++
+	}
+	s.duration += e.Duration
+
+	key := patternKey{function: e.Function, pattern: e.Pattern}
+	p, ok := s.patterns[key]
+	if !ok {
+		p = &patternStats{}
+		s.patterns[key] = p
+	}
+	p.evaluations++
+	p.duration += e.Duration
+}
+
+// record sets the attributes and events of span.
+func (s *statement) record(span trace.Span, maxPatterns int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	distinct := make(map[string]bool, len(s.patterns))
+	keys := make([]patternKey, 0, len(s.patterns))
+	for key := range s.patterns {
+		distinct[key.pattern] = true
+		keys = append(keys, key)
+	}
+	span.SetAttributes(
+		attribute.Int64("sqlite_regexp.evaluations", s.evaluations),
+		attribute.Int64("sqlite_regexp.errors", s.errors),
+		attribute.Float64("sqlite_regexp.duration_ms", milliseconds(s.duration)),
+		attribute.Int("sqlite_regexp.patterns", len(distinct)),
+	)
+
+	sort.Slice(keys, func(i, j int) bool {
+		return s.patterns[keys[i]].duration > s.patterns[keys[j]].duration
+	})
+	if len(keys) > maxPatterns {
+		keys = keys[:maxPatterns]
+	}
+	for _, key := range keys {
+		p := s.patterns[key]
+		span.AddEvent("sqlite_regexp.pattern", trace.WithAttributes(
+			attribute.String("function", key.function),
+			attribute.String("pattern.hash", hashPattern(key.pattern)),
+			attribute.Int64("evaluations", p.evaluations),
+			attribute.Float64("duration_ms", milliseconds(p.duration)),
+		))
+	}
+}
+
+func hashPattern(pattern string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(pattern))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	sqlite_regexp "github.com/go-go-golems/go-sqlite-regexp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestDo(t *testing.T) {
+	db, err := sqlite_regexp.OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	_, err = db.Exec(`CREATE TABLE lines (text TEXT);
+		INSERT INTO lines VALUES ('error: disk full'), ('ok'), ('error: timeout')`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	var n int
+	err = Do(context.Background(), db, func(ctx context.Context, conn *sql.Conn) error {
+		return conn.QueryRowContext(ctx, `SELECT count(*) FROM lines
+			WHERE text REGEXP '^error' AND regexp_like(text, 'full|timeout')`).Scan(&n)
+	}, WithTracerProvider(provider), WithMaxPatterns(1))
+	if err != nil || n != 2 {
+		t.Fatalf("Do failed: %d, %v", n, err)
+	}
+
+	// Evaluations outside Do are not recorded.
+	if _, err := db.Exec(`SELECT 'a' REGEXP 'a'`); err != nil {
+		t.Fatalf("REGEXP failed: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Got %d spans, expected 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "sqlite_regexp.statement" {
+		t.Errorf("Unexpected span name %q", span.Name())
+	}
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if got := attrs["sqlite_regexp.evaluations"].AsInt64(); got != 5 {
+		t.Errorf("Got %d evaluations, expected 5", got)
+	}
+	if got := attrs["sqlite_regexp.patterns"].AsInt64(); got != 2 {
+		t.Errorf("Got %d patterns, expected 2", got)
+	}
+	events := span.Events()
+	if len(events) != 1 {
+		t.Fatalf("Got %d events, expected 1", len(events))
+	}
+	for _, kv := range events[0].Attributes {
+		if kv.Key == "pattern.hash" && len(kv.Value.AsString()) != 16 {
+			t.Errorf("Unexpected pattern hash %q", kv.Value.AsString())
+		}
+	}
+}
+
+func TestDoError(t *testing.T) {
+	db, err := sqlite_regexp.OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	err = Do(context.Background(), db, func(ctx context.Context, conn *sql.Conn) error {
+		_, err := conn.ExecContext(ctx, `SELECT 'a' REGEXP '('`)
+		return err
+	}, WithTracerProvider(provider), WithSpanName("classify"))
+	if !sqlite_regexp.IsPatternError(err) {
+		t.Fatalf("Expected a pattern error, got %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "classify" || spans[0].Status().Code != codes.Error {
+		t.Fatalf("Unexpected spans %v", spans)
+	}
+	for _, kv := range spans[0].Attributes() {
+		if kv.Key == "sqlite_regexp.errors" && kv.Value.AsInt64() != 1 {
+			t.Errorf("Got %d errors, expected 1", kv.Value.AsInt64())
+		}
+	}
+}
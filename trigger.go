@@ -0,0 +1,100 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+)
+
+// ColumnRule requires the values of a column to match a pattern.
+type ColumnRule struct {
+	Table  string
+	Column string
+	// Pattern is matched with the usual REGEXP semantics: it should be
+	// anchored, e.g. `^[A-Z]{3}-\d{4}$`, to validate whole values.
+	Pattern string
+	Flags   string
+}
+
+// validate checks that r is complete and that its pattern compiles.
+func (r ColumnRule) validate() error {
+	if r.Table == "" || r.Column == "" {
+		return errors.New("column rule needs a table and a column")
+	}
+	flags, err := core.ParseFlags(r.Flags)
+	if err != nil {
+		return fmt.Errorf("rule %s.%s: %w", r.Table, r.Column, err)
+	}
+	if _, err := core.Compile(r.Pattern, flags); err != nil {
+		return fmt.Errorf("rule %s.%s: %w", r.Table, r.Column, err)
+	}
+	return nil
+}
+
+// ValidationTriggersSQL returns the statements creating the triggers that
+// enforce rules, see CreateValidationTriggers.
+func ValidationTriggersSQL(rules ...ColumnRule) ([]string, error) {
+	var stmts []string
+	for _, r := range rules {
+		if err := r.validate(); err != nil {
+			return nil, err
+		}
+		if core.HasIncludes(r.Pattern) {
+			return nil, fmt.Errorf("rule %s.%s: pattern includes library patterns, which are not stored in the schema", r.Table, r.Column)
+		}
+		column := "NEW." + quoteIdent(r.Column)
+		message := fmt.Sprintf("%s.%s does not match %s", r.Table, r.Column, r.Pattern)
+		body := fmt.Sprintf("WHEN %s IS NOT NULL AND NOT regexp(%s, %s, %s)\nBEGIN\n\tSELECT RAISE(ABORT, %s);\nEND",
+			column, quoteString(r.Pattern), column, quoteString(r.Flags), quoteString(message))
+
+		name := r.Table + "_" + r.Column + "_regexp"
+		stmts = append(stmts,
+			fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s BEFORE INSERT ON %s\n%s",
+				quoteIdent(name+"_insert"), quoteIdent(r.Table), body),
+			fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s BEFORE UPDATE OF %s ON %s\n%s",
+				quoteIdent(name+"_update"), quoteIdent(r.Column), quoteIdent(r.Table), body))
+	}
+	return stmts, nil
+}
+
+// CreateValidationTriggers creates BEFORE INSERT and BEFORE UPDATE triggers
+// rejecting the rows whose columns do not match their rules, as CHECK
+// constraints cannot portably call custom functions:
+//
+//	err := sqlite_regexp.CreateValidationTriggers(db,
+//		sqlite_regexp.ColumnRule{Table: "users", Column: "email", Pattern: `^[^@\s]+@[^@\s]+$`},
+//		sqlite_regexp.ColumnRule{Table: "products", Column: "sku", Pattern: `^[A-Z]{3}-\d{4}$`},
+//	)
+//
+// NULL values are accepted, as by CHECK constraints. The triggers of a rule
+// are named <table>_<column>_regexp_insert and _update; existing triggers
+// with these names are kept. Every connection writing to the tables must
+// have the function suite registered.
+func CreateValidationTriggers(db *sql.DB, rules ...ColumnRule) error {
+	return CreateValidationTriggersContext(context.Background(), db, rules...)
+}
+
+// CreateValidationTriggersContext is like CreateValidationTriggers, but
+// gives up, rolling back the triggers created so far, once ctx is done,
+// such as while waiting for a lock on a busy database.
+func CreateValidationTriggersContext(ctx context.Context, db *sql.DB, rules ...ColumnRule) error {
+	stmts, err := ValidationTriggersSQL(rules...)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,113 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCreateValidationTriggers(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	_, err = db.Exec(`CREATE TABLE products (id INTEGER PRIMARY KEY, sku TEXT, "o'dd name" TEXT)`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	rules := []ColumnRule{
+		{Table: "products", Column: "sku", Pattern: `^[a-z]{3}-\d{4}$`, Flags: "i"},
+		{Table: "products", Column: "o'dd name", Pattern: `^\w+$`},
+	}
+	if err := CreateValidationTriggers(db, rules...); err != nil {
+		t.Fatalf("CreateValidationTriggers failed: %v", err)
+	}
+	// Creating them again does nothing.
+	if err := CreateValidationTriggers(db, rules...); err != nil {
+		t.Fatalf("CreateValidationTriggers failed: %v", err)
+	}
+
+	valid := []string{
+		`INSERT INTO products (sku, "o'dd name") VALUES ('ABC-1234', 'x')`,
+		`INSERT INTO products (sku) VALUES ('abc-0001')`,
+		`INSERT INTO products (sku) VALUES (NULL)`,
+		`UPDATE products SET sku = 'XYZ-9999' WHERE id = 1`,
+	}
+	for _, stmt := range valid {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Errorf("%s failed: %v", stmt, err)
+		}
+	}
+
+	invalid := []string{
+		`INSERT INTO products (sku) VALUES ('ABC-12345')`,
+		`INSERT INTO products (sku, "o'dd name") VALUES ('ABC-1234', 'two words')`,
+		`UPDATE products SET sku = 'nope' WHERE id = 1`,
+	}
+	for _, stmt := range invalid {
+		_, err := db.Exec(stmt)
+		if err == nil {
+			t.Errorf("%s: expected a validation error, got nil", stmt)
+		} else if !strings.Contains(err.Error(), "does not match") {
+			t.Errorf("%s: unexpected error %v", stmt, err)
+		}
+	}
+
+	var sku string
+	if err := db.QueryRow(`SELECT sku FROM products WHERE id = 1`).Scan(&sku); err != nil || sku != "XYZ-9999" {
+		t.Errorf("sku = %q, %v, expected XYZ-9999", sku, err)
+	}
+
+	for _, bad := range []ColumnRule{
+		{Column: "sku", Pattern: `x`},
+		{Table: "products", Column: "sku", Pattern: `(`},
+		{Table: "products", Column: "sku", Pattern: `{{sku}}`},
+		{Table: "products", Column: "sku", Pattern: `x`, Flags: "q"},
+	} {
+		if _, err := ValidationTriggersSQL(bad); err == nil {
+			t.Errorf("ValidationTriggersSQL(%+v): expected an error, got nil", bad)
+		}
+	}
+
+	// A failing statement leaves no trigger behind.
+	err = CreateValidationTriggers(db,
+		ColumnRule{Table: "products", Column: "id", Pattern: `^\d+$`},
+		ColumnRule{Table: "missing", Column: "x", Pattern: `x`})
+	if err == nil {
+		t.Fatal("Expected error for missing table, got nil")
+	}
+	var triggers int
+	if err := db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'products_id_%'`).Scan(&triggers); err != nil || triggers != 0 {
+		t.Errorf("Got %d triggers, %v, expected none", triggers, err)
+	}
+}
+
+func TestCreateValidationTriggersContext(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	if _, err := db.Exec(`CREATE TABLE products (sku TEXT)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rule := ColumnRule{Table: "products", Column: "sku", Pattern: `^[A-Z]{3}-\d{4}$`}
+	if err := CreateValidationTriggersContext(ctx, db, rule); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	var triggers int
+	if err := db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type = 'trigger'`).Scan(&triggers); err != nil || triggers != 0 {
+		t.Errorf("Cancelled call created %d triggers, %v", triggers, err)
+	}
+}
@@ -0,0 +1,148 @@
+//go:build sqlite_vtable || vtable
+
+package sqlite_regexp
+
+import (
+	"time"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+	"github.com/mattn/go-sqlite3"
+)
+
+// registerModules installs the virtual tables of the suite. They need
+// go-sqlite3's virtual table support, enabled with the sqlite_vtable tag.
+func registerModules(conn *sqlite3.SQLiteConn, cfg *config) error {
+	// In read-only mode, the tables running queries or needing CREATE
+	// VIRTUAL TABLE are left out.
+	if !cfg.readOnly {
+		if err := conn.CreateModule("regexp_sequence", &sequenceModule{cfg: &cfg.Config}); err != nil {
+			return err
+		}
+	}
+	if err := conn.CreateModule("regexp_split", &splitModule{cfg: &cfg.Config}); err != nil {
+		return err
+	}
+	if !cfg.readOnly {
+		if err := conn.CreateModule("regexp_scrape", &scrapeModule{cfg: &cfg.Config}); err != nil {
+			return err
+		}
+	}
+	if cfg.fileTables {
+		if err := conn.CreateModule("regexp_log", &logModule{cfg: &cfg.Config}); err != nil {
+			return err
+		}
+		if err := conn.CreateModule("regexp_grep", &grepModule{cfg: &cfg.Config}); err != nil {
+			return err
+		}
+	}
+	if cfg.Library != nil {
+		if err := conn.CreateModule("regexp_pattern_history", &historyModule{lib: cfg.Library}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// historyModule implements the regexp_pattern_history table, which lists
+// every version of every pattern of the library:
+//
+//	SELECT version, author, changed_at, pattern
+//	FROM regexp_pattern_history WHERE name = 'invoice' ORDER BY version DESC;
+type historyModule struct {
+	lib *core.Library
+}
+
+var _ sqlite3.EponymousOnlyModule = &historyModule{}
+
+func (m *historyModule) EponymousOnlyModule() {}
+
+func (m *historyModule) Create(c *sqlite3.SQLiteConn, args []string) (sqlite3.VTab, error) {
+	return m.Connect(c, args)
+}
+
+func (m *historyModule) Connect(c *sqlite3.SQLiteConn, args []string) (sqlite3.VTab, error) {
+	err := c.DeclareVTab(`CREATE TABLE x(
+		name TEXT,
+		version INTEGER,
+		pattern TEXT,
+		removed INTEGER,
+		author TEXT,
+		reason TEXT,
+		changed_at TEXT
+	)`)
+	if err != nil {
+		return nil, err
+	}
+	return &historyTable{lib: m.lib}, nil
+}
+
+func (m *historyModule) DestroyModule() {}
+
+type historyTable struct {
+	lib *core.Library
+}
+
+func (t *historyTable) BestIndex(csts []sqlite3.InfoConstraint, ob []sqlite3.InfoOrderBy) (*sqlite3.IndexResult, error) {
+	// The history is small; SQLite filters and sorts a full scan.
+	return &sqlite3.IndexResult{Used: make([]bool, len(csts))}, nil
+}
+
+func (t *historyTable) Open() (sqlite3.VTabCursor, error) {
+	return &historyCursor{lib: t.lib}, nil
+}
+
+func (t *historyTable) Disconnect() error { return nil }
+
+func (t *historyTable) Destroy() error { return nil }
+
+type historyCursor struct {
+	lib      *core.Library
+	versions []core.Version
+	i        int
+}
+
+func (c *historyCursor) Filter(idxNum int, idxStr string, vals []any) error {
+	c.versions = c.lib.Versions()
+	c.i = 0
+	return nil
+}
+
+func (c *historyCursor) Next() error {
+	c.i++
+	return nil
+}
+
+func (c *historyCursor) EOF() bool {
+	return c.i >= len(c.versions)
+}
+
+func (c *historyCursor) Column(ctx *sqlite3.SQLiteContext, col int) error {
+	v := c.versions[c.i]
+	switch col {
+	case 0:
+		ctx.ResultText(v.Name)
+	case 1:
+		ctx.ResultInt(v.Version)
+	case 2:
+		if v.Removed {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(v.Pattern)
+		}
+	case 3:
+		ctx.ResultBool(v.Removed)
+	case 4:
+		ctx.ResultText(v.Author)
+	case 5:
+		ctx.ResultText(v.Reason)
+	case 6:
+		ctx.ResultText(v.Time.UTC().Format(time.RFC3339Nano))
+	}
+	return nil
+}
+
+func (c *historyCursor) Rowid() (int64, error) {
+	return int64(c.i), nil
+}
+
+func (c *historyCursor) Close() error { return nil }
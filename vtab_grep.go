@@ -0,0 +1,213 @@
+//go:build sqlite_vtable || vtable
+
+package sqlite_regexp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Hidden columns of regexp_grep, holding its arguments.
+const (
+	grepColPath = iota + 3
+	grepColGlob
+)
+
+// grepModule implements the regexp_grep table-valued function, which walks
+// the files under path whose name matches glob and returns one row per line,
+// to be filtered with REGEXP or joined with a table of patterns:
+//
+//	SELECT file, line_no, line FROM regexp_grep('/etc', '*.conf')
+//	WHERE line REGEXP '^\s*listen\s';
+//
+// glob follows GLOB's syntax and is matched against the base name of each
+// file; without it, every file is read. Entries of the tree that cannot be
+// read are skipped, and symbolic links are not followed.
+type grepModule struct {
+	cfg *core.Config
+}
+
+var _ sqlite3.EponymousOnlyModule = &grepModule{}
+
+func (m *grepModule) EponymousOnlyModule() {}
+
+func (m *grepModule) Create(c *sqlite3.SQLiteConn, args []string) (sqlite3.VTab, error) {
+	return m.Connect(c, args)
+}
+
+func (m *grepModule) Connect(c *sqlite3.SQLiteConn, args []string) (sqlite3.VTab, error) {
+	err := c.DeclareVTab(`CREATE TABLE x(
+		file TEXT,
+		line_no INTEGER,
+		line TEXT,
+		path HIDDEN,
+		glob HIDDEN
+	)`)
+	if err != nil {
+		return nil, err
+	}
+	return &grepTable{cfg: m.cfg}, nil
+}
+
+func (m *grepModule) DestroyModule() {}
+
+type grepTable struct {
+	cfg *core.Config
+}
+
+// BestIndex passes the arguments to Filter, recording the column of each one
+// in IdxStr.
+func (t *grepTable) BestIndex(csts []sqlite3.InfoConstraint, ob []sqlite3.InfoOrderBy) (*sqlite3.IndexResult, error) {
+	used := make([]bool, len(csts))
+	var columns []byte
+	cost := 1e12
+	for i, c := range csts {
+		if !c.Usable || c.Op != sqlite3.OpEQ || c.Column < grepColPath {
+			continue
+		}
+		used[i] = true
+		columns = append(columns, byte(c.Column))
+		if c.Column == grepColPath {
+			cost = 1e6
+		}
+	}
+	return &sqlite3.IndexResult{Used: used, IdxStr: string(columns), EstimatedCost: cost}, nil
+}
+
+func (t *grepTable) Open() (sqlite3.VTabCursor, error) {
+	return &grepCursor{cfg: t.cfg}, nil
+}
+
+func (t *grepTable) Disconnect() error { return nil }
+
+func (t *grepTable) Destroy() error { return nil }
+
+type grepCursor struct {
+	cfg    *core.Config
+	files  []string
+	next   int
+	f      *os.File
+	r      *bufio.Reader
+	file   string
+	lineNo int64
+	line   string
+	rowid  int64
+	eof    bool
+}
+
+func (c *grepCursor) Filter(idxNum int, idxStr string, vals []any) error {
+	if err := c.Close(); err != nil {
+		return err
+	}
+	args := make(map[int]any, len(vals))
+	for i, v := range vals {
+		args[int(idxStr[i])] = v
+	}
+
+	root, ok := core.TextArg(args[grepColPath])
+	if !ok {
+		return errors.New("regexp_grep: missing path")
+	}
+	var glob *regexp.Regexp
+	if g, ok := core.TextArg(args[grepColGlob]); ok {
+		var err error
+		if glob, err = c.cfg.CompileGlob(g); err != nil {
+			return fmt.Errorf("regexp_grep: %w", err)
+		}
+	}
+
+	c.files, c.next, c.rowid, c.eof = nil, 0, 0, false
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && (glob == nil || glob.MatchString(d.Name())) {
+			c.files = append(c.files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("regexp_grep: %w", err)
+	}
+	return c.Next()
+}
+
+// Next reads the next line, moving on to the next readable file at the end
+// of the current one.
+func (c *grepCursor) Next() error {
+	for {
+		if c.r == nil {
+			if c.next >= len(c.files) {
+				c.eof = true
+				return nil
+			}
+			c.file = c.files[c.next]
+			c.next++
+			f, err := os.Open(c.file)
+			if err != nil {
+				continue
+			}
+			c.f, c.r, c.lineNo = f, bufio.NewReader(f), 0
+		}
+
+		line, err := c.r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			line = ""
+		}
+		if line == "" {
+			if err := c.Close(); err != nil {
+				return err
+			}
+			continue
+		}
+		c.lineNo++
+		c.rowid++
+		c.line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		return nil
+	}
+}
+
+func (c *grepCursor) EOF() bool {
+	return c.eof
+}
+
+func (c *grepCursor) Column(ctx *sqlite3.SQLiteContext, col int) error {
+	switch col {
+	case 0:
+		ctx.ResultText(c.file)
+	case 1:
+		ctx.ResultInt64(c.lineNo)
+	case 2:
+		ctx.ResultText(c.line)
+	default:
+		ctx.ResultNull()
+	}
+	return nil
+}
+
+func (c *grepCursor) Rowid() (int64, error) {
+	return c.rowid, nil
+}
+
+// Close closes the file being read, if any.
+func (c *grepCursor) Close() error {
+	if c.f == nil {
+		return nil
+	}
+	err := c.f.Close()
+	c.f, c.r = nil, nil
+	return err
+}
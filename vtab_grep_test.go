@@ -0,0 +1,81 @@
+//go:build sqlite_vtable || vtable
+
+package sqlite_regexp
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGrepTable(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.conf":         "listen 80\r\n# comment\nlisten 443",
+		"sub/b.conf":     "server_name example.org\nlisten 8080\n",
+		"sub/c.txt":      "listen 1\n",
+		"sub/empty.conf": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := OpenWithRegexp(":memory:", WithFileTables())
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	_, err = db.Exec(`CREATE TABLE rules (name TEXT, pattern TEXT);
+		INSERT INTO rules VALUES ('tls', '^listen 443$'), ('listen', '^listen\s');`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	rows, err := db.Query(`SELECT r.name, g.file, g.line_no, g.line
+		FROM regexp_grep(?, '*.conf') AS g JOIN rules AS r ON g.line REGEXP r.pattern
+		ORDER BY g.file, g.line_no, r.name`, dir)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var got []string
+	for rows.Next() {
+		var name, file, line string
+		var lineNo int
+		if err := rows.Scan(&name, &file, &lineNo, &line); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		rel, _ := filepath.Rel(dir, file)
+		got = append(got, strings.Join([]string{name, filepath.ToSlash(rel), line, strconv.Itoa(lineNo)}, "|"))
+	}
+	expected := []string{
+		"listen|a.conf|listen 80|1",
+		"listen|a.conf|listen 443|3",
+		"tls|a.conf|listen 443|3",
+		"listen|sub/b.conf|listen 8080|2",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Got rows\n%s\nexpected\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM regexp_grep(?)`, dir).Scan(&count); err != nil || count != 6 {
+		t.Errorf("Got %d lines, %v, expected 6", count, err)
+	}
+	if err := db.QueryRow(`SELECT count(*) FROM regexp_grep(?)`, filepath.Join(dir, "missing")).Scan(&count); err == nil {
+		t.Error("Expected error for missing path, got nil")
+	}
+}
@@ -0,0 +1,178 @@
+//go:build sqlite_vtable || vtable
+
+package sqlite_regexp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+	"github.com/mattn/go-sqlite3"
+)
+
+// logModule implements the regexp_log virtual table, which parses a text
+// file with a pattern and exposes one column per named capture group:
+//
+//	CREATE VIRTUAL TABLE logs USING regexp_log(
+//		file='/var/log/app.log',
+//		pattern='(?P<ts>\S+) (?P<level>\w+) (?P<msg>.*)',
+//		flags='i'
+//	);
+//	SELECT level, count(*) FROM logs GROUP BY level;
+//
+// Lines that do not match are skipped, and groups that do not participate in
+// a match are NULL. The rowid of a row is its line number. The file is read
+// again by every query, so the table follows a growing log.
+type logModule struct {
+	cfg *core.Config
+}
+
+var _ sqlite3.Module = &logModule{}
+
+func (m *logModule) Create(c *sqlite3.SQLiteConn, args []string) (sqlite3.VTab, error) {
+	return m.Connect(c, args)
+}
+
+func (m *logModule) Connect(c *sqlite3.SQLiteConn, args []string) (sqlite3.VTab, error) {
+	// args holds the module, database and table names, then the arguments.
+	params, err := core.ParseModuleArgs(args[3:])
+	if err != nil {
+		return nil, fmt.Errorf("regexp_log: %w", err)
+	}
+	file, pattern := params["file"], params["pattern"]
+	if file == "" || pattern == "" {
+		return nil, errors.New("regexp_log: file and pattern are required")
+	}
+	for key := range params {
+		if key != "file" && key != "pattern" && key != "flags" {
+			return nil, fmt.Errorf("regexp_log: unknown argument %q", key)
+		}
+	}
+	flags, err := core.ParseFlags(params["flags"])
+	if err != nil {
+		return nil, fmt.Errorf("regexp_log: %w", err)
+	}
+	re, err := m.cfg.Compile(pattern, flags)
+	if err != nil {
+		return nil, fmt.Errorf("regexp_log: %w", err)
+	}
+
+	t := &logTable{file: file, re: re}
+	seen := make(map[string]bool)
+	var columns []string
+	for i, name := range re.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		if seen[strings.ToLower(name)] {
+			return nil, fmt.Errorf("regexp_log: duplicate capture group %q", name)
+		}
+		seen[strings.ToLower(name)] = true
+		t.groups = append(t.groups, i)
+		columns = append(columns, `"`+name+`" TEXT`)
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("regexp_log: pattern has no named capture groups")
+	}
+	if err := c.DeclareVTab("CREATE TABLE x(" + strings.Join(columns, ", ") + ")"); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (m *logModule) DestroyModule() {}
+
+type logTable struct {
+	file string
+	re   *regexp.Regexp
+	// groups holds the index of the capture group of each column.
+	groups []int
+}
+
+func (t *logTable) BestIndex(csts []sqlite3.InfoConstraint, ob []sqlite3.InfoOrderBy) (*sqlite3.IndexResult, error) {
+	// Every query reads the whole file; SQLite filters and sorts the lines.
+	return &sqlite3.IndexResult{Used: make([]bool, len(csts)), EstimatedCost: 1e6}, nil
+}
+
+func (t *logTable) Open() (sqlite3.VTabCursor, error) {
+	return &logCursor{table: t}, nil
+}
+
+func (t *logTable) Disconnect() error { return nil }
+
+func (t *logTable) Destroy() error { return nil }
+
+type logCursor struct {
+	table  *logTable
+	f      *os.File
+	r      *bufio.Reader
+	lineNo int64
+	line   string
+	match  []int
+	eof    bool
+}
+
+func (c *logCursor) Filter(idxNum int, idxStr string, vals []any) error {
+	if err := c.Close(); err != nil {
+		return err
+	}
+	f, err := os.Open(c.table.file)
+	if err != nil {
+		return fmt.Errorf("regexp_log: %w", err)
+	}
+	c.f, c.r = f, bufio.NewReader(f)
+	c.lineNo, c.eof = 0, false
+	return c.Next()
+}
+
+// Next reads lines until one matches.
+func (c *logCursor) Next() error {
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("regexp_log: %w", err)
+		}
+		if line == "" && err == io.EOF {
+			c.eof = true
+			return nil
+		}
+		c.lineNo++
+		c.line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if c.match = c.table.re.FindStringSubmatchIndex(c.line); c.match != nil {
+			return nil
+		}
+	}
+}
+
+func (c *logCursor) EOF() bool {
+	return c.eof
+}
+
+func (c *logCursor) Column(ctx *sqlite3.SQLiteContext, col int) error {
+	group := c.table.groups[col]
+	start, end := c.match[2*group], c.match[2*group+1]
+	if start < 0 {
+		ctx.ResultNull()
+	} else {
+		ctx.ResultText(c.line[start:end])
+	}
+	return nil
+}
+
+func (c *logCursor) Rowid() (int64, error) {
+	return c.lineNo, nil
+}
+
+func (c *logCursor) Close() error {
+	if c.f == nil {
+		return nil
+	}
+	err := c.f.Close()
+	c.f, c.r = nil, nil
+	return err
+}
@@ -0,0 +1,108 @@
+//go:build sqlite_vtable || vtable
+
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLogTable(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	content := "2024-05-01T10:00:00 INFO started\r\n" +
+		"garbage\n" +
+		"2024-05-01T10:00:01 error disk full\n" +
+		"2024-05-01T10:00:02 WARN\n" +
+		"2024-05-01T10:00:03 ERROR it's over"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := OpenWithRegexp(":memory:", WithFileTables())
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	_, err = db.Exec(`CREATE VIRTUAL TABLE logs USING regexp_log(
+		file='` + strings.ReplaceAll(file, "'", "''") + `',
+		pattern='^(?P<ts>\S+) (?P<level>[a-z]+)(?: (?P<msg>.*))?$',
+		flags='i')`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	rows, err := db.Query(`SELECT rowid, ts, upper(level), msg FROM logs ORDER BY rowid`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var got []string
+	for rows.Next() {
+		var (
+			lineNo    int
+			ts, level string
+			msg       sql.NullString
+		)
+		if err := rows.Scan(&lineNo, &ts, &level, &msg); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		got = append(got, strings.Join([]string{strconv.Itoa(lineNo), ts, level, msg.String}, "|"))
+	}
+	expected := []string{
+		"1|2024-05-01T10:00:00|INFO|started",
+		"3|2024-05-01T10:00:01|ERROR|disk full",
+		"4|2024-05-01T10:00:02|WARN|",
+		"5|2024-05-01T10:00:03|ERROR|it's over",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Got rows\n%s\nexpected\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM logs WHERE msg IS NULL`).Scan(&count); err != nil || count != 1 {
+		t.Errorf("Got %d rows without a message, %v, expected 1", count, err)
+	}
+
+	for _, stmt := range []string{
+		`CREATE VIRTUAL TABLE t1 USING regexp_log(file='x.log')`,
+		`CREATE VIRTUAL TABLE t2 USING regexp_log(file='x.log', pattern='(\w+)')`,
+		`CREATE VIRTUAL TABLE t3 USING regexp_log(file='x.log', pattern='(?P<a>x)(?P<a>y)')`,
+		`CREATE VIRTUAL TABLE t4 USING regexp_log(file='x.log', pattern='(?P<a>x', flags='i')`,
+		`CREATE VIRTUAL TABLE t5 USING regexp_log(file='x.log', pattern='(?P<a>x)', colour='red')`,
+	} {
+		if _, err := db.Exec(stmt); err == nil {
+			t.Errorf("%s: expected an error, got nil", stmt)
+		}
+	}
+
+	_, err = db.Exec(`CREATE VIRTUAL TABLE missing USING regexp_log(file='does-not-exist.log', pattern='(?P<a>.*)')`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if err := db.QueryRow(`SELECT count(*) FROM missing`).Scan(&count); err == nil {
+		t.Error("Expected error for missing file, got nil")
+	}
+}
+
+func TestLogTableRequiresOption(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	if _, err := db.Exec(`CREATE VIRTUAL TABLE logs USING regexp_log(file='app.log', pattern='(?P<a>.*)')`); err == nil {
+		t.Error("Expected regexp_log to be unavailable without WithFileTables")
+	}
+}
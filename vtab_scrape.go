@@ -0,0 +1,198 @@
+//go:build sqlite_vtable || vtable
+
+package sqlite_regexp
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+	"github.com/mattn/go-sqlite3"
+)
+
+// scrapeModule implements the regexp_scrape virtual table, which parses a
+// text column of another table with a pattern and exposes one column per
+// named capture group, turning semi-structured text into a relation:
+//
+//	CREATE VIRTUAL TABLE order_items USING regexp_scrape(
+//		source=orders,
+//		column=note,
+//		pattern='(?P<sku>[A-Z]{3}-\d+) x(?P<qty>\d+)',
+//		flags='i'
+//	);
+//	SELECT o.id, i.sku, i.qty FROM orders o JOIN order_items i ON i.rowid = o.id;
+//
+// Each row of the source whose text matches gives one row, from its first
+// match; groups that do not participate in it are NULL. The rowid of a row
+// is that of its source row, or the integer column named by key, for views.
+// The source is read again by every query, so the table follows its changes.
+type scrapeModule struct {
+	cfg *core.Config
+}
+
+var _ sqlite3.Module = &scrapeModule{}
+
+func (m *scrapeModule) Create(c *sqlite3.SQLiteConn, args []string) (sqlite3.VTab, error) {
+	return m.Connect(c, args)
+}
+
+func (m *scrapeModule) Connect(c *sqlite3.SQLiteConn, args []string) (sqlite3.VTab, error) {
+	// args holds the module, database and table names, then the arguments.
+	params, err := core.ParseModuleArgs(args[3:])
+	if err != nil {
+		return nil, fmt.Errorf("regexp_scrape: %w", err)
+	}
+	source, column, pattern := params["source"], params["column"], params["pattern"]
+	if source == "" || column == "" || pattern == "" {
+		return nil, errors.New("regexp_scrape: source, column and pattern are required")
+	}
+	for key := range params {
+		switch key {
+		case "source", "column", "pattern", "flags", "key":
+		default:
+			return nil, fmt.Errorf("regexp_scrape: unknown argument %q", key)
+		}
+	}
+	flags, err := core.ParseFlags(params["flags"])
+	if err != nil {
+		return nil, fmt.Errorf("regexp_scrape: %w", err)
+	}
+	re, err := m.cfg.Compile(pattern, flags)
+	if err != nil {
+		return nil, fmt.Errorf("regexp_scrape: %w", err)
+	}
+	key := "rowid"
+	if params["key"] != "" {
+		key = quoteIdent(params["key"])
+	}
+
+	t := &scrapeTable{
+		conn:  c,
+		query: fmt.Sprintf("SELECT %s, %s FROM %s", key, quoteIdent(column), quoteIdent(source)),
+		re:    re,
+	}
+	seen := make(map[string]bool)
+	var columns []string
+	for i, name := range re.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		if seen[strings.ToLower(name)] {
+			return nil, fmt.Errorf("regexp_scrape: duplicate capture group %q", name)
+		}
+		seen[strings.ToLower(name)] = true
+		t.groups = append(t.groups, i)
+		columns = append(columns, quoteIdent(name)+" TEXT")
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("regexp_scrape: pattern has no named capture groups")
+	}
+	if err := c.DeclareVTab("CREATE TABLE x(" + strings.Join(columns, ", ") + ")"); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (m *scrapeModule) DestroyModule() {}
+
+type scrapeTable struct {
+	conn *sqlite3.SQLiteConn
+	// query reads the key and text of every row of the source.
+	query string
+	re    *regexp.Regexp
+	// groups holds the index of the capture group of each column.
+	groups []int
+}
+
+func (t *scrapeTable) BestIndex(csts []sqlite3.InfoConstraint, ob []sqlite3.InfoOrderBy) (*sqlite3.IndexResult, error) {
+	// Every query scans the source; SQLite filters and sorts the rows.
+	return &sqlite3.IndexResult{Used: make([]bool, len(csts)), EstimatedCost: 1e6}, nil
+}
+
+func (t *scrapeTable) Open() (sqlite3.VTabCursor, error) {
+	return &scrapeCursor{table: t}, nil
+}
+
+func (t *scrapeTable) Disconnect() error { return nil }
+
+func (t *scrapeTable) Destroy() error { return nil }
+
+type scrapeCursor struct {
+	table *scrapeTable
+	rows  driver.Rows
+	dest  []driver.Value
+	rowid int64
+	text  string
+	match []int
+	eof   bool
+}
+
+func (c *scrapeCursor) Filter(idxNum int, idxStr string, vals []any) error {
+	if err := c.Close(); err != nil {
+		return err
+	}
+	rows, err := c.table.conn.Query(c.table.query, nil)
+	if err != nil {
+		return fmt.Errorf("regexp_scrape: %w", err)
+	}
+	c.rows, c.dest, c.eof = rows, make([]driver.Value, 2), false
+	return c.Next()
+}
+
+// Next reads rows of the source until one matches. The rows are read one at
+// a time, so the source is never held in memory.
+func (c *scrapeCursor) Next() error {
+	for {
+		if err := c.rows.Next(c.dest); err == io.EOF {
+			c.eof = true
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("regexp_scrape: %w", err)
+		}
+		text, ok := core.TextArg(c.dest[1])
+		if !ok {
+			continue
+		}
+		if c.match = c.table.re.FindStringSubmatchIndex(text); c.match == nil {
+			continue
+		}
+		rowid, ok := c.dest[0].(int64)
+		if !ok {
+			return fmt.Errorf("regexp_scrape: key %v is not an integer", c.dest[0])
+		}
+		c.rowid, c.text = rowid, text
+		return nil
+	}
+}
+
+func (c *scrapeCursor) EOF() bool {
+	return c.eof
+}
+
+func (c *scrapeCursor) Column(ctx *sqlite3.SQLiteContext, col int) error {
+	group := c.table.groups[col]
+	start, end := c.match[2*group], c.match[2*group+1]
+	if start < 0 {
+		ctx.ResultNull()
+	} else {
+		ctx.ResultText(c.text[start:end])
+	}
+	return nil
+}
+
+func (c *scrapeCursor) Rowid() (int64, error) {
+	return c.rowid, nil
+}
+
+func (c *scrapeCursor) Close() error {
+	if c.rows == nil {
+		return nil
+	}
+	err := c.rows.Close()
+	c.rows = nil
+	return err
+}
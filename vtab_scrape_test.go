@@ -0,0 +1,112 @@
+//go:build sqlite_vtable || vtable
+
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestScrapeTable(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	_, err = db.Exec(`CREATE TABLE orders (id INTEGER PRIMARY KEY, note TEXT);
+		INSERT INTO orders (id, note) VALUES
+			(1, 'ship ABC-12 x3 asap'),
+			(2, 'no items'),
+			(3, NULL),
+			(5, 'xyz-7 x10, DEF-8 x1'),
+			(6, 'GHI-9');
+		CREATE VIRTUAL TABLE order_items USING regexp_scrape(
+			source=orders,
+			column=note,
+			pattern='(?P<sku>[A-Z]{3}-\d+)(?: x(?P<qty>\d+))?',
+			flags='i')`)
+	if err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	query := `SELECT o.id, i.sku, i.qty FROM orders o JOIN order_items i ON i.rowid = o.id ORDER BY o.id`
+	expected := []string{"1|ABC-12|3", "5|xyz-7|10", "6|GHI-9|"}
+	if got := scrapeRows(t, db, query); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Got rows %q, expected %q", got, expected)
+	}
+
+	// The table follows the source.
+	if _, err := db.Exec(`UPDATE orders SET note = 'JKL-1 x2' WHERE id = 2`); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	var total sql.NullInt64
+	if err := db.QueryRow(`SELECT count(*), sum(qty) FROM order_items`).Scan(&count, &total); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if count != 4 || total.Int64 != 15 {
+		t.Errorf("Got %d rows totaling %d, expected 4 totaling 15", count, total.Int64)
+	}
+
+	// A view has no rowid; key names an integer column instead.
+	_, err = db.Exec(`CREATE VIEW notes AS SELECT id * 10 AS n, note FROM orders;
+		CREATE VIRTUAL TABLE note_items USING regexp_scrape(source=notes, column=note, key=n, pattern='(?P<sku>[A-Z]{3}-\d+)')`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	expected = []string{"10|ABC-12", "20|JKL-1", "50|DEF-8", "60|GHI-9"}
+	if got := scrapeRows(t, db, `SELECT rowid, sku FROM note_items ORDER BY rowid`); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Got rows %q, expected %q", got, expected)
+	}
+
+	for _, stmt := range []string{
+		`CREATE VIRTUAL TABLE bad USING regexp_scrape(source=orders, column=note)`,
+		`CREATE VIRTUAL TABLE bad USING regexp_scrape(source=orders, column=note, pattern='\d+')`,
+		`CREATE VIRTUAL TABLE bad USING regexp_scrape(source=orders, column=note, pattern='(?P<a>x)', table=x)`,
+		`CREATE VIRTUAL TABLE bad USING regexp_scrape(source=orders, column=note, pattern='(?P<a>x)(?P<A>y)')`,
+	} {
+		if _, err := db.Exec(stmt); err == nil {
+			t.Errorf("%s: expected an error", stmt)
+		}
+	}
+}
+
+// scrapeRows returns the rows of query, the first column an integer, joined
+// with |.
+func scrapeRows(t *testing.T, db *sql.DB, query string) []string {
+	t.Helper()
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	columns, _ := rows.Columns()
+	var got []string
+	for rows.Next() {
+		var id int64
+		values := make([]sql.NullString, len(columns)-1)
+		dest := []any{&id}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		row := []string{strconv.FormatInt(id, 10)}
+		for _, v := range values {
+			row = append(row, v.String)
+		}
+		got = append(got, strings.Join(row, "|"))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	return got
+}
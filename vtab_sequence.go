@@ -0,0 +1,303 @@
+//go:build sqlite_vtable || vtable
+
+package sqlite_regexp
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Columns of regexp_sequence; the hidden ones hold the arguments.
+const (
+	sequenceColQuery = iota + 6
+	sequenceColFirst
+	sequenceColThen
+	sequenceColWithinRows
+	sequenceColWithinSeconds
+	sequenceColFlags
+)
+
+// sequenceModule implements the regexp_sequence table-valued function, which
+// correlates rows the way MatchWindows matches them: it runs query, which
+// returns a key, a text and optionally a time column, in order, and returns
+// one row for each row matching first followed by a row matching then within
+// within_rows rows and within_seconds seconds:
+//
+//	SELECT start_key, end_key, seconds FROM regexp_sequence(
+//		'SELECT id, line, ts FROM logs ORDER BY id',
+//		'login failed', 'login succeeded', NULL, 60);
+//
+// A NULL limit is not checked, but one of them must be given. Times are
+// numbers of seconds, such as unixepoch(ts), or timestamps in SQLite's
+// date and time formats.
+type sequenceModule struct {
+	cfg *core.Config
+}
+
+var _ sqlite3.EponymousOnlyModule = &sequenceModule{}
+
+func (m *sequenceModule) EponymousOnlyModule() {}
+
+func (m *sequenceModule) Create(c *sqlite3.SQLiteConn, args []string) (sqlite3.VTab, error) {
+	return m.Connect(c, args)
+}
+
+func (m *sequenceModule) Connect(c *sqlite3.SQLiteConn, args []string) (sqlite3.VTab, error) {
+	err := c.DeclareVTab(`CREATE TABLE x(
+		start_key,
+		end_key,
+		start_text TEXT,
+		end_text TEXT,
+		rows INTEGER,
+		seconds REAL,
+		query HIDDEN,
+		first_pattern HIDDEN,
+		then_pattern HIDDEN,
+		within_rows HIDDEN,
+		within_seconds HIDDEN,
+		flags HIDDEN
+	)`)
+	if err != nil {
+		return nil, err
+	}
+	return &sequenceTable{conn: c, cfg: m.cfg}, nil
+}
+
+func (m *sequenceModule) DestroyModule() {}
+
+type sequenceTable struct {
+	conn *sqlite3.SQLiteConn
+	cfg  *core.Config
+}
+
+// BestIndex passes the arguments to Filter, recording the column of each one
+// in IdxStr.
+func (t *sequenceTable) BestIndex(csts []sqlite3.InfoConstraint, ob []sqlite3.InfoOrderBy) (*sqlite3.IndexResult, error) {
+	used := make([]bool, len(csts))
+	var columns []byte
+	required := 0
+	for i, c := range csts {
+		if !c.Usable || c.Op != sqlite3.OpEQ || c.Column < sequenceColQuery {
+			continue
+		}
+		used[i] = true
+		columns = append(columns, byte(c.Column))
+		if c.Column <= sequenceColThen {
+			required++
+		}
+	}
+
+	cost := 1.0
+	if required < 3 {
+		// Not callable without its arguments; Filter reports the error if
+		// SQLite has no better plan.
+		cost = 1e12
+	}
+	return &sqlite3.IndexResult{Used: used, IdxStr: string(columns), EstimatedCost: cost}, nil
+}
+
+func (t *sequenceTable) Open() (sqlite3.VTabCursor, error) {
+	return &sequenceCursor{conn: t.conn, cfg: t.cfg}, nil
+}
+
+func (t *sequenceTable) Disconnect() error { return nil }
+
+func (t *sequenceTable) Destroy() error { return nil }
+
+type sequenceCursor struct {
+	conn    *sqlite3.SQLiteConn
+	cfg     *core.Config
+	timed   bool
+	matches []core.SequenceMatch
+	i       int
+}
+
+func (c *sequenceCursor) Filter(idxNum int, idxStr string, vals []any) error {
+	args := make(map[int]any, len(vals))
+	for i, v := range vals {
+		args[int(idxStr[i])] = v
+	}
+
+	query, ok := core.TextArg(args[sequenceColQuery])
+	if !ok {
+		return errors.New("regexp_sequence: missing query")
+	}
+	var flags core.Flags
+	if f, ok := core.TextArg(args[sequenceColFlags]); ok {
+		var err error
+		if flags, err = core.ParseFlags(f); err != nil {
+			return err
+		}
+	}
+	first, okFirst := core.TextArg(args[sequenceColFirst])
+	then, okThen := core.TextArg(args[sequenceColThen])
+	if !okFirst || !okThen {
+		return errors.New("regexp_sequence: missing pattern")
+	}
+	var rule core.SequenceRule
+	var err error
+	if rule.First, err = c.cfg.Compile(first, flags); err != nil {
+		return err
+	}
+	if rule.Then, err = c.cfg.Compile(then, flags); err != nil {
+		return err
+	}
+	if v, ok := args[sequenceColWithinRows].(int64); ok {
+		rule.WithinRows = int(v)
+	}
+	switch v := args[sequenceColWithinSeconds].(type) {
+	case int64:
+		rule.WithinSeconds = float64(v)
+	case float64:
+		rule.WithinSeconds = v
+	}
+	if rule.WithinRows <= 0 && rule.WithinSeconds <= 0 {
+		return errors.New("regexp_sequence: within_rows or within_seconds must be positive")
+	}
+
+	c.matches, c.i = nil, 0
+	if err := c.run(query, rule); err != nil {
+		return fmt.Errorf("regexp_sequence: %w", err)
+	}
+	return nil
+}
+
+// run streams the rows of query through a matcher for rule.
+func (c *sequenceCursor) run(query string, rule core.SequenceRule) error {
+	rows, err := c.conn.Query(query, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns := len(rows.Columns())
+	if columns != 2 && columns != 3 {
+		return fmt.Errorf("query must return a key, a text and an optional time column, got %d columns", columns)
+	}
+	if rule.WithinSeconds > 0 && columns != 3 {
+		return errors.New("within_seconds requires a time column")
+	}
+	c.timed = columns == 3
+
+	m := core.NewSequenceMatcher(rule)
+	dest := make([]driver.Value, columns)
+	for {
+		if err := rows.Next(dest); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		text, _ := core.TextArg(dest[1])
+		var t float64
+		if c.timed {
+			if t, err = sequenceTime(dest[2]); err != nil {
+				return err
+			}
+		}
+		c.matches = append(c.matches, m.Push(dest[0], text, t)...)
+	}
+}
+
+// sequenceTimeLayouts are the timestamp formats of SQLite's date and time
+// functions.
+var sequenceTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// sequenceTime converts a value of the time column to seconds.
+func sequenceTime(v any) (float64, error) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case time.Time:
+		return float64(v.UnixNano()) / 1e9, nil
+	case string, []byte:
+		s, _ := core.TextArg(v)
+		s = strings.TrimSpace(s)
+		for _, layout := range sequenceTimeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return float64(t.UnixNano()) / 1e9, nil
+			}
+		}
+		return 0, fmt.Errorf("invalid time %q", s)
+	default:
+		return 0, fmt.Errorf("invalid time %v", v)
+	}
+}
+
+func (c *sequenceCursor) Next() error {
+	c.i++
+	return nil
+}
+
+func (c *sequenceCursor) EOF() bool {
+	return c.i >= len(c.matches)
+}
+
+func (c *sequenceCursor) Column(ctx *sqlite3.SQLiteContext, col int) error {
+	m := c.matches[c.i]
+	switch col {
+	case 0:
+		resultValue(ctx, m.StartKey)
+	case 1:
+		resultValue(ctx, m.EndKey)
+	case 2:
+		ctx.ResultText(m.StartText)
+	case 3:
+		ctx.ResultText(m.EndText)
+	case 4:
+		ctx.ResultInt(m.Rows)
+	case 5:
+		if c.timed {
+			ctx.ResultDouble(m.Seconds)
+		} else {
+			ctx.ResultNull()
+		}
+	default:
+		ctx.ResultNull()
+	}
+	return nil
+}
+
+func (c *sequenceCursor) Rowid() (int64, error) {
+	return int64(c.i), nil
+}
+
+func (c *sequenceCursor) Close() error { return nil }
+
+// resultValue sets a value read from a query as the result of a column.
+func resultValue(ctx *sqlite3.SQLiteContext, v any) {
+	switch v := v.(type) {
+	case int64:
+		ctx.ResultInt64(v)
+	case float64:
+		ctx.ResultDouble(v)
+	case bool:
+		ctx.ResultBool(v)
+	case string:
+		ctx.ResultText(v)
+	case []byte:
+		ctx.ResultBlob(v)
+	case time.Time:
+		ctx.ResultText(v.Format(time.RFC3339Nano))
+	default:
+		ctx.ResultNull()
+	}
+}
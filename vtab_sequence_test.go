@@ -0,0 +1,79 @@
+//go:build sqlite_vtable || vtable
+
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestSequenceTable(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	_, err = db.Exec(`CREATE TABLE logs (id INTEGER PRIMARY KEY, ts TEXT, line TEXT);
+		INSERT INTO logs (ts, line) VALUES
+			('2024-05-01 10:00:00', 'Login FAILED for alice'),
+			('2024-05-01 10:00:30', 'noise'),
+			('2024-05-01 10:00:45', 'login succeeded for alice'),
+			('2024-05-01 11:00:00', 'login failed for bob'),
+			('2024-05-01 11:05:00', 'login succeeded for bob')`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	rows, err := db.Query(`SELECT start_key, end_key, rows, seconds FROM regexp_sequence(
+		'SELECT id, line, ts FROM logs ORDER BY id', 'login failed', 'login succeeded', NULL, 60, 'i')`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	var got [][4]float64
+	for rows.Next() {
+		var start, end, n int
+		var seconds float64
+		if err := rows.Scan(&start, &end, &n, &seconds); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		got = append(got, [4]float64{float64(start), float64(end), float64(n), seconds})
+	}
+	_ = rows.Close()
+	if len(got) != 1 || got[0] != [4]float64{1, 3, 3, 45} {
+		t.Errorf("Unexpected spans %v", got)
+	}
+
+	// Without a time column, only row limits apply and seconds is NULL.
+	var count int
+	var seconds sql.NullFloat64
+	err = db.QueryRow(`SELECT count(*), max(seconds) FROM regexp_sequence(
+		'SELECT id, line FROM logs ORDER BY id', 'failed', 'succeeded', 2)`).Scan(&count, &seconds)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if count != 1 || seconds.Valid {
+		t.Errorf("Got %d spans and seconds %+v, expected 1 span and NULL", count, seconds)
+	}
+
+	for _, query := range []string{
+		`SELECT * FROM regexp_sequence('SELECT id, line FROM logs', 'a', 'b', NULL)`,
+		`SELECT * FROM regexp_sequence('SELECT id, line FROM logs', 'a', 'b', NULL, 60)`,
+		`SELECT * FROM regexp_sequence('SELECT line FROM logs', 'a', 'b', 5)`,
+		`SELECT * FROM regexp_sequence('SELECT id, line FROM logs', '(', 'b', 5)`,
+		`SELECT * FROM regexp_sequence('SELECT id, line, line FROM logs', 'a', 'b', NULL, 60)`,
+	} {
+		rows, err := db.Query(query)
+		if err == nil {
+			for rows.Next() {
+			}
+			err = rows.Err()
+			_ = rows.Close()
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error, got nil", query)
+		}
+	}
+}
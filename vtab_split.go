@@ -0,0 +1,177 @@
+//go:build sqlite_vtable || vtable
+
+package sqlite_regexp
+
+import (
+	"errors"
+	"unicode/utf8"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Columns of regexp_split; the hidden ones hold the arguments.
+const (
+	splitColText = iota + 3
+	splitColPattern
+	splitColFlags
+)
+
+// splitModule implements the regexp_split table-valued function, which
+// returns the parts of a text between the matches of a pattern, one row per
+// part, in order:
+//
+//	SELECT ordinal, token, start FROM regexp_split(body, '\n{2,}');
+//
+// Unlike regexp_tokenize, it keeps empty parts, and it finds each match only
+// when SQLite steps to the part before it, so splitting a large text does
+// not build all its parts at once. start is the position of the part in
+// characters, from 1, as substr counts them.
+type splitModule struct {
+	cfg *core.Config
+}
+
+var _ sqlite3.EponymousOnlyModule = &splitModule{}
+
+func (m *splitModule) EponymousOnlyModule() {}
+
+func (m *splitModule) Create(c *sqlite3.SQLiteConn, args []string) (sqlite3.VTab, error) {
+	return m.Connect(c, args)
+}
+
+func (m *splitModule) Connect(c *sqlite3.SQLiteConn, args []string) (sqlite3.VTab, error) {
+	err := c.DeclareVTab(`CREATE TABLE x(
+		ordinal INTEGER,
+		token TEXT,
+		start INTEGER,
+		text HIDDEN,
+		pattern HIDDEN,
+		flags HIDDEN
+	)`)
+	if err != nil {
+		return nil, err
+	}
+	return &splitTable{cfg: m.cfg}, nil
+}
+
+func (m *splitModule) DestroyModule() {}
+
+type splitTable struct {
+	cfg *core.Config
+}
+
+// BestIndex passes the arguments to Filter, recording the column of each one
+// in IdxStr.
+func (t *splitTable) BestIndex(csts []sqlite3.InfoConstraint, ob []sqlite3.InfoOrderBy) (*sqlite3.IndexResult, error) {
+	used := make([]bool, len(csts))
+	var columns []byte
+	required := 0
+	for i, c := range csts {
+		if !c.Usable || c.Op != sqlite3.OpEQ || c.Column < splitColText {
+			continue
+		}
+		used[i] = true
+		columns = append(columns, byte(c.Column))
+		if c.Column <= splitColPattern {
+			required++
+		}
+	}
+
+	cost := 1.0
+	if required < 2 {
+		// Not callable without its arguments; Filter reports the error if
+		// SQLite has no better plan.
+		cost = 1e12
+	}
+	return &sqlite3.IndexResult{Used: used, IdxStr: string(columns), EstimatedCost: cost}, nil
+}
+
+func (t *splitTable) Open() (sqlite3.VTabCursor, error) {
+	return &splitCursor{cfg: t.cfg}, nil
+}
+
+func (t *splitTable) Disconnect() error { return nil }
+
+func (t *splitTable) Destroy() error { return nil }
+
+type splitCursor struct {
+	cfg      *core.Config
+	text     string
+	splitter *core.Splitter
+	// start and end are the byte offsets of the current part, and chars the
+	// number of characters before it.
+	start, end int
+	chars      int
+	ordinal    int64
+	eof        bool
+}
+
+func (c *splitCursor) Filter(idxNum int, idxStr string, vals []any) error {
+	args := make(map[int]any, len(vals))
+	for i, v := range vals {
+		args[int(idxStr[i])] = v
+	}
+
+	c.splitter, c.text, c.start, c.end, c.chars, c.ordinal, c.eof = nil, "", 0, 0, 0, 0, true
+	_, hasText := args[splitColText]
+	_, hasPattern := args[splitColPattern]
+	if !hasText || !hasPattern {
+		return errors.New("regexp_split: missing text or pattern")
+	}
+	// Like json_each, a NULL text or pattern has no rows.
+	text, okText := core.TextArg(args[splitColText])
+	pattern, okPattern := core.TextArg(args[splitColPattern])
+	if !okText || !okPattern {
+		return nil
+	}
+	var flags core.Flags
+	if f, ok := core.TextArg(args[splitColFlags]); ok {
+		var err error
+		if flags, err = core.ParseFlags(f); err != nil {
+			return err
+		}
+	}
+	re, err := c.cfg.Compile(pattern, flags)
+	if err != nil {
+		return err
+	}
+
+	c.text, c.splitter, c.eof = text, core.NewSplitter(re, text), false
+	return c.Next()
+}
+
+func (c *splitCursor) Next() error {
+	start, end, ok := c.splitter.Next()
+	if !ok {
+		c.eof = true
+		return nil
+	}
+	c.chars += utf8.RuneCountInString(c.text[c.start:start])
+	c.start, c.end = start, end
+	c.ordinal++
+	return nil
+}
+
+func (c *splitCursor) EOF() bool {
+	return c.eof
+}
+
+func (c *splitCursor) Column(ctx *sqlite3.SQLiteContext, col int) error {
+	switch col {
+	case 0:
+		ctx.ResultInt64(c.ordinal)
+	case 1:
+		ctx.ResultText(c.text[c.start:c.end])
+	case 2:
+		ctx.ResultInt64(int64(c.chars) + 1)
+	default:
+		ctx.ResultNull()
+	}
+	return nil
+}
+
+func (c *splitCursor) Rowid() (int64, error) {
+	return c.ordinal, nil
+}
+
+func (c *splitCursor) Close() error { return nil }
@@ -0,0 +1,102 @@
+//go:build sqlite_vtable || vtable
+
+package sqlite_regexp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitTable(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	rows, err := db.Query(`SELECT ordinal, token, start FROM regexp_split('héllo, wörld,,end', ',\s*')`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	type part struct {
+		ordinal int
+		token   string
+		start   int
+	}
+	var got []part
+	for rows.Next() {
+		var p part
+		if err := rows.Scan(&p.ordinal, &p.token, &p.start); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		got = append(got, p)
+	}
+	_ = rows.Close()
+	expected := []part{{1, "héllo", 1}, {2, "wörld", 8}, {3, "", 14}, {4, "end", 15}}
+	if len(got) != len(expected) {
+		t.Fatalf("Got parts %v, expected %v", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Part %d is %v, expected %v", i, got[i], expected[i])
+		}
+	}
+
+	// start is a position for substr, and flags apply to the pattern.
+	var mismatches int
+	err = db.QueryRow(`SELECT count(*) FROM regexp_split('aXbxc', 'x', 'i') s
+		WHERE substr('aXbxc', s.start, length(s.token)) != s.token`).Scan(&mismatches)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if mismatches != 0 {
+		t.Errorf("Got %d parts not at their start", mismatches)
+	}
+
+	// Splitting a column, stopping early on a large text.
+	if _, err := db.Exec(`CREATE TABLE docs (id INTEGER PRIMARY KEY, body TEXT);
+		INSERT INTO docs (body) VALUES ('a b'), (NULL), (?)`, strings.Repeat("word ", 1<<20)); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM docs, regexp_split(docs.body, ' ') WHERE docs.id < 3`).Scan(&count); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Got %d parts, expected 2", count)
+	}
+	var token string
+	if err := db.QueryRow(`SELECT token FROM docs, regexp_split(docs.body, ' ') WHERE docs.id = 3 AND ordinal = 3 LIMIT 1`).Scan(&token); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if token != "word" {
+		t.Errorf("Got token %q, expected word", token)
+	}
+
+	if _, err := db.Exec(`SELECT * FROM regexp_split('abc', '(')`); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
+
+func TestReadOnlyTables(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:", WithReadOnly())
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM regexp_split('a,b,c', ',')`).Scan(&count); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Got %d parts, expected 3", count)
+	}
+	if _, err := db.Exec(`SELECT * FROM regexp_sequence('SELECT 1, 1', 'a', 'b', 1)`); err == nil {
+		t.Error("Expected regexp_sequence to be missing in read-only mode")
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !sqlite_vtable && !vtable
+
+package sqlite_regexp
+
+import "github.com/mattn/go-sqlite3"
+
+// registerModules is a no-op unless the package is built with the
+// sqlite_vtable tag, which go-sqlite3 requires for virtual tables.
+func registerModules(conn *sqlite3.SQLiteConn, cfg *config) error {
+	return nil
+}
@@ -0,0 +1,80 @@
+//go:build sqlite_vtable || vtable
+
+package sqlite_regexp
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestPatternHistoryTable(t *testing.T) {
+	lib := NewPatternLibrary()
+	if err := lib.Define("invoice", `INV-\d+`, ByAuthor("alice")); err != nil {
+		t.Fatal(err)
+	}
+	if err := lib.Define("invoice", `INV-\d{6}`, ByAuthor("bob")); err != nil {
+		t.Fatal(err)
+	}
+	if err := lib.Remove("invoice", ByAuthor("carol"), Because("obsolete")); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := OpenWithRegexp(":memory:", WithPatternLibrary(lib))
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var reason string
+	if err := db.QueryRow(`SELECT reason FROM regexp_pattern_history WHERE removed`).Scan(&reason); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if reason != "obsolete" {
+		t.Errorf("Unexpected reason %q", reason)
+	}
+
+	rows, err := db.Query(`SELECT version, pattern, removed, author, changed_at
+		FROM regexp_pattern_history WHERE name = 'invoice' ORDER BY version DESC`)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var got []string
+	for rows.Next() {
+		var (
+			version   int
+			pattern   sql.NullString
+			removed   bool
+			author    string
+			changedAt string
+		)
+		if err := rows.Scan(&version, &pattern, &removed, &author, &changedAt); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if changedAt == "" {
+			t.Errorf("Version %d has no timestamp", version)
+		}
+		if removed != !pattern.Valid {
+			t.Errorf("Version %d: removed=%v but pattern=%+v", version, removed, pattern)
+		}
+		got = append(got, author+":"+pattern.String)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Rows failed: %v", err)
+	}
+
+	expected := []string{"carol:", `bob:INV-\d{6}`, `alice:INV-\d+`}
+	if len(got) != len(expected) {
+		t.Fatalf("Got %v, expected %v", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Row %d = %q, expected %q", i, got[i], expected[i])
+		}
+	}
+}
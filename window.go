@@ -0,0 +1,95 @@
+package sqlite_regexp
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/go-go-golems/go-sqlite-regexp/internal/core"
+)
+
+// WindowMatch is a match spanning one or more consecutive rows, as returned
+// by MatchWindows. StartKey and EndKey are the keys of the first and last
+// rows of the match, and Text the matched text, rows being joined with "\n".
+type WindowMatch = core.WindowMatch
+
+// WindowQuery describes a windowed match, see MatchWindows.
+type WindowQuery struct {
+	// Query selects the rows to scan, as a key column followed by a text
+	// column, in order, e.g. "SELECT id, line FROM logs ORDER BY id".
+	Query string
+	Args  []any
+	// Pattern is matched against the text of Size consecutive rows joined
+	// with "\n". Flags are the usual matching flags; 'm' makes ^ and $ match
+	// at row boundaries.
+	Pattern string
+	Flags   string
+	// Size is the number of rows a match may span.
+	Size int
+}
+
+// MatchWindows finds the matches of a pattern spanning up to q.Size
+// consecutive rows, which single-row REGEXP cannot see, such as multi-line
+// log events:
+//
+//	matches, err := sqlite_regexp.MatchWindows(db, sqlite_regexp.WindowQuery{
+//		Query:   "SELECT id, line FROM logs ORDER BY id",
+//		Pattern: `Exception[^\n]*(\n\s+at [^\n]*)+`,
+//		Size:    50,
+//	})
+//
+// Each match is reported once, for the row it starts in, and cannot extend
+// more than Size-1 rows past it. Rows are streamed, so only Size rows are
+// held in memory. A NULL text is treated as an empty row.
+func MatchWindows(db *sql.DB, q WindowQuery) ([]WindowMatch, error) {
+	return MatchWindowsContext(context.Background(), db, q)
+}
+
+// MatchWindowsContext is like MatchWindows, but stops, interrupting the
+// query, once ctx is done.
+func MatchWindowsContext(ctx context.Context, db *sql.DB, q WindowQuery) ([]WindowMatch, error) {
+	flags, err := core.ParseFlags(q.Flags)
+	if err != nil {
+		return nil, err
+	}
+	re, err := core.Compile(q.Pattern, flags)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, q.Query, q.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) != 2 {
+		return nil, fmt.Errorf("window query must return a key and a text column, got %d columns", len(columns))
+	}
+
+	w := core.NewWindowMatcher(re, q.Size)
+	var matches []WindowMatch
+	for rows.Next() {
+		var (
+			key  any
+			text sql.NullString
+		)
+		if err := rows.Scan(&key, &text); err != nil {
+			return nil, err
+		}
+		matches = append(matches, w.Push(key, text.String)...)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return append(matches, w.Flush()...), nil
+}
@@ -0,0 +1,52 @@
+package sqlite_regexp
+
+import (
+	"testing"
+)
+
+func TestMatchWindows(t *testing.T) {
+	db, err := OpenWithRegexp(":memory:")
+	if err != nil {
+		t.Fatalf("OpenWithRegexp failed: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	_, err = db.Exec(`CREATE TABLE logs (id INTEGER PRIMARY KEY, line TEXT);
+		INSERT INTO logs (line) VALUES
+			('INFO start'),
+			('ERROR java.lang.NullPointerException'),
+			('    at com.example.Foo.bar(Foo.java:10)'),
+			('    at com.example.Main.main(Main.java:3)'),
+			(NULL),
+			('INFO done')`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	matches, err := MatchWindows(db, WindowQuery{
+		Query:   `SELECT id, line FROM logs WHERE id > ? ORDER BY id`,
+		Args:    []any{0},
+		Pattern: `exception$(\n\s+at .*$)+`,
+		Flags:   "im",
+		Size:    10,
+	})
+	if err != nil {
+		t.Fatalf("MatchWindows failed: %v", err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("Expected 1 match, got %+v", matches)
+	}
+	m := matches[0]
+	if m.StartKey != int64(2) || m.EndKey != int64(4) || m.Rows != 3 {
+		t.Errorf("Unexpected match %+v", m)
+	}
+
+	if _, err := MatchWindows(db, WindowQuery{Query: `SELECT line FROM logs`, Pattern: "a", Size: 2}); err == nil {
+		t.Error("Expected error for a query with one column, got nil")
+	}
+	if _, err := MatchWindows(db, WindowQuery{Query: `SELECT id, line FROM logs`, Pattern: "(", Size: 2}); err == nil {
+		t.Error("Expected error for invalid pattern, got nil")
+	}
+}